@@ -0,0 +1,281 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mem/internal/config"
+	"mem/internal/store"
+)
+
+const (
+	// Popularity saturates around this many inclusions.
+	popularitySaturationHits = 50.0
+	// Popularity fades with a 14-day half-life since the last inclusion,
+	// matching the recency bonus time scale.
+	popularityHalfLifeDays = 14.0
+
+	defaultStaleDays = 30
+
+	accessFlushInterval = 5 * time.Second
+)
+
+type StaleMemoryItem struct {
+	ID             string `json:"id"`
+	ThreadID       string `json:"thread_id"`
+	Title          string `json:"title"`
+	Summary        string `json:"summary"`
+	CreatedAt      string `json:"created_at"`
+	AnchorCommit   string `json:"anchor_commit,omitempty"`
+	HitCount       int    `json:"hit_count"`
+	LastIncludedAt string `json:"last_included_at,omitempty"`
+}
+
+func runStale(args []string, out, errOut io.Writer) int {
+	fs := flag.NewFlagSet("stale", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	repoOverride := fs.String("repo", "", "Override repo id")
+	workspace := fs.String("workspace", "", "Workspace name")
+	days := fs.Int("days", defaultStaleDays, "Report memories not included in a context pack for this many days")
+	limit := fs.Int("limit", 50, "Max memories to show")
+	format := fs.String("format", "json", "Output format: json")
+	positional, flagArgs, err := splitFlagArgs(args, map[string]flagSpec{
+		"repo":      {RequiresValue: true},
+		"workspace": {RequiresValue: true},
+		"days":      {RequiresValue: true},
+		"limit":     {RequiresValue: true},
+		"format":    {RequiresValue: true},
+	})
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 2
+	}
+	if err := fs.Parse(flagArgs); err != nil {
+		return 2
+	}
+	if len(positional) > 0 {
+		fmt.Fprintf(errOut, "unexpected args: %s\n", strings.Join(positional, " "))
+		return 2
+	}
+	if strings.TrimSpace(*format) != "json" {
+		fmt.Fprintf(errOut, "unsupported format: %s\n", *format)
+		return 2
+	}
+	if *days <= 0 {
+		fmt.Fprintln(errOut, "days must be > 0")
+		return 2
+	}
+	if *limit < 0 {
+		fmt.Fprintln(errOut, "limit must be >= 0")
+		return 2
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(errOut, "config error: %v\n", err)
+		return 1
+	}
+	workspaceName := resolveWorkspace(cfg, strings.TrimSpace(*workspace))
+
+	repoInfo, err := resolveRepo(&cfg, strings.TrimSpace(*repoOverride))
+	if err != nil {
+		fmt.Fprintf(errOut, "repo detection error: %v\n", err)
+		return 1
+	}
+
+	st, err := openStore(cfg, repoInfo.ID)
+	if err != nil {
+		fmt.Fprintf(errOut, "store open error: %v\n", err)
+		return 1
+	}
+	defer st.Close()
+
+	cutoff := time.Now().UTC().Add(-time.Duration(*days) * 24 * time.Hour)
+	memories, err := st.ListStaleMemories(repoInfo.ID, workspaceName, cutoff, *limit)
+	if err != nil {
+		fmt.Fprintf(errOut, "stale error: %v\n", err)
+		return 1
+	}
+
+	items := make([]StaleMemoryItem, 0, len(memories))
+	for _, mem := range memories {
+		items = append(items, StaleMemoryItem{
+			ID:             mem.ID,
+			ThreadID:       mem.ThreadID,
+			Title:          mem.Title,
+			Summary:        mem.Summary,
+			CreatedAt:      mem.CreatedAt.UTC().Format(time.RFC3339Nano),
+			AnchorCommit:   mem.AnchorCommit,
+			HitCount:       mem.HitCount,
+			LastIncludedAt: formatTime(mem.LastIncludedAt),
+		})
+	}
+	return writeJSON(out, errOut, items)
+}
+
+// Pack hits are queued in memory and written in batches so recording them
+// never puts a write transaction on the `mem get` or mem_get_context path.
+// The MCP server flushes the queue on an interval and at shutdown; one-shot
+// commands flush it after their output is written.
+
+type accessQueueKey struct {
+	dbPath    string
+	repoID    string
+	workspace string
+}
+
+type pendingAccess struct {
+	cfg  config.Config
+	hits map[string]*store.AccessHit
+}
+
+type accessQueue struct {
+	mu      sync.Mutex
+	pending map[accessQueueKey]*pendingAccess
+}
+
+var packAccessQueue = &accessQueue{pending: map[accessQueueKey]*pendingAccess{}}
+
+// queuePackAccess queues one hit for every memory and chunk the budget kept.
+func queuePackAccess(cfg config.Config, repoID, workspace string, budget BudgetResult, now time.Time) {
+	packAccessQueue.add(cfg, repoID, workspace, sortedIDs(budget.IncludedMemoryIDs), sortedIDs(budget.IncludedChunkIDs), now)
+}
+
+func (q *accessQueue) add(cfg config.Config, repoID, workspace string, memoryIDs, chunkIDs []string, now time.Time) {
+	if len(memoryIDs) == 0 && len(chunkIDs) == 0 {
+		return
+	}
+	key := accessQueueKey{dbPath: cfg.RepoDBPath(repoID), repoID: repoID, workspace: workspace}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	entry := q.pending[key]
+	if entry == nil {
+		entry = &pendingAccess{cfg: cloneConfig(cfg), hits: map[string]*store.AccessHit{}}
+		q.pending[key] = entry
+	}
+	bump := func(kind, id string) {
+		hit := entry.hits[kind+":"+id]
+		if hit == nil {
+			hit = &store.AccessHit{Kind: kind, ItemID: id}
+			entry.hits[kind+":"+id] = hit
+		}
+		hit.Hits++
+		if now.After(hit.LastIncludedAt) {
+			hit.LastIncludedAt = now
+		}
+	}
+	for _, id := range memoryIDs {
+		bump(store.AccessKindMemory, id)
+	}
+	for _, id := range chunkIDs {
+		bump(store.AccessKindChunk, id)
+	}
+}
+
+// flush writes every queued hit, one transaction per repo and workspace.
+// Hits for a repo whose write fails are dropped; popularity is advisory.
+func (q *accessQueue) flush() error {
+	q.mu.Lock()
+	pending := q.pending
+	q.pending = map[accessQueueKey]*pendingAccess{}
+	q.mu.Unlock()
+
+	var firstErr error
+	for key, entry := range pending {
+		hits := make([]store.AccessHit, 0, len(entry.hits))
+		for _, hit := range entry.hits {
+			hits = append(hits, *hit)
+		}
+		sort.Slice(hits, func(i, j int) bool {
+			if hits[i].Kind != hits[j].Kind {
+				return hits[i].Kind < hits[j].Kind
+			}
+			return hits[i].ItemID < hits[j].ItemID
+		})
+		st, release, err := openStoreForRequest(entry.cfg, key.repoID)
+		if err == nil {
+			err = st.RecordAccessHits(key.repoID, key.workspace, hits)
+			release()
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// flushPackAccess writes queued hits and reports a failure as a warning.
+func flushPackAccess(errOut io.Writer) {
+	if err := packAccessQueue.flush(); err != nil {
+		fmt.Fprintf(errOut, "access tracking warning: %v\n", err)
+	}
+}
+
+// startAccessFlusher flushes queued hits every accessFlushInterval until the
+// returned stop function is called, which also writes whatever is left.
+func startAccessFlusher(errOut io.Writer) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(accessFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				flushPackAccess(errOut)
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+		flushPackAccess(errOut)
+	}
+}
+
+func sortedIDs(set map[string]struct{}) []string {
+	ids := make([]string, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func loadPopularityBonuses(st *store.Store, repoID, workspace string, ids []string, weight float64) (map[string]float64, error) {
+	if weight <= 0 || len(ids) == 0 {
+		return nil, nil
+	}
+	stats, err := st.ListAccessStats(repoID, workspace, ids)
+	if err != nil {
+		return nil, err
+	}
+	return popularityBonuses(stats, weight, time.Now().UTC()), nil
+}
+
+// popularityBonuses scales weight by a log-saturating hit count and a decay
+// on the time since the item was last included.
+func popularityBonuses(stats map[string]store.AccessStat, weight float64, now time.Time) map[string]float64 {
+	if weight <= 0 || len(stats) == 0 {
+		return nil
+	}
+	bonuses := make(map[string]float64, len(stats))
+	for id, stat := range stats {
+		if stat.HitCount <= 0 || stat.LastIncludedAt.IsZero() {
+			continue
+		}
+		hits := math.Min(1, math.Log1p(float64(stat.HitCount))/math.Log1p(popularitySaturationHits))
+		ageDays := math.Max(0, now.Sub(stat.LastIncludedAt).Hours()/24)
+		bonuses[id] = weight * hits * math.Exp(-math.Ln2*ageDays/popularityHalfLifeDays)
+	}
+	return bonuses
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"mem/internal/config"
+	"mem/internal/store"
+)
+
+func TestPopularityBonusesSaturateAndDecay(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	stats := map[string]store.AccessStat{
+		"M-hot":   {ItemID: "M-hot", HitCount: 500, LastIncludedAt: now},
+		"M-warm":  {ItemID: "M-warm", HitCount: 3, LastIncludedAt: now},
+		"M-faded": {ItemID: "M-faded", HitCount: 500, LastIncludedAt: now.Add(-14 * 24 * time.Hour)},
+		"M-none":  {ItemID: "M-none"},
+	}
+
+	bonuses := popularityBonuses(stats, 0.2, now)
+
+	if got := bonuses["M-hot"]; math.Abs(got-0.2) > 1e-9 {
+		t.Fatalf("expected saturated bonus 0.2, got %v", got)
+	}
+	if got := bonuses["M-warm"]; got <= 0 || got >= bonuses["M-hot"] {
+		t.Fatalf("expected partial bonus for few hits, got %v", got)
+	}
+	if got := bonuses["M-faded"]; math.Abs(got-0.1) > 1e-9 {
+		t.Fatalf("expected one half-life decay to 0.1, got %v", got)
+	}
+	if _, ok := bonuses["M-none"]; ok {
+		t.Fatalf("expected no bonus without hits")
+	}
+	if popularityBonuses(stats, 0, now) != nil {
+		t.Fatalf("expected zero weight to disable popularity")
+	}
+}
+
+func TestSortedIDs(t *testing.T) {
+	got := sortedIDs(map[string]struct{}{"M-b": {}, "M-a": {}})
+	if len(got) != 2 || got[0] != "M-a" || got[1] != "M-b" {
+		t.Fatalf("unexpected ids: %v", got)
+	}
+}
+
+func TestPackAccessIsQueuedUntilFlush(t *testing.T) {
+	base := t.TempDir()
+	setXDGEnv(t, base)
+	repoDir := setupRepo(t, base)
+	withCwd(t, repoDir)
+	writeTestConfig(t, base, func(cfg *config.Config) {
+		cfg.EmbeddingProvider = "none"
+	})
+	runCLI(t, "init", "--no-agents")
+
+	var added addResp
+	if err := json.Unmarshal(runCLI(t, "add", "--thread", "T-access", "--title", "Retry policy", "--summary", "delta-99 retries three times"), &added); err != nil {
+		t.Fatalf("decode add: %v", err)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	repoInfo, err := resolveRepo(&cfg, "")
+	if err != nil {
+		t.Fatalf("resolve repo: %v", err)
+	}
+	st, err := openStore(cfg, repoInfo.ID)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+	hits := func() int {
+		stats, err := st.ListAccessStats(repoInfo.ID, "", []string{added.ID})
+		if err != nil {
+			t.Fatalf("list access stats: %v", err)
+		}
+		return stats[added.ID].HitCount
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := buildContextPack("delta-99", ContextOptions{RecordAccess: true}, nil); err != nil {
+			t.Fatalf("build context pack: %v", err)
+		}
+	}
+	if got := hits(); got != 0 {
+		t.Fatalf("expected hits to stay queued, got %d written", got)
+	}
+	if err := packAccessQueue.flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if got := hits(); got != 2 {
+		t.Fatalf("expected both queued hits in one flush, got %d", got)
+	}
+
+	runCLI(t, "get", "delta-99")
+	if got := hits(); got != 3 {
+		t.Fatalf("expected mem get to flush its hit, got %d", got)
+	}
+}
@@ -23,14 +23,18 @@ func runAdd(args []string, out, errOut io.Writer) int {
 	entities := fs.String("entities", "", "Comma-separated entities")
 	workspace := fs.String("workspace", "", "Workspace name")
 	repoOverride := fs.String("repo", "", "Override repo id")
+	onDuplicate := fs.String("on-duplicate", onDuplicateWarn, "When a near-duplicate exists: warn|reject|update|supersede|link|force")
+	duplicateOf := fs.String("duplicate-of", "", "Existing memory id to update, supersede, or link instead of the closest match")
 	positional, flagArgs, err := splitFlagArgs(args, map[string]flagSpec{
-		"thread":    {RequiresValue: true},
-		"title":     {RequiresValue: true},
-		"summary":   {RequiresValue: true},
-		"tags":      {RequiresValue: true},
-		"entities":  {RequiresValue: true},
-		"workspace": {RequiresValue: true},
-		"repo":      {RequiresValue: true},
+		"thread":       {RequiresValue: true},
+		"title":        {RequiresValue: true},
+		"summary":      {RequiresValue: true},
+		"tags":         {RequiresValue: true},
+		"entities":     {RequiresValue: true},
+		"workspace":    {RequiresValue: true},
+		"repo":         {RequiresValue: true},
+		"on-duplicate": {RequiresValue: true},
+		"duplicate-of": {RequiresValue: true},
 	})
 	if err != nil {
 		fmt.Fprintln(errOut, err.Error())
@@ -68,6 +72,11 @@ func runAdd(args []string, out, errOut io.Writer) int {
 		return 2
 	}
 	summaryText := strings.TrimSpace(*summary)
+	dedupe := dedupeOptions{Mode: *onDuplicate, DuplicateOf: *duplicateOf}
+	if _, err := normalizeOnDuplicate(dedupe.Mode); err != nil {
+		fmt.Fprintf(errOut, "invalid --on-duplicate: %v\n", err)
+		return 2
+	}
 
 	cfg, err := loadConfig()
 	if err != nil {
@@ -135,7 +144,7 @@ func runAdd(args []string, out, errOut io.Writer) int {
 	}
 
 	summaryTokens := counter.Count(summaryText)
-	memory, err := st.AddMemory(store.AddMemoryInput{
+	outcome, err := addMemoryWithDedupe(cfg, st, store.AddMemoryInput{
 		RepoID:        repoInfo.ID,
 		Workspace:     workspaceName,
 		ThreadID:      threadUsed,
@@ -148,11 +157,20 @@ func runAdd(args []string, out, errOut io.Writer) int {
 		EntitiesText:  entitiesText,
 		AnchorCommit:  anchorCommit,
 		CreatedAt:     createdAt,
-	})
+	}, dedupe)
 	if err != nil {
 		fmt.Fprintf(errOut, "add memory error: %v\n", err)
 		return 1
 	}
+	if outcome.Status == "duplicate" {
+		fmt.Fprintln(errOut, "near-duplicate memory exists; rerun with --on-duplicate update|supersede|link|force")
+		writeJSON(out, errOut, duplicateResponse(titleText, outcome))
+		return 1
+	}
+	if outcome.Status == "added" && len(outcome.Duplicates) > 0 {
+		fmt.Fprintf(errOut, "duplicate warning: saved next to near-duplicate %s; use --on-duplicate update|supersede|link to resolve or reject to skip\n", outcome.Duplicates[0].ID)
+	}
+	memory := outcome.Memory
 	if err := maybeEmbedMemory(cfg, st, memory); err != nil {
 		fmt.Fprintf(errOut, "embedding warning: %v\n", err)
 	}
@@ -165,7 +183,9 @@ func runAdd(args []string, out, errOut io.Writer) int {
 		"title":            memory.Title,
 		"anchor_commit":    memory.AnchorCommit,
 		"created_at":       memory.CreatedAt.UTC().Format(time.RFC3339Nano),
+		"status":           outcome.Status,
 	}
+	addDuplicateFields(resp, outcome)
 	encoded, err := json.MarshalIndent(resp, "", "  ")
 	if err != nil {
 		fmt.Fprintf(errOut, "json error: %v\n", err)
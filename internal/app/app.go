@@ -50,14 +50,22 @@ func Run(args []string, out, errOut io.Writer) int {
 		return runUpdate(args[1:], out, errOut)
 	case "explain":
 		return runExplain(args[1:], out, errOut)
+	case "eval":
+		return runEval(args[1:], out, errOut)
 	case "show":
 		return runShow(args[1:], out, errOut)
 	case "forget":
 		return runForget(args[1:], out, errOut)
 	case "supersede":
 		return runSupersede(args[1:], out, errOut)
+	case "dedupe":
+		return runDedupe(args[1:], out, errOut)
+	case "consolidate":
+		return runConsolidate(args[1:], out, errOut)
 	case "link":
 		return runLink(args[1:], out, errOut)
+	case "feedback":
+		return runFeedback(args[1:], out, errOut)
 	case "checkpoint":
 		return runCheckpoint(args[1:], out, errOut)
 	case "repos":
@@ -68,6 +76,8 @@ func Run(args []string, out, errOut io.Writer) int {
 		return runThreads(args[1:], out, errOut)
 	case "thread":
 		return runThreadShow(args[1:], out, errOut)
+	case "stale":
+		return runStale(args[1:], out, errOut)
 	case "recent":
 		return runRecent(args[1:], out, errOut)
 	case "sessions":
@@ -78,6 +88,14 @@ func Run(args []string, out, errOut io.Writer) int {
 		return runShare(args[1:], out, errOut)
 	case "ingest", "ingest-artifact":
 		return runIngest(args[1:], out, errOut)
+	case "ingest-git-log":
+		return runIngestGitLog(args[1:], out, errOut)
+	case "symbol":
+		return runSymbol(args[1:], out, errOut)
+	case "refs":
+		return runRefs(args[1:], out, errOut)
+	case "sources":
+		return runSources(args[1:], out, errOut)
 	case "embed":
 		return runEmbed(args[1:], out, errOut)
 	case "template":
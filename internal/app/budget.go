@@ -23,6 +23,19 @@ type BudgetResult struct {
 	UsedTokens        int
 	IncludedMemoryIDs map[string]struct{}
 	IncludedChunkIDs  map[string]struct{}
+	Selection         string
+	RedundantWith     map[string]string
+}
+
+// BudgetOptions selects how candidates are ordered before the token budget is
+// enforced. The zero value keeps the plain score ordering.
+type BudgetOptions struct {
+	Selection        string
+	MMRLambda        float64
+	RedundantCosine  float64
+	RedundantOverlap float64
+	MemoryEmbeddings map[string][]float64
+	ChunkEmbeddings  map[string][]float64
 }
 
 type TokenCounter interface {
@@ -39,9 +52,14 @@ type budgetItem struct {
 	Score     float64
 	CreatedAt time.Time
 	ID        string
+	Text      string
 }
 
 func applyBudget(cfg config.Config, counter TokenCounter, state json.RawMessage, stateTokens int, memories []RankedMemory, chunks []RankedChunk) (BudgetResult, error) {
+	return applyBudgetWithOptions(cfg, counter, state, stateTokens, memories, chunks, BudgetOptions{})
+}
+
+func applyBudgetWithOptions(cfg config.Config, counter TokenCounter, state json.RawMessage, stateTokens int, memories []RankedMemory, chunks []RankedChunk, opts BudgetOptions) (BudgetResult, error) {
 	stateJSON, stateOriginalTokens, stateTokens, err := normalizeState(cfg, counter, state, stateTokens)
 	if err != nil {
 		return BudgetResult{}, err
@@ -50,19 +68,20 @@ func applyBudget(cfg config.Config, counter TokenCounter, state json.RawMessage,
 	preBudgetTokens := stateTokens
 	truncatedTokens := stateOriginalTokens - stateTokens
 
-	memCount := cfg.MemoriesK
-	if memCount > len(memories) {
-		memCount = len(memories)
-	}
-	chunkCount := cfg.ChunksK
-	if chunkCount > len(chunks) {
-		chunkCount = len(chunks)
+	memPicks := leadingIndexes(len(memories), cfg.MemoriesK)
+	chunkPicks := leadingIndexes(len(chunks), cfg.ChunksK)
+	selection := selectionScore
+	var redundantWith map[string]string
+	var mmrRank map[string]int
+	if normalizeSelection(opts.Selection) == selectionMMR {
+		selection = selectionMMR
+		memPicks, chunkPicks, mmrRank, redundantWith = mmrPicks(cfg, memories, chunks, opts)
 	}
 
-	memItems := make([]pack.MemoryItem, 0, memCount)
-	memTokens := make([]int, 0, memCount)
-	for i := 0; i < memCount; i++ {
-		mem := memories[i]
+	memItems := make([]pack.MemoryItem, 0, len(memPicks))
+	memTokens := make([]int, 0, len(memPicks))
+	for _, idx := range memPicks {
+		mem := memories[idx]
 		truncated, originalTokens, tokens, err := summarizeMemory(counter, mem.Memory.Summary, mem.Memory.SummaryTokens, cfg.MemoryMaxEach)
 		if err != nil {
 			return BudgetResult{}, err
@@ -80,10 +99,10 @@ func applyBudget(cfg config.Config, counter TokenCounter, state json.RawMessage,
 		memTokens = append(memTokens, tokens)
 	}
 
-	chunkItems := make([]pack.ChunkItem, 0, chunkCount)
-	chunkTokens := make([]int, 0, chunkCount)
-	for i := 0; i < chunkCount; i++ {
-		chunk := chunks[i]
+	chunkItems := make([]pack.ChunkItem, 0, len(chunkPicks))
+	chunkTokens := make([]int, 0, len(chunkPicks))
+	for _, idx := range chunkPicks {
+		chunk := chunks[idx]
 		truncated, originalTokens, tokens, err := summarizeChunk(counter, chunk.Chunk.Text, chunk.Chunk.TextTokens, cfg.ChunkMaxEach)
 		if err != nil {
 			return BudgetResult{}, err
@@ -103,36 +122,22 @@ func applyBudget(cfg config.Config, counter TokenCounter, state json.RawMessage,
 	usedTokens := preBudgetTokens
 
 	items := make([]budgetItem, 0, len(memItems)+len(chunkItems))
-	for i, mem := range memItems {
-		items = append(items, budgetItem{
-			Kind:      "memory",
-			Index:     i,
-			Tokens:    memTokens[i],
-			Score:     memories[i].FinalScore,
-			CreatedAt: memories[i].Memory.CreatedAt,
-			ID:        mem.ID,
-		})
+	for i, idx := range memPicks {
+		item := memoryBudgetItem(memories[idx], i)
+		item.Tokens = memTokens[i]
+		items = append(items, item)
 	}
-	for i, chunk := range chunkItems {
-		items = append(items, budgetItem{
-			Kind:      "chunk",
-			Index:     i,
-			Tokens:    chunkTokens[i],
-			Score:     chunks[i].FinalScore,
-			CreatedAt: chunks[i].Chunk.CreatedAt,
-			ID:        chunk.ChunkID,
-		})
+	for i, idx := range chunkPicks {
+		item := chunkBudgetItem(chunks[idx], i)
+		item.Tokens = chunkTokens[i]
+		items = append(items, item)
+	}
+	if mmrRank != nil {
+		// Budget trimming drops from the tail, so later MMR picks go first.
+		sort.SliceStable(items, func(i, j int) bool { return mmrRank[items[i].ID] < mmrRank[items[j].ID] })
+	} else {
+		sortBudgetItems(items)
 	}
-
-	sort.SliceStable(items, func(i, j int) bool {
-		if items[i].Score != items[j].Score {
-			return items[i].Score > items[j].Score
-		}
-		if !items[i].CreatedAt.Equal(items[j].CreatedAt) {
-			return items[i].CreatedAt.After(items[j].CreatedAt)
-		}
-		return items[i].ID < items[j].ID
-	})
 
 	keepMemory := make(map[int]bool)
 	keepChunk := make(map[int]bool)
@@ -178,6 +183,15 @@ func applyBudget(cfg config.Config, counter TokenCounter, state json.RawMessage,
 		includedChunkIDs[item.ChunkID] = struct{}{}
 	}
 
+	// A redundant item only points at an item the caller can see in the pack.
+	for id, with := range redundantWith {
+		_, keptMemory := includedMemIDs[with]
+		_, keptChunk := includedChunkIDs[with]
+		if !keptMemory && !keptChunk {
+			delete(redundantWith, id)
+		}
+	}
+
 	return BudgetResult{
 		State:             stateJSON,
 		StateTokens:       stateTokens,
@@ -191,9 +205,91 @@ func applyBudget(cfg config.Config, counter TokenCounter, state json.RawMessage,
 		UsedTokens:        usedTokens,
 		IncludedMemoryIDs: includedMemIDs,
 		IncludedChunkIDs:  includedChunkIDs,
+		Selection:         selection,
+		RedundantWith:     redundantWith,
 	}, nil
 }
 
+func leadingIndexes(n, k int) []int {
+	if k > n {
+		k = n
+	}
+	if k < 0 {
+		k = 0
+	}
+	indexes := make([]int, k)
+	for i := range indexes {
+		indexes[i] = i
+	}
+	return indexes
+}
+
+func memoryBudgetItem(mem RankedMemory, index int) budgetItem {
+	return budgetItem{
+		Kind:      "memory",
+		Index:     index,
+		Score:     mem.FinalScore,
+		CreatedAt: mem.Memory.CreatedAt,
+		ID:        mem.Memory.ID,
+		Text:      mem.Memory.Title + " " + mem.Memory.Summary,
+	}
+}
+
+func chunkBudgetItem(chunk RankedChunk, index int) budgetItem {
+	return budgetItem{
+		Kind:      "chunk",
+		Index:     index,
+		Score:     chunk.FinalScore,
+		CreatedAt: chunk.Chunk.CreatedAt,
+		ID:        chunk.Chunk.ID,
+		Text:      chunk.Chunk.Text,
+	}
+}
+
+func sortBudgetItems(items []budgetItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+		if !items[i].CreatedAt.Equal(items[j].CreatedAt) {
+			return items[i].CreatedAt.After(items[j].CreatedAt)
+		}
+		return items[i].ID < items[j].ID
+	})
+}
+
+// mmrPicks runs MMR over every ranked candidate, not just the score-mode
+// top K, and takes picks in MMR order until each kind reaches its K. Slots
+// freed by near-duplicates are filled by the next picks from below the
+// score cutoff. It returns the picked indexes into memories and chunks, each
+// pick's MMR rank by ID, and the redundant candidates.
+func mmrPicks(cfg config.Config, memories []RankedMemory, chunks []RankedChunk, opts BudgetOptions) ([]int, []int, map[string]int, map[string]string) {
+	candidates := make([]budgetItem, 0, len(memories)+len(chunks))
+	for i, mem := range memories {
+		candidates = append(candidates, memoryBudgetItem(mem, i))
+	}
+	for i, chunk := range chunks {
+		candidates = append(candidates, chunkBudgetItem(chunk, i))
+	}
+	sortBudgetItems(candidates)
+	selected, _, redundantWith := selectMMR(candidates, opts)
+
+	var memPicks, chunkPicks []int
+	rank := make(map[string]int, len(selected))
+	for _, item := range selected {
+		switch {
+		case item.Kind == "memory" && len(memPicks) < cfg.MemoriesK:
+			memPicks = append(memPicks, item.Index)
+		case item.Kind == "chunk" && len(chunkPicks) < cfg.ChunksK:
+			chunkPicks = append(chunkPicks, item.Index)
+		default:
+			continue
+		}
+		rank[item.ID] = len(rank)
+	}
+	return memPicks, chunkPicks, rank, redundantWith
+}
+
 func normalizeState(cfg config.Config, counter TokenCounter, state json.RawMessage, stateTokens int) (json.RawMessage, int, int, error) {
 	if len(state) == 0 {
 		state = json.RawMessage("{}")
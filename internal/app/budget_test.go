@@ -164,3 +164,182 @@ func BenchmarkBudgetPack(b *testing.B) {
 		}
 	}
 }
+
+func TestBudgetMMRSkipsNearDuplicates(t *testing.T) {
+	cfg := config.Config{
+		TokenBudget:   100,
+		StateMax:      2,
+		MemoryMaxEach: 20,
+		MemoriesK:     3,
+	}
+	memories := []RankedMemory{
+		{
+			Memory:     store.Memory{ID: "M-1", Title: "Auth", Summary: "use jwt middleware for auth routes", CreatedAt: time.Unix(10, 0)},
+			FinalScore: 3,
+		},
+		{
+			Memory:     store.Memory{ID: "M-2", Title: "Auth", Summary: "use jwt middleware for auth routes", CreatedAt: time.Unix(9, 0)},
+			FinalScore: 2.9,
+		},
+		{
+			Memory:     store.Memory{ID: "M-3", Title: "Cache", Summary: "redis timeout is five seconds", CreatedAt: time.Unix(8, 0)},
+			FinalScore: 1,
+		},
+	}
+
+	result, err := applyBudgetWithOptions(cfg, fakeCounter{}, []byte("{}"), 0, memories, nil, BudgetOptions{Selection: selectionMMR})
+	if err != nil {
+		t.Fatalf("apply budget error: %v", err)
+	}
+	if result.Selection != selectionMMR {
+		t.Fatalf("expected mmr selection, got %q", result.Selection)
+	}
+	if got := result.RedundantWith["M-2"]; got != "M-1" {
+		t.Fatalf("expected M-2 redundant with M-1, got %q", got)
+	}
+	if _, ok := result.IncludedMemoryIDs["M-2"]; ok {
+		t.Fatalf("expected redundant M-2 to be excluded")
+	}
+	if _, ok := result.IncludedMemoryIDs["M-3"]; !ok {
+		t.Fatalf("expected M-3 to be included")
+	}
+	if result.UsedTokens != 2+6+5 {
+		t.Fatalf("expected redundant tokens to be released, used=%d", result.UsedTokens)
+	}
+}
+
+func TestBudgetMMRPrefersDiverseItemsUnderBudget(t *testing.T) {
+	cfg := config.Config{
+		TokenBudget:   2 + 4 + 4,
+		StateMax:      2,
+		MemoryMaxEach: 10,
+		MemoriesK:     3,
+	}
+	memories := []RankedMemory{
+		{Memory: store.Memory{ID: "M-1", Summary: "alpha beta gamma delta", CreatedAt: time.Unix(10, 0)}, FinalScore: 3},
+		{Memory: store.Memory{ID: "M-2", Summary: "alpha beta gamma epsilon", CreatedAt: time.Unix(9, 0)}, FinalScore: 2.8},
+		{Memory: store.Memory{ID: "M-3", Summary: "zeta eta theta iota", CreatedAt: time.Unix(8, 0)}, FinalScore: 2.5},
+	}
+	embeddings := map[string][]float64{
+		"M-1": {1, 0},
+		"M-2": {0.8, 0.6},
+		"M-3": {0, 1},
+	}
+
+	plain, err := applyBudget(cfg, fakeCounter{}, []byte("{}"), 0, memories, nil)
+	if err != nil {
+		t.Fatalf("apply budget error: %v", err)
+	}
+	if _, ok := plain.IncludedMemoryIDs["M-2"]; !ok {
+		t.Fatalf("expected score selection to keep M-2")
+	}
+
+	result, err := applyBudgetWithOptions(cfg, fakeCounter{}, []byte("{}"), 0, memories, nil, BudgetOptions{
+		Selection:        selectionMMR,
+		MMRLambda:        0.5,
+		MemoryEmbeddings: embeddings,
+	})
+	if err != nil {
+		t.Fatalf("apply budget error: %v", err)
+	}
+	if _, ok := result.IncludedMemoryIDs["M-3"]; !ok {
+		t.Fatalf("expected mmr to keep diverse M-3, got %v", result.IncludedMemoryIDs)
+	}
+	if _, ok := result.IncludedMemoryIDs["M-2"]; ok {
+		t.Fatalf("expected mmr to drop M-2 under budget")
+	}
+	if len(result.RedundantWith) != 0 {
+		t.Fatalf("expected no hard redundancy, got %v", result.RedundantWith)
+	}
+}
+
+func TestBudgetMMRFillsFreedSlotsFromBelowCutoff(t *testing.T) {
+	cfg := config.Config{
+		TokenBudget:   100,
+		StateMax:      2,
+		MemoryMaxEach: 20,
+		MemoriesK:     2,
+	}
+	memories := []RankedMemory{
+		{Memory: store.Memory{ID: "M-1", Title: "Auth", Summary: "use jwt middleware for auth routes", CreatedAt: time.Unix(10, 0)}, FinalScore: 3},
+		{Memory: store.Memory{ID: "M-2", Title: "Auth", Summary: "use jwt middleware for auth routes", CreatedAt: time.Unix(9, 0)}, FinalScore: 2.9},
+		{Memory: store.Memory{ID: "M-3", Title: "Cache", Summary: "redis timeout is five seconds", CreatedAt: time.Unix(8, 0)}, FinalScore: 1},
+	}
+
+	plain, err := applyBudget(cfg, fakeCounter{}, []byte("{}"), 0, memories, nil)
+	if err != nil {
+		t.Fatalf("apply budget error: %v", err)
+	}
+	if _, ok := plain.IncludedMemoryIDs["M-3"]; ok {
+		t.Fatalf("expected score selection to stop at MemoriesK")
+	}
+
+	result, err := applyBudgetWithOptions(cfg, fakeCounter{}, []byte("{}"), 0, memories, nil, BudgetOptions{Selection: selectionMMR})
+	if err != nil {
+		t.Fatalf("apply budget error: %v", err)
+	}
+	if got := result.RedundantWith["M-2"]; got != "M-1" {
+		t.Fatalf("expected M-2 redundant with M-1, got %q", got)
+	}
+	if len(result.Memories) != 2 || result.Memories[0].ID != "M-1" || result.Memories[1].ID != "M-3" {
+		t.Fatalf("expected M-3 to fill the slot freed by M-2, got %+v", result.Memories)
+	}
+}
+
+func TestBudgetMMRRedundantWithOnlyNamesKeptItems(t *testing.T) {
+	cfg := config.Config{
+		TokenBudget:   2 + 12,
+		StateMax:      2,
+		MemoryMaxEach: 20,
+		ChunkMaxEach:  20,
+		MemoriesK:     3,
+		ChunksK:       1,
+	}
+	memories := []RankedMemory{
+		{Memory: store.Memory{ID: "M-1", Title: "Auth", Summary: "use jwt middleware for auth routes", CreatedAt: time.Unix(10, 0)}, FinalScore: 3},
+		{Memory: store.Memory{ID: "M-2", Title: "Auth", Summary: "use jwt middleware for auth routes", CreatedAt: time.Unix(9, 0)}, FinalScore: 2.9},
+	}
+	chunks := []RankedChunk{
+		{Chunk: store.Chunk{ID: "C-1", Text: "one two three four five six seven eight nine ten eleven twelve", CreatedAt: time.Unix(10, 0)}, FinalScore: 5},
+	}
+
+	result, err := applyBudgetWithOptions(cfg, fakeCounter{}, []byte("{}"), 0, memories, chunks, BudgetOptions{Selection: selectionMMR})
+	if err != nil {
+		t.Fatalf("apply budget error: %v", err)
+	}
+	if _, ok := result.IncludedMemoryIDs["M-1"]; ok {
+		t.Fatalf("expected the budget to drop M-1, got %v", result.IncludedMemoryIDs)
+	}
+	if got, ok := result.RedundantWith["M-2"]; ok {
+		t.Fatalf("expected no redundancy pointing at dropped M-1, got %q", got)
+	}
+}
+
+func TestBudgetMMRRedundantOverlapIsConfigurable(t *testing.T) {
+	cfg := config.Config{
+		TokenBudget:   100,
+		StateMax:      2,
+		MemoryMaxEach: 20,
+		MemoriesK:     3,
+	}
+	memories := []RankedMemory{
+		{Memory: store.Memory{ID: "M-1", Title: "Auth", Summary: "use jwt middleware for auth routes", CreatedAt: time.Unix(10, 0)}, FinalScore: 3},
+		{Memory: store.Memory{ID: "M-2", Title: "Auth", Summary: "use jwt middleware for api paths", CreatedAt: time.Unix(9, 0)}, FinalScore: 2.9},
+	}
+
+	result, err := applyBudgetWithOptions(cfg, fakeCounter{}, []byte("{}"), 0, memories, nil, BudgetOptions{Selection: selectionMMR})
+	if err != nil {
+		t.Fatalf("apply budget error: %v", err)
+	}
+	if len(result.RedundantWith) != 0 {
+		t.Fatalf("expected the default overlap to keep M-2, got %v", result.RedundantWith)
+	}
+
+	result, err = applyBudgetWithOptions(cfg, fakeCounter{}, []byte("{}"), 0, memories, nil, BudgetOptions{Selection: selectionMMR, RedundantOverlap: 0.5})
+	if err != nil {
+		t.Fatalf("apply budget error: %v", err)
+	}
+	if got := result.RedundantWith["M-2"]; got != "M-1" {
+		t.Fatalf("expected a lower overlap to mark M-2 redundant with M-1, got %q", got)
+	}
+}
@@ -0,0 +1,545 @@
+package app
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"mem/internal/store"
+)
+
+// References are extracted per file after chunking and attached to the chunks
+// whose lines contain them. Go is read from the AST; Python and TypeScript
+// are scanned line by line, which misses some references but needs no
+// toolchain. An import is attached to every chunk that uses it, so a
+// function calling fmt.Println references both "fmt.Println" and "fmt".
+
+type fileImport struct {
+	Alias string
+	Path  string
+	Line  int
+}
+
+type lineRef struct {
+	Line      int
+	Kind      string
+	Name      string
+	Qualifier string
+}
+
+type fileRefs struct {
+	Imports []fileImport
+	Refs    []lineRef
+}
+
+var refExtractors = map[string]func(path string, content []byte) fileRefs{
+	"go":         goFileRefs,
+	"python":     pythonFileRefs,
+	"typescript": typeScriptFileRefs,
+}
+
+// attachChunkRefs fills in Refs for chunks produced by the named chunker.
+// Chunkers without an extractor leave chunks untouched.
+func attachChunkRefs(chunkerName, path string, content []byte, chunks []SemanticChunk) {
+	extract, ok := refExtractors[chunkerName]
+	if !ok {
+		return
+	}
+	refs := extract(path, content)
+	if len(refs.Imports) == 0 && len(refs.Refs) == 0 {
+		return
+	}
+	importsByAlias := make(map[string]fileImport, len(refs.Imports))
+	for _, imp := range refs.Imports {
+		if imp.Alias != "" {
+			importsByAlias[imp.Alias] = imp
+		}
+	}
+
+	for i := range chunks {
+		chunk := &chunks[i]
+		seen := map[store.ChunkRef]struct{}{}
+		add := func(kind, name string) {
+			ref := store.ChunkRef{Kind: kind, Name: name}
+			if _, ok := seen[ref]; ok || name == "" || name == chunk.SymbolName {
+				return
+			}
+			seen[ref] = struct{}{}
+			chunk.Refs = append(chunk.Refs, ref)
+		}
+		for _, imp := range refs.Imports {
+			// Go imports sit between the package clause and the first
+			// declaration, so the package overview carries them. Files
+			// without one keep the imports on the chunks that use them.
+			if (imp.Line >= chunk.StartLine && imp.Line <= chunk.EndLine) || chunk.SymbolKind == "package" {
+				add(store.RefKindImport, imp.Path)
+			}
+		}
+		for _, ref := range refs.Refs {
+			if ref.Line < chunk.StartLine || ref.Line > chunk.EndLine {
+				continue
+			}
+			add(ref.Kind, ref.Name)
+			if imp, ok := importsByAlias[ref.Qualifier]; ok {
+				add(store.RefKindImport, imp.Path)
+			}
+		}
+	}
+}
+
+func goFileRefs(path string, content []byte) fileRefs {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, content, parser.SkipObjectResolution)
+	if err != nil {
+		return fileRefs{}
+	}
+
+	var refs fileRefs
+	for _, spec := range f.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		alias := goImportAlias(importPath)
+		if spec.Name != nil {
+			alias = spec.Name.Name
+		}
+		refs.Imports = append(refs.Imports, fileImport{Alias: alias, Path: importPath, Line: fset.Position(spec.Pos()).Line})
+	}
+
+	typeParams := map[string]struct{}{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncType:
+			if node.TypeParams != nil {
+				for _, field := range node.TypeParams.List {
+					for _, name := range field.Names {
+						typeParams[name.Name] = struct{}{}
+					}
+				}
+			}
+		case *ast.TypeSpec:
+			if node.TypeParams != nil {
+				for _, field := range node.TypeParams.List {
+					for _, name := range field.Names {
+						typeParams[name.Name] = struct{}{}
+					}
+				}
+			}
+		}
+		return true
+	})
+
+	addType := func(expr ast.Expr) {
+		goTypeRefs(expr, func(name, qualifier string, pos token.Pos) {
+			if _, ok := typeParams[name]; ok {
+				return
+			}
+			refs.Refs = append(refs.Refs, lineRef{Line: fset.Position(pos).Line, Kind: store.RefKindType, Name: name, Qualifier: qualifier})
+		})
+	}
+
+	for _, decl := range f.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			continue
+		}
+		ast.Inspect(decl, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.FuncDecl:
+				// Skip the receiver: a method naming its own type is noise.
+				if node.Type != nil {
+					ast.Inspect(node.Type, func(n ast.Node) bool {
+						if field, ok := n.(*ast.Field); ok {
+							addType(field.Type)
+						}
+						return true
+					})
+				}
+				if node.Body != nil {
+					ast.Inspect(node.Body, func(n ast.Node) bool {
+						return goInspectRef(n, fset, addType, &refs)
+					})
+				}
+				return false
+			default:
+				return goInspectRef(n, fset, addType, &refs)
+			}
+		})
+	}
+	return refs
+}
+
+func goInspectRef(n ast.Node, fset *token.FileSet, addType func(ast.Expr), refs *fileRefs) bool {
+	switch node := n.(type) {
+	case *ast.CallExpr:
+		if name, qualifier := goCallName(node.Fun); name != "" {
+			refs.Refs = append(refs.Refs, lineRef{Line: fset.Position(node.Pos()).Line, Kind: store.RefKindCall, Name: name, Qualifier: qualifier})
+		}
+	case *ast.Field:
+		addType(node.Type)
+	case *ast.CompositeLit:
+		addType(node.Type)
+	case *ast.ValueSpec:
+		addType(node.Type)
+	case *ast.TypeSpec:
+		addType(node.Type)
+	case *ast.TypeAssertExpr:
+		addType(node.Type)
+	}
+	return true
+}
+
+// goCallName names a call target as written, keeping one qualifier:
+// "Println" in fmt.Println is "fmt.Println", s.db.Query is "db.Query".
+// Builtins and predeclared conversions are skipped.
+func goCallName(fun ast.Expr) (string, string) {
+	switch f := fun.(type) {
+	case *ast.ParenExpr:
+		return goCallName(f.X)
+	case *ast.IndexExpr:
+		return goCallName(f.X)
+	case *ast.IndexListExpr:
+		return goCallName(f.X)
+	case *ast.Ident:
+		if types.Universe.Lookup(f.Name) != nil {
+			return "", ""
+		}
+		return f.Name, ""
+	case *ast.SelectorExpr:
+		switch x := f.X.(type) {
+		case *ast.Ident:
+			return x.Name + "." + f.Sel.Name, x.Name
+		case *ast.SelectorExpr:
+			return x.Sel.Name + "." + f.Sel.Name, ""
+		}
+		return f.Sel.Name, ""
+	}
+	return "", ""
+}
+
+// goTypeRefs reports the named types in a type expression, skipping
+// predeclared types.
+func goTypeRefs(expr ast.Expr, report func(name, qualifier string, pos token.Pos)) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if types.Universe.Lookup(t.Name) == nil {
+			report(t.Name, "", t.Pos())
+		}
+	case *ast.SelectorExpr:
+		if x, ok := t.X.(*ast.Ident); ok {
+			report(x.Name+"."+t.Sel.Name, x.Name, t.Pos())
+		}
+	case *ast.StarExpr:
+		goTypeRefs(t.X, report)
+	case *ast.ParenExpr:
+		goTypeRefs(t.X, report)
+	case *ast.ArrayType:
+		goTypeRefs(t.Elt, report)
+	case *ast.Ellipsis:
+		goTypeRefs(t.Elt, report)
+	case *ast.MapType:
+		goTypeRefs(t.Key, report)
+		goTypeRefs(t.Value, report)
+	case *ast.ChanType:
+		goTypeRefs(t.Value, report)
+	case *ast.IndexExpr:
+		goTypeRefs(t.X, report)
+		goTypeRefs(t.Index, report)
+	case *ast.IndexListExpr:
+		goTypeRefs(t.X, report)
+		for _, index := range t.Indices {
+			goTypeRefs(index, report)
+		}
+	}
+}
+
+// goImportAlias is the name an import is used by when it is not renamed:
+// the last path element, skipping a major version suffix.
+func goImportAlias(importPath string) string {
+	base := path.Base(importPath)
+	if len(base) > 1 && base[0] == 'v' && strings.Trim(base[1:], "0123456789") == "" {
+		base = path.Base(path.Dir(importPath))
+	}
+	return strings.TrimPrefix(base, "go-")
+}
+
+var (
+	scriptCallPattern     = regexp.MustCompile(`([A-Za-z_$][\w$]*(?:\.[A-Za-z_$][\w$]*)*)\s*\(`)
+	scriptTypeNamePattern = regexp.MustCompile(`\b[A-Z][\w]*(?:\.[A-Z][\w]*)*`)
+	scriptStringPattern   = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|` + "`(?:[^`\\\\]|\\\\.)*`")
+
+	pythonImportPattern     = regexp.MustCompile(`^\s*import\s+(.+)$`)
+	pythonFromImportPattern = regexp.MustCompile(`^\s*from\s+(\S+)\s+import\s+(.+)$`)
+	pythonDefPattern        = regexp.MustCompile(`^\s*(?:async\s+)?def\s+\w+\s*\((.*)$`)
+	pythonClassPattern      = regexp.MustCompile(`^\s*class\s+\w+\s*\((.*)\)\s*:`)
+
+	tsImportFromPattern      = regexp.MustCompile(`^\s*(?:import|export)\s+(?:type\s+)?(.*?)\s*from\s*['"]([^'"]+)['"]`)
+	tsImportStatementPattern = regexp.MustCompile(`^(?:import[\s{*'"]|export\s+(?:type\s+)?[{*])`)
+	tsBareImportPattern      = regexp.MustCompile(`^\s*import\s*['"]([^'"]+)['"]`)
+	tsRequirePattern         = regexp.MustCompile(`(?:const|let|var)\s+(.+?)\s*=\s*require\(\s*['"]([^'"]+)['"]\s*\)`)
+	tsHeritagePattern        = regexp.MustCompile(`\b(?:extends|implements)\s+([\w.$]+(?:\s*<[^>]*>)?(?:\s*,\s*[\w.$]+(?:\s*<[^>]*>)?)*)`)
+	tsNewPattern             = regexp.MustCompile(`\bnew\s+([A-Za-z_$][\w.$]*)`)
+	tsAnnotationPattern      = regexp.MustCompile(`(?:[:<|&]|\bas)\s*([A-Z][\w.$]*)`)
+	tsMethodDeclPattern      = regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|async|readonly|override|abstract|get|set)\s+)*(?:function\s*\*?\s*)?([A-Za-z_$][\w$]*)\s*(?:<[^>]*>)?\s*\([^)]*\)\s*(?::[^{]*)?\{\s*$`)
+	tsFunctionDeclPrefix     = regexp.MustCompile(`\bfunction\s*\*?\s*$`)
+)
+
+var pythonSkipCalls = map[string]struct{}{
+	"if": {}, "elif": {}, "while": {}, "for": {}, "return": {}, "and": {}, "or": {}, "not": {}, "in": {},
+	"print": {}, "len": {}, "range": {}, "str": {}, "int": {}, "float": {}, "bool": {}, "list": {}, "dict": {},
+	"set": {}, "tuple": {}, "isinstance": {}, "super": {}, "type": {}, "lambda": {}, "with": {}, "assert": {},
+	"yield": {}, "await": {}, "except": {}, "del": {}, "enumerate": {}, "zip": {}, "sorted": {}, "open": {},
+	"getattr": {}, "setattr": {}, "hasattr": {}, "min": {}, "max": {}, "sum": {}, "any": {}, "all": {},
+}
+
+var pythonSkipTypes = map[string]struct{}{
+	"Optional": {}, "List": {}, "Dict": {}, "Any": {}, "Union": {}, "Tuple": {}, "Callable": {}, "Iterable": {},
+	"Iterator": {}, "Sequence": {}, "Mapping": {}, "Set": {}, "Type": {}, "None": {}, "True": {}, "False": {},
+}
+
+var tsSkipCalls = map[string]struct{}{
+	"if": {}, "for": {}, "while": {}, "switch": {}, "catch": {}, "function": {}, "return": {}, "typeof": {},
+	"await": {}, "super": {}, "import": {}, "require": {}, "constructor": {}, "new": {}, "void": {}, "delete": {},
+	"in": {}, "of": {}, "instanceof": {}, "yield": {},
+}
+
+var tsSkipTypes = map[string]struct{}{
+	"Promise": {}, "Array": {}, "Record": {}, "Partial": {}, "Readonly": {}, "Map": {}, "Set": {}, "Date": {},
+	"Object": {}, "String": {}, "Number": {}, "Boolean": {}, "Function": {}, "Error": {}, "Pick": {}, "Omit": {},
+	"Required": {}, "ReturnType": {}, "JSX": {},
+}
+
+// scriptCallName keeps one qualifier on a dotted call, like goCallName, and
+// returns the first segment as the qualifier an import alias may match.
+func scriptCallName(name string) (string, string) {
+	parts := strings.Split(name, ".")
+	qualifier := ""
+	if len(parts) > 1 {
+		qualifier = parts[0]
+	}
+	if len(parts) > 2 {
+		parts = parts[len(parts)-2:]
+	}
+	return strings.Join(parts, "."), qualifier
+}
+
+func scriptQualifier(name string) string {
+	if idx := strings.Index(name, "."); idx > 0 {
+		return name[:idx]
+	}
+	return name
+}
+
+func pythonFileRefs(_ string, content []byte) fileRefs {
+	var refs fileRefs
+	inDocstring := ""
+	for i, raw := range strings.Split(string(content), "\n") {
+		lineNo := i + 1
+		line := raw
+		if inDocstring != "" {
+			if strings.Contains(line, inDocstring) {
+				inDocstring = ""
+			}
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		for _, quote := range []string{`"""`, `'''`} {
+			if strings.HasPrefix(trimmed, quote) && strings.Count(trimmed, quote) == 1 {
+				inDocstring = quote
+			}
+		}
+		if inDocstring != "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if idx := strings.Index(line, "#"); idx >= 0 && !strings.ContainsAny(line[:idx], `"'`) {
+			line = line[:idx]
+		}
+
+		if m := pythonFromImportPattern.FindStringSubmatch(line); m != nil {
+			for _, name := range strings.Split(strings.Trim(m[2], "() "), ",") {
+				fields := strings.Fields(name)
+				if len(fields) == 0 || fields[0] == "*" {
+					continue
+				}
+				alias := fields[len(fields)-1]
+				refs.Imports = append(refs.Imports, fileImport{Alias: alias, Path: m[1], Line: lineNo})
+			}
+			continue
+		}
+		if m := pythonImportPattern.FindStringSubmatch(line); m != nil {
+			for _, name := range strings.Split(m[1], ",") {
+				fields := strings.Fields(name)
+				if len(fields) == 0 {
+					continue
+				}
+				alias := scriptQualifier(fields[0])
+				if len(fields) == 3 && fields[1] == "as" {
+					alias = fields[2]
+				}
+				refs.Imports = append(refs.Imports, fileImport{Alias: alias, Path: fields[0], Line: lineNo})
+			}
+			continue
+		}
+
+		line = scriptStringPattern.ReplaceAllString(line, `""`)
+		addType := func(name string) {
+			if _, skip := pythonSkipTypes[name]; !skip {
+				refs.Refs = append(refs.Refs, lineRef{Line: lineNo, Kind: store.RefKindType, Name: name, Qualifier: scriptQualifier(name)})
+			}
+		}
+		if m := pythonClassPattern.FindStringSubmatch(line); m != nil {
+			for _, base := range strings.Split(m[1], ",") {
+				base = strings.TrimSpace(base)
+				if base == "" || base == "object" || strings.Contains(base, "=") {
+					continue
+				}
+				addType(base)
+			}
+			continue
+		}
+		callText := line
+		if m := pythonDefPattern.FindStringSubmatch(line); m != nil {
+			for _, name := range scriptTypeNamePattern.FindAllString(m[1], -1) {
+				addType(name)
+			}
+			callText = ""
+		}
+		for _, m := range scriptCallPattern.FindAllStringSubmatch(callText, -1) {
+			if _, skip := pythonSkipCalls[m[1]]; skip {
+				continue
+			}
+			name, qualifier := scriptCallName(m[1])
+			if qualifier == "" {
+				qualifier = name
+			}
+			refs.Refs = append(refs.Refs, lineRef{Line: lineNo, Kind: store.RefKindCall, Name: name, Qualifier: qualifier})
+		}
+	}
+	return refs
+}
+
+func typeScriptFileRefs(_ string, content []byte) fileRefs {
+	var refs fileRefs
+	lines := strings.Split(string(content), "\n")
+	inComment := false
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if inComment {
+			if strings.Contains(line, "*/") {
+				inComment = false
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "/*") {
+			inComment = !strings.Contains(trimmed, "*/")
+			continue
+		}
+		if strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "*") {
+			continue
+		}
+
+		if tsImportStatementPattern.MatchString(trimmed) {
+			statement := trimmed
+			end := i
+			for end+1 < len(lines) && !tsImportFromPattern.MatchString(statement) && !tsBareImportPattern.MatchString(statement) && end-i < 50 {
+				end++
+				statement += " " + strings.TrimSpace(lines[end])
+			}
+			if m := tsImportFromPattern.FindStringSubmatch(statement); m != nil {
+				for _, alias := range tsImportAliases(m[1]) {
+					refs.Imports = append(refs.Imports, fileImport{Alias: alias, Path: m[2], Line: lineNo})
+				}
+				if len(tsImportAliases(m[1])) == 0 {
+					refs.Imports = append(refs.Imports, fileImport{Path: m[2], Line: lineNo})
+				}
+				i = end
+				continue
+			}
+			if m := tsBareImportPattern.FindStringSubmatch(statement); m != nil {
+				refs.Imports = append(refs.Imports, fileImport{Path: m[1], Line: lineNo})
+				i = end
+				continue
+			}
+		}
+		if m := tsRequirePattern.FindStringSubmatch(line); m != nil {
+			for _, alias := range tsImportAliases(m[1]) {
+				refs.Imports = append(refs.Imports, fileImport{Alias: alias, Path: m[2], Line: lineNo})
+			}
+			continue
+		}
+
+		if idx := strings.Index(line, "//"); idx >= 0 && !strings.ContainsAny(line[:idx], "\"'`") {
+			line = line[:idx]
+		}
+		line = scriptStringPattern.ReplaceAllString(line, `""`)
+
+		addType := func(name string) {
+			name = strings.TrimSpace(name)
+			if _, skip := tsSkipTypes[name]; skip || name == "" {
+				return
+			}
+			refs.Refs = append(refs.Refs, lineRef{Line: lineNo, Kind: store.RefKindType, Name: name, Qualifier: scriptQualifier(name)})
+		}
+		for _, m := range tsHeritagePattern.FindAllStringSubmatch(line, -1) {
+			for _, name := range strings.Split(m[1], ",") {
+				if idx := strings.Index(name, "<"); idx >= 0 {
+					name = name[:idx]
+				}
+				addType(name)
+			}
+		}
+		for _, m := range tsNewPattern.FindAllStringSubmatch(line, -1) {
+			addType(m[1])
+		}
+		for _, m := range tsAnnotationPattern.FindAllStringSubmatch(line, -1) {
+			addType(m[1])
+		}
+
+		declared := ""
+		if m := tsMethodDeclPattern.FindStringSubmatch(line); m != nil {
+			declared = m[1]
+		}
+		for _, loc := range scriptCallPattern.FindAllStringSubmatchIndex(line, -1) {
+			name := line[loc[2]:loc[3]]
+			if _, skip := tsSkipCalls[name]; skip || name == declared {
+				continue
+			}
+			prefix := line[:loc[2]]
+			if tsFunctionDeclPrefix.MatchString(prefix) || strings.HasSuffix(strings.TrimSpace(prefix), "new") {
+				continue
+			}
+			callName, qualifier := scriptCallName(name)
+			if qualifier == "" {
+				qualifier = callName
+			}
+			refs.Refs = append(refs.Refs, lineRef{Line: lineNo, Kind: store.RefKindCall, Name: callName, Qualifier: qualifier})
+		}
+	}
+	return refs
+}
+
+// tsImportAliases lists the local names an import clause binds:
+// "React, { useState as use }" binds React and use; "* as ns" binds ns.
+func tsImportAliases(clause string) []string {
+	clause = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(clause), "type "))
+	var aliases []string
+	for _, part := range strings.FieldsFunc(clause, func(r rune) bool { return r == ',' || r == '{' || r == '}' }) {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		alias := fields[len(fields)-1]
+		if alias == "*" || alias == "type" {
+			continue
+		}
+		aliases = append(aliases, strings.TrimSuffix(alias, ":"))
+	}
+	return aliases
+}
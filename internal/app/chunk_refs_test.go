@@ -0,0 +1,119 @@
+package app
+
+import (
+	"testing"
+
+	"mem/internal/store"
+)
+
+func chunkRefsFor(t *testing.T, path, src string) map[string][]store.ChunkRef {
+	t.Helper()
+	chunks, err := chunkFile(path, []byte(src), 400, 0, testTokenCounter(t))
+	if err != nil {
+		t.Fatalf("chunk %s: %v", path, err)
+	}
+	c, _ := chunkerForPath(path)
+	attachChunkRefs(c.Name(), path, []byte(src), chunks)
+	bySymbol := map[string][]store.ChunkRef{}
+	for _, chunk := range chunks {
+		bySymbol[chunk.SymbolName] = chunk.Refs
+	}
+	return bySymbol
+}
+
+func assertChunkRefs(t *testing.T, symbol string, got []store.ChunkRef, want ...store.ChunkRef) {
+	t.Helper()
+	have := map[store.ChunkRef]struct{}{}
+	for _, ref := range got {
+		have[ref] = struct{}{}
+	}
+	for _, ref := range want {
+		if _, ok := have[ref]; !ok {
+			t.Fatalf("%s: missing %s %s in %v", symbol, ref.Kind, ref.Name, got)
+		}
+	}
+}
+
+func TestAttachChunkRefsGo(t *testing.T) {
+	refs := chunkRefsFor(t, "server.go", `// Package server serves.
+package server
+
+import (
+	"fmt"
+	sq "database/sql"
+	"mem/internal/store"
+)
+
+type Server struct {
+	db *sq.DB
+}
+
+func (s *Server) Find(name string) ([]store.Chunk, error) {
+	fmt.Println(len(name))
+	return s.st.FindChunksBySymbol(name)
+}
+`)
+	assertChunkRefs(t, "server", refs["server"],
+		store.ChunkRef{Kind: "import", Name: "fmt"},
+		store.ChunkRef{Kind: "import", Name: "database/sql"},
+		store.ChunkRef{Kind: "import", Name: "mem/internal/store"})
+	assertChunkRefs(t, "Server", refs["Server"],
+		store.ChunkRef{Kind: "type", Name: "sq.DB"},
+		store.ChunkRef{Kind: "import", Name: "database/sql"})
+	assertChunkRefs(t, "Server.Find", refs["Server.Find"],
+		store.ChunkRef{Kind: "type", Name: "store.Chunk"},
+		store.ChunkRef{Kind: "import", Name: "mem/internal/store"},
+		store.ChunkRef{Kind: "call", Name: "fmt.Println"},
+		store.ChunkRef{Kind: "import", Name: "fmt"},
+		store.ChunkRef{Kind: "call", Name: "st.FindChunksBySymbol"})
+	for _, ref := range refs["Server.Find"] {
+		if ref.Name == "len" || ref.Name == "Server" || ref.Name == "database/sql" {
+			t.Fatalf("unexpected ref %v in Server.Find", ref)
+		}
+	}
+}
+
+func TestAttachChunkRefsPythonAndTypeScript(t *testing.T) {
+	py := chunkRefsFor(t, "service.py", `import os
+from models import User, Account as Acct
+
+def load(user: User) -> Optional[Acct]:
+    """Load with call(x)."""
+    path = os.path.join("a", "b")
+    return Acct(fetch(user))
+`)
+	assertChunkRefs(t, "load", py["load"],
+		store.ChunkRef{Kind: "type", Name: "User"},
+		store.ChunkRef{Kind: "import", Name: "models"},
+		store.ChunkRef{Kind: "call", Name: "path.join"},
+		store.ChunkRef{Kind: "import", Name: "os"},
+		store.ChunkRef{Kind: "call", Name: "fetch"})
+	for _, ref := range py["load"] {
+		if ref.Name == "call" || ref.Name == "Optional" {
+			t.Fatalf("unexpected python ref %v", ref)
+		}
+	}
+
+	ts := chunkRefsFor(t, "view.ts", `import React, { useState as use } from 'react';
+import {
+  Store,
+} from './store';
+
+export function render(store: Store): void {
+  const [x] = use(0);
+  return new Widget(store.find(x));
+}
+`)
+	assertChunkRefs(t, "render", ts["render"],
+		store.ChunkRef{Kind: "type", Name: "Store"},
+		store.ChunkRef{Kind: "import", Name: "./store"},
+		store.ChunkRef{Kind: "call", Name: "use"},
+		store.ChunkRef{Kind: "import", Name: "react"},
+		store.ChunkRef{Kind: "type", Name: "Widget"},
+		store.ChunkRef{Kind: "call", Name: "store.find"})
+	for _, ref := range ts["render"] {
+		if ref.Name == "render" {
+			t.Fatalf("declaration recorded as a call: %v", ts["render"])
+		}
+	}
+}
@@ -1,13 +1,10 @@
 package app
 
 import (
-	"go/ast"
-	"go/parser"
-	"go/token"
-	"path/filepath"
 	"strings"
 	"unicode"
 
+	"mem/internal/store"
 	memtoken "mem/internal/token"
 )
 
@@ -19,144 +16,22 @@ type SemanticChunk struct {
 	ChunkType  string
 	SymbolName string
 	SymbolKind string
+	// Anchor, when set, replaces the line range in the chunk's locator
+	// ("#cell-<id>" instead of "#L<start>-L<end>").
+	Anchor string
+	// Refs is filled in by attachChunkRefs after chunking.
+	Refs []store.ChunkRef
 }
 
+// chunkFile chunks content with the default chunker for the file's
+// extension, using line chunks for unknown extensions.
 func chunkFile(path string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
-	ext := strings.ToLower(filepath.Ext(path))
-
-	switch ext {
-	case ".go":
-		chunks, err := chunkGo(path, content, maxTokens, overlapTokens, counter)
-		if err != nil || len(chunks) == 0 {
-			return chunkLinesSemanticWrap(content, maxTokens, overlapTokens, counter)
-		}
-		return chunks, nil
-	case ".py":
-		chunks, err := chunkPython(content, maxTokens, overlapTokens, counter)
-		if err != nil || len(chunks) == 0 {
-			return chunkLinesSemanticWrap(content, maxTokens, overlapTokens, counter)
-		}
-		return chunks, nil
-	case ".js", ".jsx", ".ts", ".tsx", ".mts", ".cts":
-		chunks, err := chunkTypeScript(content, maxTokens, overlapTokens, counter)
-		if err != nil || len(chunks) == 0 {
-			return chunkLinesSemanticWrap(content, maxTokens, overlapTokens, counter)
-		}
-		return chunks, nil
-	default:
-		return chunkLinesSemanticWrap(content, maxTokens, overlapTokens, counter)
-	}
-}
-
-func chunkGo(path string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
-	fset := token.NewFileSet()
-	f, err := parser.ParseFile(fset, path, content, parser.ParseComments)
-	if err != nil {
-		return nil, err
-	}
-
-	lines := strings.Split(string(content), "\n")
-	var chunks []SemanticChunk
-
-	for _, decl := range f.Decls {
-		switch d := decl.(type) {
-		case *ast.FuncDecl:
-			funcChunks := extractGoFunc(fset, d, lines, counter, maxTokens, overlapTokens)
-			chunks = append(chunks, funcChunks...)
-		case *ast.GenDecl:
-			for _, spec := range d.Specs {
-				if ts, ok := spec.(*ast.TypeSpec); ok {
-					typeChunks := extractGoType(fset, ts, lines, counter, maxTokens, overlapTokens)
-					chunks = append(chunks, typeChunks...)
-				}
-			}
-		}
-	}
-
-	return chunks, nil
-}
-
-func extractGoFunc(fset *token.FileSet, fn *ast.FuncDecl, lines []string, counter *memtoken.Counter, maxTokens, overlapTokens int) []SemanticChunk {
-	startPos := fset.Position(fn.Pos())
-	endPos := fset.Position(fn.End())
-
-	startLine := startPos.Line - 1
-	endLine := endPos.Line
-	if startLine < 0 {
-		startLine = 0
-	}
-	if endLine > len(lines) {
-		endLine = len(lines)
-	}
-
-	text := strings.Join(lines[startLine:endLine], "\n")
-	tokens := counter.Count(text)
-
-	symbolKind := "function"
-	symbolName := fn.Name.Name
-	if fn.Recv != nil && len(fn.Recv.List) > 0 {
-		symbolKind = "method"
-		switch t := fn.Recv.List[0].Type.(type) {
-		case *ast.StarExpr:
-			if ident, ok := t.X.(*ast.Ident); ok {
-				symbolName = ident.Name + "." + fn.Name.Name
-			}
-		case *ast.Ident:
-			symbolName = t.Name + "." + fn.Name.Name
-		}
+	c, ok := chunkerForPath(path)
+	if !ok {
+		c, _ = lookupChunker(lineChunkerName)
 	}
-
-	if tokens <= maxTokens {
-		return []SemanticChunk{{
-			Text:       text,
-			StartLine:  startLine + 1,
-			EndLine:    endLine,
-			ChunkType:  "function",
-			SymbolName: symbolName,
-			SymbolKind: symbolKind,
-		}}
-	}
-
-	return splitWithMetadata(lines[startLine:endLine], startLine+1, symbolName, symbolKind, counter, maxTokens, overlapTokens)
-}
-
-func extractGoType(fset *token.FileSet, spec *ast.TypeSpec, lines []string, counter *memtoken.Counter, maxTokens, overlapTokens int) []SemanticChunk {
-	startPos := fset.Position(spec.Pos())
-	endPos := fset.Position(spec.End())
-
-	startLine := startPos.Line - 1
-	endLine := endPos.Line
-	if startLine < 0 {
-		startLine = 0
-	}
-	if endLine > len(lines) {
-		endLine = len(lines)
-	}
-
-	text := strings.Join(lines[startLine:endLine], "\n")
-	tokens := counter.Count(text)
-
-	symbolKind := "type"
-	switch spec.Type.(type) {
-	case *ast.StructType:
-		symbolKind = "struct"
-	case *ast.InterfaceType:
-		symbolKind = "interface"
-	}
-	symbolName := spec.Name.Name
-
-	if tokens <= maxTokens {
-		return []SemanticChunk{{
-			Text:       text,
-			StartLine:  startLine + 1,
-			EndLine:    endLine,
-			ChunkType:  "class",
-			SymbolName: symbolName,
-			SymbolKind: symbolKind,
-		}}
-	}
-
-	return splitWithMetadata(lines[startLine:endLine], startLine+1, symbolName, symbolKind, counter, maxTokens, overlapTokens)
+	chunks, _, err := runChunker(c, path, content, maxTokens, overlapTokens, counter)
+	return chunks, err
 }
 
 type pythonDecl struct {
@@ -988,6 +863,210 @@ func isIdentifierPart(r rune, allowDollar bool) bool {
 	return isIdentifierStart(r, allowDollar) || unicode.IsDigit(r)
 }
 
+func isASCIIIdentByte(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
+
+// braceDecl is a declaration in a brace-delimited language. Containers
+// (classes, impls, modules) are opened up when they do not fit the budget, so
+// their members become chunks of their own.
+type braceDecl struct {
+	StartLine   int
+	HeaderLine  int
+	EndLine     int
+	ChunkType   string
+	SymbolName  string
+	SymbolKind  string
+	IsContainer bool
+}
+
+// braceDeclChunks emits the chunks for decl. Lines of an opened container
+// that fall between its members are kept as chunks of the container itself
+// unless they hold only comments and braces.
+func braceDeclChunks(lines []string, decl braceDecl, members func(braceDecl) []braceDecl, counter *memtoken.Counter, maxTokens, overlapTokens int) []SemanticChunk {
+	declLines := lines[decl.StartLine:decl.EndLine]
+	text := strings.Join(declLines, "\n")
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	fits := counter.Count(text) <= maxTokens
+	var inner []braceDecl
+	if decl.IsContainer && !fits {
+		inner = members(decl)
+	}
+	if len(inner) == 0 {
+		if fits {
+			return []SemanticChunk{{
+				Text:       text,
+				StartLine:  decl.StartLine + 1,
+				EndLine:    decl.EndLine,
+				ChunkType:  decl.ChunkType,
+				SymbolName: decl.SymbolName,
+				SymbolKind: decl.SymbolKind,
+			}}
+		}
+		return splitWithMetadata(declLines, decl.StartLine+1, decl.SymbolName, decl.SymbolKind, counter, maxTokens, overlapTokens)
+	}
+
+	var chunks []SemanticChunk
+	gapStart := decl.StartLine
+	flushGap := func(end int) {
+		if end <= gapStart || isTrivialBraceGap(lines[gapStart:end]) {
+			return
+		}
+		gap := lines[gapStart:end]
+		gapText := strings.Join(gap, "\n")
+		if counter.Count(gapText) > maxTokens {
+			chunks = append(chunks, splitWithMetadata(gap, gapStart+1, decl.SymbolName, decl.SymbolKind, counter, maxTokens, overlapTokens)...)
+			return
+		}
+		chunks = append(chunks, SemanticChunk{
+			Text:       gapText,
+			StartLine:  gapStart + 1,
+			EndLine:    end,
+			ChunkType:  decl.ChunkType,
+			SymbolName: decl.SymbolName,
+			SymbolKind: decl.SymbolKind,
+		})
+	}
+	for _, member := range inner {
+		flushGap(member.StartLine)
+		chunks = append(chunks, braceDeclChunks(lines, member, members, counter, maxTokens, overlapTokens)...)
+		gapStart = member.EndLine
+	}
+	flushGap(decl.EndLine)
+	return chunks
+}
+
+// findBraceDeclEnd returns the exclusive end line of the declaration whose
+// header is at headerLine. Bodies end when braces return to the header depth;
+// bodiless declarations end at a semicolon or, when continues is set, at the
+// first line that does not continue onto the next.
+func findBraceDeclEnd(lines []string, scan []typeScriptLineScan, headerLine, limit int, continues func(line, next string) bool) int {
+	baseDepth := scan[headerLine].StartDepth
+	parens := 0
+	for i := headerLine; i < limit; i++ {
+		trimmed := strings.TrimSpace(stripTypeScriptLineComment(lines[i]))
+		parens += strings.Count(trimmed, "(") - strings.Count(trimmed, ")")
+		if parens < 0 {
+			parens = 0
+		}
+		if parens > 0 {
+			continue
+		}
+		if scan[i].HasOpenBrace || scan[i].EndDepth > baseDepth {
+			for j := i; j < limit; j++ {
+				if scan[j].EndDepth <= baseDepth {
+					return j + 1
+				}
+			}
+			return limit
+		}
+		if strings.HasSuffix(trimmed, ";") {
+			return i + 1
+		}
+		if continues != nil && !continues(trimmed, nextBraceCodeLine(lines, i+1, limit)) {
+			return i + 1
+		}
+	}
+	return limit
+}
+
+func nextBraceCodeLine(lines []string, start, limit int) string {
+	for j := start; j < limit; j++ {
+		trimmed := strings.TrimSpace(stripTypeScriptLineComment(lines[j]))
+		if trimmed != "" && !isBraceCommentLine(trimmed) {
+			return trimmed
+		}
+	}
+	return ""
+}
+
+// braceLeadingCommentStart extends a declaration upwards over the doc or line
+// comments directly above it.
+func braceLeadingCommentStart(lines []string, scan []typeScriptLineScan, start, floor, depth int) int {
+	for start > floor {
+		prev := strings.TrimSpace(lines[start-1])
+		if prev == "" || scan[start-1].StartDepth != depth || !isBraceCommentLine(prev) {
+			break
+		}
+		start--
+	}
+	return start
+}
+
+func isBraceCommentLine(trimmed string) bool {
+	return strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*") || strings.HasPrefix(trimmed, "*")
+}
+
+// isTrivialBraceGap reports whether lines hold nothing but blanks, comments,
+// braces, and C++ access specifiers.
+func isTrivialBraceGap(lines []string) bool {
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(stripTypeScriptLineComment(line))
+		trimmed = strings.Trim(trimmed, "{};")
+		if trimmed == "" || isBraceCommentLine(trimmed) || isAccessSpecifierLine(trimmed) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+func isAccessSpecifierLine(trimmed string) bool {
+	switch trimmed {
+	case "public:", "protected:", "private:":
+		return true
+	}
+	return false
+}
+
+// packLineUnits fills chunks with whole units, each a [start, end) line
+// range that must not be broken up. Units are expected in order and may be
+// separated by lines that belong to none of them; those lines travel with
+// the unit after them. A unit larger than the budget on its own is split by
+// lines.
+func packLineUnits(lines []string, units [][2]int, chunkType, symbolName, symbolKind string, counter *memtoken.Counter, maxTokens, overlapTokens int) []SemanticChunk {
+	if len(units) == 0 {
+		return nil
+	}
+	var chunks []SemanticChunk
+	bufStart, bufEnd, bufTokens := units[0][0], units[0][0], 0
+	flush := func() {
+		if bufEnd > bufStart {
+			text := strings.Join(lines[bufStart:bufEnd], "\n")
+			if strings.TrimSpace(text) != "" {
+				chunks = append(chunks, SemanticChunk{
+					Text:       text,
+					StartLine:  bufStart + 1,
+					EndLine:    bufEnd,
+					ChunkType:  chunkType,
+					SymbolName: symbolName,
+					SymbolKind: symbolKind,
+				})
+			}
+		}
+		bufStart, bufTokens = bufEnd, 0
+	}
+
+	for _, unit := range units {
+		unitTokens := counter.Count(strings.Join(lines[unit[0]:unit[1]], "\n"))
+		if unitTokens > maxTokens {
+			flush()
+			chunks = append(chunks, splitWithMetadata(lines[unit[0]:unit[1]], unit[0]+1, symbolName, symbolKind, counter, maxTokens, overlapTokens)...)
+			bufStart, bufEnd = unit[1], unit[1]
+			continue
+		}
+		if bufTokens > 0 && bufTokens+unitTokens > maxTokens {
+			flush()
+		}
+		bufEnd = unit[1]
+		bufTokens += unitTokens
+	}
+	flush()
+	return chunks
+}
+
 func splitWithMetadata(lines []string, baseLineNum int, symbolName, symbolKind string, counter *memtoken.Counter, maxTokens, overlapTokens int) []SemanticChunk {
 	var chunks []SemanticChunk
 	var buf []string
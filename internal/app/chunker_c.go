@@ -0,0 +1,557 @@
+package app
+
+import (
+	"strings"
+
+	memtoken "mem/internal/token"
+)
+
+// C, C++, and C# share enough syntax for one brace-aware chunker. Function
+// definitions, structs, classes, unions, and enums become chunks; namespaces
+// and extern "C" blocks are transparent but qualify the symbols inside them
+// ("net::Socket::send" in C++, "Net.Socket.Send" in C#). C and C++ function
+// prototypes, which carry no body, are marked with the "header" chunk type so
+// declarations can be told apart from definitions.
+
+var csharpModifiers = map[string]struct{}{
+	"public": {}, "private": {}, "protected": {}, "internal": {}, "static": {},
+	"sealed": {}, "abstract": {}, "partial": {}, "readonly": {}, "unsafe": {},
+	"new": {}, "file": {}, "ref": {}, "virtual": {}, "override": {}, "async": {},
+	"extern": {}, "required": {}, "volatile": {},
+}
+
+var cNonFunctionWords = map[string]struct{}{
+	"if": {}, "for": {}, "while": {}, "switch": {}, "return": {}, "else": {},
+	"do": {}, "sizeof": {}, "case": {}, "new": {}, "delete": {}, "throw": {},
+	"goto": {}, "catch": {}, "using": {}, "typedef": {}, "co_return": {},
+	"static_assert": {}, "lock": {}, "foreach": {}, "await": {},
+}
+
+type cFamilySource struct {
+	lines  []string
+	code   []string
+	scan   []typeScriptLineScan
+	csharp bool
+}
+
+func (src cFamilySource) separator() string {
+	if src.csharp {
+		return "."
+	}
+	return "::"
+}
+
+func chunkCFamily(content []byte, csharp bool, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+	lines := strings.Split(string(content), "\n")
+	src := cFamilySource{
+		lines:  lines,
+		code:   cFamilyCodeLines(lines),
+		scan:   scanTypeScriptLines(lines),
+		csharp: csharp,
+	}
+
+	members := func(owner braceDecl) []braceDecl {
+		short := owner.SymbolName
+		if idx := strings.LastIndex(short, src.separator()); idx >= 0 {
+			short = short[idx+len(src.separator()):]
+		}
+		depth := src.scan[owner.HeaderLine].StartDepth + 1
+		return collectCFamilyDecls(src, owner.HeaderLine+1, owner.EndLine, depth, owner.SymbolName+src.separator(), short)
+	}
+
+	decls := collectCFamilyDecls(src, 0, len(lines), 0, "", "")
+	chunks := make([]SemanticChunk, 0, len(decls))
+	for _, decl := range decls {
+		chunks = append(chunks, braceDeclChunks(lines, decl, members, counter, maxTokens, overlapTokens)...)
+	}
+	return chunks, nil
+}
+
+// collectCFamilyDecls finds declarations starting at depth within [from, to).
+// prefix qualifies symbol names; owner is the enclosing type's short name.
+func collectCFamilyDecls(src cFamilySource, from, to, depth int, prefix, owner string) []braceDecl {
+	decls := make([]braceDecl, 0)
+	for i := from; i < to; {
+		trimmed := strings.TrimSpace(src.code[i])
+		if src.scan[i].StartDepth != depth || trimmed == "" {
+			i++
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			i = skipPreprocessorLines(src.code, i, to)
+			continue
+		}
+		if src.csharp && (strings.HasPrefix(trimmed, "using ") || strings.HasPrefix(trimmed, "extern alias ")) && strings.HasSuffix(trimmed, ";") {
+			i++
+			continue
+		}
+
+		headerLine := skipCFamilyPrefixLines(src.code, i, to)
+		if headerLine >= to {
+			break
+		}
+		header := cFamilyHeaderText(src.code, headerLine, to)
+
+		if name, ok := parseCFamilyScope(header); ok {
+			scopePrefix := prefix
+			if name != "" {
+				scopePrefix = prefix + strings.ReplaceAll(name, ".", src.separator()) + src.separator()
+			}
+			if strings.HasSuffix(header, ";") {
+				// C# file-scoped namespace: the rest of the file belongs to it.
+				decls = append(decls, collectCFamilyDecls(src, headerLine+1, to, depth, scopePrefix, owner)...)
+				break
+			}
+			end := findBraceDeclEnd(src.code, src.scan, headerLine, to, nil)
+			decls = append(decls, collectCFamilyDecls(src, headerLine+1, end, depth+1, scopePrefix, owner)...)
+			i = end
+			continue
+		}
+
+		end := findBraceDeclEnd(src.code, src.scan, headerLine, to, nil)
+		decl := braceDecl{
+			StartLine:  braceLeadingCommentStart(src.lines, src.scan, i, from, depth),
+			HeaderLine: headerLine,
+			EndLine:    end,
+		}
+		if kind, name, ok := parseCFamilyTypeHeader(header, src.csharp); ok {
+			if name == "" {
+				name = cTypedefAlias(src.code[end-1])
+			}
+			if name == "" {
+				i = end
+				continue
+			}
+			decl.SymbolKind = kind
+			decl.SymbolName = prefix + name
+			decl.ChunkType = "class"
+			switch kind {
+			case "enum":
+				decl.ChunkType = "enum"
+			case "interface":
+				decl.ChunkType = "interface"
+			}
+			decl.IsContainer = kind != "enum"
+		} else if kind, name, ok := parseCFamilyMemberHeader(header, owner, src.csharp); ok {
+			decl.SymbolKind = kind
+			decl.SymbolName = prefix + name
+			decl.ChunkType = "function"
+			if !src.csharp && !cHeaderHasBody(header) {
+				decl.ChunkType = "header"
+			}
+		} else {
+			i = headerLine + 1
+			continue
+		}
+		decls = append(decls, decl)
+		i = end
+	}
+	return decls
+}
+
+// skipPreprocessorLines returns the line after the directive at start,
+// following backslash continuations.
+func skipPreprocessorLines(code []string, start, to int) int {
+	i := start
+	for i < to && strings.HasSuffix(strings.TrimSpace(code[i]), "\\") {
+		i++
+	}
+	return i + 1
+}
+
+// skipCFamilyPrefixLines moves past attribute lines ([Serializable],
+// [[nodiscard]]) and bare template parameter lists to the line that carries
+// the declaration itself.
+func skipCFamilyPrefixLines(code []string, start, to int) int {
+	for i := start; i < to; i++ {
+		trimmed := strings.TrimSpace(code[i])
+		if trimmed == "" {
+			continue
+		}
+		if stripCFamilyPrefixes(trimmed) != "" {
+			return i
+		}
+		if strings.Count(trimmed, "[") > strings.Count(trimmed, "]") || strings.Count(trimmed, "<") > strings.Count(trimmed, ">") {
+			// The prefix continues up to the line that closes it.
+			for i+1 < to && !strings.ContainsAny(code[i+1], "]>") {
+				i++
+			}
+			i++
+		}
+	}
+	return to
+}
+
+// stripCFamilyPrefixes drops leading attributes and template<...> clauses.
+func stripCFamilyPrefixes(line string) string {
+	line = strings.TrimSpace(line)
+	for {
+		switch {
+		case strings.HasPrefix(line, "["):
+			depth := 0
+			end := -1
+			for idx := 0; idx < len(line); idx++ {
+				if line[idx] == '[' {
+					depth++
+				} else if line[idx] == ']' {
+					depth--
+					if depth == 0 {
+						end = idx
+						break
+					}
+				}
+			}
+			if end < 0 {
+				return ""
+			}
+			line = strings.TrimSpace(line[end+1:])
+		case strings.HasPrefix(line, "template") && strings.HasPrefix(strings.TrimSpace(line[len("template"):]), "<"):
+			rest := strings.TrimSpace(line[len("template"):])
+			stripped := skipJavaTypeParams(rest)
+			if stripped == rest {
+				return ""
+			}
+			line = stripped
+		default:
+			return line
+		}
+	}
+}
+
+// cFamilyHeaderText joins a declaration header up to its opening brace or
+// semicolon, so return types and parameter lists split across lines are
+// recognised.
+func cFamilyHeaderText(code []string, headerLine, limit int) string {
+	parts := make([]string, 0, 4)
+	for j := headerLine; j < limit && j < headerLine+8; j++ {
+		trimmed := strings.TrimSpace(code[j])
+		if j == headerLine {
+			trimmed = stripCFamilyPrefixes(trimmed)
+		} else if strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		parts = append(parts, trimmed)
+		if strings.Contains(trimmed, "{") || strings.HasSuffix(trimmed, ";") || strings.Contains(trimmed, "=>") {
+			break
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseCFamilyScope recognises namespaces and extern "C" blocks, returning
+// the namespace name (empty for anonymous namespaces and extern blocks).
+func parseCFamilyScope(header string) (string, bool) {
+	switch {
+	case strings.HasPrefix(header, "namespace ") || header == "namespace" || strings.HasPrefix(header, "namespace{"):
+		if !strings.Contains(header, "{") && !strings.HasSuffix(header, ";") {
+			return "", false
+		}
+		name := strings.TrimPrefix(header, "namespace")
+		if idx := strings.IndexAny(name, "{;"); idx >= 0 {
+			name = name[:idx]
+		}
+		return strings.TrimSpace(name), true
+	case strings.HasPrefix(header, "extern \""):
+		rest := header[len("extern \""):]
+		end := strings.Index(rest, "\"")
+		if end < 0 {
+			return "", false
+		}
+		return "", strings.HasPrefix(strings.TrimSpace(rest[end+1:]), "{")
+	}
+	return "", false
+}
+
+func stripCSharpModifiers(line string) string {
+	for {
+		word, rest, found := strings.Cut(line, " ")
+		if !found {
+			return line
+		}
+		if _, ok := csharpModifiers[word]; !ok {
+			return line
+		}
+		line = strings.TrimSpace(rest)
+	}
+}
+
+// parseCFamilyTypeHeader recognises struct, class, union, and enum
+// definitions, plus C# interfaces and records. An empty name with ok set means
+// an anonymous typedef whose name follows the closing brace.
+func parseCFamilyTypeHeader(header string, csharp bool) (symbolKind, symbolName string, ok bool) {
+	line := strings.TrimSpace(strings.TrimPrefix(header, "typedef "))
+	if csharp {
+		line = stripCSharpModifiers(line)
+	}
+	keyword, rest, found := strings.Cut(line, " ")
+	if !found {
+		return "", "", false
+	}
+	switch keyword {
+	case "struct", "class", "union":
+		symbolKind = keyword
+	case "enum":
+		symbolKind = "enum"
+		rest = strings.TrimPrefix(strings.TrimPrefix(rest, "class "), "struct ")
+	case "interface":
+		if !csharp {
+			return "", "", false
+		}
+		symbolKind = "interface"
+	case "record":
+		if !csharp {
+			return "", "", false
+		}
+		symbolKind = "record"
+		rest = strings.TrimPrefix(strings.TrimPrefix(rest, "class "), "struct ")
+	default:
+		return "", "", false
+	}
+
+	head, _, hasBody := strings.Cut(rest, "{")
+	if !hasBody && symbolKind != "record" {
+		// Forward declarations and variables of struct type.
+		return "", "", false
+	}
+	if strings.Contains(head, "(") && symbolKind != "record" {
+		// A function returning a struct, e.g. "struct node *make(void) {".
+		return "", "", false
+	}
+	if idx := strings.Index(head, " where "); idx >= 0 {
+		head = head[:idx]
+	}
+	head = cutBaseClause(head)
+	if csharp {
+		return symbolKind, readIdentifierPrefix(strings.TrimSpace(head), false), true
+	}
+	fields := strings.Fields(head)
+	for len(fields) > 0 && fields[len(fields)-1] == "final" {
+		fields = fields[:len(fields)-1]
+	}
+	if len(fields) == 0 {
+		return symbolKind, "", true
+	}
+	name := fields[len(fields)-1]
+	if idx := strings.Index(name, "<"); idx >= 0 {
+		name = name[:idx]
+	}
+	if readIdentifierPrefix(name, false) != name {
+		return "", "", false
+	}
+	return symbolKind, name, true
+}
+
+// cutBaseClause drops a ": Base" list while keeping "::" qualifiers.
+func cutBaseClause(head string) string {
+	for idx := 0; idx < len(head); idx++ {
+		if head[idx] != ':' {
+			continue
+		}
+		if idx+1 < len(head) && head[idx+1] == ':' {
+			idx++
+			continue
+		}
+		return head[:idx]
+	}
+	return head
+}
+
+// cTypedefAlias returns the name after the closing brace of an anonymous
+// typedef, e.g. "} point_t;".
+func cTypedefAlias(line string) string {
+	idx := strings.LastIndex(line, "}")
+	if idx < 0 {
+		return ""
+	}
+	return readIdentifierPrefix(strings.TrimLeft(strings.TrimSpace(line[idx+1:]), "*& "), false)
+}
+
+// parseCFamilyMemberHeader recognises functions, methods, constructors,
+// destructors, operators, and C# properties.
+func parseCFamilyMemberHeader(header, owner string, csharp bool) (symbolKind, symbolName string, ok bool) {
+	line := header
+	if csharp {
+		line = stripCSharpModifiers(line)
+	} else if strings.HasPrefix(line, "extern \"") {
+		if end := strings.Index(line[len("extern \""):], "\""); end >= 0 {
+			line = strings.TrimSpace(line[len("extern \"")+end+1:])
+		}
+	}
+
+	paren := strings.Index(line, "(")
+	if paren < 0 {
+		if csharp && owner != "" {
+			return parseCSharpProperty(line)
+		}
+		return "", "", false
+	}
+	before := strings.TrimSpace(line[:paren])
+	var typePart, name string
+	if idx := strings.Index(before, "operator"); idx >= 0 && (idx == 0 || !isASCIIIdentByte(before[idx-1]) || before[idx-1] == ':') {
+		start := idx
+		for start > 0 && (isASCIIIdentByte(before[start-1]) || before[start-1] == ':' || before[start-1] == '~') {
+			start--
+		}
+		typePart = strings.TrimSpace(before[:start])
+		name = strings.ReplaceAll(before[start:], " ", "")
+	} else {
+		if strings.ContainsAny(before, "=;{}") {
+			return "", "", false
+		}
+		before = trimTrailingTypeArgs(before)
+		fields := strings.Fields(before)
+		if len(fields) == 0 {
+			return "", "", false
+		}
+		if _, skip := cNonFunctionWords[fields[0]]; skip {
+			return "", "", false
+		}
+		name = strings.TrimLeft(fields[len(fields)-1], "*&")
+		typePart = strings.Join(fields[:len(fields)-1], " ")
+		if !isCQualifiedName(name) {
+			return "", "", false
+		}
+	}
+
+	segments := strings.Split(name, "::")
+	base := strings.TrimPrefix(segments[len(segments)-1], "~")
+	qualified := len(segments) > 1
+	isCtor := base == owner || (qualified && segments[len(segments)-2] == base)
+	switch {
+	case isCtor && strings.HasPrefix(segments[len(segments)-1], "~"):
+		symbolKind = "destructor"
+	case isCtor:
+		symbolKind = "constructor"
+	case typePart == "" && !qualified:
+		// A bare call such as a macro invocation.
+		return "", "", false
+	case owner != "" || (qualified && !csharp):
+		symbolKind = "method"
+	default:
+		symbolKind = "function"
+	}
+	if csharp {
+		name = strings.ReplaceAll(name, "::", ".")
+	}
+	return symbolKind, name, true
+}
+
+func parseCSharpProperty(line string) (symbolKind, symbolName string, ok bool) {
+	head := line
+	if idx := strings.Index(head, "=>"); idx >= 0 {
+		head = head[:idx]
+	} else if idx := strings.Index(head, "{"); idx >= 0 {
+		head = head[:idx]
+	} else {
+		return "", "", false
+	}
+	if strings.Contains(head, "=") {
+		return "", "", false
+	}
+	fields := strings.Fields(head)
+	if len(fields) < 2 {
+		return "", "", false
+	}
+	name := fields[len(fields)-1]
+	if readIdentifierPrefix(name, false) != name || fields[0] == "event" {
+		return "", "", false
+	}
+	return "property", name, true
+}
+
+// trimTrailingTypeArgs drops generic arguments after a method name, as in
+// "T Get<T>".
+func trimTrailingTypeArgs(before string) string {
+	if !strings.HasSuffix(before, ">") {
+		return before
+	}
+	depth := 0
+	for idx := len(before) - 1; idx >= 0; idx-- {
+		switch before[idx] {
+		case '>':
+			depth++
+		case '<':
+			depth--
+			if depth == 0 {
+				return strings.TrimSpace(before[:idx])
+			}
+		}
+	}
+	return before
+}
+
+func isCQualifiedName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, segment := range strings.Split(name, "::") {
+		segment = strings.TrimPrefix(segment, "~")
+		if segment == "" || readIdentifierPrefix(segment, false) != segment {
+			return false
+		}
+	}
+	return true
+}
+
+// cHeaderHasBody reports whether a function header opens a body before any
+// terminating semicolon.
+func cHeaderHasBody(header string) bool {
+	brace := strings.Index(header, "{")
+	semi := strings.Index(header, ";")
+	return brace >= 0 && (semi < 0 || brace < semi)
+}
+
+// cFamilyCodeLines returns lines with comments removed and string and
+// character literal contents blanked, for header parsing.
+func cFamilyCodeLines(lines []string) []string {
+	code := make([]string, len(lines))
+	inBlockComment := false
+	for i, line := range lines {
+		var out strings.Builder
+		var quote byte
+		escaped := false
+		for j := 0; j < len(line); j++ {
+			ch := line[j]
+			next := byte(0)
+			if j+1 < len(line) {
+				next = line[j+1]
+			}
+			if inBlockComment {
+				if ch == '*' && next == '/' {
+					inBlockComment = false
+					j++
+				}
+				continue
+			}
+			if quote != 0 {
+				if escaped {
+					escaped = false
+					continue
+				}
+				if ch == '\\' {
+					escaped = true
+					continue
+				}
+				if ch == quote {
+					quote = 0
+					out.WriteByte(ch)
+				}
+				continue
+			}
+			if ch == '/' && next == '/' {
+				break
+			}
+			if ch == '/' && next == '*' {
+				inBlockComment = true
+				j++
+				continue
+			}
+			if ch == '"' || ch == '\'' {
+				quote = ch
+			}
+			out.WriteByte(ch)
+		}
+		code[i] = out.String()
+	}
+	return code
+}
@@ -0,0 +1,217 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestChunkFileCSemantic(t *testing.T) {
+	content := []byte(`#include <stdio.h>
+#define MAX(a, b) \
+	((a) > (b) ? (a) : (b))
+
+/* A point on the grid. */
+typedef struct {
+	int x;
+	int y;
+} point_t;
+
+struct node *make_node(int value);
+
+static int
+add(int a, int b)
+{
+	const char *brace = "{";
+	return a + b;
+}
+
+enum color { RED, GREEN };
+`)
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("grid.c", content, 400, 20, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+
+	byName := chunksBySymbolName(chunks)
+	cases := map[string][2]string{
+		"point_t":   {"struct", "class"},
+		"make_node": {"function", "header"},
+		"add":       {"function", "function"},
+		"color":     {"enum", "enum"},
+	}
+	for name, want := range cases {
+		chunk, ok := byName[name]
+		if !ok {
+			t.Fatalf("missing %s chunk in %#v", name, chunks)
+		}
+		if chunk.SymbolKind != want[0] || chunk.ChunkType != want[1] {
+			t.Fatalf("unexpected %s metadata: kind=%s type=%s", name, chunk.SymbolKind, chunk.ChunkType)
+		}
+	}
+	if len(chunks) != len(cases) {
+		t.Fatalf("expected %d chunks, got %d: %#v", len(cases), len(chunks), chunks)
+	}
+	if point := byName["point_t"]; point.StartLine != 5 || point.EndLine != 9 {
+		t.Fatalf("expected typedef with its comment, got %d-%d", point.StartLine, point.EndLine)
+	}
+	if add := byName["add"]; add.StartLine != 13 || add.EndLine != 18 {
+		t.Fatalf("expected multi-line add definition, got %d-%d", add.StartLine, add.EndLine)
+	}
+}
+
+func TestChunkFileCppSemantic(t *testing.T) {
+	content := []byte(`#pragma once
+
+namespace net {
+namespace detail {
+int checksum(const char *data);
+}
+
+template <typename T>
+class Socket final : public Stream {
+public:
+	explicit Socket(int fd) : fd_(fd) {}
+	~Socket();
+
+	bool operator==(const Socket &other) const { return fd_ == other.fd_; }
+
+private:
+	int fd_;
+};
+
+size_t Socket::send(const char *data, size_t len) {
+	return write(fd_, data, len);
+}
+} // namespace net
+
+extern "C" {
+void net_init(void);
+}
+`)
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("socket.hpp", content, 400, 20, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+
+	byName := chunksBySymbolName(chunks)
+	cases := map[string][2]string{
+		"net::detail::checksum": {"function", "header"},
+		"net::Socket":           {"class", "class"},
+		"net::Socket::send":     {"method", "function"},
+		"net_init":              {"function", "header"},
+	}
+	for name, want := range cases {
+		chunk, ok := byName[name]
+		if !ok {
+			t.Fatalf("missing %s chunk in %#v", name, chunks)
+		}
+		if chunk.SymbolKind != want[0] || chunk.ChunkType != want[1] {
+			t.Fatalf("unexpected %s metadata: kind=%s type=%s", name, chunk.SymbolKind, chunk.ChunkType)
+		}
+	}
+	if socket := byName["net::Socket"]; socket.StartLine != 8 || socket.EndLine != 18 {
+		t.Fatalf("expected Socket to include its template line, got %d-%d", socket.StartLine, socket.EndLine)
+	}
+}
+
+func TestChunkFileCppSplitsOversizedClass(t *testing.T) {
+	var body strings.Builder
+	body.WriteString("class Buffer {\npublic:\n\tBuffer() = default;\n\t~Buffer() { clear(); }\n\n")
+	for i := 0; i < 12; i++ {
+		body.WriteString(fmt.Sprintf("\tint read%d(char *dst) {\n\t\treturn copy(dst, %d);\n\t}\n\n", i, i))
+	}
+	body.WriteString("private:\n\tchar *data_;\n};\n")
+
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("buffer.cpp", []byte(body.String()), 120, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+	byName := chunksBySymbolName(chunks)
+	cases := map[string][2]string{
+		"Buffer::Buffer":  {"constructor", "header"},
+		"Buffer::~Buffer": {"destructor", "function"},
+		"Buffer::read0":   {"method", "function"},
+		"Buffer::read11":  {"method", "function"},
+	}
+	for name, want := range cases {
+		chunk, ok := byName[name]
+		if !ok {
+			t.Fatalf("missing %s chunk in %#v", name, chunks)
+		}
+		if chunk.SymbolKind != want[0] || chunk.ChunkType != want[1] {
+			t.Fatalf("unexpected %s metadata: kind=%s type=%s", name, chunk.SymbolKind, chunk.ChunkType)
+		}
+	}
+	fields := false
+	for _, chunk := range chunks {
+		if strings.TrimSpace(chunk.Text) == "private:" {
+			t.Fatalf("expected access specifiers to stay out of their own chunks")
+		}
+		if chunk.SymbolName == "Buffer" && strings.Contains(chunk.Text, "data_") {
+			fields = true
+		}
+	}
+	if !fields {
+		t.Fatalf("expected a Buffer chunk holding its fields, got %#v", chunks)
+	}
+}
+
+func TestChunkFileCSharpSemantic(t *testing.T) {
+	var body strings.Builder
+	body.WriteString(`using System;
+
+namespace Shop.Orders;
+
+/// <summary>An order.</summary>
+[Serializable]
+public sealed class Order : IEntity
+{
+    public Order(int id) { Id = id; }
+
+    public int Id { get; }
+
+    public decimal Total => Lines * 2m;
+
+    public async Task<int> SaveAsync<T>(T repo) where T : IRepo
+    {
+        return await repo.Save(this);
+    }
+`)
+	for i := 0; i < 10; i++ {
+		body.WriteString(fmt.Sprintf("\n    public int Line%d() => %d;\n", i, i))
+	}
+	body.WriteString("}\n\npublic interface IEntity\n{\n    int Id { get; }\n}\n\npublic record Point(int X, int Y);\n")
+
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("Order.cs", []byte(body.String()), 120, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+	byName := chunksBySymbolName(chunks)
+	cases := map[string][2]string{
+		"Shop.Orders.Order":           {"class", "class"},
+		"Shop.Orders.Order.Order":     {"constructor", "function"},
+		"Shop.Orders.Order.Id":        {"property", "function"},
+		"Shop.Orders.Order.Total":     {"property", "function"},
+		"Shop.Orders.Order.SaveAsync": {"method", "function"},
+		"Shop.Orders.Order.Line3":     {"method", "function"},
+		"Shop.Orders.IEntity":         {"interface", "interface"},
+		"Shop.Orders.Point":           {"record", "class"},
+	}
+	for name, want := range cases {
+		chunk, ok := byName[name]
+		if !ok {
+			t.Fatalf("missing %s chunk in %#v", name, chunks)
+		}
+		if chunk.SymbolKind != want[0] || chunk.ChunkType != want[1] {
+			t.Fatalf("unexpected %s metadata: kind=%s type=%s", name, chunk.SymbolKind, chunk.ChunkType)
+		}
+	}
+	if order := byName["Shop.Orders.Order"]; order.StartLine != 5 {
+		t.Fatalf("expected Order header with doc comment and attribute, start_line=%d", order.StartLine)
+	}
+}
@@ -0,0 +1,640 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	memtoken "mem/internal/token"
+)
+
+// JSON, YAML, and TOML files are parsed into a tree of keys with line ranges
+// and chunked along it. A key whose value fits the budget becomes one chunk
+// named by its dotted key path ("services.api.env"). Larger values are opened
+// up: nested objects get chunks of their own, while runs of one-line keys and
+// array elements are packed together under the parent's path, so a long
+// array is split without losing where it lives.
+
+const configChunkType = "config"
+
+type configNode struct {
+	Path     string
+	Kind     string
+	Start    int
+	End      int
+	Children []*configNode
+}
+
+func chunkConfig(ext string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+	lines := strings.Split(string(content), "\n")
+	var root *configNode
+	var err error
+	switch ext {
+	case ".json":
+		root, err = parseJSONConfig(content, len(lines))
+	case ".yaml", ".yml":
+		root = parseYAMLConfig(lines)
+	case ".toml":
+		root = parseTOMLConfig(lines)
+	default:
+		return nil, fmt.Errorf("unsupported config extension %s", ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return configNodeChunks(lines, root, counter, maxTokens, overlapTokens), nil
+}
+
+func configNodeChunks(lines []string, node *configNode, counter *memtoken.Counter, maxTokens, overlapTokens int) []SemanticChunk {
+	nodeLines := lines[node.Start:node.End]
+	text := strings.Join(nodeLines, "\n")
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	if counter.Count(text) <= maxTokens {
+		return []SemanticChunk{configChunk(lines, node.Start, node.End, node.Path, node.Kind)}
+	}
+	if len(node.Children) == 0 {
+		pieces := splitWithMetadata(nodeLines, node.Start+1, node.Path, node.Kind, counter, maxTokens, overlapTokens)
+		for i := range pieces {
+			pieces[i].ChunkType = configChunkType
+		}
+		return pieces
+	}
+
+	var chunks []SemanticChunk
+	groupStart, groupTokens := node.Start, 0
+	var groupChildren []*configNode
+	flush := func(end int) {
+		if end > groupStart && !isTrivialConfigGap(lines[groupStart:end]) && (len(groupChildren) > 0 || groupStart != node.Start) {
+			path, kind := node.Path, node.Kind
+			if len(groupChildren) == 1 && node.Kind != "array" {
+				path, kind = groupChildren[0].Path, groupChildren[0].Kind
+			}
+			chunks = append(chunks, configChunk(lines, groupStart, end, path, kind))
+		}
+		groupStart, groupTokens, groupChildren = end, 0, nil
+	}
+
+	for _, child := range configLineUnits(node) {
+		if groupStart > child.Start {
+			groupStart = child.Start
+		}
+		if len(groupChildren) == 0 && groupStart != node.Start && isTrivialConfigGap(lines[groupStart:child.Start]) {
+			groupStart = child.Start
+		}
+		childTokens := counter.Count(strings.Join(lines[groupStart:child.End], "\n")) - groupTokens
+		packable := childTokens <= maxTokens && (node.Kind == "array" || child.End-child.Start <= 1)
+		if !packable {
+			flush(child.Start)
+			chunks = append(chunks, configNodeChunks(lines, child, counter, maxTokens, overlapTokens)...)
+			groupStart = child.End
+			continue
+		}
+		if len(groupChildren) > 0 && groupTokens+childTokens > maxTokens {
+			flush(child.Start)
+			childTokens = counter.Count(strings.Join(lines[child.Start:child.End], "\n"))
+		}
+		groupChildren = append(groupChildren, child)
+		groupTokens += childTokens
+	}
+	end := node.End
+	if last := node.Children[len(node.Children)-1]; last.End <= end && isTrivialConfigGap(lines[last.End:end]) {
+		end = last.End
+	}
+	flush(end)
+	return chunks
+}
+
+// configLineUnits returns the children of node, replacing each run of
+// siblings that share a line, as in minified JSON, with one childless node
+// under the parent's path. Line ranges of the units never overlap, and a unit
+// too large for the budget is split by lines.
+func configLineUnits(node *configNode) []*configNode {
+	units := make([]*configNode, 0, len(node.Children))
+	merged := false
+	for _, child := range node.Children {
+		n := len(units)
+		if n == 0 || child.Start >= units[n-1].End {
+			units = append(units, child)
+			merged = false
+			continue
+		}
+		if !merged {
+			last := units[n-1]
+			units[n-1] = &configNode{Path: node.Path, Kind: node.Kind, Start: last.Start, End: last.End}
+			merged = true
+		}
+		if child.End > units[n-1].End {
+			units[n-1].End = child.End
+		}
+	}
+	return units
+}
+
+func configChunk(lines []string, start, end int, path, kind string) SemanticChunk {
+	return SemanticChunk{
+		Text:       strings.Join(lines[start:end], "\n"),
+		StartLine:  start + 1,
+		EndLine:    end,
+		ChunkType:  configChunkType,
+		SymbolName: path,
+		SymbolKind: kind,
+	}
+}
+
+// isTrivialConfigGap reports whether lines hold only brackets, separators,
+// comments, and YAML document markers.
+func isTrivialConfigGap(lines []string) bool {
+	for _, line := range lines {
+		trimmed := strings.Trim(strings.TrimSpace(line), "{}[],")
+		if trimmed == "" || trimmed == "---" || trimmed == "..." || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+func joinConfigPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+func configKind(children []*configNode, array bool) string {
+	switch {
+	case array:
+		return "array"
+	case len(children) > 0:
+		return "object"
+	default:
+		return "value"
+	}
+}
+
+// JSON
+
+type jsonConfigParser struct {
+	data []byte
+	pos  int
+	line int
+}
+
+var errJSONConfig = errors.New("invalid json")
+
+func parseJSONConfig(content []byte, lineCount int) (*configNode, error) {
+	p := &jsonConfigParser{data: content}
+	p.skipSpace()
+	root, err := p.value("", 0)
+	if err != nil {
+		return nil, err
+	}
+	root.Start, root.End = 0, lineCount
+	return root, nil
+}
+
+func (p *jsonConfigParser) value(path string, start int) (*configNode, error) {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return nil, errJSONConfig
+	}
+	node := &configNode{Path: path, Start: start}
+	switch p.data[p.pos] {
+	case '{':
+		p.pos++
+		for {
+			p.skipSpace()
+			if p.pos < len(p.data) && p.data[p.pos] == '}' {
+				p.pos++
+				break
+			}
+			keyLine := p.line
+			key, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+				return nil, errJSONConfig
+			}
+			p.pos++
+			child, err := p.value(joinConfigPath(path, key), keyLine)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, child)
+			if !p.separator('}') {
+				return nil, errJSONConfig
+			}
+		}
+		node.Kind = "object"
+	case '[':
+		p.pos++
+		for i := 0; ; i++ {
+			p.skipSpace()
+			if p.pos < len(p.data) && p.data[p.pos] == ']' {
+				p.pos++
+				break
+			}
+			child, err := p.value(fmt.Sprintf("%s[%d]", path, i), p.line)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, child)
+			if !p.separator(']') {
+				return nil, errJSONConfig
+			}
+		}
+		node.Kind = "array"
+	case '"':
+		if _, err := p.str(); err != nil {
+			return nil, err
+		}
+		node.Kind = "value"
+	default:
+		for p.pos < len(p.data) && !strings.ContainsRune(",}] \t\r\n", rune(p.data[p.pos])) {
+			p.pos++
+		}
+		node.Kind = "value"
+	}
+	node.End = p.line + 1
+	return node, nil
+}
+
+// separator consumes the comma after a member or element, reporting false
+// when neither a comma nor the closing bracket follows.
+func (p *jsonConfigParser) separator(closing byte) bool {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return false
+	}
+	if p.data[p.pos] == ',' {
+		p.pos++
+		return true
+	}
+	return p.data[p.pos] == closing
+}
+
+func (p *jsonConfigParser) str() (string, error) {
+	if p.pos >= len(p.data) || p.data[p.pos] != '"' {
+		return "", errJSONConfig
+	}
+	p.pos++
+	var out strings.Builder
+	for p.pos < len(p.data) {
+		ch := p.data[p.pos]
+		switch ch {
+		case '\\':
+			if p.pos+1 < len(p.data) {
+				out.WriteByte(p.data[p.pos+1])
+			}
+			p.pos += 2
+			continue
+		case '"':
+			p.pos++
+			return out.String(), nil
+		case '\n':
+			return "", errJSONConfig
+		}
+		out.WriteByte(ch)
+		p.pos++
+	}
+	return "", errJSONConfig
+}
+
+// skipSpace skips whitespace and, for JSONC files such as tsconfig.json,
+// comments.
+func (p *jsonConfigParser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch ch := p.data[p.pos]; {
+		case ch == '\n':
+			p.line++
+			p.pos++
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			p.pos++
+		case ch == '/' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '/':
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' {
+				p.pos++
+			}
+		case ch == '/' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '*':
+			p.pos += 2
+			for p.pos+1 < len(p.data) && !(p.data[p.pos] == '*' && p.data[p.pos+1] == '/') {
+				if p.data[p.pos] == '\n' {
+					p.line++
+				}
+				p.pos++
+			}
+			p.pos += 2
+		default:
+			return
+		}
+	}
+}
+
+// YAML
+
+type yamlConfigLine struct {
+	Content bool
+	Indent  int
+	Item    bool
+	Key     string
+	Inline  bool
+}
+
+func parseYAMLConfig(lines []string) *configNode {
+	parsed := make([]yamlConfigLine, len(lines))
+	for i, line := range lines {
+		parsed[i] = parseYAMLConfigLine(line)
+	}
+	root := &configNode{Start: 0, End: len(lines)}
+	root.Children, root.Kind = yamlConfigChildren(lines, parsed, 0, len(lines), "", -1)
+	return root
+}
+
+func parseYAMLConfigLine(line string) yamlConfigLine {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" || trimmed == "..." {
+		return yamlConfigLine{}
+	}
+	parsed := yamlConfigLine{Content: true, Indent: leadingIndentWidth(line)}
+	rest := trimmed
+	if rest == "-" || strings.HasPrefix(rest, "- ") {
+		parsed.Item = true
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, "-"))
+	}
+	key, value, ok := splitYAMLKey(rest)
+	if ok {
+		parsed.Key = key
+		value = strings.TrimSpace(value)
+		parsed.Inline = value != "" && !strings.HasPrefix(value, "#") && !strings.HasPrefix(value, "&")
+		if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+			// Block scalars own the indented lines below them.
+			parsed.Inline = false
+		}
+	} else if parsed.Item {
+		parsed.Inline = rest != ""
+	}
+	return parsed
+}
+
+// splitYAMLKey splits "key: value", honouring quoted keys.
+func splitYAMLKey(text string) (string, string, bool) {
+	if strings.HasPrefix(text, "\"") || strings.HasPrefix(text, "'") {
+		end := strings.IndexByte(text[1:], text[0])
+		if end < 0 || !strings.HasPrefix(text[end+2:], ":") {
+			return "", "", false
+		}
+		return text[1 : end+1], text[end+3:], true
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ' || text[i+1] == '\t') {
+			key := strings.TrimSpace(text[:i])
+			if key == "" || strings.ContainsAny(key, "{[\"'") {
+				return "", "", false
+			}
+			return key, text[i+1:], true
+		}
+		if text[i] == '#' || text[i] == '{' || text[i] == '[' {
+			break
+		}
+	}
+	return "", "", false
+}
+
+// yamlConfigChildren returns the entries of the block in [start, end). When
+// itemCol is not negative, the first line is a sequence item whose mapping
+// keys sit at itemCol.
+func yamlConfigChildren(lines []string, parsed []yamlConfigLine, start, end int, path string, itemCol int) ([]*configNode, string) {
+	col := itemCol
+	if col < 0 {
+		for i := start; i < end; i++ {
+			if parsed[i].Content {
+				col = parsed[i].Indent
+				break
+			}
+		}
+		if col < 0 {
+			return nil, "value"
+		}
+	}
+
+	entryCol := func(i int) int {
+		if i == start && itemCol >= 0 {
+			return itemCol
+		}
+		return parsed[i].Indent
+	}
+	// In a mapping, sequence items at the key's own indent belong to the key
+	// above them ("ports:\n- 80") rather than starting entries of their own.
+	var entries []int
+	mapping := false
+	for i := start; i < end; i++ {
+		if !parsed[i].Content || entryCol(i) != col {
+			continue
+		}
+		item := parsed[i].Item && !(i == start && itemCol >= 0)
+		if len(entries) == 0 {
+			mapping = !item
+		} else if mapping && item {
+			continue
+		}
+		entries = append(entries, i)
+	}
+
+	var children []*configNode
+	array := false
+	for n, entry := range entries {
+		next := end
+		if n+1 < len(entries) {
+			next = entries[n+1]
+		}
+		for next > entry+1 && !parsed[next-1].Content {
+			next--
+		}
+		line := parsed[entry]
+		node := &configNode{Start: yamlLeadingCommentStart(lines, entry, start), End: next}
+		isItem := line.Item && !(entry == start && itemCol >= 0)
+		switch {
+		case isItem:
+			array = true
+			node.Path = fmt.Sprintf("%s[%d]", path, len(children))
+			if line.Key != "" {
+				node.Children, node.Kind = yamlConfigChildren(lines, parsed, entry, next, node.Path, line.Indent+2)
+				node.Kind = "object"
+			} else if !line.Inline {
+				node.Children, node.Kind = yamlConfigChildren(lines, parsed, entry+1, next, node.Path, -1)
+			} else {
+				node.Kind = "value"
+			}
+		case line.Key != "":
+			node.Path = joinConfigPath(path, line.Key)
+			node.Kind = "value"
+			if !line.Inline {
+				node.Children, node.Kind = yamlConfigChildren(lines, parsed, entry+1, next, node.Path, -1)
+			}
+		default:
+			continue
+		}
+		children = append(children, node)
+	}
+	return children, configKind(children, array)
+}
+
+// yamlLeadingCommentStart extends an entry upwards over the comment lines
+// directly above it.
+func yamlLeadingCommentStart(lines []string, start, floor int) int {
+	for start > floor && strings.HasPrefix(strings.TrimSpace(lines[start-1]), "#") {
+		start--
+	}
+	return start
+}
+
+// TOML
+
+func parseTOMLConfig(lines []string) *configNode {
+	root := &configNode{Start: 0, End: len(lines), Kind: "object"}
+	var current *configNode
+	var arrays []*configNode
+	arrayCounts := map[string]int{}
+
+	closeTable := func(end int) {
+		if current != nil {
+			current.End = end
+			current = nil
+		}
+	}
+	addKey := func(parent *configNode, path string, start, end int) {
+		node := &configNode{Path: path, Kind: "value", Start: start, End: end}
+		parent.Children = append(parent.Children, node)
+	}
+
+	for i := 0; i < len(lines); {
+		trimmed := strings.TrimSpace(lines[i])
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			i++
+		case strings.HasPrefix(trimmed, "[["):
+			closeTable(yamlLeadingCommentStart(lines, i, 0))
+			name := strings.TrimSpace(strings.Trim(stripTOMLComment(trimmed), "[]"))
+			array := lastTOMLArray(arrays, name)
+			if array == nil {
+				array = &configNode{Path: name, Kind: "array", Start: yamlLeadingCommentStart(lines, i, 0)}
+				root.Children = append(root.Children, array)
+				arrays = append(arrays, array)
+			}
+			current = &configNode{Path: fmt.Sprintf("%s[%d]", name, arrayCounts[name]), Kind: "object", Start: yamlLeadingCommentStart(lines, i, 0)}
+			arrayCounts[name]++
+			array.Children = append(array.Children, current)
+			i++
+		case strings.HasPrefix(trimmed, "["):
+			closeTable(yamlLeadingCommentStart(lines, i, 0))
+			name := strings.TrimSpace(strings.Trim(stripTOMLComment(trimmed), "[]"))
+			current = &configNode{Path: name, Kind: "object", Start: yamlLeadingCommentStart(lines, i, 0)}
+			root.Children = append(root.Children, current)
+			i++
+		default:
+			end := tomlValueEnd(lines, i)
+			key, _, _ := strings.Cut(trimmed, "=")
+			key = strings.Trim(strings.TrimSpace(key), "\"'")
+			parent, path := root, key
+			if current != nil {
+				parent, path = current, joinConfigPath(current.Path, key)
+			}
+			addKey(parent, path, yamlLeadingCommentStart(lines, i, parent.Start), end)
+			i = end
+		}
+	}
+	closeTable(len(lines))
+	for _, array := range arrays {
+		array.End = array.Children[len(array.Children)-1].End
+	}
+	for _, child := range root.Children {
+		if child.End == 0 {
+			child.End = len(lines)
+		}
+	}
+	return root
+}
+
+func lastTOMLArray(arrays []*configNode, name string) *configNode {
+	for i := len(arrays) - 1; i >= 0; i-- {
+		if arrays[i].Path == name {
+			return arrays[i]
+		}
+	}
+	return nil
+}
+
+// tomlValueEnd returns the line after the value that starts on line start,
+// following multi-line arrays, inline tables, and triple-quoted strings.
+func tomlValueEnd(lines []string, start int) int {
+	_, value, _ := strings.Cut(lines[start], "=")
+	value = strings.TrimSpace(value)
+	for _, quote := range []string{`"""`, `'''`} {
+		if strings.HasPrefix(value, quote) && strings.Count(value, quote) == 1 {
+			for i := start + 1; i < len(lines); i++ {
+				if strings.Contains(lines[i], quote) {
+					return i + 1
+				}
+			}
+			return len(lines)
+		}
+	}
+	depth := tomlBracketDelta(value)
+	i := start + 1
+	for depth > 0 && i < len(lines) {
+		depth += tomlBracketDelta(lines[i])
+		i++
+	}
+	return i
+}
+
+func tomlBracketDelta(line string) int {
+	line = stripTOMLComment(line)
+	delta := 0
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		if quote != 0 {
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch ch {
+		case '"', '\'':
+			quote = ch
+		case '[', '{':
+			delta++
+		case ']', '}':
+			delta--
+		}
+	}
+	return delta
+}
+
+func stripTOMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		if quote != 0 {
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch ch {
+		case '"', '\'':
+			quote = ch
+		case '#':
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,252 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func configNodePaths(node *configNode) []string {
+	var paths []string
+	for _, child := range node.Children {
+		paths = append(paths, child.Path)
+		paths = append(paths, configNodePaths(child)...)
+	}
+	return paths
+}
+
+func assertConfigPaths(t *testing.T, root *configNode, want ...string) {
+	t.Helper()
+	got := map[string]bool{}
+	for _, path := range configNodePaths(root) {
+		got[path] = true
+	}
+	for _, path := range want {
+		if !got[path] {
+			t.Fatalf("missing key path %q in %v", path, configNodePaths(root))
+		}
+	}
+}
+
+func TestChunkFileJSONSplitsAlongKeyPaths(t *testing.T) {
+	var body strings.Builder
+	body.WriteString("{\n  \"name\": \"shop\",\n  \"services\": {\n    \"api\": {\n      \"image\": \"api:1\",\n      \"env\": {\n")
+	body.WriteString("        \"REDIS_TIMEOUT\": \"5s\",\n        \"REDIS_URL\": \"redis://cache:6379\"\n      }\n    },\n")
+	body.WriteString("    \"worker\": {\n      \"image\": \"worker:1\",\n      \"replicas\": 3\n    }\n  },\n  \"hosts\": [\n")
+	for i := 0; i < 40; i++ {
+		sep := ","
+		if i == 39 {
+			sep = ""
+		}
+		body.WriteString(fmt.Sprintf("    \"host-%02d.example.internal\"%s\n", i, sep))
+	}
+	body.WriteString("  ]\n}\n")
+
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("compose.json", []byte(body.String()), 60, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+
+	timeout, hostParts := false, 0
+	for _, chunk := range chunks {
+		if chunk.ChunkType != "config" {
+			t.Fatalf("unexpected chunk type %s", chunk.ChunkType)
+		}
+		if strings.Contains(chunk.Text, "REDIS_TIMEOUT") && strings.HasPrefix(chunk.SymbolName, "services.api.env") {
+			timeout = true
+		}
+		if chunk.SymbolName == "hosts" {
+			hostParts++
+			if strings.Contains(chunk.Text, "services") {
+				t.Fatalf("hosts chunk leaked other keys: %q", chunk.Text)
+			}
+		}
+	}
+	if !timeout {
+		t.Fatalf("expected REDIS_TIMEOUT under services.api.env, got %#v", chunks)
+	}
+	if hostParts < 2 {
+		t.Fatalf("expected the hosts array to be split under its path, got %d parts", hostParts)
+	}
+
+	root, err := parseJSONConfig([]byte(body.String()), strings.Count(body.String(), "\n")+1)
+	if err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+	assertConfigPaths(t, root, "name", "services.api.env.REDIS_TIMEOUT", "services.worker.replicas", "hosts[39]")
+}
+
+func TestChunkFileJSONGroupsKeysSharingALine(t *testing.T) {
+	var minified strings.Builder
+	minified.WriteString(`{"packages":{`)
+	for i := 0; i < 200; i++ {
+		if i > 0 {
+			minified.WriteString(",")
+		}
+		minified.WriteString(fmt.Sprintf(`"node_modules/pkg-%03d":{"version":"1.0.%d","deps":["a","b"]}`, i, i))
+	}
+	minified.WriteString(`},"lockfileVersion":3}`)
+
+	counter := testTokenCounter(t)
+	for _, content := range []string{`{"a": [1, 2], "c": {"d": 1}}`, minified.String(), "{\n  \"a\": 1, \"b\": {\n    \"c\": [1, 2, 3]\n  },\n  \"d\": true\n}\n"} {
+		chunks, err := chunkFile("a.json", []byte(content), 10, 0, counter)
+		if err != nil {
+			t.Fatalf("chunk file: %v", err)
+		}
+		if len(chunks) == 0 {
+			t.Fatalf("expected chunks for %q", content)
+		}
+		for _, chunk := range chunks {
+			if chunk.ChunkType != configChunkType {
+				t.Fatalf("expected config chunk type, got %+v", chunk)
+			}
+			if chunk.StartLine < 1 || chunk.EndLine < chunk.StartLine {
+				t.Fatalf("unexpected line range %+v", chunk)
+			}
+		}
+	}
+}
+
+func TestChunkFileJSONFallsBackOnInvalidInput(t *testing.T) {
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("broken.json", []byte("{\n  \"a\": [1, 2\n"), 400, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+	if len(chunks) != 1 || chunks[0].ChunkType == "config" {
+		t.Fatalf("expected a line chunk fallback, got %#v", chunks)
+	}
+}
+
+func TestParseYAMLConfigKeyPaths(t *testing.T) {
+	content := `# deployment
+version: "3"
+services:
+  api:
+    image: api
+    environment:
+      - REDIS_URL=redis://cache
+    ports:
+    - "80:80"
+  redis:
+    # cache settings
+    image: redis
+    command: redis-server --timeout 30
+    script: |
+      echo start
+      echo done
+listeners:
+  - name: http
+    port: 80
+  - name: grpc
+    port: 9090
+`
+	root := parseYAMLConfig(strings.Split(content, "\n"))
+	assertConfigPaths(t, root,
+		"version",
+		"services.api.environment[0]",
+		"services.api.ports[0]",
+		"services.redis.command",
+		"services.redis.script",
+		"listeners[1].port",
+	)
+	for _, path := range configNodePaths(root) {
+		if strings.Contains(path, "echo") {
+			t.Fatalf("block scalar content was read as a key: %q", path)
+		}
+	}
+
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("compose.yml", []byte(content), 400, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+	if len(chunks) != 1 || chunks[0].SymbolName != "" || chunks[0].ChunkType != "config" {
+		t.Fatalf("expected one config chunk for a small file, got %#v", chunks)
+	}
+}
+
+func TestChunkFileYAMLSplitsOversizedSequence(t *testing.T) {
+	var body strings.Builder
+	body.WriteString("redis:\n  # seconds before idle clients are dropped\n  timeout: 30\n  host: cache\nallowlist:\n")
+	for i := 0; i < 40; i++ {
+		body.WriteString(fmt.Sprintf("  - 10.0.%d.0/24 # office network %d\n", i, i))
+	}
+
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("values.yaml", []byte(body.String()), 60, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+	redis, allowParts := false, 0
+	for _, chunk := range chunks {
+		if strings.HasPrefix(chunk.SymbolName, "redis") && strings.Contains(chunk.Text, "timeout: 30") {
+			redis = true
+		}
+		if chunk.SymbolName == "allowlist" {
+			allowParts++
+			if chunk.SymbolKind != "array" {
+				t.Fatalf("unexpected allowlist kind %s", chunk.SymbolKind)
+			}
+		}
+	}
+	if !redis || allowParts < 2 {
+		t.Fatalf("expected redis chunk and split allowlist, got %#v", chunks)
+	}
+}
+
+func TestParseTOMLConfigKeyPaths(t *testing.T) {
+	content := `title = "shop"
+
+[database]
+url = "postgres://localhost/shop" # primary
+ports = [
+  5432,
+  5433,
+]
+motd = """
+[not a table]
+"""
+
+[servers.alpha]
+ip = "10.0.0.1"
+
+# catalogue
+[[products]]
+name = "Hammer"
+
+[[products]]
+name = "Nail"
+`
+	root := parseTOMLConfig(strings.Split(content, "\n"))
+	assertConfigPaths(t, root,
+		"title",
+		"database",
+		"database.ports",
+		"database.motd",
+		"servers.alpha.ip",
+		"products",
+		"products[1].name",
+	)
+	for _, path := range configNodePaths(root) {
+		if strings.Contains(path, "not a table") {
+			t.Fatalf("multi-line string was read as a table: %q", path)
+		}
+	}
+
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("Cargo.toml", []byte(content), 30, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+	byName := chunksBySymbolName(chunks)
+	if ports, ok := byName["database.ports"]; ok && !strings.Contains(ports.Text, "5433") {
+		t.Fatalf("expected the ports array to stay whole, got %q", ports.Text)
+	}
+	for _, chunk := range chunks {
+		if strings.Contains(chunk.Text, "Nail") && !strings.HasPrefix(chunk.SymbolName, "products") {
+			t.Fatalf("expected products table under its path, got %q", chunk.SymbolName)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package app
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	memtoken "mem/internal/token"
+)
+
+// Markdown and reStructuredText are chunked along their heading hierarchy.
+// A section that fits the budget, subsections included, becomes one chunk;
+// larger sections emit their own text and recurse into their subsections.
+// Text is only ever split between paragraphs, so fenced code blocks, literal
+// blocks, and tables stay whole unless a single one exceeds the budget. Every
+// chunk carries its heading breadcrumb ("Architecture > Storage") as the
+// symbol name.
+
+const docSectionKind = "section"
+
+type docHeading struct {
+	Line  int
+	Level int
+	Title string
+}
+
+type docSection struct {
+	Start      int
+	End        int
+	Level      int
+	Breadcrumb string
+	Children   []*docSection
+}
+
+func chunkDocument(content []byte, rst bool, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+	lines := strings.Split(string(content), "\n")
+	var headings []docHeading
+	if rst {
+		headings = rstHeadings(lines)
+	} else {
+		headings = markdownHeadings(lines)
+	}
+	root := buildDocSections(headings, len(lines))
+	return docSectionChunks(lines, root, rst, counter, maxTokens, overlapTokens), nil
+}
+
+func buildDocSections(headings []docHeading, lineCount int) *docSection {
+	root := &docSection{Start: 0, End: lineCount}
+	stack := []*docSection{root}
+	for _, heading := range headings {
+		for len(stack) > 1 && stack[len(stack)-1].Level >= heading.Level {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1]
+		breadcrumb := heading.Title
+		if parent.Breadcrumb != "" {
+			breadcrumb = parent.Breadcrumb + " > " + heading.Title
+		}
+		section := &docSection{Start: heading.Line, Level: heading.Level, Breadcrumb: breadcrumb}
+		parent.Children = append(parent.Children, section)
+		stack = append(stack, section)
+	}
+	setDocSectionEnds(root)
+	return root
+}
+
+func setDocSectionEnds(section *docSection) {
+	for i, child := range section.Children {
+		child.End = section.End
+		if i+1 < len(section.Children) {
+			child.End = section.Children[i+1].Start
+		}
+		setDocSectionEnds(child)
+	}
+}
+
+func docSectionChunks(lines []string, section *docSection, rst bool, counter *memtoken.Counter, maxTokens, overlapTokens int) []SemanticChunk {
+	text := strings.Join(lines[section.Start:section.End], "\n")
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	if counter.Count(text) <= maxTokens {
+		breadcrumb := section.Breadcrumb
+		if breadcrumb == "" && len(section.Children) > 0 {
+			// A whole document that fits is named after its first heading.
+			breadcrumb = section.Children[0].Breadcrumb
+		}
+		return []SemanticChunk{{
+			Text:       text,
+			StartLine:  section.Start + 1,
+			EndLine:    section.End,
+			ChunkType:  docSectionKind,
+			SymbolName: breadcrumb,
+			SymbolKind: docSectionKind,
+		}}
+	}
+
+	ownEnd := section.End
+	if len(section.Children) > 0 {
+		ownEnd = section.Children[0].Start
+	}
+	chunks := packDocUnits(lines, section.Start, ownEnd, section.Breadcrumb, rst, counter, maxTokens, overlapTokens)
+	for _, child := range section.Children {
+		chunks = append(chunks, docSectionChunks(lines, child, rst, counter, maxTokens, overlapTokens)...)
+	}
+	return chunks
+}
+
+// packDocUnits fills chunks with whole paragraphs, code blocks, and tables
+// from [start, end).
+func packDocUnits(lines []string, start, end int, breadcrumb string, rst bool, counter *memtoken.Counter, maxTokens, overlapTokens int) []SemanticChunk {
+	return packLineUnits(lines, docUnits(lines, start, end, rst), docSectionKind, breadcrumb, docSectionKind, counter, maxTokens, overlapTokens)
+}
+
+// docUnits splits [start, end) into units that must not be broken up:
+// paragraphs, fenced or literal code blocks, and tables. Blank lines are
+// attached to the unit before them. Units are returned as [start, end) pairs.
+func docUnits(lines []string, start, end int, rst bool) [][2]int {
+	var units [][2]int
+	for i := start; i < end; {
+		unitStart := i
+		trimmed := strings.TrimSpace(lines[i])
+		switch {
+		case trimmed == "":
+			i++
+		case !rst && markdownFence(lines[i]) != "":
+			i = markdownFenceEnd(lines, i, end)
+		case isDocTableLine(trimmed, rst):
+			for i < end && isDocTableLine(strings.TrimSpace(lines[i]), rst) {
+				i++
+			}
+		case rst && (strings.HasSuffix(trimmed, "::") || strings.HasPrefix(trimmed, ".. ")):
+			indent := leadingIndentWidth(lines[i])
+			for i++; i < end; i++ {
+				if strings.TrimSpace(lines[i]) != "" && leadingIndentWidth(lines[i]) <= indent {
+					break
+				}
+			}
+		default:
+			for i < end {
+				next := strings.TrimSpace(lines[i])
+				if next == "" || (i > unitStart && ((!rst && markdownFence(lines[i]) != "") || isDocTableLine(next, rst))) {
+					break
+				}
+				i++
+			}
+		}
+		for i < end && strings.TrimSpace(lines[i]) == "" {
+			i++
+		}
+		if len(units) > 0 && strings.TrimSpace(lines[unitStart]) == "" {
+			units[len(units)-1][1] = i
+			continue
+		}
+		units = append(units, [2]int{unitStart, i})
+	}
+	return units
+}
+
+func isDocTableLine(trimmed string, rst bool) bool {
+	if rst {
+		return strings.HasPrefix(trimmed, "+-") || strings.HasPrefix(trimmed, "+=") || strings.HasPrefix(trimmed, "|")
+	}
+	return strings.HasPrefix(trimmed, "|")
+}
+
+// markdownFence returns the fence marker (``` or ~~~, possibly longer) that
+// opens a fenced code block on line, or "".
+func markdownFence(line string) string {
+	if leadingIndentWidth(line) > 3 {
+		return ""
+	}
+	trimmed := strings.TrimSpace(line)
+	for _, ch := range []byte{'`', '~'} {
+		n := 0
+		for n < len(trimmed) && trimmed[n] == ch {
+			n++
+		}
+		if n >= 3 {
+			return trimmed[:n]
+		}
+	}
+	return ""
+}
+
+// markdownFenceEnd returns the line after the fence that closes the block
+// opened at start, or end when the block is never closed.
+func markdownFenceEnd(lines []string, start, end int) int {
+	open := markdownFence(lines[start])
+	for i := start + 1; i < end; i++ {
+		closing := markdownFence(lines[i])
+		if closing != "" && closing[0] == open[0] && len(closing) >= len(open) && strings.TrimSpace(lines[i]) == closing {
+			return i + 1
+		}
+	}
+	return end
+}
+
+func markdownHeadings(lines []string) []docHeading {
+	var headings []docHeading
+	i := 0
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == "---" {
+		// Skip YAML front matter so its closing --- is not read as a heading.
+		for j := 1; j < len(lines); j++ {
+			if trimmed := strings.TrimSpace(lines[j]); trimmed == "---" || trimmed == "..." {
+				i = j + 1
+				break
+			}
+		}
+	}
+	for i < len(lines) {
+		line := lines[i]
+		if markdownFence(line) != "" {
+			i = markdownFenceEnd(lines, i, len(lines))
+			continue
+		}
+		if level, title, ok := markdownATXHeading(line); ok {
+			headings = append(headings, docHeading{Line: i, Level: level, Title: title})
+			i++
+			continue
+		}
+		if i+1 < len(lines) && (i == 0 || strings.TrimSpace(lines[i-1]) == "") {
+			if level := markdownSetextLevel(lines[i+1]); level > 0 && isMarkdownSetextTitle(line) {
+				headings = append(headings, docHeading{Line: i, Level: level, Title: strings.TrimSpace(line)})
+				i += 2
+				continue
+			}
+		}
+		i++
+	}
+	return headings
+}
+
+func markdownATXHeading(line string) (int, string, bool) {
+	if leadingIndentWidth(line) > 3 {
+		return 0, "", false
+	}
+	trimmed := strings.TrimSpace(line)
+	level := 0
+	for level < len(trimmed) && trimmed[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(trimmed) && trimmed[level] != ' ' && trimmed[level] != '\t') {
+		return 0, "", false
+	}
+	title := strings.TrimSpace(trimmed[level:])
+	if stripped := strings.TrimRight(title, "#"); stripped != title && (stripped == "" || strings.HasSuffix(stripped, " ")) {
+		title = strings.TrimSpace(stripped)
+	}
+	if title == "" {
+		return 0, "", false
+	}
+	return level, title, true
+}
+
+func markdownSetextLevel(line string) int {
+	if leadingIndentWidth(line) > 3 {
+		return 0
+	}
+	trimmed := strings.TrimSpace(line)
+	if len(trimmed) < 2 {
+		return 0
+	}
+	switch {
+	case strings.Trim(trimmed, "=") == "":
+		return 1
+	case strings.Trim(trimmed, "-") == "":
+		return 2
+	}
+	return 0
+}
+
+func isMarkdownSetextTitle(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || leadingIndentWidth(line) > 3 || isDocTableLine(trimmed, false) {
+		return false
+	}
+	for _, marker := range []string{"- ", "* ", "+ ", "> "} {
+		if strings.HasPrefix(trimmed, marker) {
+			return false
+		}
+	}
+	return true
+}
+
+// rstHeadings finds section titles. reStructuredText has no fixed levels: a
+// title's level is the order in which its adornment style (underline
+// character, with or without overline) first appears in the document.
+func rstHeadings(lines []string) []docHeading {
+	var headings []docHeading
+	styles := map[string]int{}
+	for i := 0; i+1 < len(lines); i++ {
+		title := strings.TrimSpace(lines[i])
+		if title == "" || leadingIndentWidth(lines[i]) > 0 || isRSTAdornment(lines[i]) {
+			continue
+		}
+		underline := strings.TrimSpace(lines[i+1])
+		if !isRSTAdornment(underline) || len(underline) < utf8.RuneCountInString(title) {
+			continue
+		}
+		start := i
+		style := underline[:1]
+		if i > 0 && strings.TrimSpace(lines[i-1]) == underline {
+			start = i - 1
+			style += "/overline"
+		}
+		level, ok := styles[style]
+		if !ok {
+			level = len(styles) + 1
+			styles[style] = level
+		}
+		headings = append(headings, docHeading{Line: start, Level: level, Title: title})
+		i++
+	}
+	return headings
+}
+
+func isRSTAdornment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if len(trimmed) < 2 || leadingIndentWidth(line) > 0 {
+		return false
+	}
+	ch := trimmed[0]
+	if !strings.ContainsRune("=-`:'\"~^_*+#<>.", rune(ch)) {
+		return false
+	}
+	return strings.Trim(trimmed, string(ch)) == ""
+}
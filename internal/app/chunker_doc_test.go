@@ -0,0 +1,112 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestChunkFileMarkdownSmallDocumentIsOneChunk(t *testing.T) {
+	content := []byte("# Title\n\nIntro.\n\n## Usage\n\nRun it.\n")
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("README.md", content, 400, 20, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+	if len(chunks) != 1 || chunks[0].ChunkType != "section" || chunks[0].StartLine != 1 {
+		t.Fatalf("expected one section chunk, got %#v", chunks)
+	}
+	if chunks[0].SymbolName != "Title" {
+		t.Fatalf("expected whole-document chunk named after its first heading, got %q", chunks[0].SymbolName)
+	}
+}
+
+func TestChunkFileMarkdownSplitsOnHeadingHierarchy(t *testing.T) {
+	var body strings.Builder
+	body.WriteString("---\ntitle: Design\n---\n\n# Architecture\n\nOverview of the system.\n\n## Storage\n\n")
+	for i := 0; i < 20; i++ {
+		body.WriteString(fmt.Sprintf("Storage paragraph %d describes how rows are persisted.\n\n", i))
+	}
+	body.WriteString("### Migrations\n\n```sql\n# not a heading\nALTER TABLE chunks ADD COLUMN x;\n```\n\n")
+	body.WriteString("Setext Section\n--------------\n\n| a | b |\n|---|---|\n| 1 | 2 |\n")
+
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("design.md", []byte(body.String()), 120, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+
+	byName := chunksBySymbolName(chunks)
+	for _, name := range []string{
+		"Architecture",
+		"Architecture > Storage",
+		"Architecture > Storage > Migrations",
+		"Architecture > Setext Section",
+	} {
+		chunk, ok := byName[name]
+		if !ok {
+			t.Fatalf("missing %q chunk in %#v", name, chunks)
+		}
+		if chunk.SymbolKind != "section" {
+			t.Fatalf("unexpected %q kind %s", name, chunk.SymbolKind)
+		}
+	}
+	for _, chunk := range chunks {
+		if strings.Contains(chunk.SymbolName, "not a heading") {
+			t.Fatalf("fenced code comment was read as a heading: %#v", chunk)
+		}
+		if strings.Contains(chunk.Text, "```sql") && !strings.Contains(chunk.Text, "ALTER TABLE") {
+			t.Fatalf("fenced code block was split: %q", chunk.Text)
+		}
+		if strings.Contains(chunk.Text, "| a | b |") && !strings.Contains(chunk.Text, "| 1 | 2 |") {
+			t.Fatalf("table was split: %q", chunk.Text)
+		}
+		if strings.Contains(chunk.Text, "Storage paragraph") && strings.Count(chunk.Text, "Storage paragraph") != strings.Count(chunk.Text, "persisted.") {
+			t.Fatalf("paragraph was split: %q", chunk.Text)
+		}
+	}
+	storageParts := 0
+	for _, chunk := range chunks {
+		if chunk.SymbolName == "Architecture > Storage" {
+			storageParts++
+		}
+	}
+	if storageParts < 2 {
+		t.Fatalf("expected oversized Storage section to be packed into several chunks, got %d", storageParts)
+	}
+}
+
+func TestChunkFileRSTHeadingLevelsFollowAdornmentOrder(t *testing.T) {
+	var body strings.Builder
+	body.WriteString("=====\nGuide\n=====\n\nInstall\n-------\n\n")
+	for i := 0; i < 6; i++ {
+		body.WriteString(fmt.Sprintf("Step %d explains one part of the installation.\n\n", i))
+	}
+	body.WriteString("Example::\n\n    pip install mem\n\n    mem init\n\nConfigure\n---------\n\nOptions\n~~~~~~~\n\nSet the key.\n")
+
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("guide.rst", []byte(body.String()), 100, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+	byName := chunksBySymbolName(chunks)
+	for _, name := range []string{"Guide > Install", "Guide > Configure"} {
+		if _, ok := byName[name]; !ok {
+			t.Fatalf("missing %q chunk in %#v", name, chunks)
+		}
+	}
+	for _, chunk := range chunks {
+		if strings.Contains(chunk.Text, "pip install mem") && !strings.Contains(chunk.Text, "mem init") {
+			t.Fatalf("literal block was split: %q", chunk.Text)
+		}
+	}
+
+	headings := rstHeadings(strings.Split(body.String(), "\n"))
+	var levels []string
+	for _, heading := range headings {
+		levels = append(levels, fmt.Sprintf("%s=%d", heading.Title, heading.Level))
+	}
+	if got := strings.Join(levels, ","); got != "Guide=1,Install=2,Configure=2,Options=3" {
+		t.Fatalf("unexpected heading levels %s", got)
+	}
+}
@@ -0,0 +1,228 @@
+package app
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	memtoken "mem/internal/token"
+)
+
+// Go files are chunked from the parsed AST: one chunk per function, method,
+// type, and const or var declaration, each starting at its doc comment.
+// Methods are named "Type.Method" after their receiver, so symbol queries
+// such as Store.Search find them. The file holding the package doc comment
+// also gets a package overview chunk: the doc, the package clause, and a
+// generated listing of the identifiers that file exports. Other files of the
+// package get none, so a package has one overview rather than one per file.
+
+func chunkGo(path string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, content, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(string(content), "\n")
+	var chunks []SemanticChunk
+	if overview, ok := goPackageOverview(fset, f, filepath.Base(path), lines, counter, maxTokens); ok {
+		chunks = append(chunks, overview)
+	}
+
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			chunks = append(chunks, extractGoFunc(fset, d, lines, counter, maxTokens, overlapTokens)...)
+		case *ast.GenDecl:
+			switch d.Tok {
+			case token.TYPE:
+				for _, spec := range d.Specs {
+					if ts, ok := spec.(*ast.TypeSpec); ok {
+						chunks = append(chunks, extractGoType(fset, d, ts, lines, counter, maxTokens, overlapTokens)...)
+					}
+				}
+			case token.CONST, token.VAR:
+				chunks = append(chunks, extractGoValues(fset, d, lines, counter, maxTokens, overlapTokens)...)
+			}
+		}
+	}
+
+	return chunks, nil
+}
+
+func extractGoFunc(fset *token.FileSet, fn *ast.FuncDecl, lines []string, counter *memtoken.Counter, maxTokens, overlapTokens int) []SemanticChunk {
+	symbolKind := "function"
+	symbolName := fn.Name.Name
+	if fn.Recv != nil && len(fn.Recv.List) > 0 {
+		symbolKind = "method"
+		if recv := goReceiverTypeName(fn.Recv.List[0].Type); recv != "" {
+			symbolName = recv + "." + fn.Name.Name
+		}
+	}
+	return goDeclChunks(fset, goDeclStart(fn.Doc, fn.Pos()), fn.End(), lines, "function", symbolName, symbolKind, counter, maxTokens, overlapTokens)
+}
+
+func extractGoType(fset *token.FileSet, decl *ast.GenDecl, spec *ast.TypeSpec, lines []string, counter *memtoken.Counter, maxTokens, overlapTokens int) []SemanticChunk {
+	symbolKind := "type"
+	switch spec.Type.(type) {
+	case *ast.StructType:
+		symbolKind = "struct"
+	case *ast.InterfaceType:
+		symbolKind = "interface"
+	}
+
+	// An ungrouped declaration keeps its doc comment and "type" keyword on
+	// the GenDecl rather than the spec.
+	start, end := goDeclStart(spec.Doc, spec.Pos()), spec.End()
+	if !decl.Lparen.IsValid() {
+		start, end = goDeclStart(decl.Doc, decl.Pos()), decl.End()
+	}
+	return goDeclChunks(fset, start, end, lines, "class", spec.Name.Name, symbolKind, counter, maxTokens, overlapTokens)
+}
+
+// extractGoValues emits a const or var declaration, grouped blocks such as
+// iota enums included, as one chunk named after its first identifier.
+func extractGoValues(fset *token.FileSet, decl *ast.GenDecl, lines []string, counter *memtoken.Counter, maxTokens, overlapTokens int) []SemanticChunk {
+	symbolName := ""
+	for _, spec := range decl.Specs {
+		if vs, ok := spec.(*ast.ValueSpec); ok && len(vs.Names) > 0 {
+			symbolName = vs.Names[0].Name
+			break
+		}
+	}
+	if symbolName == "" || symbolName == "_" {
+		return nil
+	}
+	return goDeclChunks(fset, goDeclStart(decl.Doc, decl.Pos()), decl.End(), lines, decl.Tok.String(), symbolName, decl.Tok.String(), counter, maxTokens, overlapTokens)
+}
+
+func goDeclStart(doc *ast.CommentGroup, pos token.Pos) token.Pos {
+	if doc != nil {
+		return doc.Pos()
+	}
+	return pos
+}
+
+func goDeclChunks(fset *token.FileSet, startPos, endPos token.Pos, lines []string, chunkType, symbolName, symbolKind string, counter *memtoken.Counter, maxTokens, overlapTokens int) []SemanticChunk {
+	startLine := fset.Position(startPos).Line - 1
+	endLine := fset.Position(endPos).Line
+	if startLine < 0 {
+		startLine = 0
+	}
+	if endLine > len(lines) {
+		endLine = len(lines)
+	}
+
+	text := strings.Join(lines[startLine:endLine], "\n")
+	if counter.Count(text) <= maxTokens {
+		return []SemanticChunk{{
+			Text:       text,
+			StartLine:  startLine + 1,
+			EndLine:    endLine,
+			ChunkType:  chunkType,
+			SymbolName: symbolName,
+			SymbolKind: symbolKind,
+		}}
+	}
+	return splitWithMetadata(lines[startLine:endLine], startLine+1, symbolName, symbolKind, counter, maxTokens, overlapTokens)
+}
+
+// goReceiverTypeName returns the base type name of a method receiver,
+// stripping pointers and type parameters ("*Set[T]" is "Set").
+func goReceiverTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return goReceiverTypeName(t.X)
+	case *ast.ParenExpr:
+		return goReceiverTypeName(t.X)
+	case *ast.IndexExpr:
+		return goReceiverTypeName(t.X)
+	case *ast.IndexListExpr:
+		return goReceiverTypeName(t.X)
+	}
+	return ""
+}
+
+// goPackageOverview builds a chunk from the package doc comment, the
+// package clause, and a listing of the file's exported identifiers. Files
+// without a package doc get no overview. The chunk spans the doc comment and
+// package clause lines; the listing is not in the file, so it is appended
+// under a comment saying so and stops once the budget is reached.
+func goPackageOverview(fset *token.FileSet, f *ast.File, name string, lines []string, counter *memtoken.Counter, maxTokens int) (SemanticChunk, bool) {
+	if f.Doc == nil {
+		return SemanticChunk{}, false
+	}
+	exported := goExportedIdentifiers(f)
+
+	startLine := fset.Position(goDeclStart(f.Doc, f.Package)).Line - 1
+	endLine := fset.Position(f.Name.End()).Line
+	text := strings.Join(lines[startLine:endLine], "\n")
+	if counter.Count(text) > maxTokens {
+		// A package doc larger than the budget is left to speak for itself.
+		return SemanticChunk{}, false
+	}
+	if len(exported) > 0 {
+		listing := text + "\n\n// Exported identifiers in " + name + " (generated listing, not part of the file):"
+		for _, ident := range exported {
+			next := listing + "\n//   " + ident
+			if counter.Count(next) > maxTokens {
+				listing += "\n//   ..."
+				break
+			}
+			listing = next
+		}
+		if counter.Count(listing) <= maxTokens {
+			text = listing
+		}
+	}
+
+	return SemanticChunk{
+		Text:       text,
+		StartLine:  startLine + 1,
+		EndLine:    endLine,
+		ChunkType:  "package",
+		SymbolName: f.Name.Name,
+		SymbolKind: "package",
+	}, true
+}
+
+func goExportedIdentifiers(f *ast.File) []string {
+	var idents []string
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				recv := goReceiverTypeName(d.Recv.List[0].Type)
+				if !ast.IsExported(recv) {
+					continue
+				}
+				idents = append(idents, "func "+recv+"."+d.Name.Name)
+				continue
+			}
+			idents = append(idents, "func "+d.Name.Name)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.IsExported() {
+						idents = append(idents, "type "+s.Name.Name)
+					}
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						if name.IsExported() {
+							idents = append(idents, d.Tok.String()+" "+name.Name)
+						}
+					}
+				}
+			}
+		}
+	}
+	return idents
+}
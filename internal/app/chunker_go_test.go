@@ -0,0 +1,98 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChunkFileGoSemantic(t *testing.T) {
+	content := []byte(`// Package shapes draws things.
+package shapes
+
+import "fmt"
+
+// Color names a fill.
+type Color int
+
+// Fill colors.
+const (
+	Red Color = iota
+	Green
+)
+
+var defaultColor = Red
+
+type (
+	// Point is a position.
+	Point struct{ X, Y int }
+	set[T comparable] map[T]struct{}
+)
+
+// String implements fmt.Stringer.
+func (c Color) String() string {
+	return fmt.Sprint(int(c))
+}
+
+func (s *set[T]) Add(v T) { (*s)[v] = struct{}{} }
+
+// New returns an empty set.
+func New() {}
+`)
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("shapes.go", content, 400, 20, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+
+	byName := chunksBySymbolName(chunks)
+	cases := map[string]struct {
+		kind, chunkType string
+		start, end      int
+	}{
+		"shapes":       {"package", "package", 1, 2},
+		"Color":        {"type", "class", 6, 7},
+		"Red":          {"const", "const", 9, 13},
+		"defaultColor": {"var", "var", 15, 15},
+		"Point":        {"struct", "class", 18, 19},
+		"set":          {"type", "class", 20, 20},
+		"Color.String": {"method", "function", 23, 26},
+		"set.Add":      {"method", "function", 28, 28},
+		"New":          {"function", "function", 30, 31},
+	}
+	for name, want := range cases {
+		chunk, ok := byName[name]
+		if !ok {
+			t.Fatalf("missing %s chunk in %#v", name, chunks)
+		}
+		if chunk.SymbolKind != want.kind || chunk.ChunkType != want.chunkType || chunk.StartLine != want.start || chunk.EndLine != want.end {
+			t.Fatalf("unexpected %s chunk: kind=%s type=%s lines=%d-%d", name, chunk.SymbolKind, chunk.ChunkType, chunk.StartLine, chunk.EndLine)
+		}
+	}
+	if len(chunks) != len(cases) {
+		t.Fatalf("expected %d chunks, got %d: %#v", len(cases), len(chunks), chunks)
+	}
+
+	overview := byName["shapes"].Text
+	for _, want := range []string{"// Package shapes draws things.", "// Exported identifiers in shapes.go (generated listing, not part of the file):", "type Color", "const Red", "func Color.String", "func New"} {
+		if !strings.Contains(overview, want) {
+			t.Fatalf("expected overview to mention %q, got %q", want, overview)
+		}
+	}
+	for _, unwanted := range []string{"defaultColor", "set.Add"} {
+		if strings.Contains(overview, unwanted) {
+			t.Fatalf("expected overview to skip unexported %q, got %q", unwanted, overview)
+		}
+	}
+}
+
+func TestChunkFileGoSkipsOverviewWithoutPackageDoc(t *testing.T) {
+	content := []byte("package shapes\n\n// Area measures.\nfunc Area() int { return 0 }\n")
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("area.go", content, 400, 20, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+	if len(chunks) != 1 || chunks[0].SymbolName != "Area" || chunks[0].SymbolKind != "function" {
+		t.Fatalf("expected only the Area function chunk, got %#v", chunks)
+	}
+}
@@ -0,0 +1,342 @@
+package app
+
+import (
+	"strings"
+
+	memtoken "mem/internal/token"
+)
+
+// Java and Kotlin share brace-delimited bodies, annotations, and modifier
+// soup, so one chunker handles both. Top-level declarations become one chunk
+// each; a type that does not fit the budget is opened up and its methods,
+// constructors, and nested types are chunked individually, with the remaining
+// lines (header, fields, initializers) kept as chunks of the type itself.
+
+var javaModifiers = map[string]struct{}{
+	"public": {}, "protected": {}, "private": {}, "static": {}, "final": {},
+	"abstract": {}, "sealed": {}, "non-sealed": {}, "strictfp": {},
+	"synchronized": {}, "native": {}, "default": {}, "transient": {}, "volatile": {},
+}
+
+var kotlinModifiers = map[string]struct{}{
+	"public": {}, "protected": {}, "private": {}, "internal": {}, "open": {},
+	"abstract": {}, "final": {}, "override": {}, "sealed": {}, "data": {},
+	"inline": {}, "value": {}, "inner": {}, "suspend": {}, "tailrec": {},
+	"operator": {}, "infix": {}, "external": {}, "expect": {}, "actual": {},
+	"lateinit": {}, "const": {}, "noinline": {}, "crossinline": {},
+}
+
+var javaNonMethodWords = map[string]struct{}{
+	"if": {}, "for": {}, "while": {}, "switch": {}, "catch": {}, "return": {},
+	"new": {}, "else": {}, "try": {}, "throw": {}, "do": {}, "assert": {},
+	"synchronized": {}, "super": {}, "this": {},
+}
+
+func chunkJVM(content []byte, kotlin bool, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+	lines := strings.Split(string(content), "\n")
+	scan := scanTypeScriptLines(lines)
+	decls := collectJVMDecls(lines, scan, 0, len(lines), 0, "", kotlin)
+	members := func(owner braceDecl) []braceDecl {
+		return collectJVMDecls(lines, scan, owner.HeaderLine+1, owner.EndLine, scan[owner.HeaderLine].StartDepth+1, owner.SymbolName, kotlin)
+	}
+	chunks := make([]SemanticChunk, 0, len(decls))
+	for _, decl := range decls {
+		chunks = append(chunks, braceDeclChunks(lines, decl, members, counter, maxTokens, overlapTokens)...)
+	}
+	return chunks, nil
+}
+
+// collectJVMDecls finds declarations starting at depth within [from, to).
+// owner is the enclosing type name, empty at the top level.
+func collectJVMDecls(lines []string, scan []typeScriptLineScan, from, to, depth int, owner string, kotlin bool) []braceDecl {
+	decls := make([]braceDecl, 0)
+	for i := from; i < to; {
+		if scan[i].StartDepth != depth {
+			i++
+			continue
+		}
+		trimmed := strings.TrimSpace(stripTypeScriptLineComment(lines[i]))
+		if trimmed == "" || isBraceCommentLine(trimmed) {
+			i++
+			continue
+		}
+
+		headerLine := i
+		if isJVMAnnotationLine(trimmed) {
+			headerLine = skipJVMAnnotations(lines, scan, i, to, depth)
+			if headerLine >= to {
+				break
+			}
+		}
+		header := strings.TrimSpace(stripTypeScriptLineComment(lines[headerLine]))
+		kind, name, chunkType, isType, ok := parseJVMDeclHeader(header, owner, kotlin)
+		if !ok {
+			i = headerLine + 1
+			continue
+		}
+		if owner != "" && !isType && kind != "constructor" {
+			name = owner + "." + name
+		}
+
+		continues := kotlinLineContinues
+		if !kotlin {
+			continues = nil
+		}
+		endLine := findBraceDeclEnd(lines, scan, headerLine, to, continues)
+		decls = append(decls, braceDecl{
+			StartLine:   braceLeadingCommentStart(lines, scan, i, from, depth),
+			HeaderLine:  headerLine,
+			EndLine:     endLine,
+			ChunkType:   chunkType,
+			SymbolName:  name,
+			SymbolKind:  kind,
+			IsContainer: isType,
+		})
+		i = endLine
+	}
+	return decls
+}
+
+func isJVMAnnotationLine(trimmed string) bool {
+	return strings.HasPrefix(trimmed, "@") && !strings.HasPrefix(trimmed, "@interface")
+}
+
+// skipJVMAnnotations returns the first line after the annotations starting at
+// start that carries more than annotations, following multi-line arguments.
+func skipJVMAnnotations(lines []string, scan []typeScriptLineScan, start, to, depth int) int {
+	parens := 0
+	for j := start; j < to; j++ {
+		trimmed := strings.TrimSpace(stripTypeScriptLineComment(lines[j]))
+		if parens > 0 || scan[j].StartDepth != depth {
+			parens += strings.Count(trimmed, "(") - strings.Count(trimmed, ")")
+			continue
+		}
+		if trimmed == "" || isBraceCommentLine(trimmed) {
+			continue
+		}
+		if !isJVMAnnotationLine(trimmed) {
+			return j
+		}
+		rest := stripJVMAnnotations(trimmed)
+		if rest != "" && !strings.HasPrefix(rest, "(") {
+			return j
+		}
+		parens += strings.Count(trimmed, "(") - strings.Count(trimmed, ")")
+	}
+	return to
+}
+
+// stripJVMAnnotations drops leading annotations, including balanced argument
+// lists, from a single line.
+func stripJVMAnnotations(line string) string {
+	line = strings.TrimSpace(line)
+	for strings.HasPrefix(line, "@") && !strings.HasPrefix(line, "@interface") {
+		rest := line[1:]
+		for _, prefix := range []string{"field:", "get:", "set:", "param:", "file:", "property:"} {
+			rest = strings.TrimPrefix(rest, prefix)
+		}
+		name := readIdentifierPrefix(rest, false)
+		for strings.HasPrefix(rest[len(name):], ".") {
+			next := readIdentifierPrefix(rest[len(name)+1:], false)
+			if next == "" {
+				break
+			}
+			name = rest[:len(name)+1+len(next)]
+		}
+		if name == "" {
+			return line
+		}
+		rest = rest[len(name):]
+		if strings.HasPrefix(rest, "(") {
+			depth := 0
+			end := -1
+			for idx, r := range rest {
+				if r == '(' {
+					depth++
+				} else if r == ')' {
+					depth--
+					if depth == 0 {
+						end = idx
+						break
+					}
+				}
+			}
+			if end < 0 {
+				return rest
+			}
+			rest = rest[end+1:]
+		}
+		line = strings.TrimSpace(rest)
+	}
+	return line
+}
+
+func stripJVMModifiers(line string, kotlin bool) string {
+	modifiers := javaModifiers
+	if kotlin {
+		modifiers = kotlinModifiers
+	}
+	for {
+		line = stripJVMAnnotations(line)
+		word := line
+		if idx := strings.IndexAny(line, " \t"); idx >= 0 {
+			word = line[:idx]
+		} else {
+			return line
+		}
+		if _, ok := modifiers[word]; !ok {
+			return line
+		}
+		line = strings.TrimSpace(line[len(word):])
+	}
+}
+
+func parseJVMDeclHeader(line, owner string, kotlin bool) (symbolKind, symbolName, chunkType string, isType, ok bool) {
+	line = stripJVMModifiers(line, kotlin)
+	if kotlin {
+		return parseKotlinDeclHeader(line, owner)
+	}
+	return parseJavaDeclHeader(line, owner)
+}
+
+func parseJavaDeclHeader(line, owner string) (symbolKind, symbolName, chunkType string, isType, ok bool) {
+	for _, decl := range []struct{ keyword, kind, chunkType string }{
+		{"class ", "class", "class"},
+		{"interface ", "interface", "interface"},
+		{"@interface ", "annotation", "interface"},
+		{"enum ", "enum", "enum"},
+		{"record ", "record", "class"},
+	} {
+		if strings.HasPrefix(line, decl.keyword) {
+			name := readIdentifierPrefix(strings.TrimPrefix(line, decl.keyword), true)
+			if name == "" {
+				return "", "", "", false, false
+			}
+			return decl.kind, name, decl.chunkType, true, true
+		}
+	}
+	if owner == "" {
+		return "", "", "", false, false
+	}
+
+	paren := strings.Index(line, "(")
+	if paren <= 0 {
+		return "", "", "", false, false
+	}
+	before := line[:paren]
+	if strings.ContainsAny(before, "=;") {
+		return "", "", "", false, false
+	}
+	fields := strings.Fields(skipJavaTypeParams(before))
+	if len(fields) == 0 {
+		return "", "", "", false, false
+	}
+	name := fields[len(fields)-1]
+	if readIdentifierPrefix(name, true) != name {
+		return "", "", "", false, false
+	}
+	if _, skip := javaNonMethodWords[fields[0]]; skip {
+		return "", "", "", false, false
+	}
+	if len(fields) == 1 {
+		if name != owner {
+			return "", "", "", false, false
+		}
+		return "constructor", owner + "." + name, "function", false, true
+	}
+	return "method", name, "function", false, true
+}
+
+// skipJavaTypeParams removes a leading generic parameter list such as
+// "<T extends Comparable<T>>" from a method header.
+func skipJavaTypeParams(line string) string {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "<") {
+		return line
+	}
+	depth := 0
+	for idx, r := range line {
+		switch r {
+		case '<':
+			depth++
+		case '>':
+			depth--
+			if depth == 0 {
+				return strings.TrimSpace(line[idx+1:])
+			}
+		}
+	}
+	return line
+}
+
+func parseKotlinDeclHeader(line, owner string) (symbolKind, symbolName, chunkType string, isType, ok bool) {
+	typeDecl := func(rest, kind, chunkType string) (string, string, string, bool, bool) {
+		name := readIdentifierPrefix(strings.TrimSpace(rest), false)
+		if name == "" {
+			return "", "", "", false, false
+		}
+		return kind, name, chunkType, true, true
+	}
+	switch {
+	case strings.HasPrefix(line, "enum class "):
+		return typeDecl(strings.TrimPrefix(line, "enum class "), "enum", "enum")
+	case strings.HasPrefix(line, "annotation class "):
+		return typeDecl(strings.TrimPrefix(line, "annotation class "), "annotation", "interface")
+	case strings.HasPrefix(line, "class "):
+		return typeDecl(strings.TrimPrefix(line, "class "), "class", "class")
+	case strings.HasPrefix(line, "fun interface "):
+		return typeDecl(strings.TrimPrefix(line, "fun interface "), "interface", "interface")
+	case strings.HasPrefix(line, "interface "):
+		return typeDecl(strings.TrimPrefix(line, "interface "), "interface", "interface")
+	case strings.HasPrefix(line, "companion object"):
+		rest := strings.TrimSpace(strings.TrimPrefix(line, "companion object"))
+		name := readIdentifierPrefix(rest, false)
+		if name == "" {
+			name = "Companion"
+		}
+		return "object", name, "class", true, true
+	case strings.HasPrefix(line, "object "):
+		return typeDecl(strings.TrimPrefix(line, "object "), "object", "class")
+	case strings.HasPrefix(line, "typealias "):
+		name := readIdentifierPrefix(strings.TrimPrefix(line, "typealias "), false)
+		if name == "" {
+			return "", "", "", false, false
+		}
+		return "type", name, "type", false, true
+	case line == "constructor" || strings.HasPrefix(line, "constructor(") || strings.HasPrefix(line, "constructor "):
+		if owner == "" {
+			return "", "", "", false, false
+		}
+		return "constructor", owner + ".constructor", "function", false, true
+	case strings.HasPrefix(line, "fun "):
+		rest := skipJavaTypeParams(strings.TrimPrefix(line, "fun "))
+		paren := strings.Index(rest, "(")
+		if paren <= 0 {
+			return "", "", "", false, false
+		}
+		name := strings.TrimSpace(rest[:paren])
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return "", "", "", false, false
+		}
+		kind := "function"
+		if owner != "" {
+			kind = "method"
+		}
+		return kind, name, "function", false, true
+	}
+	return "", "", "", false, false
+}
+
+func kotlinLineContinues(line, next string) bool {
+	for _, suffix := range []string{"=", ",", ".", ":", "->", "+", "-", "*", "/", "&&", "||", "?:", "("} {
+		if strings.HasSuffix(line, suffix) {
+			return true
+		}
+	}
+	for _, prefix := range []string{"{", ".", "?.", "?:", "&&", "||", ")", "=", ":", "where "} {
+		if strings.HasPrefix(next, prefix) {
+			return true
+		}
+	}
+	return false
+}
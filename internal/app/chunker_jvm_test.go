@@ -0,0 +1,293 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestChunkFileJavaSemantic(t *testing.T) {
+	content := []byte(`package com.example;
+
+import java.util.List;
+
+/** Greets people. */
+@Service
+public final class Greeter {
+    private final String prefix;
+}
+
+public interface Named {
+    String name();
+}
+
+@Retention(RetentionPolicy.RUNTIME)
+public @interface Audited {
+}
+
+enum Color { RED, GREEN }
+
+public record Point(int x, int y) {}
+`)
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("Sample.java", content, 400, 20, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+	if len(chunks) != 5 {
+		t.Fatalf("expected 5 semantic chunks, got %d: %#v", len(chunks), chunks)
+	}
+
+	byName := chunksBySymbolName(chunks)
+	cases := map[string][2]string{
+		"Greeter": {"class", "class"},
+		"Named":   {"interface", "interface"},
+		"Audited": {"annotation", "interface"},
+		"Color":   {"enum", "enum"},
+		"Point":   {"record", "class"},
+	}
+	for name, want := range cases {
+		chunk, ok := byName[name]
+		if !ok {
+			t.Fatalf("missing %s chunk", name)
+		}
+		if chunk.SymbolKind != want[0] || chunk.ChunkType != want[1] {
+			t.Fatalf("unexpected %s metadata: kind=%s type=%s", name, chunk.SymbolKind, chunk.ChunkType)
+		}
+	}
+	if greeter := byName["Greeter"]; greeter.StartLine != 5 || greeter.EndLine != 9 {
+		t.Fatalf("expected Greeter to include javadoc and annotation, got %d-%d", greeter.StartLine, greeter.EndLine)
+	}
+	if audited := byName["Audited"]; audited.StartLine != 15 {
+		t.Fatalf("expected Audited to include its annotation, start_line=%d", audited.StartLine)
+	}
+}
+
+func TestChunkFileJavaSplitsOversizedClassIntoMembers(t *testing.T) {
+	content := []byte(`public class Account {
+    private long balance;
+
+    public Account(long opening) {
+        this.balance = opening;
+    }
+
+    @Override
+    public String toString() {
+        return "Account(" + balance + ")";
+    }
+
+    public <T extends Number> void deposit(T amount) {
+        if (amount.longValue() < 0) {
+            throw new IllegalArgumentException("negative");
+        }
+        balance += amount.longValue();
+    }
+
+    static class Ledger {
+        void record() {}
+    }
+`)
+	for i := 0; i < 20; i++ {
+		content = append(content, []byte(fmt.Sprintf("\n    int limit%d() { return %d; }\n", i, i))...)
+	}
+	content = append(content, []byte("}\n")...)
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("Account.java", content, 240, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+
+	byName := chunksBySymbolName(chunks)
+	cases := map[string][2]string{
+		"Account":          {"class", "class"},
+		"Account.Account":  {"constructor", "function"},
+		"Account.toString": {"method", "function"},
+		"Account.deposit":  {"method", "function"},
+		"Ledger":           {"class", "class"},
+	}
+	for name, want := range cases {
+		chunk, ok := byName[name]
+		if !ok {
+			t.Fatalf("missing %s chunk in %#v", name, chunks)
+		}
+		if chunk.SymbolKind != want[0] || chunk.ChunkType != want[1] {
+			t.Fatalf("unexpected %s metadata: kind=%s type=%s", name, chunk.SymbolKind, chunk.ChunkType)
+		}
+	}
+	if header := byName["Account"]; !strings.Contains(header.Text, "private long balance;") {
+		t.Fatalf("expected class header chunk to keep fields, got %q", header.Text)
+	}
+	if toString := byName["Account.toString"]; toString.StartLine != 8 || toString.EndLine != 11 {
+		t.Fatalf("expected toString to include @Override, got %d-%d", toString.StartLine, toString.EndLine)
+	}
+}
+
+func TestChunkFileJavaSplitsOversizedMethod(t *testing.T) {
+	var body strings.Builder
+	body.WriteString("class Big {\n    void run() {\n")
+	for i := 0; i < 40; i++ {
+		body.WriteString("        System.out.println(\"line number that is fairly long\");\n")
+	}
+	body.WriteString("    }\n}\n")
+
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("Big.java", []byte(body.String()), 200, 20, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+	blocks := 0
+	for _, chunk := range chunks {
+		if chunk.SymbolName == "Big.run" {
+			if chunk.ChunkType != "block" {
+				t.Fatalf("expected split method parts to be blocks, got %s", chunk.ChunkType)
+			}
+			blocks++
+		}
+	}
+	if blocks < 2 {
+		t.Fatalf("expected oversized method to be split, got %d parts", blocks)
+	}
+}
+
+func TestChunkFileKotlinSemantic(t *testing.T) {
+	content := []byte(`package com.example
+
+import kotlin.math.max
+
+@JvmInline
+value class UserId(val raw: String)
+
+data class User(
+    val id: UserId,
+    val name: String,
+)
+
+sealed interface Shape
+
+enum class Level { LOW, HIGH }
+
+object Registry {
+    val users = mutableListOf<User>()
+}
+
+typealias Users = List<User>
+
+fun <T> List<T>.second(): T = this[1]
+
+suspend fun load(id: UserId): User {
+    return User(id, "x")
+}
+
+fun greeting(name: String) =
+    "hello " + name
+`)
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("Sample.kt", content, 400, 20, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+	if len(chunks) != 9 {
+		t.Fatalf("expected 9 semantic chunks, got %d: %#v", len(chunks), chunks)
+	}
+
+	byName := chunksBySymbolName(chunks)
+	cases := map[string][2]string{
+		"UserId":         {"class", "class"},
+		"User":           {"class", "class"},
+		"Shape":          {"interface", "interface"},
+		"Level":          {"enum", "enum"},
+		"Registry":       {"object", "class"},
+		"Users":          {"type", "type"},
+		"List<T>.second": {"function", "function"},
+		"load":           {"function", "function"},
+		"greeting":       {"function", "function"},
+	}
+	for name, want := range cases {
+		chunk, ok := byName[name]
+		if !ok {
+			t.Fatalf("missing %s chunk in %#v", name, chunks)
+		}
+		if chunk.SymbolKind != want[0] || chunk.ChunkType != want[1] {
+			t.Fatalf("unexpected %s metadata: kind=%s type=%s", name, chunk.SymbolKind, chunk.ChunkType)
+		}
+	}
+	if user := byName["User"]; user.StartLine != 8 || user.EndLine != 11 {
+		t.Fatalf("expected multi-line constructor in User chunk, got %d-%d", user.StartLine, user.EndLine)
+	}
+	if id := byName["UserId"]; id.StartLine != 5 {
+		t.Fatalf("expected UserId to include annotation, start_line=%d", id.StartLine)
+	}
+	if greeting := byName["greeting"]; greeting.EndLine != 30 {
+		t.Fatalf("expected expression body continuation, end_line=%d", greeting.EndLine)
+	}
+}
+
+func TestChunkFileKotlinSplitsOversizedClassIntoMembers(t *testing.T) {
+	content := []byte(`class Cache(private val size: Int) {
+    private val entries = HashMap<String, String>()
+
+    constructor() : this(16)
+
+    fun get(key: String): String? = entries[key]
+
+    override fun toString(): String {
+        return "Cache(size=" + size + ", entries=" + entries.size + ")"
+    }
+
+    companion object {
+        const val DEFAULT = 16
+    }
+`)
+	for i := 0; i < 20; i++ {
+		content = append(content, []byte(fmt.Sprintf("\n    fun limit%d() = %d\n", i, i))...)
+	}
+	content = append(content, []byte("}\n")...)
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("Cache.kt", content, 150, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+
+	byName := chunksBySymbolName(chunks)
+	cases := map[string][2]string{
+		"Cache":             {"class", "class"},
+		"Cache.constructor": {"constructor", "function"},
+		"Cache.get":         {"method", "function"},
+		"Cache.toString":    {"method", "function"},
+		"Companion":         {"object", "class"},
+	}
+	for name, want := range cases {
+		chunk, ok := byName[name]
+		if !ok {
+			t.Fatalf("missing %s chunk in %#v", name, chunks)
+		}
+		if chunk.SymbolKind != want[0] || chunk.ChunkType != want[1] {
+			t.Fatalf("unexpected %s metadata: kind=%s type=%s", name, chunk.SymbolKind, chunk.ChunkType)
+		}
+	}
+	if get := byName["Cache.get"]; get.StartLine != 6 || get.EndLine != 6 {
+		t.Fatalf("expected single-line get, got %d-%d", get.StartLine, get.EndLine)
+	}
+}
+
+func TestChunkFileKotlinMultilineSignatureWithInlineBody(t *testing.T) {
+	content := []byte(`fun render(
+    name: String,
+    width: Int,
+) { println(name.padEnd(width)) }
+
+fun next() = 1
+`)
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("Render.kt", content, 400, 20, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+	byName := chunksBySymbolName(chunks)
+	if render := byName["render"]; render.EndLine != 4 {
+		t.Fatalf("expected render to end with its inline body, end_line=%d", render.EndLine)
+	}
+	if _, ok := byName["next"]; !ok {
+		t.Fatalf("missing next chunk in %#v", chunks)
+	}
+}
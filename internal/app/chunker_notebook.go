@@ -0,0 +1,195 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	memtoken "mem/internal/token"
+)
+
+// Jupyter notebooks are chunked one cell per chunk. Each chunk is anchored to
+// its cell ("#cell-<id>") rather than a line range, using the nbformat cell
+// id so the locator survives cells being added above it; notebooks written
+// before cell ids existed fall back to a hash of the cell source. Line ranges
+// still point at the cell inside the notebook JSON. The notebook-outputs
+// chunker also appends each code cell's text outputs, truncated, with images
+// and other binary payloads dropped.
+
+const (
+	notebookMaxOutputLines = 20
+	notebookMaxOutputChars = 2000
+)
+
+var ansiEscapePattern = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+type notebookFile struct {
+	Cells []notebookCell `json:"cells"`
+}
+
+type notebookCell struct {
+	ID             string           `json:"id"`
+	CellType       string           `json:"cell_type"`
+	Source         notebookText     `json:"source"`
+	ExecutionCount *int             `json:"execution_count"`
+	Outputs        []notebookOutput `json:"outputs"`
+}
+
+type notebookOutput struct {
+	OutputType string                     `json:"output_type"`
+	Text       notebookText               `json:"text"`
+	Data       map[string]json.RawMessage `json:"data"`
+	EName      string                     `json:"ename"`
+	EValue     string                     `json:"evalue"`
+}
+
+// notebookText is multiline notebook text, stored either as one string or
+// as a list of lines.
+type notebookText string
+
+func (t *notebookText) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*t = notebookText(strings.Join(lines, ""))
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	*t = notebookText(text)
+	return nil
+}
+
+func chunkNotebook(content []byte, includeOutputs bool, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+	var nb notebookFile
+	if err := json.Unmarshal(content, &nb); err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(content), "\n")
+	root, err := parseJSONConfig(content, len(lines))
+	if err != nil {
+		return nil, err
+	}
+	var cellNodes []*configNode
+	for _, child := range root.Children {
+		if child.Path == "cells" {
+			cellNodes = child.Children
+		}
+	}
+
+	var chunks []SemanticChunk
+	anchors := map[string]int{}
+	for i, cell := range nb.Cells {
+		if cell.CellType != "markdown" && cell.CellType != "code" {
+			continue
+		}
+		text := strings.TrimRight(string(cell.Source), "\n")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if includeOutputs && cell.CellType == "code" {
+			if outputs := notebookOutputText(cell); outputs != "" {
+				text += "\n\n" + outputs
+			}
+		}
+
+		anchor := notebookCellAnchor(cell, anchors)
+		start, end := i+1, i+1
+		if i < len(cellNodes) {
+			start, end = cellNodes[i].Start+1, cellNodes[i].End
+		}
+
+		cellChunks := []SemanticChunk{{Text: text}}
+		if counter.Count(text) > maxTokens {
+			cellChunks = splitWithMetadata(strings.Split(text, "\n"), 1, "", "", counter, maxTokens, overlapTokens)
+		}
+		for _, chunk := range cellChunks {
+			chunk.StartLine, chunk.EndLine = start, end
+			chunk.ChunkType = cell.CellType
+			chunk.Anchor = anchor
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks, nil
+}
+
+func notebookCellAnchor(cell notebookCell, seen map[string]int) string {
+	key := strings.TrimSpace(cell.ID)
+	if key == "" {
+		sum := sha256.Sum256([]byte(cell.CellType + "\x00" + string(cell.Source)))
+		key = hex.EncodeToString(sum[:4])
+	}
+	seen[key]++
+	if n := seen[key]; n > 1 {
+		key = fmt.Sprintf("%s-%d", key, n)
+	}
+	return "cell-" + key
+}
+
+// notebookOutputText renders a code cell's outputs as plain text under an
+// Out[n]: header, keeping only text/plain data and truncating long output.
+func notebookOutputText(cell notebookCell) string {
+	var parts []string
+	for _, output := range cell.Outputs {
+		switch output.OutputType {
+		case "stream":
+			parts = append(parts, string(output.Text))
+		case "execute_result", "display_data":
+			if raw, ok := output.Data["text/plain"]; ok {
+				var text notebookText
+				if err := json.Unmarshal(raw, &text); err == nil {
+					parts = append(parts, string(text))
+				}
+				continue
+			}
+			mimeTypes := make([]string, 0, len(output.Data))
+			for mimeType := range output.Data {
+				mimeTypes = append(mimeTypes, mimeType)
+			}
+			sort.Strings(mimeTypes)
+			if len(mimeTypes) > 0 {
+				parts = append(parts, fmt.Sprintf("[%s output omitted]", mimeTypes[0]))
+			}
+		case "error":
+			parts = append(parts, output.EName+": "+output.EValue)
+		}
+	}
+	for i, part := range parts {
+		parts[i] = strings.TrimRight(part, "\n")
+	}
+	text := strings.TrimRight(ansiEscapePattern.ReplaceAllString(strings.Join(parts, "\n"), ""), "\n")
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+
+	outLines := strings.Split(text, "\n")
+	truncated := 0
+	if len(outLines) > notebookMaxOutputLines {
+		truncated = len(outLines) - notebookMaxOutputLines
+		outLines = outLines[:notebookMaxOutputLines]
+	}
+	text = strings.Join(outLines, "\n")
+	if len(text) > notebookMaxOutputChars {
+		text = strings.ToValidUTF8(text[:notebookMaxOutputChars], "")
+		if truncated == 0 {
+			truncated = -1
+		}
+	}
+	switch {
+	case truncated > 0:
+		text += fmt.Sprintf("\n... [%d more lines truncated]", truncated)
+	case truncated < 0:
+		text += "\n... [truncated]"
+	}
+
+	header := "Out:"
+	if cell.ExecutionCount != nil {
+		header = fmt.Sprintf("Out[%d]:", *cell.ExecutionCount)
+	}
+	return header + "\n" + text
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func testNotebook(t *testing.T, cells ...map[string]any) []byte {
+	t.Helper()
+	data, err := json.MarshalIndent(map[string]any{
+		"cells":          cells,
+		"metadata":       map[string]any{"language_info": map[string]any{"name": "python"}},
+		"nbformat":       4,
+		"nbformat_minor": 5,
+	}, "", " ")
+	if err != nil {
+		t.Fatalf("marshal notebook: %v", err)
+	}
+	return data
+}
+
+func TestChunkNotebookCells(t *testing.T) {
+	var longOutput []string
+	for i := 0; i < 30; i++ {
+		longOutput = append(longOutput, fmt.Sprintf("row %d\n", i))
+	}
+	cells := []map[string]any{
+		{"id": "intro", "cell_type": "markdown", "metadata": map[string]any{}, "source": []string{"# Churn model\n", "Why we drop rows.\n"}},
+		{"id": "load", "cell_type": "code", "execution_count": 3, "metadata": map[string]any{}, "source": "df = load()\ndf.head()", "outputs": []map[string]any{
+			{"output_type": "stream", "name": "stdout", "text": longOutput},
+		}},
+		{"id": "plot", "cell_type": "code", "execution_count": 4, "metadata": map[string]any{}, "source": "plot(df)", "outputs": []map[string]any{
+			{"output_type": "display_data", "metadata": map[string]any{}, "data": map[string]any{"image/png": "iVBORw0KGgoAAAANSUhEUgAAAAEAAAAB"}},
+			{"output_type": "error", "ename": "KeyError", "evalue": "'age'", "traceback": []string{"\x1b[31mKeyError\x1b[0m"}},
+		}},
+		{"id": "raw", "cell_type": "raw", "metadata": map[string]any{}, "source": "ignored"},
+		{"cell_type": "code", "execution_count": nil, "metadata": map[string]any{}, "source": "", "outputs": []any{}},
+	}
+	content := testNotebook(t, cells...)
+	counter := testTokenCounter(t)
+
+	chunks, err := chunkNotebook(content, false, 400, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk notebook: %v", err)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("expected markdown and code chunks, got %#v", chunks)
+	}
+	if chunks[0].Anchor != "cell-intro" || chunks[0].ChunkType != "markdown" || chunks[0].Text != "# Churn model\nWhy we drop rows." {
+		t.Fatalf("unexpected markdown chunk %#v", chunks[0])
+	}
+	if chunks[1].Anchor != "cell-load" || chunks[1].ChunkType != "code" || strings.Contains(chunks[1].Text, "row 0") {
+		t.Fatalf("expected code chunk without outputs, got %#v", chunks[1])
+	}
+	lines := strings.Split(string(content), "\n")
+	if !strings.Contains(lines[chunks[1].StartLine-1], "{") || !strings.Contains(strings.Join(lines[chunks[1].StartLine-1:chunks[1].EndLine], "\n"), `"id": "load"`) {
+		t.Fatalf("expected line range to cover the code cell, got %d-%d", chunks[1].StartLine, chunks[1].EndLine)
+	}
+
+	withOutputs, err := chunkNotebook(content, true, 400, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk notebook outputs: %v", err)
+	}
+	text := withOutputs[1].Text
+	for _, want := range []string{"Out[3]:", "row 0", "row 19", "[10 more lines truncated]"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %q in outputs, got %q", want, text)
+		}
+	}
+	if strings.Contains(text, "row 25") {
+		t.Fatalf("expected long output to be truncated, got %q", text)
+	}
+	if plot := withOutputs[2].Text; plot != "plot(df)\n\nOut[4]:\n[image/png output omitted]\nKeyError: 'age'" {
+		t.Fatalf("expected image payload and traceback to be dropped, got %q", plot)
+	}
+}
+
+func TestChunkNotebookAnchorsSurviveInsertedCells(t *testing.T) {
+	counter := testTokenCounter(t)
+	legacy := func(source string) map[string]any {
+		return map[string]any{"cell_type": "code", "metadata": map[string]any{}, "source": source, "outputs": []any{}}
+	}
+	before, err := chunkNotebook(testNotebook(t, legacy("a = 1"), legacy("b = 2")), false, 400, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk before: %v", err)
+	}
+	after, err := chunkNotebook(testNotebook(t, legacy("setup()"), legacy("a = 1"), legacy("b = 2")), false, 400, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk after: %v", err)
+	}
+	if len(before) != 2 || len(after) != 3 {
+		t.Fatalf("unexpected chunk counts %d and %d", len(before), len(after))
+	}
+	if before[0].Anchor != after[1].Anchor || before[1].Anchor != after[2].Anchor || before[0].Anchor == before[1].Anchor {
+		t.Fatalf("expected stable anchors, got %q %q then %q %q", before[0].Anchor, before[1].Anchor, after[1].Anchor, after[2].Anchor)
+	}
+}
@@ -0,0 +1,265 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"mem/internal/config"
+	memtoken "mem/internal/token"
+
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+// Chunker splits one file into chunks. Built-in chunkers register under a
+// name and the extensions they handle by default; repos can route any other
+// file to a registered chunker by glob in .mem/config.json.
+type Chunker interface {
+	Name() string
+	// Strategy is one of "semantic", "document", "structured", "notebook",
+	// or "line-wrap".
+	Strategy() string
+	Chunk(path string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error)
+}
+
+type chunkerFunc struct {
+	name     string
+	strategy string
+	chunk    func(path string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error)
+}
+
+func (c chunkerFunc) Name() string     { return c.name }
+func (c chunkerFunc) Strategy() string { return c.strategy }
+
+func (c chunkerFunc) Chunk(path string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+	return c.chunk(path, content, maxTokens, overlapTokens, counter)
+}
+
+var chunkerRegistry = struct {
+	byName map[string]Chunker
+	byExt  map[string]Chunker
+}{
+	byName: map[string]Chunker{},
+	byExt:  map[string]Chunker{},
+}
+
+// registerChunker adds c to the registry and makes it the default for exts.
+// It panics on a duplicate name or extension, which is a programming error.
+func registerChunker(c Chunker, exts ...string) {
+	if _, exists := chunkerRegistry.byName[c.Name()]; exists {
+		panic(fmt.Sprintf("chunker %q registered twice", c.Name()))
+	}
+	chunkerRegistry.byName[c.Name()] = c
+	for _, ext := range exts {
+		if existing, exists := chunkerRegistry.byExt[ext]; exists {
+			panic(fmt.Sprintf("extension %s registered to both %q and %q", ext, existing.Name(), c.Name()))
+		}
+		chunkerRegistry.byExt[ext] = c
+	}
+}
+
+func lookupChunker(name string) (Chunker, bool) {
+	c, ok := chunkerRegistry.byName[strings.ToLower(strings.TrimSpace(name))]
+	return c, ok
+}
+
+func chunkerForPath(path string) (Chunker, bool) {
+	c, ok := chunkerRegistry.byExt[strings.ToLower(filepath.Ext(path))]
+	return c, ok
+}
+
+func registeredChunkerNames() []string {
+	names := make([]string, 0, len(chunkerRegistry.byName))
+	for name := range chunkerRegistry.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+const lineChunkerName = "lines"
+
+func init() {
+	semantic := func(name string, chunk func(path string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error), exts ...string) {
+		registerChunker(chunkerFunc{name: name, strategy: "semantic", chunk: chunk}, exts...)
+	}
+	semantic("go", chunkGo, ".go")
+	semantic("python", func(_ string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+		return chunkPython(content, maxTokens, overlapTokens, counter)
+	}, ".py")
+	semantic("typescript", func(_ string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+		return chunkTypeScript(content, maxTokens, overlapTokens, counter)
+	}, ".js", ".jsx", ".ts", ".tsx", ".mts", ".cts")
+	semantic("rust", func(_ string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+		return chunkRust(content, maxTokens, overlapTokens, counter)
+	}, ".rs")
+	semantic("c", func(_ string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+		return chunkCFamily(content, false, maxTokens, overlapTokens, counter)
+	}, ".c", ".h", ".cc", ".cpp", ".cxx", ".hpp", ".hh")
+	semantic("csharp", func(_ string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+		return chunkCFamily(content, true, maxTokens, overlapTokens, counter)
+	}, ".cs")
+	semantic("java", func(_ string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+		return chunkJVM(content, false, maxTokens, overlapTokens, counter)
+	}, ".java")
+	semantic("kotlin", func(_ string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+		return chunkJVM(content, true, maxTokens, overlapTokens, counter)
+	}, ".kt", ".kts")
+	semantic("sql", func(_ string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+		return chunkSQL(content, maxTokens, overlapTokens, counter)
+	}, ".sql")
+	semantic("shell", func(_ string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+		return chunkShell(content, maxTokens, overlapTokens, counter)
+	}, ".sh", ".bash", ".zsh")
+
+	for _, doc := range []struct {
+		name string
+		rst  bool
+		exts []string
+	}{
+		{"markdown", false, []string{".md", ".markdown"}},
+		{"rst", true, []string{".rst"}},
+	} {
+		rst := doc.rst
+		registerChunker(chunkerFunc{name: doc.name, strategy: "document", chunk: func(_ string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+			return chunkDocument(content, rst, maxTokens, overlapTokens, counter)
+		}}, doc.exts...)
+	}
+
+	for _, structured := range []struct {
+		name string
+		exts []string
+	}{
+		{"json", []string{".json"}},
+		{"yaml", []string{".yaml", ".yml"}},
+		{"toml", []string{".toml"}},
+	} {
+		ext := structured.exts[0]
+		registerChunker(chunkerFunc{name: structured.name, strategy: "structured", chunk: func(_ string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+			return chunkConfig(ext, content, maxTokens, overlapTokens, counter)
+		}}, structured.exts...)
+	}
+
+	// notebook-outputs has no extensions of its own; repos opt in with a
+	// chunkers rule such as {"glob": "*.ipynb", "chunker": "notebook-outputs"}.
+	registerChunker(chunkerFunc{name: "notebook", strategy: "notebook", chunk: func(_ string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+		return chunkNotebook(content, false, maxTokens, overlapTokens, counter)
+	}}, ".ipynb")
+	registerChunker(chunkerFunc{name: "notebook-outputs", strategy: "notebook", chunk: func(_ string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+		return chunkNotebook(content, true, maxTokens, overlapTokens, counter)
+	}})
+
+	registerChunker(chunkerFunc{name: lineChunkerName, strategy: "line-wrap", chunk: func(_ string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+		return chunkLinesSemanticWrap(content, maxTokens, overlapTokens, counter)
+	}}, ".txt", ".log")
+}
+
+// runChunker chunks content with c, falling back to line chunks when c fails
+// or finds nothing to emit. A panic in c counts as a failure. The second
+// result reports whether the fallback was used.
+func runChunker(c Chunker, path string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, bool, error) {
+	chunks, err := chunkRecovered(c, path, content, maxTokens, overlapTokens, counter)
+	if c.Name() == lineChunkerName {
+		return chunks, false, err
+	}
+	if err != nil || len(chunks) == 0 {
+		chunks, err = chunkLinesSemanticWrap(content, maxTokens, overlapTokens, counter)
+		return chunks, true, err
+	}
+	return chunks, false, nil
+}
+
+// chunkRecovered calls c.Chunk and turns a panic into an error, so one file
+// a chunker cannot handle does not stop ingest workers or the daemon watcher.
+func chunkRecovered(c Chunker, path string, content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) (chunks []SemanticChunk, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			chunks, err = nil, fmt.Errorf("%s chunker panicked on %s: %v", c.Name(), path, r)
+		}
+	}()
+	return c.Chunk(path, content, maxTokens, overlapTokens, counter)
+}
+
+type chunkerRule struct {
+	glob    string
+	matcher *ignore.GitIgnore
+	chunker Chunker
+}
+
+// chunkerSelector picks the chunker and token sizes for each ingested file:
+// the first matching repo rule wins, then the extension default. Files
+// neither claims are not ingested.
+type chunkerSelector struct {
+	rules         []chunkerRule
+	sizes         map[string]config.ChunkSize
+	chunkTokens   int
+	overlapTokens int
+	// fixedSizes is set when sizes were given on the command line, which
+	// takes precedence over per-chunker sizes from the repo config.
+	fixedSizes bool
+}
+
+type chunkPlan struct {
+	Chunker       Chunker
+	Rule          string
+	ChunkTokens   int
+	OverlapTokens int
+}
+
+func newChunkerSelector(repoCfg config.RepoConfig, chunkTokens, overlapTokens int, fixedSizes bool) (chunkerSelector, error) {
+	selector := chunkerSelector{
+		sizes:         repoCfg.ChunkSizes,
+		chunkTokens:   chunkTokens,
+		overlapTokens: overlapTokens,
+		fixedSizes:    fixedSizes,
+	}
+	for _, rule := range repoCfg.Chunkers {
+		glob := strings.TrimSpace(rule.Glob)
+		if glob == "" {
+			return chunkerSelector{}, fmt.Errorf("chunker rule for %q is missing a glob", rule.Chunker)
+		}
+		c, ok := lookupChunker(rule.Chunker)
+		if !ok {
+			return chunkerSelector{}, fmt.Errorf("unknown chunker %q for glob %q (available: %s)", rule.Chunker, glob, strings.Join(registeredChunkerNames(), ", "))
+		}
+		selector.rules = append(selector.rules, chunkerRule{glob: glob, matcher: ignore.CompileIgnoreLines(glob), chunker: c})
+	}
+	for name, size := range repoCfg.ChunkSizes {
+		if _, ok := lookupChunker(name); !ok {
+			return chunkerSelector{}, fmt.Errorf("unknown chunker %q in chunk_sizes (available: %s)", name, strings.Join(registeredChunkerNames(), ", "))
+		}
+		if size.ChunkTokens < 0 || (size.OverlapTokens != nil && *size.OverlapTokens < 0) {
+			return chunkerSelector{}, fmt.Errorf("chunk_sizes for %q must not be negative", name)
+		}
+	}
+	return selector, nil
+}
+
+// plan returns how relPath should be chunked, or false when no chunker
+// claims it.
+func (s chunkerSelector) plan(relPath string) (chunkPlan, bool) {
+	plan := chunkPlan{ChunkTokens: s.chunkTokens, OverlapTokens: s.overlapTokens}
+	for _, rule := range s.rules {
+		if rule.matcher.MatchesPath(relPath) {
+			plan.Chunker, plan.Rule = rule.chunker, rule.glob
+			break
+		}
+	}
+	if plan.Chunker == nil {
+		c, ok := chunkerForPath(relPath)
+		if !ok {
+			return chunkPlan{}, false
+		}
+		plan.Chunker = c
+	}
+	if size, ok := s.sizes[plan.Chunker.Name()]; ok && !s.fixedSizes {
+		if size.ChunkTokens > 0 {
+			plan.ChunkTokens = size.ChunkTokens
+		}
+		if size.OverlapTokens != nil {
+			plan.OverlapTokens = *size.OverlapTokens
+		}
+	}
+	return plan, true
+}
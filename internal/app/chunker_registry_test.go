@@ -0,0 +1,101 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"mem/internal/config"
+	memtoken "mem/internal/token"
+)
+
+func TestChunkerSelectorRulesAndSizes(t *testing.T) {
+	overlap := 0
+	selector, err := newChunkerSelector(config.RepoConfig{
+		Chunkers: []config.ChunkerRule{
+			{Glob: "*.vue", Chunker: "typescript"},
+			{Glob: "infra/**/*.tf", Chunker: "lines"},
+			{Glob: "docs/*.txt", Chunker: "Markdown"},
+		},
+		ChunkSizes: map[string]config.ChunkSize{
+			"markdown": {ChunkTokens: 800, OverlapTokens: &overlap},
+		},
+	}, 320, 40, false)
+	if err != nil {
+		t.Fatalf("new selector: %v", err)
+	}
+
+	cases := []struct {
+		path, chunker, rule string
+		chunkTokens         int
+		overlapTokens       int
+	}{
+		{"web/App.vue", "typescript", "*.vue", 320, 40},
+		{"infra/prod/main.tf", "lines", "infra/**/*.tf", 320, 40},
+		{"docs/guide.txt", "markdown", "docs/*.txt", 800, 0},
+		{"README.md", "markdown", "", 800, 0},
+		{"notes.txt", "lines", "", 320, 40},
+		{"cmd/main.go", "go", "", 320, 40},
+	}
+	for _, tc := range cases {
+		plan, ok := selector.plan(tc.path)
+		if !ok {
+			t.Fatalf("expected a chunker for %s", tc.path)
+		}
+		if plan.Chunker.Name() != tc.chunker || plan.Rule != tc.rule || plan.ChunkTokens != tc.chunkTokens || plan.OverlapTokens != tc.overlapTokens {
+			t.Fatalf("%s: got chunker=%s rule=%q sizes=%d/%d", tc.path, plan.Chunker.Name(), plan.Rule, plan.ChunkTokens, plan.OverlapTokens)
+		}
+	}
+	if _, ok := selector.plan("terraform/main.tf"); ok {
+		t.Fatalf("expected .tf outside infra/ to have no chunker")
+	}
+
+	fixed, err := newChunkerSelector(config.RepoConfig{ChunkSizes: map[string]config.ChunkSize{"markdown": {ChunkTokens: 800}}}, 200, 10, true)
+	if err != nil {
+		t.Fatalf("new fixed selector: %v", err)
+	}
+	if plan, _ := fixed.plan("README.md"); plan.ChunkTokens != 200 || plan.OverlapTokens != 10 {
+		t.Fatalf("expected command-line sizes to win, got %d/%d", plan.ChunkTokens, plan.OverlapTokens)
+	}
+}
+
+func TestChunkerSelectorRejectsUnknownChunker(t *testing.T) {
+	_, err := newChunkerSelector(config.RepoConfig{
+		Chunkers: []config.ChunkerRule{{Glob: "*.proto", Chunker: "protobuf"}},
+	}, 320, 40, false)
+	if err == nil || !strings.Contains(err.Error(), `unknown chunker "protobuf"`) {
+		t.Fatalf("expected unknown chunker error, got %v", err)
+	}
+	_, err = newChunkerSelector(config.RepoConfig{
+		ChunkSizes: map[string]config.ChunkSize{"nope": {ChunkTokens: 10}},
+	}, 320, 40, false)
+	if err == nil {
+		t.Fatalf("expected unknown chunk_sizes key to fail")
+	}
+}
+
+func TestRunChunkerFallsBackToLines(t *testing.T) {
+	counter := testTokenCounter(t)
+	c, _ := lookupChunker("json")
+	chunks, fallback, err := runChunker(c, "broken.json", []byte("{\"a\": \n"), 400, 0, counter)
+	if err != nil {
+		t.Fatalf("run chunker: %v", err)
+	}
+	if !fallback || len(chunks) != 1 {
+		t.Fatalf("expected a line fallback chunk, got fallback=%v %#v", fallback, chunks)
+	}
+}
+
+func TestRunChunkerRecoversFromPanic(t *testing.T) {
+	counter := testTokenCounter(t)
+	c := chunkerFunc{name: "explodes", strategy: "semantic", chunk: func(string, []byte, int, int, *memtoken.Counter) ([]SemanticChunk, error) {
+		var lines []string
+		return []SemanticChunk{{Text: lines[1]}}, nil
+	}}
+	chunks, fallback, err := runChunker(c, "boom.txt", []byte("one\ntwo\n"), 400, 0, counter)
+	if err != nil {
+		t.Fatalf("run chunker: %v", err)
+	}
+	if !fallback || len(chunks) != 1 || !strings.Contains(chunks[0].Text, "two") {
+		t.Fatalf("expected a line fallback after the panic, got fallback=%v %#v", fallback, chunks)
+	}
+}
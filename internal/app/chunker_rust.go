@@ -0,0 +1,464 @@
+package app
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	memtoken "mem/internal/token"
+)
+
+// Rust items are chunked much like JVM types: fn, struct, enum, trait, mod,
+// impl, and macro_rules! items become chunks with their attributes and doc
+// comments attached. An impl chunk is named after its target ("Parser", or
+// "Display for Parser" for a trait impl). Oversized impls are opened so each
+// method is its own chunk named "Parser::parse", which is what symbol queries
+// ask for, while the header stays in the impl's first piece.
+
+func chunkRust(content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+	lines := strings.Split(string(content), "\n")
+	scan, code := scanRustLines(lines)
+
+	members := func(owner braceDecl) []braceDecl {
+		prefix, implTarget := "", owner.SymbolName
+		switch owner.SymbolKind {
+		case "module":
+			prefix, implTarget = owner.SymbolName+"::", ""
+		case "impl":
+			if _, self, ok := strings.Cut(owner.SymbolName, " for "); ok {
+				implTarget = self
+			}
+		}
+		return collectRustDecls(lines, code, scan, owner.HeaderLine+1, owner.EndLine, scan[owner.HeaderLine].StartDepth+1, prefix, implTarget)
+	}
+
+	decls := collectRustDecls(lines, code, scan, 0, len(lines), 0, "", "")
+	chunks := make([]SemanticChunk, 0, len(decls))
+	for _, decl := range decls {
+		chunks = append(chunks, braceDeclChunks(lines, decl, members, counter, maxTokens, overlapTokens)...)
+	}
+	return chunks, nil
+}
+
+// collectRustDecls finds items starting at depth within [from, to). prefix
+// is the module path for items inside a mod; implTarget names the impl or
+// trait whose associated functions are being collected.
+func collectRustDecls(lines, code []string, scan []typeScriptLineScan, from, to, depth int, prefix, implTarget string) []braceDecl {
+	decls := make([]braceDecl, 0)
+	for i := from; i < to; {
+		trimmed := strings.TrimSpace(code[i])
+		if scan[i].StartDepth != depth || trimmed == "" || strings.HasPrefix(trimmed, "#!") {
+			i++
+			continue
+		}
+
+		headerLine := i
+		if strings.HasPrefix(trimmed, "#[") {
+			headerLine = skipRustAttributes(code, scan, i, to, depth)
+			if headerLine >= to {
+				break
+			}
+		}
+
+		item, ok := parseRustItemHeader(rustHeaderText(code, headerLine, to))
+		if !ok {
+			i = headerLine + 1
+			continue
+		}
+
+		decl := braceDecl{
+			StartLine:  braceLeadingCommentStart(lines, scan, i, from, depth),
+			HeaderLine: headerLine,
+			EndLine:    findBraceDeclEnd(code, scan, headerLine, to, nil),
+			ChunkType:  item.ChunkType,
+			SymbolName: prefix + item.Name,
+			SymbolKind: item.Kind,
+		}
+		switch item.Kind {
+		case "function":
+			if implTarget != "" {
+				decl.SymbolName = implTarget + "::" + item.Name
+				decl.SymbolKind = "method"
+			}
+		case "impl":
+			decl.SymbolName = item.Name
+			decl.IsContainer = true
+		case "trait", "module":
+			decl.IsContainer = true
+		}
+		decls = append(decls, decl)
+		i = decl.EndLine
+	}
+	return decls
+}
+
+// skipRustAttributes returns the first line after the outer attributes
+// starting at start, following attributes that span several lines.
+func skipRustAttributes(code []string, scan []typeScriptLineScan, start, to, depth int) int {
+	brackets := 0
+	for j := start; j < to; j++ {
+		trimmed := strings.TrimSpace(code[j])
+		if brackets > 0 || scan[j].StartDepth != depth {
+			brackets += strings.Count(trimmed, "[") - strings.Count(trimmed, "]")
+			continue
+		}
+		if trimmed == "" {
+			continue
+		}
+		if !strings.HasPrefix(trimmed, "#[") {
+			return j
+		}
+		brackets += strings.Count(trimmed, "[") - strings.Count(trimmed, "]")
+		if brackets <= 0 {
+			brackets = 0
+			if rest := rustAfterAttributes(trimmed); rest != "" {
+				return j
+			}
+		}
+	}
+	return to
+}
+
+// rustAfterAttributes drops complete leading attributes from a line.
+func rustAfterAttributes(line string) string {
+	for strings.HasPrefix(line, "#[") {
+		depth := 0
+		end := -1
+		for idx := 1; idx < len(line); idx++ {
+			if line[idx] == '[' {
+				depth++
+			} else if line[idx] == ']' {
+				depth--
+				if depth == 0 {
+					end = idx
+					break
+				}
+			}
+		}
+		if end < 0 {
+			return ""
+		}
+		line = strings.TrimSpace(line[end+1:])
+	}
+	return line
+}
+
+// rustHeaderText joins an item header up to its opening brace or semicolon,
+// so impl targets and generics split across lines are still recognised.
+func rustHeaderText(code []string, headerLine, limit int) string {
+	parts := make([]string, 0, 4)
+	for j := headerLine; j < limit && j < headerLine+6; j++ {
+		trimmed := strings.TrimSpace(code[j])
+		if j == headerLine {
+			trimmed = rustAfterAttributes(trimmed)
+		}
+		parts = append(parts, trimmed)
+		if strings.Contains(trimmed, "{") || strings.HasSuffix(trimmed, ";") {
+			break
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+type rustItem struct {
+	Kind      string
+	Name      string
+	ChunkType string
+}
+
+func parseRustItemHeader(header string) (rustItem, bool) {
+	line := stripRustQualifiers(header)
+	named := func(keyword, kind, chunkType string) (rustItem, bool) {
+		name := readIdentifierPrefix(strings.TrimSpace(strings.TrimPrefix(line, keyword)), false)
+		if name == "" {
+			return rustItem{}, false
+		}
+		return rustItem{Kind: kind, Name: name, ChunkType: chunkType}, true
+	}
+	switch {
+	case strings.HasPrefix(line, "fn "):
+		return named("fn ", "function", "function")
+	case strings.HasPrefix(line, "struct "):
+		return named("struct ", "struct", "class")
+	case strings.HasPrefix(line, "union "):
+		return named("union ", "union", "class")
+	case strings.HasPrefix(line, "enum "):
+		return named("enum ", "enum", "enum")
+	case strings.HasPrefix(line, "trait "):
+		return named("trait ", "trait", "interface")
+	case strings.HasPrefix(line, "mod "):
+		return named("mod ", "module", "module")
+	case strings.HasPrefix(line, "macro_rules!"):
+		return named("macro_rules!", "macro", "function")
+	case line == "impl" || strings.HasPrefix(line, "impl ") || strings.HasPrefix(line, "impl<"):
+		header := strings.TrimPrefix(line, "impl")
+		target := rustImplTarget(header)
+		if target == "" {
+			return rustItem{}, false
+		}
+		if trait := rustImplTrait(header); trait != "" {
+			target = trait + " for " + target
+		}
+		return rustItem{Kind: "impl", Name: target, ChunkType: "class"}, true
+	}
+	return rustItem{}, false
+}
+
+// stripRustQualifiers removes visibility and item qualifiers such as
+// pub(crate), async, unsafe, const fn, and extern "C".
+func stripRustQualifiers(line string) string {
+	line = strings.TrimSpace(line)
+	for {
+		switch {
+		case strings.HasPrefix(line, "pub("):
+			end := strings.Index(line, ")")
+			if end < 0 {
+				return line
+			}
+			line = strings.TrimSpace(line[end+1:])
+		case strings.HasPrefix(line, "extern \""):
+			end := strings.Index(line[len("extern \""):], "\"")
+			if end < 0 {
+				return line
+			}
+			line = strings.TrimSpace(line[len("extern \"")+end+1:])
+		default:
+			word, rest, found := strings.Cut(line, " ")
+			if !found {
+				return line
+			}
+			rest = strings.TrimSpace(rest)
+			switch word {
+			case "const":
+				// const is a qualifier only in "const fn"; otherwise it is
+				// a constant item.
+				if !strings.HasPrefix(rest, "fn ") && !strings.HasPrefix(rest, "unsafe ") && !strings.HasPrefix(rest, "async ") && !strings.HasPrefix(rest, "extern ") {
+					return line
+				}
+				line = rest
+			case "pub", "default", "async", "unsafe", "extern", "auto":
+				line = rest
+			default:
+				return line
+			}
+		}
+	}
+}
+
+// rustImplTarget returns the self type of an impl header, without generics
+// or module path: "<T> fmt::Display for Parser<T> where T: X {" is "Parser".
+func rustImplTarget(header string) string {
+	header = rustImplClause(header)
+	if idx := rustTopLevelIndex(header, " for "); idx >= 0 {
+		header = header[idx+len(" for "):]
+	}
+	header = strings.TrimSpace(header)
+	for _, prefix := range []string{"&", "mut ", "dyn "} {
+		header = strings.TrimSpace(strings.TrimPrefix(header, prefix))
+	}
+	if idx := strings.IndexAny(header, "< \t"); idx >= 0 {
+		header = header[:idx]
+	}
+	if idx := strings.LastIndex(header, "::"); idx >= 0 {
+		header = header[idx+2:]
+	}
+	return readIdentifierPrefix(header, false)
+}
+
+// rustImplTrait returns the trait of a trait impl header, without generics or
+// module path: "<T> fmt::Display for Parser<T>" is "Display". It is empty for
+// inherent impls.
+func rustImplTrait(header string) string {
+	header = rustImplClause(header)
+	idx := rustTopLevelIndex(header, " for ")
+	if idx < 0 {
+		return ""
+	}
+	trait := strings.TrimPrefix(strings.TrimSpace(header[:idx]), "!")
+	if end := strings.IndexAny(trait, "<("); end >= 0 {
+		trait = trait[:end]
+	}
+	if end := strings.LastIndex(trait, "::"); end >= 0 {
+		trait = trait[end+2:]
+	}
+	return readIdentifierPrefix(trait, false)
+}
+
+// rustImplClause trims an impl header to "Trait for Type" or "Type",
+// dropping the impl's own generics, any where clause, and the body.
+func rustImplClause(header string) string {
+	header = skipJavaTypeParams(header)
+	if idx := strings.Index(header, "{"); idx >= 0 {
+		header = header[:idx]
+	}
+	header = strings.TrimSuffix(strings.TrimSpace(header), ";")
+	if idx := rustTopLevelIndex(header, " where "); idx >= 0 {
+		header = header[:idx]
+	}
+	return header
+}
+
+// rustTopLevelIndex finds sep outside of angle brackets.
+func rustTopLevelIndex(s, sep string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '<':
+			depth++
+		case '>':
+			if depth > 0 && (i == 0 || s[i-1] != '-') {
+				depth--
+			}
+		}
+		if depth == 0 && strings.HasPrefix(s[i:], sep) {
+			return i
+		}
+	}
+	return -1
+}
+
+// scanRustLines tracks brace depth like scanTypeScriptLines, but understands
+// Rust lexing: nested block comments, raw strings, and lifetimes that look
+// like unterminated character literals. It also returns each line with
+// comments removed and literal contents blanked, for header parsing.
+func scanRustLines(lines []string) ([]typeScriptLineScan, []string) {
+	scan := make([]typeScriptLineScan, len(lines))
+	code := make([]string, len(lines))
+	depth := 0
+	blockDepth := 0
+	inString := false
+	rawHashes := -1
+	escaped := false
+
+	for i, line := range lines {
+		scan[i].StartDepth = depth
+		var out strings.Builder
+
+		for j := 0; j < len(line); j++ {
+			ch := line[j]
+			next := byte(0)
+			if j+1 < len(line) {
+				next = line[j+1]
+			}
+
+			if blockDepth > 0 {
+				if ch == '/' && next == '*' {
+					blockDepth++
+					j++
+				} else if ch == '*' && next == '/' {
+					blockDepth--
+					j++
+				}
+				continue
+			}
+			if inString {
+				if rawHashes >= 0 {
+					if ch == '"' && strings.HasPrefix(line[j+1:], strings.Repeat("#", rawHashes)) {
+						inString = false
+						j += rawHashes
+						out.WriteByte('"')
+					}
+					continue
+				}
+				if escaped {
+					escaped = false
+					continue
+				}
+				if ch == '\\' {
+					escaped = true
+					continue
+				}
+				if ch == '"' {
+					inString = false
+					out.WriteByte('"')
+				}
+				continue
+			}
+
+			if ch == '/' && next == '/' {
+				break
+			}
+			if ch == '/' && next == '*' {
+				blockDepth = 1
+				j++
+				continue
+			}
+
+			switch {
+			case ch == 'r' && (next == '"' || next == '#') && rustRawStringPrefixAllowed(line, j):
+				hashes := 0
+				for j+1+hashes < len(line) && line[j+1+hashes] == '#' {
+					hashes++
+				}
+				if j+1+hashes < len(line) && line[j+1+hashes] == '"' {
+					inString = true
+					rawHashes = hashes
+					j += 1 + hashes
+					out.WriteByte('"')
+					continue
+				}
+				out.WriteByte(ch)
+			case ch == '"':
+				inString = true
+				rawHashes = -1
+				out.WriteByte('"')
+			case ch == '\'':
+				if end := rustCharLiteralEnd(line, j); end > j {
+					j = end
+					out.WriteString("''")
+					continue
+				}
+				out.WriteByte(ch)
+			case ch == '{':
+				depth++
+				scan[i].HasOpenBrace = true
+				out.WriteByte(ch)
+			case ch == '}':
+				if depth > 0 {
+					depth--
+				}
+				out.WriteByte(ch)
+			default:
+				out.WriteByte(ch)
+			}
+		}
+
+		scan[i].EndDepth = depth
+		code[i] = out.String()
+	}
+
+	return scan, code
+}
+
+// rustRawStringPrefixAllowed reports whether the r at index j starts a raw
+// string literal (r"..", br"..") rather than ending an identifier.
+func rustRawStringPrefixAllowed(line string, j int) bool {
+	if j == 0 {
+		return true
+	}
+	prev := line[j-1]
+	if prev == 'b' {
+		return j == 1 || !isASCIIIdentByte(line[j-2])
+	}
+	return !isASCIIIdentByte(prev)
+}
+
+// rustCharLiteralEnd returns the index of the closing quote when the quote at
+// start opens a character literal, or -1 for a lifetime or label.
+func rustCharLiteralEnd(line string, start int) int {
+	if start+1 >= len(line) {
+		return -1
+	}
+	if line[start+1] == '\\' {
+		for k := start + 2; k < len(line); k++ {
+			if line[k] == '\'' && (k == start+3 || line[k-1] != '\\') {
+				return k
+			}
+		}
+		return -1
+	}
+	_, size := utf8.DecodeRuneInString(line[start+1:])
+	if end := start + 1 + size; end < len(line) && line[end] == '\'' {
+		return end
+	}
+	return -1
+}
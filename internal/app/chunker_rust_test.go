@@ -0,0 +1,184 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChunkFileRustSemantic(t *testing.T) {
+	content := []byte(`//! Tokenizer and parser.
+#![allow(dead_code)]
+
+use std::fmt;
+
+/// A parsed token stream.
+#[derive(Debug, Clone)]
+pub struct Parser<'a> {
+    input: &'a str,
+    pos: usize,
+}
+
+pub(crate) enum Token {
+    Open = '{' as isize,
+    Close = '}' as isize,
+}
+
+pub trait Visit {
+    fn visit(&self, token: &Token);
+}
+
+impl<'a> Parser<'a> {
+    /// Parses the whole input.
+    pub fn parse(&mut self) -> Vec<Token> {
+        let brace = "{";
+        Vec::new()
+    }
+
+    fn peek(&self) -> Option<char> {
+        self.input[self.pos..].chars().next()
+    }
+}
+
+impl<'a> fmt::Display for Parser<'a>
+where
+    'a: 'static,
+{
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(f, "{}", self.input)
+    }
+}
+
+macro_rules! token {
+    ($kind:ident) => {
+        Token::$kind
+    };
+}
+
+pub const fn limit() -> usize {
+    64
+}
+
+mod tests {
+    #[test]
+    fn parses() {}
+}
+`)
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("parser.rs", content, 400, 20, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+
+	byName := chunksBySymbolName(chunks)
+	cases := map[string][2]string{
+		"Parser":             {"struct", "class"},
+		"Token":              {"enum", "enum"},
+		"Visit":              {"trait", "interface"},
+		"Display for Parser": {"impl", "class"},
+		"token":              {"macro", "function"},
+		"limit":              {"function", "function"},
+		"tests":              {"module", "module"},
+	}
+	for name, want := range cases {
+		chunk, ok := byName[name]
+		if !ok {
+			t.Fatalf("missing %s chunk in %#v", name, chunks)
+		}
+		if chunk.SymbolKind != want[0] || chunk.ChunkType != want[1] {
+			t.Fatalf("unexpected %s metadata: kind=%s type=%s", name, chunk.SymbolKind, chunk.ChunkType)
+		}
+	}
+	if len(chunks) != len(cases)+1 {
+		t.Fatalf("expected %d chunks, got %d: %#v", len(cases)+1, len(chunks), chunks)
+	}
+
+	if parser := byName["Parser"]; parser.StartLine != 6 || parser.EndLine != 11 {
+		t.Fatalf("expected Parser to include doc comment and derive, got %d-%d", parser.StartLine, parser.EndLine)
+	}
+	if token := byName["Token"]; token.EndLine != 16 {
+		t.Fatalf("expected char literal braces to be ignored, Token ends at %d", token.EndLine)
+	}
+	var inherent *SemanticChunk
+	for i := range chunks {
+		if chunks[i].SymbolName == "Parser" && chunks[i].SymbolKind == "impl" {
+			inherent = &chunks[i]
+		}
+	}
+	if inherent == nil || inherent.StartLine != 22 || inherent.EndLine != 32 || !strings.Contains(inherent.Text, "fn peek") {
+		t.Fatalf("expected the inherent impl as one chunk, got %#v", inherent)
+	}
+	if display := byName["Display for Parser"]; !strings.HasPrefix(display.Text, "impl<'a> fmt::Display for Parser<'a>") || !strings.Contains(display.Text, "write!") {
+		t.Fatalf("unexpected Display impl chunk %q", display.Text)
+	}
+}
+
+func TestChunkFileRustSplitsOversizedImpl(t *testing.T) {
+	var body strings.Builder
+	body.WriteString("impl<'a> Codec for Parser<'a> {\n    type Error = String;\n\n")
+	for i := 0; i < 12; i++ {
+		body.WriteString("    fn step")
+		body.WriteString(strings.Repeat("x", i))
+		body.WriteString("(&self, input: &str) -> usize {\n        input.len() + self.pos\n    }\n\n")
+	}
+	body.WriteString("}\n")
+
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("codec.rs", []byte(body.String()), 150, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+	if len(chunks) < 2 {
+		t.Fatalf("expected the impl to be split, got %#v", chunks)
+	}
+	if header := chunks[0]; header.SymbolName != "Codec for Parser" || header.SymbolKind != "impl" || !strings.HasPrefix(header.Text, "impl<'a> Codec for Parser<'a> {") {
+		t.Fatalf("expected the impl header in the first piece, got %#v", header)
+	}
+	byName := chunksBySymbolName(chunks)
+	if step, ok := byName["Parser::step"]; !ok || step.SymbolKind != "method" {
+		t.Fatalf("expected method Parser::step, got %#v", chunks)
+	}
+}
+
+func TestChunkFileRustSplitsOversizedModule(t *testing.T) {
+	var body strings.Builder
+	body.WriteString("pub mod codec {\n    pub const MAGIC: u32 = 7;\n\n")
+	for i := 0; i < 12; i++ {
+		body.WriteString("    pub fn step")
+		body.WriteString(strings.Repeat("x", i))
+		body.WriteString("(input: &str) -> usize {\n        input.len() + MAGIC as usize\n    }\n\n")
+	}
+	body.WriteString("}\n")
+
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("codec.rs", []byte(body.String()), 150, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+	byName := chunksBySymbolName(chunks)
+	if step, ok := byName["codec::step"]; !ok || step.SymbolKind != "function" {
+		t.Fatalf("expected module function codec::step, got %#v", chunks)
+	}
+	if header, ok := byName["codec"]; !ok || !strings.Contains(header.Text, "MAGIC") {
+		t.Fatalf("expected module header chunk with constants, got %#v", header)
+	}
+}
+
+func TestScanRustLinesIgnoresLifetimesAndRawStrings(t *testing.T) {
+	lines := []string{
+		`fn f<'a>(x: &'a str) -> &'a str {`,
+		`    let s = r#"{ "json": "}" }"#;`,
+		`    /* { /* nested { */ } */`,
+		`    let c = '{';`,
+		`    x`,
+		`}`,
+	}
+	scan, code := scanRustLines(lines)
+	for i, want := range []int{1, 1, 1, 1, 1, 0} {
+		if scan[i].EndDepth != want {
+			t.Fatalf("line %d: expected depth %d, got %d", i+1, want, scan[i].EndDepth)
+		}
+	}
+	if strings.Contains(code[1], "json") || strings.Contains(code[2], "nested") {
+		t.Fatalf("expected literals and comments to be blanked, got %q", code[1:3])
+	}
+}
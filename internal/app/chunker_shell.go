@@ -0,0 +1,306 @@
+package app
+
+import (
+	"strings"
+
+	memtoken "mem/internal/token"
+)
+
+// Shell scripts are chunked into function definitions and the top-level
+// command blocks between them. Blocks are split only at blank lines outside
+// compound commands (if/fi, case/esac, do/done, { }), heredocs, and quoted
+// strings, then packed up to the budget.
+
+type shellLineScan struct {
+	StartDepth int
+	EndDepth   int
+	// Opened reports whether a compound command opened on this line, which
+	// distinguishes "f() { :; }" from a plain command.
+	Opened bool
+	// Continued reports whether the line starts inside a heredoc or a quoted
+	// string, so it cannot begin a block or a function.
+	Continued bool
+}
+
+func chunkShell(content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+	lines := strings.Split(string(content), "\n")
+	scan := scanShellLines(lines)
+
+	var chunks []SemanticChunk
+	blockStart := 0
+	flushBlock := func(end int) {
+		chunks = append(chunks, packLineUnits(lines, shellBlockUnits(lines, scan, blockStart, end), "block", "", "", counter, maxTokens, overlapTokens)...)
+	}
+	for i := 0; i < len(lines); i++ {
+		if scan[i].StartDepth != 0 || scan[i].Continued {
+			continue
+		}
+		name, ok := parseShellFunctionHeader(lines[i])
+		if !ok {
+			continue
+		}
+		end := shellFunctionEnd(lines, scan, i)
+		if end < 0 {
+			continue
+		}
+		start := i
+		for start > blockStart && isShellCommentLine(lines[start-1]) {
+			start--
+		}
+		flushBlock(start)
+
+		fnLines := lines[start:end]
+		text := strings.Join(fnLines, "\n")
+		if counter.Count(text) <= maxTokens {
+			chunks = append(chunks, SemanticChunk{
+				Text:       text,
+				StartLine:  start + 1,
+				EndLine:    end,
+				ChunkType:  "function",
+				SymbolName: name,
+				SymbolKind: "function",
+			})
+		} else {
+			chunks = append(chunks, splitWithMetadata(fnLines, start+1, name, "function", counter, maxTokens, overlapTokens)...)
+		}
+		blockStart = end
+		i = end - 1
+	}
+	flushBlock(len(lines))
+	return chunks, nil
+}
+
+// shellBlockUnits splits [start, end) at blank lines that sit outside any
+// compound command, heredoc, or string.
+func shellBlockUnits(lines []string, scan []shellLineScan, start, end int) [][2]int {
+	var units [][2]int
+	unitStart := -1
+	for i := start; i < end; i++ {
+		blank := strings.TrimSpace(lines[i]) == "" && scan[i].StartDepth == 0 && !scan[i].Continued
+		if blank {
+			if unitStart >= 0 {
+				units = append(units, [2]int{unitStart, i})
+				unitStart = -1
+			}
+			continue
+		}
+		if unitStart < 0 {
+			unitStart = i
+		}
+	}
+	if unitStart >= 0 {
+		units = append(units, [2]int{unitStart, end})
+	}
+	return units
+}
+
+// parseShellFunctionHeader recognises "name() {", "function name {", and
+// "function name() {", with the brace optionally on the next line.
+func parseShellFunctionHeader(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	keyword := false
+	if rest, ok := strings.CutPrefix(trimmed, "function "); ok {
+		keyword = true
+		trimmed = strings.TrimSpace(rest)
+	}
+	name := trimmed
+	if idx := strings.IndexAny(trimmed, " \t({"); idx >= 0 {
+		name = trimmed[:idx]
+	}
+	if name == "" || !isShellFunctionName(name) {
+		return "", false
+	}
+	rest := strings.TrimSpace(trimmed[len(name):])
+	if strings.HasPrefix(rest, "(") {
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, "("))
+		if !strings.HasPrefix(rest, ")") {
+			return "", false
+		}
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, ")"))
+	} else if !keyword {
+		return "", false
+	}
+	if rest != "" && !strings.HasPrefix(rest, "{") && !strings.HasPrefix(rest, "(") && !strings.HasPrefix(rest, "#") {
+		return "", false
+	}
+	return name, true
+}
+
+func isShellFunctionName(name string) bool {
+	for i := 0; i < len(name); i++ {
+		ch := name[i]
+		if !isASCIIIdentByte(ch) && ch != '-' && ch != ':' && ch != '.' {
+			return false
+		}
+	}
+	return !(name[0] >= '0' && name[0] <= '9')
+}
+
+// shellFunctionEnd returns the line after the body of the function declared
+// on line start, or -1 when no body follows.
+func shellFunctionEnd(lines []string, scan []shellLineScan, start int) int {
+	opened := false
+	for i := start; i < len(lines); i++ {
+		if !opened && i > start && strings.TrimSpace(lines[i]) != "" && !scan[i].Opened {
+			return -1
+		}
+		if scan[i].Opened || scan[i].EndDepth > 0 {
+			opened = true
+		}
+		if opened && scan[i].EndDepth <= 0 {
+			return i + 1
+		}
+	}
+	if opened {
+		return len(lines)
+	}
+	return -1
+}
+
+func isShellCommentLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, "#!")
+}
+
+// scanShellLines tracks compound-command depth across lines. Reserved words
+// only count in command position, quoted text and comments are skipped, and
+// heredoc bodies are passed over until their terminator.
+func scanShellLines(lines []string) []shellLineScan {
+	scan := make([]shellLineScan, len(lines))
+	depth := 0
+	var quote byte
+	var heredocs []string
+
+	for i, line := range lines {
+		entry := shellLineScan{StartDepth: depth, Continued: quote != 0 || len(heredocs) > 0}
+		if len(heredocs) > 0 {
+			terminator := heredocs[0]
+			candidate := line
+			if strings.HasPrefix(terminator, "-") {
+				terminator = terminator[1:]
+				candidate = strings.TrimLeft(candidate, "\t")
+			}
+			if strings.TrimRight(candidate, " \r") == terminator {
+				heredocs = heredocs[1:]
+			}
+			entry.EndDepth = depth
+			scan[i] = entry
+			continue
+		}
+
+		commandPos := quote == 0
+		word := strings.Builder{}
+		endWord := func() {
+			if word.Len() == 0 {
+				return
+			}
+			w := word.String()
+			word.Reset()
+			switch {
+			case w == "{":
+				depth++
+				entry.Opened = true
+				commandPos = true
+				return
+			case w == "}" && commandPos:
+				depth--
+				commandPos = false
+				return
+			case !commandPos:
+				return
+			}
+			switch w {
+			case "if", "case", "do":
+				depth++
+				entry.Opened = true
+			case "fi", "esac", "done":
+				depth--
+				commandPos = false
+				return
+			case "then", "else", "elif", "!", "time":
+				return
+			}
+			commandPos = w == "if" || w == "do" || w == "while" || w == "until"
+		}
+
+		for j := 0; j < len(line); j++ {
+			ch := line[j]
+			if quote != 0 {
+				switch {
+				case ch == '\\' && quote == '"':
+					j++
+				case ch == quote:
+					quote = 0
+				}
+				word.WriteByte('x')
+				continue
+			}
+			switch {
+			case ch == '\\':
+				j++
+				word.WriteByte('x')
+			case ch == '\'' || ch == '"' || ch == '`':
+				quote = ch
+				word.WriteByte('x')
+			case ch == '#' && word.Len() == 0:
+				j = len(line)
+			case ch == '<' && strings.HasPrefix(line[j:], "<<") && !strings.HasPrefix(line[j:], "<<<"):
+				endWord()
+				terminator, width := parseShellHeredoc(line[j+2:])
+				if terminator != "" {
+					heredocs = append(heredocs, terminator)
+				}
+				j += 1 + width
+				commandPos = false
+			case ch == ' ' || ch == '\t' || ch == '\r':
+				endWord()
+			case ch == ';' || ch == '&' || ch == '|' || ch == '(' || ch == ')':
+				endWord()
+				commandPos = true
+				if ch == ';' && strings.HasPrefix(line[j:], ";;") {
+					// End of a case arm; the next word is a pattern.
+					j++
+					commandPos = false
+				}
+			default:
+				word.WriteByte(ch)
+			}
+		}
+		endWord()
+		entry.EndDepth = depth
+		scan[i] = entry
+	}
+	return scan
+}
+
+// parseShellHeredoc reads the delimiter after "<<", returning it with a
+// leading "-" for the tab-stripping "<<-" form, and the bytes consumed.
+func parseShellHeredoc(text string) (string, int) {
+	i := 0
+	dash := ""
+	if i < len(text) && text[i] == '-' {
+		dash = "-"
+		i++
+	}
+	for i < len(text) && (text[i] == ' ' || text[i] == '\t') {
+		i++
+	}
+	if i >= len(text) {
+		return "", i
+	}
+	if text[i] == '\'' || text[i] == '"' {
+		end := strings.IndexByte(text[i+1:], text[i])
+		if end < 0 {
+			return "", len(text)
+		}
+		return dash + text[i+1:i+1+end], i + end + 2
+	}
+	start := i
+	for i < len(text) && (isASCIIIdentByte(text[i]) || text[i] == '-' || text[i] == '.') {
+		i++
+	}
+	if i == start {
+		return "", i
+	}
+	return dash + text[start:i], i
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChunkFileShellFunctionsAndBlocks(t *testing.T) {
+	content := []byte(`#!/usr/bin/env bash
+set -euo pipefail
+
+ROOT="$(cd "$(dirname "$0")" && pwd)"
+
+# Prints usage.
+usage() {
+	cat <<-EOF
+	usage: deploy.sh [env]
+
+	}
+	EOF
+}
+
+function build {
+	if [ -n "${CI:-}" ]; then
+		echo "ci build"
+
+	fi
+	for target in api worker; do
+		make "$target"
+	done
+}
+
+log() { echo "[$(date)] $*"; }
+
+case "${1:-}" in
+	prod) build ;;
+	*) usage ;;
+esac
+`)
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("deploy.sh", content, 400, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+
+	byName := chunksBySymbolName(chunks)
+	want := map[string][2]int{
+		"usage": {6, 13},
+		"build": {15, 23},
+		"log":   {25, 25},
+	}
+	for name, lines := range want {
+		chunk, ok := byName[name]
+		if !ok {
+			t.Fatalf("missing %s chunk in %#v", name, chunks)
+		}
+		if chunk.ChunkType != "function" || chunk.StartLine != lines[0] || chunk.EndLine != lines[1] {
+			t.Fatalf("unexpected %s chunk: type=%s %d-%d", name, chunk.ChunkType, chunk.StartLine, chunk.EndLine)
+		}
+	}
+	var blocks []SemanticChunk
+	for _, chunk := range chunks {
+		if chunk.ChunkType == "block" {
+			blocks = append(blocks, chunk)
+		}
+	}
+	if len(blocks) != 2 || !strings.Contains(blocks[0].Text, "ROOT=") || !strings.Contains(blocks[1].Text, "esac") {
+		t.Fatalf("expected header and case blocks, got %#v", blocks)
+	}
+}
+
+func TestScanShellLinesSkipsHeredocsAndStrings(t *testing.T) {
+	lines := []string{
+		`if true; then`,
+		`  echo "fi { done"`,
+		`  cat <<'END'`,
+		`if {`,
+		`END`,
+		`fi`,
+	}
+	scan := scanShellLines(lines)
+	for i, want := range []int{1, 1, 1, 1, 1, 0} {
+		if scan[i].EndDepth != want {
+			t.Fatalf("line %d: expected depth %d, got %d", i+1, want, scan[i].EndDepth)
+		}
+	}
+	if !scan[3].Continued {
+		t.Fatalf("expected heredoc body to be marked as continued")
+	}
+}
@@ -0,0 +1,274 @@
+package app
+
+import (
+	"strings"
+
+	memtoken "mem/internal/token"
+)
+
+// SQL files are chunked one statement at a time, in file order, so a
+// migration reads top to bottom the way it runs. CREATE and ALTER statements
+// are named after the object they define ("public.users", kind "table");
+// runs of anonymous statements such as seed INSERTs are packed together.
+// Comments between statements travel with the statement after them.
+
+const sqlStatementChunkType = "statement"
+
+var sqlObjectKinds = map[string]bool{
+	"TABLE":     true,
+	"INDEX":     true,
+	"VIEW":      true,
+	"FUNCTION":  true,
+	"PROCEDURE": true,
+	"TRIGGER":   true,
+	"SEQUENCE":  true,
+	"TYPE":      true,
+	"SCHEMA":    true,
+}
+
+var sqlNameQualifiers = map[string]bool{
+	"IF":           true,
+	"NOT":          true,
+	"EXISTS":       true,
+	"CONCURRENTLY": true,
+	"ONLY":         true,
+}
+
+type sqlStatement struct {
+	Start      int
+	End        int
+	SymbolName string
+	SymbolKind string
+}
+
+func chunkSQL(content []byte, maxTokens, overlapTokens int, counter *memtoken.Counter) ([]SemanticChunk, error) {
+	lines := strings.Split(string(content), "\n")
+	statements := sqlStatements(lines)
+
+	var chunks []SemanticChunk
+	var anonymous [][2]int
+	flushAnonymous := func() {
+		chunks = append(chunks, packLineUnits(lines, anonymous, sqlStatementChunkType, "", "", counter, maxTokens, overlapTokens)...)
+		anonymous = nil
+	}
+	for _, stmt := range statements {
+		if stmt.SymbolName == "" {
+			anonymous = append(anonymous, [2]int{stmt.Start, stmt.End})
+			continue
+		}
+		flushAnonymous()
+		stmtLines := lines[stmt.Start:stmt.End]
+		text := strings.Join(stmtLines, "\n")
+		if counter.Count(text) <= maxTokens {
+			chunks = append(chunks, SemanticChunk{
+				Text:       text,
+				StartLine:  stmt.Start + 1,
+				EndLine:    stmt.End,
+				ChunkType:  sqlStatementChunkType,
+				SymbolName: stmt.SymbolName,
+				SymbolKind: stmt.SymbolKind,
+			})
+			continue
+		}
+		chunks = append(chunks, splitWithMetadata(stmtLines, stmt.Start+1, stmt.SymbolName, stmt.SymbolKind, counter, maxTokens, overlapTokens)...)
+	}
+	flushAnonymous()
+	return chunks, nil
+}
+
+// sqlStatements splits lines into statements ending at a semicolon or a
+// T-SQL GO line. Semicolons inside strings, quoted identifiers, comments,
+// dollar-quoted bodies, and the BEGIN ... END body of a CREATE statement do
+// not end a statement. Statements sharing a line are kept together so that
+// no line belongs to two chunks.
+func sqlStatements(lines []string) []sqlStatement {
+	var statements []sqlStatement
+	var quote byte
+	var dollarTag string
+	inComment := false
+	start, lastCode := -1, -1
+	var words []string
+	depth := 0
+
+	finish := func(end int) {
+		if start < 0 {
+			return
+		}
+		from := 0
+		if len(statements) > 0 {
+			from = statements[len(statements)-1].End
+			if start < from {
+				// The statement begins on the line the previous one ended on.
+				prev := &statements[len(statements)-1]
+				prev.End = end
+				start, words, depth = -1, nil, 0
+				return
+			}
+		}
+		for from < start && strings.TrimSpace(lines[from]) == "" {
+			from++
+		}
+		name, kind := sqlStatementSymbol(words)
+		statements = append(statements, sqlStatement{Start: from, End: end, SymbolName: name, SymbolKind: kind})
+		start, words, depth = -1, nil, 0
+	}
+
+	for i, line := range lines {
+		if quote == 0 && dollarTag == "" && !inComment && strings.EqualFold(strings.TrimSpace(line), "GO") {
+			finish(i + 1)
+			continue
+		}
+		for j := 0; j < len(line); j++ {
+			ch := line[j]
+			switch {
+			case inComment:
+				if ch == '*' && j+1 < len(line) && line[j+1] == '/' {
+					inComment = false
+					j++
+				}
+				continue
+			case dollarTag != "":
+				if strings.HasPrefix(line[j:], dollarTag) {
+					j += len(dollarTag) - 1
+					dollarTag = ""
+				}
+				continue
+			case quote != 0:
+				if ch == quote {
+					quote = 0
+				}
+				continue
+			}
+
+			switch {
+			case ch == '-' && j+1 < len(line) && line[j+1] == '-':
+				j = len(line)
+				continue
+			case ch == '/' && j+1 < len(line) && line[j+1] == '*':
+				inComment = true
+				j++
+				continue
+			case ch == ' ' || ch == '\t' || ch == '\r':
+				continue
+			}
+
+			if start < 0 {
+				start = i
+			}
+			lastCode = i
+			switch {
+			case ch == '\'' || ch == '"' || ch == '`':
+				quote = ch
+				words = append(words, sqlQuotedWord(line[j:]))
+			case ch == '[' && len(words) > 0:
+				quote = ']'
+				words = append(words, sqlQuotedWord(line[j:]))
+			case ch == '$':
+				if tag := sqlDollarTag(line[j:]); tag != "" {
+					dollarTag = tag
+					j += len(tag) - 1
+				}
+			case ch == ';':
+				if depth <= 0 {
+					finish(i + 1)
+				}
+			case isASCIIIdentByte(ch):
+				k := j
+				for k < len(line) && (isASCIIIdentByte(line[k]) || line[k] == '.') {
+					k++
+				}
+				word := line[j:k]
+				words = append(words, word)
+				if len(words) > 0 && strings.EqualFold(words[0], "CREATE") {
+					switch strings.ToUpper(word) {
+					case "BEGIN", "CASE":
+						depth++
+					case "END":
+						next := strings.ToUpper(strings.Fields(line[k:] + " ;")[0])
+						next = strings.TrimRight(next, ";")
+						if next != "IF" && next != "LOOP" && next != "WHILE" && next != "REPEAT" && next != "FOR" {
+							depth--
+						}
+					}
+				}
+				j = k - 1
+			}
+		}
+	}
+	if start >= 0 {
+		finish(lastCode + 1)
+	}
+	return statements
+}
+
+// sqlStatementSymbol names CREATE and ALTER statements after the object they
+// define, returning the object kind in lower case.
+func sqlStatementSymbol(words []string) (string, string) {
+	if len(words) < 3 {
+		return "", ""
+	}
+	verb := strings.ToUpper(words[0])
+	if verb != "CREATE" && verb != "ALTER" {
+		return "", ""
+	}
+	// Modifiers and MySQL DEFINER clauses may sit between the verb and the
+	// object keyword, so look a few words ahead for it.
+	i := 1
+	for i < len(words) && i < 8 && !sqlObjectKinds[strings.ToUpper(words[i])] {
+		i++
+	}
+	if i >= len(words) || !sqlObjectKinds[strings.ToUpper(words[i])] {
+		return "", ""
+	}
+	kind := strings.ToLower(words[i])
+	i++
+	for i < len(words) && sqlNameQualifiers[strings.ToUpper(words[i])] {
+		i++
+	}
+	if i >= len(words) {
+		return "", ""
+	}
+	return words[i], kind
+}
+
+// sqlQuotedWord returns the identifier quoted at the start of text, or ""
+// for string literals, so quoted names such as "user accounts" can be used
+// as symbols.
+func sqlQuotedWord(text string) string {
+	closing := text[0]
+	if closing == '\'' {
+		return ""
+	}
+	if closing == '[' {
+		closing = ']'
+	}
+	end := strings.IndexByte(text[1:], closing)
+	if end < 0 {
+		return text[1:]
+	}
+	name := text[1 : end+1]
+	// Keep schema-qualified names such as "app"."users" together.
+	rest := text[end+2:]
+	if strings.HasPrefix(rest, ".") && len(rest) > 1 {
+		next := rest[1:]
+		if next[0] == '"' || next[0] == '`' || next[0] == '[' {
+			return name + "." + sqlQuotedWord(next)
+		}
+		return name + "." + readIdentifierPrefix(next, false)
+	}
+	return name
+}
+
+// sqlDollarTag returns the PostgreSQL dollar-quote tag ($$ or $body$) at the
+// start of text, or "".
+func sqlDollarTag(text string) string {
+	for i := 1; i < len(text); i++ {
+		if text[i] == '$' {
+			return text[:i+1]
+		}
+		if !isASCIIIdentByte(text[i]) || (i == 1 && text[i] >= '0' && text[i] <= '9') {
+			return ""
+		}
+	}
+	return ""
+}
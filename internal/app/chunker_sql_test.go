@@ -0,0 +1,95 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestChunkFileSQLStatements(t *testing.T) {
+	content := []byte(`-- 0001_init.sql
+-- Creates the core schema.
+
+CREATE TABLE IF NOT EXISTS public.users (
+    id serial PRIMARY KEY,
+    email text NOT NULL DEFAULT 'a;b'
+);
+
+CREATE UNIQUE INDEX CONCURRENTLY users_email_idx ON public.users (email);
+
+/* Keeps updated_at current; runs on every write. */
+CREATE OR REPLACE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at = now();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_touch AFTER UPDATE ON users
+BEGIN
+    UPDATE users SET updated_at = 1 WHERE id = NEW.id;
+END;
+
+CREATE VIEW "active users" AS SELECT * FROM users WHERE active;
+
+ALTER TABLE users ADD COLUMN active boolean;
+INSERT INTO users (email) VALUES ('x'); INSERT INTO users (email) VALUES ('y');
+`)
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("0001_init.sql", content, 400, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+
+	want := []struct {
+		name, kind string
+		start, end int
+	}{
+		{"public.users", "table", 1, 7},
+		{"users_email_idx", "index", 9, 9},
+		{"touch", "function", 11, 17},
+		{"users_touch", "trigger", 19, 22},
+		{"active users", "view", 24, 24},
+		{"users", "table", 26, 26},
+		{"", "", 27, 27},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("expected %d chunks, got %d: %#v", len(want), len(chunks), chunks)
+	}
+	for i, w := range want {
+		chunk := chunks[i]
+		if chunk.SymbolName != w.name || chunk.SymbolKind != w.kind || chunk.StartLine != w.start || chunk.EndLine != w.end {
+			t.Fatalf("chunk %d: got %q/%s %d-%d, want %q/%s %d-%d", i, chunk.SymbolName, chunk.SymbolKind, chunk.StartLine, chunk.EndLine, w.name, w.kind, w.start, w.end)
+		}
+		if chunk.ChunkType != "statement" {
+			t.Fatalf("chunk %d: unexpected type %s", i, chunk.ChunkType)
+		}
+	}
+}
+
+func TestChunkFileSQLPacksAnonymousStatements(t *testing.T) {
+	var body strings.Builder
+	body.WriteString("CREATE TABLE items (id int)\nGO\n")
+	for i := 0; i < 30; i++ {
+		body.WriteString(fmt.Sprintf("INSERT INTO items (id) VALUES (%d);\n", i))
+	}
+	counter := testTokenCounter(t)
+	chunks, err := chunkFile("seed.sql", []byte(body.String()), 100, 0, counter)
+	if err != nil {
+		t.Fatalf("chunk file: %v", err)
+	}
+	if chunks[0].SymbolName != "items" || chunks[0].EndLine != 2 {
+		t.Fatalf("expected items table ending at GO, got %#v", chunks[0])
+	}
+	inserts := chunks[1:]
+	if len(inserts) < 2 || len(inserts) >= 30 {
+		t.Fatalf("expected inserts packed into a few chunks, got %d", len(inserts))
+	}
+	next := 3
+	for _, chunk := range inserts {
+		if chunk.StartLine != next {
+			t.Fatalf("expected inserts in file order from line %d, got %d", next, chunk.StartLine)
+		}
+		next = chunk.EndLine + 1
+	}
+}
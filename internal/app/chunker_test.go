@@ -15,6 +15,16 @@ func testTokenCounter(t *testing.T) *memtoken.Counter {
 	return counter
 }
 
+func chunksBySymbolName(chunks []SemanticChunk) map[string]SemanticChunk {
+	byName := map[string]SemanticChunk{}
+	for _, chunk := range chunks {
+		if _, ok := byName[chunk.SymbolName]; !ok {
+			byName[chunk.SymbolName] = chunk
+		}
+	}
+	return byName
+}
+
 func TestChunkFilePythonSemantic(t *testing.T) {
 	content := []byte(`@trace
 def top(value):
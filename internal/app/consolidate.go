@@ -0,0 +1,362 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"mem/internal/config"
+	"mem/internal/embed"
+	"mem/internal/pack"
+	"mem/internal/store"
+	"mem/internal/token"
+)
+
+const (
+	summarySourceExtractive = "extractive"
+	summarySourceLLM        = "llm"
+)
+
+// errConsolidateEmbed marks a merged memory that was saved but could not be
+// queued for embedding.
+var errConsolidateEmbed = errors.New("embedding enqueue failed")
+
+const consolidatePrompt = `Merge the following related project memories into one concise summary.
+Keep every distinct fact, decision, and identifier. Drop repetition.
+Reply with the summary text only.
+
+%s
+Summary:`
+
+type ConsolidatedMemory struct {
+	ID            string   `json:"id,omitempty"`
+	ThreadID      string   `json:"thread_id,omitempty"`
+	Title         string   `json:"title"`
+	Summary       string   `json:"summary"`
+	Tags          []string `json:"tags,omitempty"`
+	Entities      []string `json:"entities,omitempty"`
+	SourceIDs     []string `json:"source_ids"`
+	Similarity    float64  `json:"similarity"`
+	SummarySource string   `json:"summary_source"`
+	LinksCarried  int      `json:"links_carried,omitempty"`
+}
+
+type ConsolidateReport struct {
+	RepoID         string               `json:"repo_id"`
+	Workspace      string               `json:"workspace"`
+	ThreadID       string               `json:"thread_id,omitempty"`
+	DryRun         bool                 `json:"dry_run"`
+	Scanned        int                  `json:"scanned"`
+	EmbeddingModel string               `json:"embedding_model"`
+	Consolidated   []ConsolidatedMemory `json:"consolidated"`
+	Warnings       []string             `json:"warnings,omitempty"`
+}
+
+func runConsolidate(args []string, out, errOut io.Writer) int {
+	fs := flag.NewFlagSet("consolidate", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	repoOverride := fs.String("repo", "", "Override repo id")
+	workspace := fs.String("workspace", "", "Workspace name")
+	threadID := fs.String("thread", "", "Only consolidate memories in this thread")
+	dryRun := fs.Bool("dry-run", false, "Show proposed merges without writing")
+	format := fs.String("format", "json", "Output format: json")
+	positional, flagArgs, err := splitFlagArgs(args, map[string]flagSpec{
+		"repo":      {RequiresValue: true},
+		"workspace": {RequiresValue: true},
+		"thread":    {RequiresValue: true},
+		"dry-run":   {RequiresValue: false},
+		"format":    {RequiresValue: true},
+	})
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 2
+	}
+	if err := fs.Parse(flagArgs); err != nil {
+		return 2
+	}
+	if len(positional) > 0 {
+		fmt.Fprintf(errOut, "unexpected args: %s\n", strings.Join(positional, " "))
+		return 2
+	}
+	if strings.TrimSpace(*format) != "json" {
+		fmt.Fprintf(errOut, "unsupported format: %s\n", *format)
+		return 2
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(errOut, "config error: %v\n", err)
+		return 1
+	}
+	workspaceName := resolveWorkspace(cfg, strings.TrimSpace(*workspace))
+
+	counter, err := token.New(cfg.Tokenizer)
+	if err != nil {
+		fmt.Fprintf(errOut, "tokenizer error: %v\n", err)
+		return 1
+	}
+
+	repoInfo, err := resolveRepo(&cfg, strings.TrimSpace(*repoOverride))
+	if err != nil {
+		fmt.Fprintf(errOut, "repo detection error: %v\n", err)
+		return 1
+	}
+
+	st, err := openStore(cfg, repoInfo.ID)
+	if err != nil {
+		fmt.Fprintf(errOut, "store open error: %v\n", err)
+		return 1
+	}
+	defer st.Close()
+
+	vectors, model, err := loadMemoryVectors(cfg, st, repoInfo.ID, workspaceName)
+	if err != nil {
+		fmt.Fprintf(errOut, "embedding lookup error: %v\n", err)
+		return 1
+	}
+	if model == "" {
+		fmt.Fprintln(errOut, "consolidate needs memory embeddings; set embedding_provider and run mem embed")
+		return 1
+	}
+
+	memories, err := st.ListActiveMemories(repoInfo.ID, workspaceName)
+	if err != nil {
+		fmt.Fprintf(errOut, "memory list error: %v\n", err)
+		return 1
+	}
+	thread := strings.TrimSpace(*threadID)
+	filtered := memories[:0]
+	for _, mem := range memories {
+		if thread != "" && mem.ThreadID != thread {
+			continue
+		}
+		if isDigestMemory(mem) {
+			continue
+		}
+		filtered = append(filtered, mem)
+	}
+	memories = filtered
+
+	report := ConsolidateReport{
+		RepoID:         repoInfo.ID,
+		Workspace:      workspaceName,
+		ThreadID:       thread,
+		DryRun:         *dryRun,
+		Scanned:        len(memories),
+		EmbeddingModel: model,
+		Consolidated:   []ConsolidatedMemory{},
+	}
+
+	generator, genStatus := embed.ResolveGenerator(cfg)
+	if genStatus.Error != "" {
+		report.Warnings = append(report.Warnings, "generation_unavailable: "+genStatus.Error)
+	}
+
+	anchorCommit := ""
+	if repoInfo.HasGit {
+		anchorCommit = repoInfo.Head
+	}
+	for _, cluster := range consolidationClusters(memories, vectors) {
+		plan := planConsolidation(cluster.members, cluster.similarity)
+		if generator != nil {
+			summary, err := generateConsolidatedSummary(cfg, generator, cluster.members)
+			if err != nil {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("generation_failed: %s: %v", plan.SourceIDs[0], err))
+			} else {
+				plan.Summary = summary
+				plan.SummarySource = summarySourceLLM
+			}
+		}
+		if !*dryRun {
+			plan, err = applyConsolidation(cfg, st, counter, repoInfo.ID, workspaceName, anchorCommit, plan, time.Now().UTC())
+			if errors.Is(err, errConsolidateEmbed) {
+				fmt.Fprintf(errOut, "embedding warning: %v\n", err)
+			} else if err != nil {
+				fmt.Fprintf(errOut, "consolidate error: %v\n", err)
+				return 1
+			}
+		}
+		report.Consolidated = append(report.Consolidated, plan)
+	}
+	return writeJSON(out, errOut, report)
+}
+
+type consolidationCluster struct {
+	members    []store.Memory
+	similarity float64
+}
+
+// consolidationClusters runs ClusterMemories separately for each thread so a
+// merged memory always has a single home. Members are ordered newest first.
+func consolidationClusters(memories []store.Memory, vectors map[string][]float64) []consolidationCluster {
+	byThread := make(map[string][]store.Memory)
+	threads := make([]string, 0)
+	for _, mem := range memories {
+		if _, ok := byThread[mem.ThreadID]; !ok {
+			threads = append(threads, mem.ThreadID)
+		}
+		byThread[mem.ThreadID] = append(byThread[mem.ThreadID], mem)
+	}
+	sort.Strings(threads)
+
+	var clusters []consolidationCluster
+	for _, thread := range threads {
+		group := byThread[thread]
+		sort.SliceStable(group, func(i, j int) bool {
+			return newerMemory(group[i], group[j])
+		})
+		byID := make(map[string]store.Memory, len(group))
+		items := make([]pack.MemoryItem, 0, len(group))
+		for _, mem := range group {
+			byID[mem.ID] = mem
+			items = append(items, pack.MemoryItem{ID: mem.ID, ThreadID: mem.ThreadID, Title: mem.Title, Summary: mem.Summary})
+		}
+		found, _ := ClusterMemories(items, vectors)
+		for _, cluster := range found {
+			members := make([]store.Memory, 0, len(cluster.Members))
+			for _, item := range cluster.Members {
+				members = append(members, byID[item.ID])
+			}
+			clusters = append(clusters, consolidationCluster{members: members, similarity: cluster.Similarity})
+		}
+	}
+	return clusters
+}
+
+// planConsolidation merges a cluster into one proposed memory. The newest
+// member supplies the title; tags and entities are unioned and the summary is
+// built extractively from the members' sentences.
+func planConsolidation(members []store.Memory, similarity float64) ConsolidatedMemory {
+	plan := ConsolidatedMemory{
+		ThreadID:      members[0].ThreadID,
+		Title:         members[0].Title,
+		Summary:       extractiveSummary(members),
+		Similarity:    roundMetric(similarity),
+		SummarySource: summarySourceExtractive,
+	}
+	var tags, entities []string
+	for _, mem := range members {
+		plan.SourceIDs = append(plan.SourceIDs, mem.ID)
+		tags = append(tags, parseTagsJSON(mem.TagsJSON)...)
+		entities = append(entities, parseEntitiesJSON(mem.EntitiesJSON)...)
+	}
+	plan.Tags = store.NormalizeTags(tags)
+	plan.Entities = store.NormalizeEntities(entities)
+	return plan
+}
+
+// extractiveSummary keeps each member's sentences in newest-first order and
+// drops sentences that repeat one already kept.
+func extractiveSummary(members []store.Memory) string {
+	var kept []string
+	var keptTokens []map[string]struct{}
+	for _, mem := range members {
+		for _, sentence := range splitSentences(mem.Summary) {
+			tokens := tokenSet(sentence)
+			redundant := false
+			for _, seen := range keptTokens {
+				if jaccard(tokens, seen) >= mmrRedundantOverlap {
+					redundant = true
+					break
+				}
+			}
+			if redundant {
+				continue
+			}
+			kept = append(kept, sentence)
+			keptTokens = append(keptTokens, tokens)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	runes := []rune(text)
+	for i, r := range runes {
+		end := r == '\n' || ((r == '.' || r == '!' || r == '?') && (i+1 == len(runes) || runes[i+1] == ' ' || runes[i+1] == '\n'))
+		if !end {
+			continue
+		}
+		if sentence := strings.TrimSpace(string(runes[start : i+1])); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = i + 1
+	}
+	if sentence := strings.TrimSpace(string(runes[start:])); sentence != "" {
+		sentences = append(sentences, sentence)
+	}
+	return sentences
+}
+
+func generateConsolidatedSummary(cfg config.Config, generator embed.Generator, members []store.Memory) (string, error) {
+	var b strings.Builder
+	for _, mem := range members {
+		fmt.Fprintf(&b, "- %s: %s\n", mem.Title, strings.TrimSpace(mem.Summary))
+	}
+	return generateText(cfg, generator, fmt.Sprintf(consolidatePrompt, b.String()))
+}
+
+// applyConsolidation saves the merged memory, moves the members' links onto
+// it, and marks every member superseded by it, all in one transaction.
+func applyConsolidation(cfg config.Config, st *store.Store, counter TokenCounter, repoID, workspace, anchorCommit string, plan ConsolidatedMemory, now time.Time) (ConsolidatedMemory, error) {
+	members := make(map[string]struct{}, len(plan.SourceIDs))
+	for _, id := range plan.SourceIDs {
+		members[id] = struct{}{}
+	}
+	links, err := st.ListLinksForIDs(plan.SourceIDs)
+	if err != nil {
+		return plan, err
+	}
+
+	if newest, err := st.GetMemory(repoID, workspace, plan.SourceIDs[0]); err == nil && strings.TrimSpace(newest.AnchorCommit) != "" {
+		anchorCommit = newest.AnchorCommit
+	}
+	mergedID := store.NewID("M")
+	var carried []store.Link
+	for _, link := range links {
+		_, fromMember := members[link.FromID]
+		_, toMember := members[link.ToID]
+		if fromMember && toMember {
+			continue
+		}
+		if fromMember {
+			link.FromID = mergedID
+		}
+		if toMember {
+			link.ToID = mergedID
+		}
+		link.CreatedAt = now
+		carried = append(carried, link)
+	}
+	mem, linksCarried, err := st.ConsolidateMemories(store.AddMemoryInput{
+		ID:            mergedID,
+		RepoID:        repoID,
+		Workspace:     workspace,
+		ThreadID:      plan.ThreadID,
+		Title:         plan.Title,
+		Summary:       plan.Summary,
+		SummaryTokens: counter.Count(plan.Summary),
+		TagsJSON:      store.TagsToJSON(plan.Tags),
+		TagsText:      store.TagsText(plan.Tags),
+		EntitiesJSON:  store.EntitiesToJSON(plan.Entities),
+		EntitiesText:  store.EntitiesText(plan.Entities),
+		AnchorCommit:  anchorCommit,
+		CreatedAt:     now,
+	}, plan.SourceIDs, carried)
+	if err != nil {
+		return plan, err
+	}
+	plan.ID = mem.ID
+	plan.LinksCarried = linksCarried
+
+	if err := maybeEmbedMemory(cfg, st, mem); err != nil {
+		return plan, fmt.Errorf("%w: %v", errConsolidateEmbed, err)
+	}
+	return plan, nil
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"mem/internal/config"
+	"mem/internal/store"
+)
+
+func TestExtractiveSummaryDropsRepeatedSentences(t *testing.T) {
+	members := []store.Memory{
+		{ID: "M-2", Summary: "Webhooks retry three times. Backoff doubles each attempt."},
+		{ID: "M-1", Summary: "Webhooks retry three times! Failures go to the dead letter queue."},
+	}
+	got := extractiveSummary(members)
+	want := "Webhooks retry three times. Backoff doubles each attempt. Failures go to the dead letter queue."
+	if got != want {
+		t.Fatalf("extractiveSummary = %q, want %q", got, want)
+	}
+}
+
+func TestConsolidateMergesClusterAndCarriesLinks(t *testing.T) {
+	st := openDedupeTestStore(t)
+	cfg := config.Config{EmbeddingProvider: "none"}
+
+	base := time.Now().UTC().Add(-time.Hour)
+	add := func(title, summary, tags string, offset time.Duration) store.Memory {
+		input := dedupeTestInput(title, summary)
+		input.TagsJSON = tags
+		input.CreatedAt = base.Add(offset)
+		mem, err := st.AddMemory(input)
+		if err != nil {
+			t.Fatalf("add %s: %v", title, err)
+		}
+		return mem
+	}
+	older := add("Webhook retries", "Webhooks retry three times.", `["webhooks"]`, 0)
+	newer := add("Webhook retry policy", "Webhooks retry three times. Backoff doubles.", `["retry"]`, time.Minute)
+	other := add("Billing", "Invoices are generated monthly.", `["billing"]`, 2*time.Minute)
+	if err := st.AddLink(store.Link{FromID: older.ID, Rel: "depends_on", ToID: other.ID, Weight: 1}); err != nil {
+		t.Fatalf("add link: %v", err)
+	}
+
+	memories, err := st.ListActiveMemories("r1", "default")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	vectors := map[string][]float64{
+		older.ID: {1, 0},
+		newer.ID: {0.98, 0.1},
+		other.ID: {0, 1},
+	}
+	clusters := consolidationClusters(memories, vectors)
+	if len(clusters) != 1 || len(clusters[0].members) != 2 || clusters[0].members[0].ID != newer.ID {
+		t.Fatalf("expected one cluster led by newest memory, got %+v", clusters)
+	}
+
+	plan := planConsolidation(clusters[0].members, clusters[0].similarity)
+	if plan.Title != newer.Title || plan.Summary != "Webhooks retry three times. Backoff doubles." {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+	if !reflect.DeepEqual(plan.Tags, []string{"retry", "webhooks"}) {
+		t.Fatalf("expected tag union, got %v", plan.Tags)
+	}
+
+	plan, err = applyConsolidation(cfg, st, fakeCounter{}, "r1", "default", "", plan, time.Now().UTC())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if plan.ID == "" || plan.LinksCarried != 1 {
+		t.Fatalf("expected merged memory with one carried link, got %+v", plan)
+	}
+	for _, id := range []string{older.ID, newer.ID} {
+		mem, err := st.GetMemory("r1", "default", id)
+		if err != nil || mem.SupersededBy != plan.ID {
+			t.Fatalf("expected %s superseded by %s, got %+v, %v", id, plan.ID, mem, err)
+		}
+	}
+	supersedes, err := st.ListSupersededMemoryIDs("r1", "default", plan.ID)
+	if err != nil || !reflect.DeepEqual(supersedes, []string{older.ID, newer.ID}) {
+		t.Fatalf("unexpected supersedes chain: %v, %v", supersedes, err)
+	}
+	links, err := st.ListLinksForIDs([]string{plan.ID})
+	if err != nil || len(links) != 1 || links[0].FromID != plan.ID || links[0].ToID != other.ID {
+		t.Fatalf("expected depends_on link moved to merged memory, got %+v, %v", links, err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"mem/internal/config"
 	"mem/internal/pack"
 	"mem/internal/store"
 	"mem/internal/token"
@@ -20,6 +21,87 @@ type ContextOptions struct {
 	IncludeRawChunks bool
 	ClusterMemories  bool
 	RequireRepo      bool
+	MMR              bool
+	MMRLambda        float64
+	RelatedChunks    bool
+	Tuning           *RankTuning
+	// RecordAccess queues hit counts for included items. Only real retrieval
+	// (get, mem_get_context) sets it so explain and eval stay read-only.
+	RecordAccess bool
+}
+
+// RankTuning overrides retrieval knobs for a single request, mainly so
+// `mem eval` can compare variants. Zero values keep the configured behaviour.
+type RankTuning struct {
+	Name                   string   `toml:"name" json:"name,omitempty"`
+	RRFK                   int      `toml:"rrf_k" json:"rrf_k,omitempty"`
+	RRFWeight              float64  `toml:"rrf_weight" json:"rrf_weight,omitempty"`
+	RecencyMultiplier      float64  `toml:"recency_multiplier" json:"recency_multiplier,omitempty"`
+	EmbeddingMinSimilarity *float64 `toml:"embedding_min_similarity" json:"embedding_min_similarity,omitempty"`
+	TokenBudget            int      `toml:"token_budget" json:"token_budget,omitempty"`
+	MemoriesK              int      `toml:"memories_k" json:"memories_k,omitempty"`
+	ChunksK                int      `toml:"chunks_k" json:"chunks_k,omitempty"`
+	ContextSelection       string   `toml:"context_selection" json:"context_selection,omitempty"`
+	MMRLambda              float64  `toml:"mmr_lambda" json:"mmr_lambda,omitempty"`
+	MMRRedundantCosine     float64  `toml:"mmr_redundant_cosine" json:"mmr_redundant_cosine,omitempty"`
+	MMRRedundantOverlap    float64  `toml:"mmr_redundant_overlap" json:"mmr_redundant_overlap,omitempty"`
+	RerankProvider         string   `toml:"rerank_provider" json:"rerank_provider,omitempty"`
+	PopularityWeight       *float64 `toml:"popularity_weight" json:"popularity_weight,omitempty"`
+}
+
+func (t *RankTuning) applyConfig(cfg *config.Config) {
+	if t == nil || cfg == nil {
+		return
+	}
+	if t.EmbeddingMinSimilarity != nil {
+		cfg.EmbeddingMinSimilarity = *t.EmbeddingMinSimilarity
+	}
+	if t.TokenBudget > 0 {
+		cfg.TokenBudget = t.TokenBudget
+	}
+	if t.MemoriesK > 0 {
+		cfg.MemoriesK = t.MemoriesK
+	}
+	if t.ChunksK > 0 {
+		cfg.ChunksK = t.ChunksK
+	}
+	if strings.TrimSpace(t.ContextSelection) != "" {
+		cfg.ContextSelection = t.ContextSelection
+	}
+	if t.MMRLambda > 0 {
+		cfg.MMRLambda = t.MMRLambda
+	}
+	if t.MMRRedundantCosine > 0 {
+		cfg.MMRRedundantCosine = t.MMRRedundantCosine
+	}
+	if t.MMRRedundantOverlap > 0 {
+		cfg.MMRRedundantOverlap = t.MMRRedundantOverlap
+	}
+	if strings.TrimSpace(t.RerankProvider) != "" {
+		cfg.RerankProvider = t.RerankProvider
+	}
+	if t.PopularityWeight != nil {
+		cfg.PopularityWeight = *t.PopularityWeight
+	}
+}
+
+func (t *RankTuning) applyRank(opts *RankOptions) {
+	if t == nil || opts == nil {
+		return
+	}
+	if t.RRFK > 0 {
+		opts.RRFK = t.RRFK
+	}
+	if t.RRFWeight > 0 {
+		opts.RRFWeight = t.RRFWeight
+	}
+	if t.RecencyMultiplier > 0 {
+		base := opts.RecencyMultiplier
+		if base <= 0 {
+			base = 1.0
+		}
+		opts.RecencyMultiplier = base * t.RecencyMultiplier
+	}
 }
 
 type retrievalTrace struct {
@@ -28,6 +110,8 @@ type retrievalTrace struct {
 	RankedChunks      []RankedChunk
 	VectorMemStatus   VectorSearchStatus
 	VectorChunkStatus VectorSearchStatus
+	RerankStatus      RerankStatus
+	BudgetOptions     BudgetOptions
 	Budget            BudgetResult
 	StateSource       string
 }
@@ -57,6 +141,7 @@ func buildContextPackWithTrace(query string, opts ContextOptions, timings *getTi
 		return pack.ContextPack{}, fmt.Errorf("repo detection error: %v", err)
 	}
 	t.RepoDetect = time.Since(repoStart)
+	opts.Tuning.applyConfig(&cfg)
 
 	storeStart := time.Now()
 	st, releaseStore, err := openStoreForRequest(cfg, repoInfo.ID)
@@ -96,6 +181,10 @@ func buildContextPackWithTrace(query string, opts ContextOptions, timings *getTi
 	if err != nil {
 		return pack.ContextPack{}, fmt.Errorf("chunk search error: %v", err)
 	}
+	commitChunks, err := fileIntentCommitChunks(st, repoInfo.ID, workspace, parsed)
+	if err != nil {
+		return pack.ContextPack{}, fmt.Errorf("commit lookup error: %v", err)
+	}
 	t.FTSChunksCandidate = chunkStats.CandidateTime
 	t.FTSChunksFetch = chunkStats.FetchTime
 	t.ChunkCandidates = chunkStats.CandidateCount
@@ -118,14 +207,26 @@ func buildContextPackWithTrace(query string, opts ContextOptions, timings *getTi
 		return pack.ContextPack{}, fmt.Errorf("vector memory load error: %v", err)
 	}
 
+	memCandidateIDs := memoryCandidateIDs(memResults, vectorMemOnly)
+	memFeedback, err := loadFeedbackBonuses(st, repoInfo.ID, workspace, query, memCandidateIDs)
+	if err != nil {
+		return pack.ContextPack{}, fmt.Errorf("feedback lookup error: %v", err)
+	}
+	memPopularity, err := loadPopularityBonuses(st, repoInfo.ID, workspace, memCandidateIDs, cfg.PopularityWeight)
+	if err != nil {
+		return pack.ContextPack{}, fmt.Errorf("access stats lookup error: %v", err)
+	}
 	rankOpts := RankOptions{
 		IncludeOrphans:    opts.IncludeOrphans,
 		VectorResults:     vectorMemResults,
 		RecencyMultiplier: parsed.BoostRecency,
+		FeedbackBonuses:   memFeedback,
+		PopularityBonuses: memPopularity,
 	}
 	if parsed.TimeHint != nil {
 		rankOpts.TimeFilter = &parsed.TimeHint.After
 	}
+	opts.Tuning.applyRank(&rankOpts)
 	rankedMemories, matchedThreads, matchedThreadIDs, rankStats, err := rankMemories(query, memResults, vectorMemOnly, repoInfo, rankOpts)
 	if err != nil {
 		return pack.ContextPack{}, fmt.Errorf("ranking error: %v", err)
@@ -140,18 +241,51 @@ func buildContextPackWithTrace(query string, opts ContextOptions, timings *getTi
 	if err != nil {
 		return pack.ContextPack{}, fmt.Errorf("vector chunk load error: %v", err)
 	}
+	chunkIDs := chunkCandidateIDs(chunkResults, append(commitChunks, vectorChunkOnly...))
+	chunkFeedback, err := loadFeedbackBonuses(st, repoInfo.ID, workspace, query, chunkIDs)
+	if err != nil {
+		return pack.ContextPack{}, fmt.Errorf("feedback lookup error: %v", err)
+	}
+	chunkPopularity, err := loadPopularityBonuses(st, repoInfo.ID, workspace, chunkIDs, cfg.PopularityWeight)
+	if err != nil {
+		return pack.ContextPack{}, fmt.Errorf("access stats lookup error: %v", err)
+	}
 	chunkRankOpts := RankOptions{
 		VectorResults:     vectorChunkResults,
+		CommitResults:     commitChunks,
 		RecencyMultiplier: parsed.BoostRecency,
+		FeedbackBonuses:   chunkFeedback,
+		PopularityBonuses: chunkPopularity,
 	}
 	if parsed.TimeHint != nil {
 		chunkRankOpts.TimeFilter = &parsed.TimeHint.After
 	}
+	opts.Tuning.applyRank(&chunkRankOpts)
 	rankedChunks := rankChunks(chunkResults, vectorChunkOnly, vectorChunkResults, matchedThreadIDs, chunkRankOpts)
 
+	rerankStart := time.Now()
+	rerankStatus := rerankCandidates(cfg, query, rankedMemories, rankedChunks)
+	t.Rerank = time.Since(rerankStart)
+
+	budgetOpts := BudgetOptions{
+		Selection:        normalizeSelection(cfg.ContextSelection),
+		MMRLambda:        cfg.MMRLambda,
+		RedundantCosine:  cfg.MMRRedundantCosine,
+		RedundantOverlap: cfg.MMRRedundantOverlap,
+	}
+	if opts.MMR {
+		budgetOpts.Selection = selectionMMR
+	}
+	if opts.MMRLambda > 0 {
+		budgetOpts.MMRLambda = opts.MMRLambda
+	}
+	if budgetOpts.Selection == selectionMMR {
+		budgetOpts.MemoryEmbeddings, budgetOpts.ChunkEmbeddings = loadSelectionEmbeddings(cfg, st, repoInfo.ID, workspace, rankedMemories, rankedChunks)
+	}
+
 	var counter TokenCounter
 	budgetStart := time.Now()
-	budget, err := applyBudget(cfg, counter, stateRaw, stateTokens, rankedMemories, rankedChunks)
+	budget, err := applyBudgetWithOptions(cfg, counter, stateRaw, stateTokens, rankedMemories, rankedChunks, budgetOpts)
 	t.Budget = time.Since(budgetStart)
 	if errors.Is(err, ErrTokenizerRequired) {
 		tokenizerStart := time.Now()
@@ -175,13 +309,22 @@ func buildContextPackWithTrace(query string, opts ContextOptions, timings *getTi
 		}
 
 		budgetStart = time.Now()
-		budget, err = applyBudget(cfg, counter, stateRaw, stateTokens, rankedMemories, rankedChunks)
+		budget, err = applyBudgetWithOptions(cfg, counter, stateRaw, stateTokens, rankedMemories, rankedChunks, budgetOpts)
 		t.Budget += time.Since(budgetStart)
 	}
 	if err != nil {
 		return pack.ContextPack{}, fmt.Errorf("budget error: %v", err)
 	}
 
+	if opts.RecordAccess && cfg.AccessTracking {
+		queuePackAccess(cfg, repoInfo.ID, workspace, budget, time.Now().UTC())
+	}
+
+	related, err := relateChunks(cfg, st, repoInfo.ID, workspace, counter, &budget, opts.RelatedChunks)
+	if err != nil {
+		return pack.ContextPack{}, fmt.Errorf("related chunk lookup error: %v", err)
+	}
+
 	linkTrail := []pack.LinkTrail{}
 	if len(budget.Memories) > 0 {
 		memIDs := make([]string, 0, len(budget.Memories))
@@ -223,6 +366,8 @@ func buildContextPackWithTrace(query string, opts ContextOptions, timings *getTi
 		}
 	}
 
+	linkTrail = append(linkTrail, related.LinkTrail...)
+
 	topMemories := budget.Memories
 	clusterWarnings := []string{}
 	clustersFormed := 0
@@ -242,11 +387,16 @@ func buildContextPackWithTrace(query string, opts ContextOptions, timings *getTi
 		}
 	}
 
-	rawChunks := budget.Chunks
-	dedupedChunks := dedupeChunksWithSources(rawChunks, rankedChunks)
-	if dedupedChunks == nil {
-		dedupedChunks = []pack.ChunkItem{}
+	rawChunks := append(append([]pack.ChunkItem(nil), budget.Chunks...), related.Chunks...)
+	dedupedChunks := append(dedupeChunksWithSources(budget.Chunks, rankedChunks), related.Chunks...)
+	storedTexts := make(map[string]string, len(rankedChunks)+len(related.Texts))
+	for _, chunk := range rankedChunks {
+		storedTexts[chunk.Chunk.ID] = chunk.Chunk.Text
 	}
+	for id, text := range related.Texts {
+		storedTexts[id] = text
+	}
+	annotateChunkFreshness(repoInfo.GitRoot, dedupedChunks, storedTexts)
 
 	searchMeta := buildSearchMeta(len(memResults)+len(chunkResults), len(vectorMemResults)+len(vectorChunkResults), memStats, chunkStats, vectorMemStatus, vectorChunkStatus)
 	searchMeta.Query = query
@@ -264,6 +414,9 @@ func buildContextPackWithTrace(query string, opts ContextOptions, timings *getTi
 	if len(stateWarnings) > 0 {
 		searchMeta.Warnings = uniqueStrings(append(searchMeta.Warnings, stateWarnings...))
 	}
+	if rerankStatus.FellBack {
+		searchMeta.Warnings = uniqueStrings(append(searchMeta.Warnings, "rerank_fallback_fused_order"))
+	}
 
 	if matchedThreads == nil {
 		matchedThreads = []pack.MatchedThread{}
@@ -304,6 +457,7 @@ func buildContextPackWithTrace(query string, opts ContextOptions, timings *getTi
 		},
 	}
 	if opts.IncludeRawChunks {
+		annotateChunkFreshness(repoInfo.GitRoot, rawChunks, storedTexts)
 		result.TopChunksRaw = rawChunks
 	}
 
@@ -316,6 +470,8 @@ func buildContextPackWithTrace(query string, opts ContextOptions, timings *getTi
 		trace.RankedChunks = rankedChunks
 		trace.VectorMemStatus = vectorMemStatus
 		trace.VectorChunkStatus = vectorChunkStatus
+		trace.RerankStatus = rerankStatus
+		trace.BudgetOptions = budgetOpts
 		trace.Budget = budget
 		trace.StateSource = stateSource
 	}
@@ -459,6 +615,34 @@ func loadMemoryEmbeddings(st *store.Store, repoID, workspace, model string, item
 	return st.ListMemoryEmbeddingsByIDs(repoID, workspace, model, ids)
 }
 
+// loadSelectionEmbeddings fetches stored vectors for every ranked candidate,
+// since MMR may pick from below the score cutoff. Lookup failures return
+// empty maps so MMR falls back to token overlap.
+func loadSelectionEmbeddings(cfg config.Config, st *store.Store, repoID, workspace string, memories []RankedMemory, chunks []RankedChunk) (map[string][]float64, map[string][]float64) {
+	model := effectiveEmbeddingModel(cfg)
+	provider := strings.TrimSpace(strings.ToLower(cfg.EmbeddingProvider))
+	if model == "" || provider == "" || provider == "none" {
+		return nil, nil
+	}
+	memIDs := make([]string, 0, len(memories))
+	for _, mem := range memories {
+		memIDs = append(memIDs, mem.Memory.ID)
+	}
+	chunkIDs := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		chunkIDs = append(chunkIDs, chunk.Chunk.ID)
+	}
+	memEmbeddings, err := st.ListMemoryEmbeddingsByIDs(repoID, workspace, model, memIDs)
+	if err != nil {
+		memEmbeddings = nil
+	}
+	chunkEmbeddings, err := st.ListChunkEmbeddingsByIDs(repoID, workspace, model, chunkIDs)
+	if err != nil {
+		chunkEmbeddings = nil
+	}
+	return memEmbeddings, chunkEmbeddings
+}
+
 func buildClusteredMemories(clusters []MemoryCluster, unclustered []pack.MemoryItem) []pack.MemoryItem {
 	total := len(clusters) + len(unclustered)
 	if total == 0 {
@@ -0,0 +1,468 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"mem/internal/config"
+	"mem/internal/store"
+)
+
+const (
+	onDuplicateWarn      = "warn"
+	onDuplicateReject    = "reject"
+	onDuplicateUpdate    = "update"
+	onDuplicateSupersede = "supersede"
+	onDuplicateLink      = "link"
+	onDuplicateForce     = "force"
+
+	// Near-duplicate thresholds match the ones MMR uses to drop redundant
+	// items from a pack.
+	dedupeTextThreshold      = mmrRedundantOverlap
+	dedupeEmbeddingThreshold = mmrRedundantCosine
+	dedupeCandidateLimit     = 5
+	dedupeSearchLimit        = 20
+
+	relatedLinkRel = "related_to"
+)
+
+type DuplicateCandidate struct {
+	ID                  string  `json:"id"`
+	ThreadID            string  `json:"thread_id,omitempty"`
+	Title               string  `json:"title"`
+	CreatedAt           string  `json:"created_at,omitempty"`
+	Similarity          float64 `json:"similarity"`
+	TextSimilarity      float64 `json:"text_similarity"`
+	EmbeddingSimilarity float64 `json:"embedding_similarity,omitempty"`
+}
+
+type dedupeOptions struct {
+	Mode        string
+	DuplicateOf string
+}
+
+type addMemoryOutcome struct {
+	Memory      store.Memory
+	Status      string
+	DuplicateOf string
+	Duplicates  []DuplicateCandidate
+}
+
+func normalizeOnDuplicate(raw string) (string, error) {
+	mode := strings.ToLower(strings.TrimSpace(raw))
+	switch mode {
+	case "":
+		return onDuplicateWarn, nil
+	case onDuplicateWarn, onDuplicateReject, onDuplicateUpdate, onDuplicateSupersede, onDuplicateLink, onDuplicateForce:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid on-duplicate mode: %s (use warn|reject|update|supersede|link|force)", raw)
+	}
+}
+
+// addMemoryWithDedupe checks input against existing memories before saving.
+// With the default mode warn the memory is saved and near-duplicates are
+// reported alongside it. With reject a near-duplicate leaves the store
+// untouched and is reported back; the other modes resolve against
+// DuplicateOf or, when unset, the closest candidate.
+func addMemoryWithDedupe(cfg config.Config, st *store.Store, input store.AddMemoryInput, opts dedupeOptions) (addMemoryOutcome, error) {
+	mode, err := normalizeOnDuplicate(opts.Mode)
+	if err != nil {
+		return addMemoryOutcome{}, err
+	}
+	target := strings.TrimSpace(opts.DuplicateOf)
+	if target != "" && (mode == onDuplicateWarn || mode == onDuplicateReject || mode == onDuplicateForce) {
+		return addMemoryOutcome{}, fmt.Errorf("duplicate-of requires on-duplicate update|supersede|link")
+	}
+
+	var candidates []DuplicateCandidate
+	if mode != onDuplicateForce && (cfg.DedupeOnAdd || target != "") {
+		candidates, err = findDuplicateMemories(cfg, st, input.RepoID, input.Workspace, input.Title, input.Summary)
+		if err != nil {
+			return addMemoryOutcome{}, fmt.Errorf("duplicate check error: %v", err)
+		}
+	}
+	if target == "" && len(candidates) > 0 {
+		target = candidates[0].ID
+	}
+	if target == "" || mode == onDuplicateWarn || mode == onDuplicateForce {
+		mem, err := st.AddMemory(input)
+		if err != nil {
+			return addMemoryOutcome{}, err
+		}
+		return addMemoryOutcome{Memory: mem, Status: "added", Duplicates: candidates}, nil
+	}
+	if mode == onDuplicateReject {
+		return addMemoryOutcome{Status: "duplicate", DuplicateOf: target, Duplicates: candidates}, nil
+	}
+
+	existing, err := ensureMemoryExistsForLink(st, input.RepoID, input.Workspace, target, "duplicate")
+	if err != nil {
+		return addMemoryOutcome{}, err
+	}
+
+	switch mode {
+	case onDuplicateUpdate:
+		title := input.Title
+		summary := input.Summary
+		summaryTokens := input.SummaryTokens
+		mem, _, err := st.UpdateMemoryWithStatus(store.UpdateMemoryInput{
+			RepoID:        input.RepoID,
+			Workspace:     input.Workspace,
+			ID:            existing.ID,
+			Title:         &title,
+			Summary:       &summary,
+			SummaryTokens: &summaryTokens,
+			TagsAdd:       parseTagsJSON(input.TagsJSON),
+			EntitiesAdd:   parseEntitiesJSON(input.EntitiesJSON),
+		})
+		if err != nil {
+			return addMemoryOutcome{}, err
+		}
+		return addMemoryOutcome{Memory: mem, Status: "updated", DuplicateOf: existing.ID, Duplicates: candidates}, nil
+	case onDuplicateSupersede:
+		mem, err := st.AddSupersedingMemory(input, existing.ID)
+		if err != nil {
+			return addMemoryOutcome{}, err
+		}
+		return addMemoryOutcome{Memory: mem, Status: "superseded", DuplicateOf: existing.ID, Duplicates: candidates}, nil
+	case onDuplicateLink:
+		mem, err := st.AddLinkedMemory(input, relatedLinkRel, existing.ID)
+		if err != nil {
+			return addMemoryOutcome{}, err
+		}
+		return addMemoryOutcome{Memory: mem, Status: "linked", DuplicateOf: existing.ID, Duplicates: candidates}, nil
+	}
+	return addMemoryOutcome{}, fmt.Errorf("unhandled on-duplicate mode: %s", mode)
+}
+
+// findDuplicateMemories scores FTS and vector neighbours of title+summary and
+// returns those over either near-duplicate threshold, closest first.
+func findDuplicateMemories(cfg config.Config, st *store.Store, repoID, workspace, title, summary string) ([]DuplicateCandidate, error) {
+	text := strings.TrimSpace(title + " " + summary)
+	if text == "" {
+		return nil, nil
+	}
+	results, err := st.SearchSimilarMemories(repoID, workspace, text, dedupeSearchLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make(map[string]*DuplicateCandidate, len(results))
+	memories := make(map[string]store.Memory, len(results))
+	for _, res := range results {
+		memories[res.Memory.ID] = res.Memory
+	}
+
+	vectorResults, _ := vectorSearchMemories(cfg, st, repoID, workspace, text, dedupeSearchLimit)
+	vectorScores := make(map[string]float64, len(vectorResults))
+	var vectorOnly []string
+	for _, res := range vectorResults {
+		if res.Score < dedupeEmbeddingThreshold {
+			continue
+		}
+		vectorScores[res.ID] = res.Score
+		if _, ok := memories[res.ID]; !ok {
+			vectorOnly = append(vectorOnly, res.ID)
+		}
+	}
+	if len(vectorOnly) > 0 {
+		extra, err := st.GetMemoriesByIDs(repoID, workspace, vectorOnly)
+		if err != nil {
+			return nil, err
+		}
+		for _, mem := range extra {
+			if strings.TrimSpace(mem.SupersededBy) != "" {
+				continue
+			}
+			memories[mem.ID] = mem
+		}
+	}
+
+	textTokens := tokenSet(text)
+	for id, mem := range memories {
+		textSim := jaccard(textTokens, tokenSet(mem.Title+" "+mem.Summary))
+		embedSim := vectorScores[id]
+		if textSim < dedupeTextThreshold && embedSim < dedupeEmbeddingThreshold {
+			continue
+		}
+		candidates[id] = &DuplicateCandidate{
+			ID:                  mem.ID,
+			ThreadID:            mem.ThreadID,
+			Title:               mem.Title,
+			CreatedAt:           formatTime(mem.CreatedAt),
+			Similarity:          roundMetric(maxFloat(textSim, embedSim)),
+			TextSimilarity:      roundMetric(textSim),
+			EmbeddingSimilarity: roundMetric(embedSim),
+		}
+	}
+
+	out := make([]DuplicateCandidate, 0, len(candidates))
+	for _, cand := range candidates {
+		out = append(out, *cand)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Similarity != out[j].Similarity {
+			return out[i].Similarity > out[j].Similarity
+		}
+		return out[i].ID < out[j].ID
+	})
+	if len(out) > dedupeCandidateLimit {
+		out = out[:dedupeCandidateLimit]
+	}
+	return out, nil
+}
+
+func duplicateResponse(title string, outcome addMemoryOutcome) map[string]any {
+	return map[string]any{
+		"status":       outcome.Status,
+		"title":        title,
+		"duplicate_of": outcome.DuplicateOf,
+		"duplicates":   outcome.Duplicates,
+	}
+}
+
+func addDuplicateFields(resp map[string]any, outcome addMemoryOutcome) {
+	if outcome.DuplicateOf != "" {
+		resp["duplicate_of"] = outcome.DuplicateOf
+	}
+	if len(outcome.Duplicates) > 0 {
+		resp["duplicates"] = outcome.Duplicates
+	}
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+type DedupeGroup struct {
+	Keep       DuplicateCandidate   `json:"keep"`
+	Duplicates []DuplicateCandidate `json:"duplicates"`
+}
+
+type DedupeReport struct {
+	RepoID             string        `json:"repo_id"`
+	Workspace          string        `json:"workspace"`
+	Scanned            int           `json:"scanned"`
+	TextThreshold      float64       `json:"text_threshold"`
+	EmbeddingThreshold float64       `json:"embedding_threshold"`
+	EmbeddingModel     string        `json:"embedding_model,omitempty"`
+	Groups             []DedupeGroup `json:"groups"`
+}
+
+func runDedupe(args []string, out, errOut io.Writer) int {
+	fs := flag.NewFlagSet("dedupe", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	repoOverride := fs.String("repo", "", "Override repo id")
+	workspace := fs.String("workspace", "", "Workspace name")
+	textThreshold := fs.Float64("text-threshold", dedupeTextThreshold, "Minimum token overlap (0-1) to treat memories as duplicates")
+	embeddingThreshold := fs.Float64("embedding-threshold", dedupeEmbeddingThreshold, "Minimum embedding cosine (0-1) to treat memories as duplicates")
+	limit := fs.Int("limit", 50, "Max groups to show")
+	format := fs.String("format", "json", "Output format: json")
+	positional, flagArgs, err := splitFlagArgs(args, map[string]flagSpec{
+		"repo":                {RequiresValue: true},
+		"workspace":           {RequiresValue: true},
+		"text-threshold":      {RequiresValue: true},
+		"embedding-threshold": {RequiresValue: true},
+		"limit":               {RequiresValue: true},
+		"format":              {RequiresValue: true},
+	})
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 2
+	}
+	if err := fs.Parse(flagArgs); err != nil {
+		return 2
+	}
+	if len(positional) > 0 {
+		fmt.Fprintf(errOut, "unexpected args: %s\n", strings.Join(positional, " "))
+		return 2
+	}
+	if strings.TrimSpace(*format) != "json" {
+		fmt.Fprintf(errOut, "unsupported format: %s\n", *format)
+		return 2
+	}
+	if *textThreshold <= 0 || *textThreshold > 1 || *embeddingThreshold <= 0 || *embeddingThreshold > 1 {
+		fmt.Fprintln(errOut, "thresholds must be between 0 and 1")
+		return 2
+	}
+	if *limit < 0 {
+		fmt.Fprintln(errOut, "limit must be >= 0")
+		return 2
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(errOut, "config error: %v\n", err)
+		return 1
+	}
+	workspaceName := resolveWorkspace(cfg, strings.TrimSpace(*workspace))
+
+	repoInfo, err := resolveRepo(&cfg, strings.TrimSpace(*repoOverride))
+	if err != nil {
+		fmt.Fprintf(errOut, "repo detection error: %v\n", err)
+		return 1
+	}
+
+	st, err := openStore(cfg, repoInfo.ID)
+	if err != nil {
+		fmt.Fprintf(errOut, "store open error: %v\n", err)
+		return 1
+	}
+	defer st.Close()
+
+	memories, err := st.ListActiveMemories(repoInfo.ID, workspaceName)
+	if err != nil {
+		fmt.Fprintf(errOut, "memory list error: %v\n", err)
+		return 1
+	}
+
+	vectors, model, err := loadMemoryVectors(cfg, st, repoInfo.ID, workspaceName)
+	if err != nil {
+		fmt.Fprintf(errOut, "embedding lookup warning: %v\n", err)
+		vectors, model = nil, ""
+	}
+
+	groups := groupDuplicateMemories(memories, vectors, *textThreshold, *embeddingThreshold)
+	if len(groups) > *limit {
+		groups = groups[:*limit]
+	}
+	return writeJSON(out, errOut, DedupeReport{
+		RepoID:             repoInfo.ID,
+		Workspace:          workspaceName,
+		Scanned:            len(memories),
+		TextThreshold:      *textThreshold,
+		EmbeddingThreshold: *embeddingThreshold,
+		EmbeddingModel:     model,
+		Groups:             groups,
+	})
+}
+
+// loadMemoryVectors returns every stored memory embedding for the configured
+// model, keyed by memory id. The model is empty when embeddings are disabled.
+func loadMemoryVectors(cfg config.Config, st *store.Store, repoID, workspace string) (map[string][]float64, string, error) {
+	provider := strings.ToLower(strings.TrimSpace(cfg.EmbeddingProvider))
+	if provider == "" || provider == "none" {
+		return nil, "", nil
+	}
+	model := effectiveEmbeddingModel(cfg)
+	if model == "" {
+		return nil, "", nil
+	}
+	embeddings, _, err := st.ListEmbeddingsForSearch(repoID, workspace, store.EmbeddingKindMemory, model)
+	if err != nil {
+		return nil, model, err
+	}
+	vectors := make(map[string][]float64, len(embeddings))
+	for _, emb := range embeddings {
+		vectors[emb.ItemID] = emb.Vector
+	}
+	return vectors, model, nil
+}
+
+// groupDuplicateMemories links every pair over either threshold and returns
+// the connected groups. The newest memory in a group is proposed as the one
+// to keep; the rest are reported with their similarity to it. Larger groups
+// come first.
+func groupDuplicateMemories(memories []store.Memory, vectors map[string][]float64, textThreshold, embeddingThreshold float64) []DedupeGroup {
+	n := len(memories)
+	tokens := make([]map[string]struct{}, n)
+	for i, mem := range memories {
+		tokens[i] = tokenSet(mem.Title + " " + mem.Summary)
+	}
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	similarity := func(i, j int) (float64, float64) {
+		textSim := jaccard(tokens[i], tokens[j])
+		embedSim := 0.0
+		a, b := vectors[memories[i].ID], vectors[memories[j].ID]
+		if len(a) > 0 && len(a) == len(b) {
+			embedSim = cosineSimilarityPair(a, b)
+		}
+		return textSim, embedSim
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			textSim, embedSim := similarity(i, j)
+			if textSim >= textThreshold || embedSim >= embeddingThreshold {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := 0; i < n; i++ {
+		root := find(i)
+		members[root] = append(members[root], i)
+	}
+	groups := make([]DedupeGroup, 0)
+	for _, idxs := range members {
+		if len(idxs) < 2 {
+			continue
+		}
+		keep := idxs[0]
+		for _, idx := range idxs[1:] {
+			if newerMemory(memories[idx], memories[keep]) {
+				keep = idx
+			}
+		}
+		group := DedupeGroup{Keep: duplicateCandidateFor(memories[keep], 1, 1, 0)}
+		for _, idx := range idxs {
+			if idx == keep {
+				continue
+			}
+			textSim, embedSim := similarity(keep, idx)
+			group.Duplicates = append(group.Duplicates, duplicateCandidateFor(memories[idx], maxFloat(textSim, embedSim), textSim, embedSim))
+		}
+		sort.SliceStable(group.Duplicates, func(i, j int) bool {
+			if group.Duplicates[i].Similarity != group.Duplicates[j].Similarity {
+				return group.Duplicates[i].Similarity > group.Duplicates[j].Similarity
+			}
+			return group.Duplicates[i].ID < group.Duplicates[j].ID
+		})
+		groups = append(groups, group)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if len(groups[i].Duplicates) != len(groups[j].Duplicates) {
+			return len(groups[i].Duplicates) > len(groups[j].Duplicates)
+		}
+		return groups[i].Keep.ID < groups[j].Keep.ID
+	})
+	return groups
+}
+
+func newerMemory(a, b store.Memory) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.ID > b.ID
+}
+
+func duplicateCandidateFor(mem store.Memory, similarity, textSim, embedSim float64) DuplicateCandidate {
+	return DuplicateCandidate{
+		ID:                  mem.ID,
+		ThreadID:            mem.ThreadID,
+		Title:               mem.Title,
+		CreatedAt:           mem.CreatedAt.UTC().Format(time.RFC3339Nano),
+		Similarity:          roundMetric(similarity),
+		TextSimilarity:      roundMetric(textSim),
+		EmbeddingSimilarity: roundMetric(embedSim),
+	}
+}
@@ -0,0 +1,140 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mem/internal/config"
+	"mem/internal/store"
+)
+
+func openDedupeTestStore(t *testing.T) *store.Store {
+	t.Helper()
+	st, err := store.Open(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+	return st
+}
+
+func dedupeTestInput(title, summary string) store.AddMemoryInput {
+	return store.AddMemoryInput{
+		RepoID:        "r1",
+		Workspace:     "default",
+		ThreadID:      "T-DUP",
+		Title:         title,
+		Summary:       summary,
+		SummaryTokens: 4,
+		TagsJSON:      `["auth"]`,
+		EntitiesJSON:  "[]",
+		CreatedAt:     time.Now().UTC(),
+	}
+}
+
+func TestAddMemoryWithDedupeModes(t *testing.T) {
+	st := openDedupeTestStore(t)
+	cfg := config.Config{DedupeOnAdd: true, EmbeddingProvider: "none"}
+
+	first, err := addMemoryWithDedupe(cfg, st, dedupeTestInput("Auth uses middleware", "Session tokens are validated in middleware"), dedupeOptions{})
+	if err != nil || first.Status != "added" {
+		t.Fatalf("first add: %+v, %v", first, err)
+	}
+
+	variant := dedupeTestInput("Auth uses middleware", "Session tokens are validated in the middleware")
+	rejected, err := addMemoryWithDedupe(cfg, st, variant, dedupeOptions{Mode: onDuplicateReject})
+	if err != nil {
+		t.Fatalf("reject add: %v", err)
+	}
+	if rejected.Status != "duplicate" || rejected.DuplicateOf != first.Memory.ID || len(rejected.Duplicates) != 1 {
+		t.Fatalf("expected duplicate of %s, got %+v", first.Memory.ID, rejected)
+	}
+	if rejected.Duplicates[0].TextSimilarity < dedupeTextThreshold {
+		t.Fatalf("unexpected similarity: %+v", rejected.Duplicates[0])
+	}
+	if count, _ := st.CountMemories("r1", "default"); count != 1 {
+		t.Fatalf("reject must not write, got %d memories", count)
+	}
+
+	updated, err := addMemoryWithDedupe(cfg, st, variant, dedupeOptions{Mode: onDuplicateUpdate})
+	if err != nil || updated.Status != "updated" || updated.Memory.ID != first.Memory.ID {
+		t.Fatalf("update: %+v, %v", updated, err)
+	}
+	if updated.Memory.Summary != variant.Summary {
+		t.Fatalf("expected summary to be replaced, got %q", updated.Memory.Summary)
+	}
+
+	superseded, err := addMemoryWithDedupe(cfg, st, variant, dedupeOptions{Mode: onDuplicateSupersede})
+	if err != nil || superseded.Status != "superseded" || superseded.DuplicateOf != first.Memory.ID {
+		t.Fatalf("supersede: %+v, %v", superseded, err)
+	}
+	old, err := st.GetMemory("r1", "default", first.Memory.ID)
+	if err != nil || old.SupersededBy != superseded.Memory.ID {
+		t.Fatalf("expected %s superseded by %s, got %+v, %v", first.Memory.ID, superseded.Memory.ID, old, err)
+	}
+
+	linked, err := addMemoryWithDedupe(cfg, st, variant, dedupeOptions{Mode: onDuplicateLink})
+	if err != nil || linked.Status != "linked" || linked.DuplicateOf != superseded.Memory.ID {
+		t.Fatalf("link: %+v, %v", linked, err)
+	}
+	links, err := st.ListLinksForIDs([]string{linked.Memory.ID})
+	if err != nil || len(links) != 1 || links[0].Rel != relatedLinkRel {
+		t.Fatalf("expected related_to link, got %+v, %v", links, err)
+	}
+
+	warned, err := addMemoryWithDedupe(cfg, st, variant, dedupeOptions{})
+	if err != nil || warned.Status != "added" || warned.Memory.ID == "" || len(warned.Duplicates) == 0 {
+		t.Fatalf("expected the default mode to save and report duplicates, got %+v, %v", warned, err)
+	}
+
+	forced, err := addMemoryWithDedupe(cfg, st, variant, dedupeOptions{Mode: onDuplicateForce})
+	if err != nil || forced.Status != "added" {
+		t.Fatalf("force: %+v, %v", forced, err)
+	}
+
+	if _, err := addMemoryWithDedupe(cfg, st, variant, dedupeOptions{DuplicateOf: first.Memory.ID}); err == nil {
+		t.Fatalf("expected duplicate-of without a resolving mode to fail")
+	}
+}
+
+func TestAddMemoryWithDedupeDisabled(t *testing.T) {
+	st := openDedupeTestStore(t)
+	cfg := config.Config{DedupeOnAdd: false, EmbeddingProvider: "none"}
+	input := dedupeTestInput("Cache TTL", "Cache entries expire after ten minutes")
+	for i := 0; i < 2; i++ {
+		outcome, err := addMemoryWithDedupe(cfg, st, input, dedupeOptions{})
+		if err != nil || outcome.Status != "added" {
+			t.Fatalf("add %d: %+v, %v", i, outcome, err)
+		}
+	}
+}
+
+func TestGroupDuplicateMemories(t *testing.T) {
+	base := time.Now().UTC()
+	memories := []store.Memory{
+		{ID: "M-1", Title: "Retry policy", Summary: "Retry webhooks three times with backoff", CreatedAt: base},
+		{ID: "M-2", Title: "Retry policy", Summary: "Retry webhooks three times with exponential backoff", CreatedAt: base.Add(time.Minute)},
+		{ID: "M-3", Title: "Billing", Summary: "Invoices are generated monthly", CreatedAt: base},
+		{ID: "M-4", Title: "Invoices", Summary: "Monthly billing run", CreatedAt: base.Add(time.Hour)},
+	}
+	vectors := map[string][]float64{
+		"M-3": {1, 0},
+		"M-4": {0.99, 0.05},
+	}
+
+	groups := groupDuplicateMemories(memories, vectors, dedupeTextThreshold, dedupeEmbeddingThreshold)
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %+v", groups)
+	}
+	byKeep := map[string]DedupeGroup{}
+	for _, group := range groups {
+		byKeep[group.Keep.ID] = group
+	}
+	if g, ok := byKeep["M-2"]; !ok || len(g.Duplicates) != 1 || g.Duplicates[0].ID != "M-1" {
+		t.Fatalf("expected newest M-2 kept over M-1, got %+v", groups)
+	}
+	if g, ok := byKeep["M-4"]; !ok || g.Duplicates[0].ID != "M-3" || g.Duplicates[0].EmbeddingSimilarity < dedupeEmbeddingThreshold {
+		t.Fatalf("expected embedding match M-3 -> M-4, got %+v", groups)
+	}
+}
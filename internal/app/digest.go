@@ -0,0 +1,298 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"mem/internal/config"
+	"mem/internal/embed"
+	"mem/internal/store"
+	"mem/internal/token"
+)
+
+const (
+	digestTag         = "digest"
+	summarizesLinkRel = "summarizes"
+
+	digestStatusCreated   = "created"
+	digestStatusUpdated   = "updated"
+	digestStatusUnchanged = "unchanged"
+)
+
+const digestPrompt = `Write a digest of the following project memories from one thread.
+Cover the decisions, current state, and open questions. Keep identifiers exact.
+Reply with the digest text only.
+
+%s
+Digest:`
+
+const digestRefreshPrompt = `Update the digest of a project thread with new memories.
+Keep facts from the current digest unless a new memory replaces them.
+Reply with the full updated digest text only.
+
+Current digest:
+%s
+
+New memories:
+%s
+Digest:`
+
+type ThreadSummarizeResponse struct {
+	ThreadID     string   `json:"thread_id"`
+	DigestID     string   `json:"digest_id"`
+	Status       string   `json:"status"`
+	Summary      string   `json:"summary"`
+	SourceIDs    []string `json:"source_ids"`
+	NewSourceIDs []string `json:"new_source_ids,omitempty"`
+	Provider     string   `json:"provider,omitempty"`
+	Model        string   `json:"model,omitempty"`
+}
+
+func runThreadSummarize(args []string, out, errOut io.Writer) int {
+	fs := flag.NewFlagSet("thread summarize", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	repoOverride := fs.String("repo", "", "Override repo id")
+	workspace := fs.String("workspace", "", "Workspace name")
+	full := fs.Bool("full", false, "Rewrite the digest from every source instead of only new memories")
+	positional, flagArgs, err := splitFlagArgs(args, map[string]flagSpec{
+		"repo":      {RequiresValue: true},
+		"workspace": {RequiresValue: true},
+		"full":      {RequiresValue: false},
+	})
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 2
+	}
+	if err := fs.Parse(flagArgs); err != nil {
+		return 2
+	}
+	threadID := strings.TrimSpace(strings.Join(positional, " "))
+	if threadID == "" {
+		fmt.Fprintln(errOut, "missing thread id")
+		return 2
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(errOut, "config error: %v\n", err)
+		return 1
+	}
+	workspaceName := resolveWorkspace(cfg, strings.TrimSpace(*workspace))
+
+	generator, genStatus := embed.ResolveGenerator(cfg)
+	if generator == nil {
+		if genStatus.Error != "" {
+			fmt.Fprintf(errOut, "generation provider unavailable: %s\n", genStatus.Error)
+		} else {
+			fmt.Fprintln(errOut, "thread summarize needs a generation provider; set generation_provider and generation_model")
+		}
+		return 1
+	}
+
+	counter, err := token.New(cfg.Tokenizer)
+	if err != nil {
+		fmt.Fprintf(errOut, "tokenizer error: %v\n", err)
+		return 1
+	}
+
+	repoInfo, err := resolveRepo(&cfg, strings.TrimSpace(*repoOverride))
+	if err != nil {
+		fmt.Fprintf(errOut, "repo detection error: %v\n", err)
+		return 1
+	}
+
+	st, err := openStore(cfg, repoInfo.ID)
+	if err != nil {
+		fmt.Fprintf(errOut, "store open error: %v\n", err)
+		return 1
+	}
+	defer st.Close()
+
+	thread, err := st.GetThread(repoInfo.ID, workspaceName, threadID)
+	if err != nil {
+		fmt.Fprintf(errOut, "thread not found: %s\n", threadID)
+		return 1
+	}
+
+	anchorCommit := ""
+	if repoInfo.HasGit {
+		anchorCommit = repoInfo.Head
+	}
+	resp, err := summarizeThread(cfg, st, generator, counter, repoInfo.ID, workspaceName, thread, anchorCommit, *full, time.Now().UTC())
+	if err != nil {
+		fmt.Fprintf(errOut, "thread summarize error: %v\n", err)
+		return 1
+	}
+	if resp.Status != digestStatusUnchanged {
+		if digest, err := st.GetMemory(repoInfo.ID, workspaceName, resp.DigestID); err == nil {
+			if err := maybeEmbedMemory(cfg, st, digest); err != nil {
+				fmt.Fprintf(errOut, "embedding warning: %v\n", err)
+			}
+		}
+	}
+	resp.Provider = genStatus.Provider
+	resp.Model = genStatus.Model
+	return writeJSON(out, errOut, resp)
+}
+
+// summarizeThread keeps one digest memory per thread. The first run writes it
+// from every active memory in the thread; later runs feed the current digest
+// and only the memories it does not yet summarize back to the generator, then
+// update the digest in place and add the missing summarizes links.
+func summarizeThread(cfg config.Config, st *store.Store, generator embed.Generator, counter TokenCounter, repoID, workspace string, thread store.Thread, anchorCommit string, full bool, now time.Time) (ThreadSummarizeResponse, error) {
+	resp := ThreadSummarizeResponse{ThreadID: thread.ThreadID}
+
+	memories, err := st.ListActiveMemories(repoID, workspace)
+	if err != nil {
+		return resp, err
+	}
+	var digest *store.Memory
+	var sources []store.Memory
+	for i := range memories {
+		mem := memories[i]
+		if mem.ThreadID != thread.ThreadID {
+			continue
+		}
+		if isDigestMemory(mem) {
+			if digest == nil || newerMemory(mem, *digest) {
+				digest = &memories[i]
+			}
+			continue
+		}
+		sources = append(sources, mem)
+	}
+	if len(sources) == 0 {
+		return resp, fmt.Errorf("thread %s has no memories to summarize", thread.ThreadID)
+	}
+	for _, mem := range sources {
+		resp.SourceIDs = append(resp.SourceIDs, mem.ID)
+	}
+
+	fresh := sources
+	if digest != nil {
+		covered, err := digestSourceIDs(st, digest.ID)
+		if err != nil {
+			return resp, err
+		}
+		fresh = nil
+		for _, mem := range sources {
+			if _, ok := covered[mem.ID]; !ok {
+				fresh = append(fresh, mem)
+			}
+		}
+		resp.DigestID = digest.ID
+		resp.Summary = digest.Summary
+		if len(fresh) == 0 && !full {
+			resp.Status = digestStatusUnchanged
+			return resp, nil
+		}
+	}
+	for _, mem := range fresh {
+		resp.NewSourceIDs = append(resp.NewSourceIDs, mem.ID)
+	}
+
+	var prompt string
+	if digest == nil || full {
+		prompt = fmt.Sprintf(digestPrompt, formatDigestSources(sources))
+	} else {
+		prompt = fmt.Sprintf(digestRefreshPrompt, strings.TrimSpace(digest.Summary), formatDigestSources(fresh))
+	}
+	summary, err := generateText(cfg, generator, prompt)
+	if err != nil {
+		return resp, err
+	}
+	summaryTokens := counter.Count(summary)
+
+	var entities []string
+	for _, mem := range fresh {
+		entities = append(entities, parseEntitiesJSON(mem.EntitiesJSON)...)
+	}
+	entities = store.NormalizeEntities(entities)
+
+	var saved store.Memory
+	if digest == nil {
+		title := strings.TrimSpace(thread.Title)
+		if title == "" {
+			title = thread.ThreadID
+		}
+		tags := []string{digestTag}
+		saved, err = st.AddMemory(store.AddMemoryInput{
+			RepoID:        repoID,
+			Workspace:     workspace,
+			ThreadID:      thread.ThreadID,
+			Title:         "Digest: " + title,
+			Summary:       summary,
+			SummaryTokens: summaryTokens,
+			TagsJSON:      store.TagsToJSON(tags),
+			TagsText:      store.TagsText(tags),
+			EntitiesJSON:  store.EntitiesToJSON(entities),
+			EntitiesText:  store.EntitiesText(entities),
+			AnchorCommit:  anchorCommit,
+			CreatedAt:     now,
+		})
+		resp.Status = digestStatusCreated
+	} else {
+		saved, _, err = st.UpdateMemoryWithStatus(store.UpdateMemoryInput{
+			RepoID:        repoID,
+			Workspace:     workspace,
+			ID:            digest.ID,
+			Summary:       &summary,
+			SummaryTokens: &summaryTokens,
+			EntitiesAdd:   entities,
+		})
+		resp.Status = digestStatusUpdated
+	}
+	if err != nil {
+		return resp, err
+	}
+	resp.DigestID = saved.ID
+	resp.Summary = saved.Summary
+
+	for _, mem := range sources {
+		if _, err := st.AddLinkIfMissing(store.Link{
+			FromID:    saved.ID,
+			Rel:       summarizesLinkRel,
+			ToID:      mem.ID,
+			Weight:    1,
+			CreatedAt: now,
+		}); err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
+
+func isDigestMemory(mem store.Memory) bool {
+	for _, tag := range parseTagsJSON(mem.TagsJSON) {
+		if strings.EqualFold(tag, digestTag) {
+			return true
+		}
+	}
+	return false
+}
+
+// digestSourceIDs returns the active memories digestID already summarizes.
+func digestSourceIDs(st *store.Store, digestID string) (map[string]struct{}, error) {
+	links, err := st.ListLinksForIDs([]string{digestID})
+	if err != nil {
+		return nil, err
+	}
+	covered := make(map[string]struct{}, len(links))
+	for _, link := range links {
+		if link.FromID == digestID && link.Rel == summarizesLinkRel {
+			covered[link.ToID] = struct{}{}
+		}
+	}
+	return covered, nil
+}
+
+func formatDigestSources(memories []store.Memory) string {
+	var b strings.Builder
+	for _, mem := range memories {
+		fmt.Fprintf(&b, "- [%s] %s: %s\n", mem.CreatedAt.UTC().Format("2006-01-02"), mem.Title, strings.TrimSpace(mem.Summary))
+	}
+	return b.String()
+}
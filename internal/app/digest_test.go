@@ -0,0 +1,117 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"mem/internal/config"
+	"mem/internal/embed"
+	"mem/internal/store"
+)
+
+func TestSummarizeThreadCreatesAndRefreshesDigest(t *testing.T) {
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Prompt string `json:"prompt"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		prompts = append(prompts, req.Prompt)
+		_ = json.NewEncoder(w).Encode(map[string]string{"response": "digest v" + string(rune('0'+len(prompts)))})
+	}))
+	defer server.Close()
+
+	st := openDedupeTestStore(t)
+	cfg := config.Config{EmbeddingProvider: "none"}
+	generator := embed.NewOllamaGenerator(server.URL, "llama3.2")
+
+	base := time.Now().UTC().Add(-time.Hour)
+	add := func(title, summary string, offset time.Duration) store.Memory {
+		input := dedupeTestInput(title, summary)
+		input.CreatedAt = base.Add(offset)
+		mem, err := st.AddMemory(input)
+		if err != nil {
+			t.Fatalf("add %s: %v", title, err)
+		}
+		return mem
+	}
+	first := add("Auth design", "Sessions live in Redis.", 0)
+	second := add("Token rotation", "Refresh tokens rotate daily.", time.Minute)
+
+	thread, err := st.GetThread("r1", "default", "T-DUP")
+	if err != nil {
+		t.Fatalf("get thread: %v", err)
+	}
+
+	created, err := summarizeThread(cfg, st, generator, fakeCounter{}, "r1", "default", thread, "", false, time.Now().UTC())
+	if err != nil {
+		t.Fatalf("summarize: %v", err)
+	}
+	if created.Status != digestStatusCreated || created.Summary != "digest v1" {
+		t.Fatalf("unexpected first digest: %+v", created)
+	}
+	if !strings.Contains(prompts[0], "Sessions live in Redis.") || !strings.Contains(prompts[0], "Refresh tokens rotate daily.") {
+		t.Fatalf("expected every source in first prompt, got %q", prompts[0])
+	}
+	digest, err := st.GetMemory("r1", "default", created.DigestID)
+	if err != nil || !isDigestMemory(digest) {
+		t.Fatalf("expected digest-tagged memory, got %+v, %v", digest, err)
+	}
+
+	unchanged, err := summarizeThread(cfg, st, generator, fakeCounter{}, "r1", "default", thread, "", false, time.Now().UTC())
+	if err != nil || unchanged.Status != digestStatusUnchanged || len(prompts) != 1 {
+		t.Fatalf("expected no regeneration without new memories, got %+v, %v (%d calls)", unchanged, err, len(prompts))
+	}
+
+	third := add("Logout", "Logout revokes the refresh token.", 2*time.Minute)
+	updated, err := summarizeThread(cfg, st, generator, fakeCounter{}, "r1", "default", thread, "", false, time.Now().UTC())
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if updated.Status != digestStatusUpdated || updated.DigestID != created.DigestID || updated.Summary != "digest v2" {
+		t.Fatalf("expected in-place refresh, got %+v", updated)
+	}
+	if !reflect.DeepEqual(updated.NewSourceIDs, []string{third.ID}) {
+		t.Fatalf("expected only the new memory as fresh source, got %v", updated.NewSourceIDs)
+	}
+	if !strings.Contains(prompts[1], "digest v1") || strings.Contains(prompts[1], "Sessions live in Redis.") {
+		t.Fatalf("expected incremental prompt, got %q", prompts[1])
+	}
+
+	covered, err := digestSourceIDs(st, created.DigestID)
+	if err != nil {
+		t.Fatalf("digest sources: %v", err)
+	}
+	for _, id := range []string{first.ID, second.ID, third.ID} {
+		if _, ok := covered[id]; !ok {
+			t.Fatalf("expected summarizes link to %s, got %v", id, covered)
+		}
+	}
+}
+
+func TestOpenAIGeneratorUsedForDigest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"chat digest"}}]}`))
+	}))
+	defer server.Close()
+
+	cfg := config.Config{GenerationProvider: "openai", GenerationModel: "local", GenerationURL: server.URL + "/v1"}
+	generator, status := embed.ResolveGenerator(cfg)
+	if generator == nil {
+		t.Fatalf("expected generator, got %+v", status)
+	}
+	text, err := generateText(cfg, generator, "prompt")
+	if err != nil || text != "chat digest" {
+		t.Fatalf("generateText = %q, %v", text, err)
+	}
+}
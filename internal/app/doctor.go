@@ -6,8 +6,10 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"path/filepath"
 	"strings"
 
+	"mem/internal/config"
 	"mem/internal/health"
 )
 
@@ -31,8 +33,9 @@ func runDoctor(args []string, out, errOut io.Writer) int {
 		report, err = health.Check(context.Background(), opts.RepoOverride, opts)
 	}
 
+	watch := doctorWatch(report)
 	if *jsonOut {
-		encoded, encErr := json.MarshalIndent(report, "", "  ")
+		encoded, encErr := json.MarshalIndent(doctorJSON{Report: report, Watch: watch}, "", "  ")
 		if encErr != nil {
 			fmt.Fprintf(errOut, "json error: %v\n", encErr)
 			return 1
@@ -46,6 +49,7 @@ func runDoctor(args []string, out, errOut io.Writer) int {
 	}
 
 	writeDoctorReport(out, report, *verbose)
+	writeDoctorWatch(out, watch)
 	if err != nil {
 		fmt.Fprintln(errOut, err.Error())
 		return 1
@@ -53,6 +57,61 @@ func runDoctor(args []string, out, errOut io.Writer) int {
 	return 0
 }
 
+type doctorJSON struct {
+	health.Report
+	Watch *doctorWatchReport `json:"watch,omitempty"`
+}
+
+// doctorWatchReport covers background watching for the checked repo. It is
+// only reported when the repo config declares watch paths.
+type doctorWatchReport struct {
+	DaemonRunning bool             `json:"daemon_running"`
+	Status        *RepoWatchStatus `json:"status,omitempty"`
+}
+
+func doctorWatch(report health.Report) *doctorWatchReport {
+	root := strings.TrimSpace(report.Repo.GitRoot)
+	if root == "" || report.Repo.ID == "" {
+		return nil
+	}
+	repoCfg, _, err := config.LoadRepoConfig(root)
+	if err != nil || repoCfg.Watch == nil || len(repoCfg.Watch.Paths) == 0 {
+		return nil
+	}
+	watch := &doctorWatchReport{}
+	cfg, err := loadConfig()
+	if err != nil {
+		return watch
+	}
+	pid, running := readPID(filepath.Join(cfg.ConfigDir, "mcp.pid"))
+	watch.DaemonRunning = running
+	if !running {
+		return watch
+	}
+	if state, ok := readDaemonWatchState(cfg, pid); ok {
+		for _, status := range state.Repos {
+			if status.RepoID == report.Repo.ID {
+				status := status
+				watch.Status = &status
+				break
+			}
+		}
+	}
+	return watch
+}
+
+func writeDoctorWatch(out io.Writer, watch *doctorWatchReport) {
+	switch {
+	case watch == nil:
+	case !watch.DaemonRunning:
+		fmt.Fprintln(out, "watch: configured, daemon not running (start it with `mem mcp start`)")
+	case watch.Status == nil:
+		fmt.Fprintln(out, "watch: configured, not yet picked up by the daemon")
+	default:
+		fmt.Fprintln(out, formatRepoWatchStatus(*watch.Status))
+	}
+}
+
 func writeDoctorReport(out io.Writer, report health.Report, verbose bool) {
 	if report.OK {
 		fmt.Fprintln(out, "mem doctor: ok")
@@ -29,6 +29,32 @@ func maybeEmbedMemory(cfg config.Config, st *store.Store, mem store.Memory) erro
 	return st.EnqueueEmbedding(queueItem)
 }
 
+// maybeEmbedChunks queues freshly written chunks for the background
+// embedding worker.
+func maybeEmbedChunks(cfg config.Config, st *store.Store, repoID, workspace string, chunkIDs []string) error {
+	provider := strings.TrimSpace(strings.ToLower(cfg.EmbeddingProvider))
+	if provider == "" || provider == "none" {
+		return nil
+	}
+	model := effectiveEmbeddingModel(cfg)
+	if model == "" {
+		return nil
+	}
+	for _, chunkID := range chunkIDs {
+		if err := st.EnqueueEmbedding(store.EmbeddingQueueItem{
+			RepoID:    repoID,
+			Workspace: workspace,
+			Kind:      store.EmbeddingKindChunk,
+			ItemID:    chunkID,
+			Model:     model,
+			CreatedAt: time.Now().UTC(),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func effectiveEmbeddingModel(cfg config.Config) string {
 	provider := strings.TrimSpace(strings.ToLower(cfg.EmbeddingProvider))
 	model := strings.TrimSpace(cfg.EmbeddingModel)
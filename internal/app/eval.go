@@ -0,0 +1,395 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"mem/internal/store"
+)
+
+const defaultEvalK = 10
+
+type EvalCase struct {
+	ID               string   `json:"id,omitempty"`
+	Query            string   `json:"query"`
+	ExpectedIDs      []string `json:"expected_ids,omitempty"`
+	ExpectedLocators []string `json:"expected_locators,omitempty"`
+	K                int      `json:"k,omitempty"`
+}
+
+type EvalReport struct {
+	Suite    string          `json:"suite"`
+	K        int             `json:"k"`
+	Cases    int             `json:"cases"`
+	Variants []EvalVariant   `json:"variants"`
+	Delta    *EvalMetrics    `json:"delta,omitempty"`
+	Errors   []EvalCaseError `json:"errors,omitempty"`
+}
+
+type EvalVariant struct {
+	Name    string           `json:"name"`
+	Tuning  *RankTuning      `json:"tuning,omitempty"`
+	Summary EvalMetrics      `json:"summary"`
+	Cases   []EvalCaseResult `json:"cases"`
+}
+
+type EvalMetrics struct {
+	RecallAtK         float64 `json:"recall_at_k"`
+	MRR               float64 `json:"mrr"`
+	NDCGAtK           float64 `json:"ndcg_at_k"`
+	PackRecall        float64 `json:"pack_recall"`
+	BudgetUtilization float64 `json:"budget_utilization"`
+}
+
+type EvalCaseResult struct {
+	ID             string   `json:"id,omitempty"`
+	Query          string   `json:"query"`
+	K              int      `json:"k"`
+	RecallAtK      float64  `json:"recall_at_k"`
+	ReciprocalRank float64  `json:"reciprocal_rank"`
+	NDCGAtK        float64  `json:"ndcg_at_k"`
+	PackRecall     float64  `json:"pack_recall"`
+	FirstHitRank   int      `json:"first_hit_rank,omitempty"`
+	BudgetUsed     int      `json:"budget_used"`
+	BudgetTarget   int      `json:"budget_target"`
+	Missing        []string `json:"missing,omitempty"`
+}
+
+type EvalCaseError struct {
+	Variant string `json:"variant"`
+	Case    string `json:"case"`
+	Error   string `json:"error"`
+}
+
+// evalCandidate is one retrieved item in merged memory+chunk rank order.
+type evalCandidate struct {
+	ID       string
+	Locator  string
+	Score    float64
+	Included bool
+}
+
+func runEval(args []string, out, errOut io.Writer) int {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	repoOverride := fs.String("repo", "", "Override repo id")
+	workspace := fs.String("workspace", "", "Workspace name")
+	k := fs.Int("k", defaultEvalK, "Cutoff for recall@k and nDCG@k")
+	baseline := fs.String("baseline", "", "TOML file with baseline tuning (default: current config)")
+	candidate := fs.String("candidate", "", "TOML file with candidate tuning to compare against the baseline")
+	positional, flagArgs, err := splitFlagArgs(args, map[string]flagSpec{
+		"repo":      {RequiresValue: true},
+		"workspace": {RequiresValue: true},
+		"k":         {RequiresValue: true},
+		"baseline":  {RequiresValue: true},
+		"candidate": {RequiresValue: true},
+	})
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 2
+	}
+	if err := fs.Parse(flagArgs); err != nil {
+		return 2
+	}
+	if len(positional) != 1 {
+		fmt.Fprintln(errOut, "usage: mem eval <suite.jsonl> [--k <n>] [--baseline <toml>] [--candidate <toml>]")
+		return 2
+	}
+	if *k <= 0 {
+		fmt.Fprintln(errOut, "--k must be > 0")
+		return 2
+	}
+
+	suitePath := positional[0]
+	cases, err := loadEvalSuite(suitePath)
+	if err != nil {
+		fmt.Fprintf(errOut, "suite error: %v\n", err)
+		return 2
+	}
+
+	variants := []*RankTuning{}
+	baseTuning, err := loadEvalTuning(*baseline, "baseline")
+	if err != nil {
+		fmt.Fprintf(errOut, "baseline error: %v\n", err)
+		return 2
+	}
+	variants = append(variants, baseTuning)
+	if strings.TrimSpace(*candidate) != "" {
+		candTuning, err := loadEvalTuning(*candidate, "candidate")
+		if err != nil {
+			fmt.Fprintf(errOut, "candidate error: %v\n", err)
+			return 2
+		}
+		variants = append(variants, candTuning)
+	}
+
+	report := EvalReport{Suite: suitePath, K: *k, Cases: len(cases)}
+	for _, tuning := range variants {
+		variant, errs := runEvalVariant(cases, tuning, *k, *repoOverride, *workspace)
+		report.Variants = append(report.Variants, variant)
+		report.Errors = append(report.Errors, errs...)
+	}
+	if len(report.Variants) == 2 {
+		a, b := report.Variants[0].Summary, report.Variants[1].Summary
+		report.Delta = &EvalMetrics{
+			RecallAtK:         b.RecallAtK - a.RecallAtK,
+			MRR:               b.MRR - a.MRR,
+			NDCGAtK:           b.NDCGAtK - a.NDCGAtK,
+			PackRecall:        b.PackRecall - a.PackRecall,
+			BudgetUtilization: b.BudgetUtilization - a.BudgetUtilization,
+		}
+	}
+
+	encoded, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintf(errOut, "json error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(out, string(encoded))
+	if len(report.Errors) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func loadEvalSuite(path string) ([]EvalCase, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var cases []EvalCase
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var c EvalCase
+		if err := json.Unmarshal([]byte(line), &c); err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNo, err)
+		}
+		c.Query = strings.TrimSpace(c.Query)
+		if err := store.EnsureValidQuery(c.Query); err != nil {
+			return nil, fmt.Errorf("line %d: invalid query: %v", lineNo, err)
+		}
+		if len(c.ExpectedIDs) == 0 && len(c.ExpectedLocators) == 0 {
+			return nil, fmt.Errorf("line %d: expected_ids or expected_locators is required", lineNo)
+		}
+		if c.ID == "" {
+			c.ID = fmt.Sprintf("line-%d", lineNo)
+		}
+		cases = append(cases, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("suite has no cases")
+	}
+	return cases, nil
+}
+
+func loadEvalTuning(path, name string) (*RankTuning, error) {
+	tuning := &RankTuning{}
+	if strings.TrimSpace(path) != "" {
+		if _, err := toml.DecodeFile(path, tuning); err != nil {
+			return nil, err
+		}
+	}
+	if strings.TrimSpace(tuning.Name) == "" {
+		tuning.Name = name
+	}
+	return tuning, nil
+}
+
+func runEvalVariant(cases []EvalCase, tuning *RankTuning, defaultK int, repoOverride, workspace string) (EvalVariant, []EvalCaseError) {
+	variant := EvalVariant{Name: tuning.Name, Tuning: tuning, Cases: make([]EvalCaseResult, 0, len(cases))}
+	var errs []EvalCaseError
+	totals := EvalMetrics{}
+	scored := 0
+	for _, c := range cases {
+		trace := retrievalTrace{}
+		contextPack, err := buildContextPackWithTrace(c.Query, ContextOptions{
+			RepoOverride: repoOverride,
+			Workspace:    workspace,
+			Tuning:       tuning,
+		}, nil, &trace)
+		if err != nil {
+			errs = append(errs, EvalCaseError{Variant: tuning.Name, Case: c.ID, Error: err.Error()})
+			continue
+		}
+		k := c.K
+		if k <= 0 {
+			k = defaultK
+		}
+		result := scoreEvalCase(c, evalCandidates(trace), k)
+		result.BudgetUsed = contextPack.Budget.UsedTotal
+		result.BudgetTarget = contextPack.Budget.TargetTotal
+		variant.Cases = append(variant.Cases, result)
+
+		totals.RecallAtK += result.RecallAtK
+		totals.MRR += result.ReciprocalRank
+		totals.NDCGAtK += result.NDCGAtK
+		totals.PackRecall += result.PackRecall
+		if result.BudgetTarget > 0 {
+			totals.BudgetUtilization += float64(result.BudgetUsed) / float64(result.BudgetTarget)
+		}
+		scored++
+	}
+	if scored > 0 {
+		n := float64(scored)
+		variant.Summary = EvalMetrics{
+			RecallAtK:         roundMetric(totals.RecallAtK / n),
+			MRR:               roundMetric(totals.MRR / n),
+			NDCGAtK:           roundMetric(totals.NDCGAtK / n),
+			PackRecall:        roundMetric(totals.PackRecall / n),
+			BudgetUtilization: roundMetric(totals.BudgetUtilization / n),
+		}
+	}
+	return variant, errs
+}
+
+// evalCandidates merges ranked memories and chunks into one list ordered by
+// final score, mirroring how the budget competes them for tokens.
+func evalCandidates(trace retrievalTrace) []evalCandidate {
+	out := make([]evalCandidate, 0, len(trace.RankedMemories)+len(trace.RankedChunks))
+	for _, mem := range trace.RankedMemories {
+		_, included := trace.Budget.IncludedMemoryIDs[mem.Memory.ID]
+		out = append(out, evalCandidate{ID: mem.Memory.ID, Score: mem.FinalScore, Included: included})
+	}
+	for _, chunk := range trace.RankedChunks {
+		_, included := trace.Budget.IncludedChunkIDs[chunk.Chunk.ID]
+		out = append(out, evalCandidate{ID: chunk.Chunk.ID, Locator: chunk.Chunk.Locator, Score: chunk.FinalScore, Included: included})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Score > out[j].Score
+	})
+	return out
+}
+
+type evalTarget struct {
+	label   string
+	id      string
+	pattern *regexp.Regexp
+}
+
+func (t evalTarget) matches(c evalCandidate) bool {
+	if t.id != "" {
+		return c.ID == t.id
+	}
+	return c.Locator != "" && t.pattern.MatchString(c.Locator)
+}
+
+func scoreEvalCase(c EvalCase, ranked []evalCandidate, k int) EvalCaseResult {
+	targets := make([]evalTarget, 0, len(c.ExpectedIDs)+len(c.ExpectedLocators))
+	for _, id := range c.ExpectedIDs {
+		id = strings.TrimSpace(id)
+		if id != "" {
+			targets = append(targets, evalTarget{label: id, id: id})
+		}
+	}
+	for _, pattern := range c.ExpectedLocators {
+		pattern = strings.TrimSpace(pattern)
+		if pattern != "" {
+			targets = append(targets, evalTarget{label: pattern, pattern: locatorPattern(pattern)})
+		}
+	}
+
+	result := EvalCaseResult{ID: c.ID, Query: c.Query, K: k}
+	if len(targets) == 0 {
+		return result
+	}
+
+	// Each target is credited once, at the first rank that satisfies it, so
+	// several chunks matching one locator pattern do not inflate the scores.
+	hitRank := make([]int, len(targets))
+	inPack := make([]bool, len(targets))
+	dcg := 0.0
+	for idx, cand := range ranked {
+		rank := idx + 1
+		for ti, target := range targets {
+			if hitRank[ti] != 0 || !target.matches(cand) {
+				continue
+			}
+			hitRank[ti] = rank
+			inPack[ti] = cand.Included
+			if result.FirstHitRank == 0 {
+				result.FirstHitRank = rank
+			}
+			if rank <= k {
+				dcg += 1 / math.Log2(float64(rank)+1)
+			}
+			break
+		}
+	}
+
+	hitsAtK := 0
+	packed := 0
+	for ti, target := range targets {
+		if hitRank[ti] > 0 && hitRank[ti] <= k {
+			hitsAtK++
+		}
+		if inPack[ti] {
+			packed++
+		}
+		if hitRank[ti] == 0 || hitRank[ti] > k {
+			result.Missing = append(result.Missing, target.label)
+		}
+	}
+	idcg := 0.0
+	for rank := 1; rank <= len(targets) && rank <= k; rank++ {
+		idcg += 1 / math.Log2(float64(rank)+1)
+	}
+
+	n := float64(len(targets))
+	result.RecallAtK = roundMetric(float64(hitsAtK) / n)
+	result.PackRecall = roundMetric(float64(packed) / n)
+	if result.FirstHitRank > 0 {
+		result.ReciprocalRank = roundMetric(1 / float64(result.FirstHitRank))
+	}
+	if idcg > 0 {
+		result.NDCGAtK = roundMetric(dcg / idcg)
+	}
+	return result
+}
+
+// locatorPattern treats patterns without wildcards as substrings and expands
+// `*` / `?` glob wildcards, which may cross path separators.
+func locatorPattern(pattern string) *regexp.Regexp {
+	if !strings.ContainsAny(pattern, "*?") {
+		return regexp.MustCompile(regexp.QuoteMeta(pattern))
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func roundMetric(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScoreEvalCaseMetrics(t *testing.T) {
+	ranked := []evalCandidate{
+		{ID: "M-noise", Score: 5, Included: true},
+		{ID: "M-want", Score: 4, Included: true},
+		{ID: "C-1", Locator: "git:abc:internal/auth/jwt.go#L1-L20", Score: 3},
+		{ID: "C-2", Locator: "git:abc:internal/auth/jwt.go#L21-L40", Score: 2},
+	}
+	c := EvalCase{
+		Query:            "auth",
+		ExpectedIDs:      []string{"M-want"},
+		ExpectedLocators: []string{"*internal/auth/*"},
+	}
+
+	result := scoreEvalCase(c, ranked, 3)
+	if result.RecallAtK != 1 {
+		t.Fatalf("expected recall@3 1, got %v", result.RecallAtK)
+	}
+	if result.FirstHitRank != 2 || result.ReciprocalRank != 0.5 {
+		t.Fatalf("expected first hit at rank 2, got %d (rr=%v)", result.FirstHitRank, result.ReciprocalRank)
+	}
+	if result.PackRecall != 0.5 {
+		t.Fatalf("expected pack recall 0.5, got %v", result.PackRecall)
+	}
+	// DCG = 1/log2(3) + 1/log2(4); IDCG = 1 + 1/log2(3).
+	if result.NDCGAtK != 0.6934 {
+		t.Fatalf("unexpected ndcg@3: %v", result.NDCGAtK)
+	}
+
+	cutoff := scoreEvalCase(c, ranked, 2)
+	if cutoff.RecallAtK != 0.5 || len(cutoff.Missing) != 1 || cutoff.Missing[0] != "*internal/auth/*" {
+		t.Fatalf("expected locator target missing at k=2, got %+v", cutoff)
+	}
+}
+
+func TestLocatorPatternSubstringAndGlob(t *testing.T) {
+	if !locatorPattern("auth/jwt.go").MatchString("git:abc:internal/auth/jwt.go#L1-L2") {
+		t.Fatalf("expected substring match")
+	}
+	if locatorPattern("git:*:cmd/*").MatchString("git:abc:internal/auth/jwt.go#L1-L2") {
+		t.Fatalf("expected glob mismatch")
+	}
+}
+
+func TestLoadEvalSuiteValidatesLines(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "suite.jsonl")
+	content := "# golden set\n{\"query\":\"auth flow\",\"expected_ids\":[\"M-1\"]}\n\n{\"id\":\"loc\",\"query\":\"cache\",\"expected_locators\":[\"cache.go\"],\"k\":3}\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write suite: %v", err)
+	}
+	cases, err := loadEvalSuite(path)
+	if err != nil {
+		t.Fatalf("load suite: %v", err)
+	}
+	if len(cases) != 2 || cases[0].ID != "line-2" || cases[1].K != 3 {
+		t.Fatalf("unexpected cases: %+v", cases)
+	}
+
+	if err := os.WriteFile(path, []byte("{\"query\":\"auth\"}\n"), 0o644); err != nil {
+		t.Fatalf("write suite: %v", err)
+	}
+	if _, err := loadEvalSuite(path); err == nil {
+		t.Fatal("expected error for case without expectations")
+	}
+}
@@ -20,6 +20,8 @@ type ExplainReport struct {
 	Memories       []ExplainMemory      `json:"memories"`
 	Chunks         []ExplainChunk       `json:"chunks"`
 	Vector         VectorExplain        `json:"vector"`
+	Rerank         RerankStatus         `json:"rerank"`
+	Selection      SelectionExplain     `json:"selection"`
 	Budget         pack.BudgetInfo      `json:"budget"`
 }
 
@@ -31,40 +33,61 @@ type VectorExplain struct {
 	Error         string  `json:"error,omitempty"`
 }
 
+type SelectionExplain struct {
+	Mode                string  `json:"mode"`
+	MMRLambda           float64 `json:"mmr_lambda,omitempty"`
+	MMRRedundantCosine  float64 `json:"mmr_redundant_cosine,omitempty"`
+	MMRRedundantOverlap float64 `json:"mmr_redundant_overlap,omitempty"`
+	Redundant           int     `json:"redundant,omitempty"`
+}
+
 type ExplainMemory struct {
-	ID            string  `json:"id"`
-	ThreadID      string  `json:"thread_id,omitempty"`
-	Title         string  `json:"title"`
-	AnchorCommit  string  `json:"anchor_commit,omitempty"`
-	BM25          float64 `json:"bm25"`
-	FTSScore      float64 `json:"fts_score"`
-	FTSRank       int     `json:"fts_rank"`
-	VectorScore   float64 `json:"vector_score"`
-	VectorRank    int     `json:"vector_rank"`
-	RRFScore      float64 `json:"rrf_score"`
-	RecencyBonus  float64 `json:"recency_bonus"`
-	ThreadBonus   float64 `json:"thread_bonus"`
-	SafetyPenalty float64 `json:"safety_penalty,omitempty"`
-	Superseded    bool    `json:"superseded"`
-	Orphaned      bool    `json:"orphaned"`
-	FinalScore    float64 `json:"final_score"`
-	Included      bool    `json:"included"`
+	ID              string  `json:"id"`
+	ThreadID        string  `json:"thread_id,omitempty"`
+	Title           string  `json:"title"`
+	AnchorCommit    string  `json:"anchor_commit,omitempty"`
+	BM25            float64 `json:"bm25"`
+	FTSScore        float64 `json:"fts_score"`
+	FTSRank         int     `json:"fts_rank"`
+	VectorScore     float64 `json:"vector_score"`
+	VectorRank      int     `json:"vector_rank"`
+	RRFScore        float64 `json:"rrf_score"`
+	RecencyBonus    float64 `json:"recency_bonus"`
+	ThreadBonus     float64 `json:"thread_bonus"`
+	FeedbackBonus   float64 `json:"feedback_bonus,omitempty"`
+	PopularityBonus float64 `json:"popularity_bonus,omitempty"`
+	RerankScore     float64 `json:"rerank_score,omitempty"`
+	RerankBonus     float64 `json:"rerank_bonus,omitempty"`
+	Reranked        bool    `json:"reranked,omitempty"`
+	SafetyPenalty   float64 `json:"safety_penalty,omitempty"`
+	Superseded      bool    `json:"superseded"`
+	Orphaned        bool    `json:"orphaned"`
+	FinalScore      float64 `json:"final_score"`
+	Included        bool    `json:"included"`
+	RedundantWith   string  `json:"redundant_with,omitempty"`
 }
 
 type ExplainChunk struct {
-	ID           string  `json:"id"`
-	ThreadID     string  `json:"thread_id,omitempty"`
-	Locator      string  `json:"locator,omitempty"`
-	BM25         float64 `json:"bm25"`
-	FTSScore     float64 `json:"fts_score"`
-	FTSRank      int     `json:"fts_rank"`
-	VectorScore  float64 `json:"vector_score"`
-	VectorRank   int     `json:"vector_rank"`
-	RRFScore     float64 `json:"rrf_score"`
-	RecencyBonus float64 `json:"recency_bonus"`
-	ThreadBonus  float64 `json:"thread_bonus"`
-	FinalScore   float64 `json:"final_score"`
-	Included     bool    `json:"included"`
+	ID              string  `json:"id"`
+	ThreadID        string  `json:"thread_id,omitempty"`
+	Locator         string  `json:"locator,omitempty"`
+	BM25            float64 `json:"bm25"`
+	FTSScore        float64 `json:"fts_score"`
+	FTSRank         int     `json:"fts_rank"`
+	VectorScore     float64 `json:"vector_score"`
+	VectorRank      int     `json:"vector_rank"`
+	CommitRank      int     `json:"commit_rank,omitempty"`
+	RRFScore        float64 `json:"rrf_score"`
+	RecencyBonus    float64 `json:"recency_bonus"`
+	ThreadBonus     float64 `json:"thread_bonus"`
+	FeedbackBonus   float64 `json:"feedback_bonus,omitempty"`
+	PopularityBonus float64 `json:"popularity_bonus,omitempty"`
+	RerankScore     float64 `json:"rerank_score,omitempty"`
+	RerankBonus     float64 `json:"rerank_bonus,omitempty"`
+	Reranked        bool    `json:"reranked,omitempty"`
+	FinalScore      float64 `json:"final_score"`
+	Included        bool    `json:"included"`
+	RedundantWith   string  `json:"redundant_with,omitempty"`
 }
 
 func runExplain(args []string, out, errOut io.Writer) int {
@@ -73,10 +96,14 @@ func runExplain(args []string, out, errOut io.Writer) int {
 	workspace := fs.String("workspace", "", "Workspace name")
 	includeOrphans := fs.Bool("include-orphans", false, "Include orphaned memories")
 	repoOverride := fs.String("repo", "", "Override repo id")
+	mmr := fs.Bool("mmr", false, "Diversify the pack with maximal marginal relevance")
+	mmrLambda := fs.Float64("mmr-lambda", 0, "MMR relevance weight in (0,1]")
 	positional, flagArgs, err := splitFlagArgs(args, map[string]flagSpec{
 		"workspace":       {RequiresValue: true},
 		"include-orphans": {RequiresValue: false},
 		"repo":            {RequiresValue: true},
+		"mmr":             {RequiresValue: false},
+		"mmr-lambda":      {RequiresValue: true},
 	})
 	if err != nil {
 		fmt.Fprintln(errOut, err.Error())
@@ -91,11 +118,17 @@ func runExplain(args []string, out, errOut io.Writer) int {
 		fmt.Fprintf(errOut, "invalid query: %v\n", err)
 		return 2
 	}
+	if *mmrLambda < 0 || *mmrLambda > 1 {
+		fmt.Fprintln(errOut, "--mmr-lambda must be between 0 and 1")
+		return 2
+	}
 
 	report, err := buildExplainReport(query, ExplainOptions{
 		RepoOverride:   *repoOverride,
 		Workspace:      *workspace,
 		IncludeOrphans: *includeOrphans,
+		MMR:            *mmr,
+		MMRLambda:      *mmrLambda,
 	})
 	if err != nil {
 		fmt.Fprintf(errOut, "%v\n", err)
@@ -9,6 +9,8 @@ type ExplainOptions struct {
 	Workspace      string
 	IncludeOrphans bool
 	RequireRepo    bool
+	MMR            bool
+	MMRLambda      float64
 }
 
 func buildExplainReport(query string, opts ExplainOptions) (ExplainReport, error) {
@@ -18,6 +20,8 @@ func buildExplainReport(query string, opts ExplainOptions) (ExplainReport, error
 		Workspace:      opts.Workspace,
 		IncludeOrphans: opts.IncludeOrphans,
 		RequireRepo:    opts.RequireRepo,
+		MMR:            opts.MMR,
+		MMRLambda:      opts.MMRLambda,
 	}, nil, &trace)
 	if err != nil {
 		return ExplainReport{}, err
@@ -27,23 +31,29 @@ func buildExplainReport(query string, opts ExplainOptions) (ExplainReport, error
 	for _, mem := range trace.RankedMemories {
 		_, included := trace.Budget.IncludedMemoryIDs[mem.Memory.ID]
 		memExplain = append(memExplain, ExplainMemory{
-			ID:            mem.Memory.ID,
-			ThreadID:      mem.Memory.ThreadID,
-			Title:         mem.Memory.Title,
-			AnchorCommit:  mem.Memory.AnchorCommit,
-			BM25:          mem.BM25,
-			FTSScore:      mem.FTSScore,
-			FTSRank:       mem.FTSRank,
-			VectorScore:   mem.VectorScore,
-			VectorRank:    mem.VectorRank,
-			RRFScore:      mem.RRFScore,
-			RecencyBonus:  mem.RecencyBonus,
-			ThreadBonus:   mem.ThreadBonus,
-			SafetyPenalty: mem.SafetyPenalty,
-			Superseded:    mem.Superseded,
-			Orphaned:      mem.Orphaned,
-			FinalScore:    mem.FinalScore,
-			Included:      included,
+			ID:              mem.Memory.ID,
+			ThreadID:        mem.Memory.ThreadID,
+			Title:           mem.Memory.Title,
+			AnchorCommit:    mem.Memory.AnchorCommit,
+			BM25:            mem.BM25,
+			FTSScore:        mem.FTSScore,
+			FTSRank:         mem.FTSRank,
+			VectorScore:     mem.VectorScore,
+			VectorRank:      mem.VectorRank,
+			RRFScore:        mem.RRFScore,
+			RecencyBonus:    mem.RecencyBonus,
+			ThreadBonus:     mem.ThreadBonus,
+			FeedbackBonus:   mem.FeedbackBonus,
+			PopularityBonus: mem.PopularityBonus,
+			RerankScore:     mem.RerankScore,
+			RerankBonus:     mem.RerankBonus,
+			Reranked:        mem.Reranked,
+			SafetyPenalty:   mem.SafetyPenalty,
+			Superseded:      mem.Superseded,
+			Orphaned:        mem.Orphaned,
+			FinalScore:      mem.FinalScore,
+			Included:        included,
+			RedundantWith:   trace.Budget.RedundantWith[mem.Memory.ID],
 		})
 	}
 
@@ -51,19 +61,26 @@ func buildExplainReport(query string, opts ExplainOptions) (ExplainReport, error
 	for _, chunk := range trace.RankedChunks {
 		_, included := trace.Budget.IncludedChunkIDs[chunk.Chunk.ID]
 		chunkExplain = append(chunkExplain, ExplainChunk{
-			ID:           chunk.Chunk.ID,
-			ThreadID:     chunk.Chunk.ThreadID,
-			Locator:      chunk.Chunk.Locator,
-			BM25:         chunk.BM25,
-			FTSScore:     chunk.FTSScore,
-			FTSRank:      chunk.FTSRank,
-			VectorScore:  chunk.VectorScore,
-			VectorRank:   chunk.VectorRank,
-			RRFScore:     chunk.RRFScore,
-			RecencyBonus: chunk.RecencyBonus,
-			ThreadBonus:  chunk.ThreadBonus,
-			FinalScore:   chunk.FinalScore,
-			Included:     included,
+			ID:              chunk.Chunk.ID,
+			ThreadID:        chunk.Chunk.ThreadID,
+			Locator:         chunk.Chunk.Locator,
+			BM25:            chunk.BM25,
+			FTSScore:        chunk.FTSScore,
+			FTSRank:         chunk.FTSRank,
+			VectorScore:     chunk.VectorScore,
+			VectorRank:      chunk.VectorRank,
+			CommitRank:      chunk.CommitRank,
+			RRFScore:        chunk.RRFScore,
+			RecencyBonus:    chunk.RecencyBonus,
+			ThreadBonus:     chunk.ThreadBonus,
+			FeedbackBonus:   chunk.FeedbackBonus,
+			PopularityBonus: chunk.PopularityBonus,
+			RerankScore:     chunk.RerankScore,
+			RerankBonus:     chunk.RerankBonus,
+			Reranked:        chunk.Reranked,
+			FinalScore:      chunk.FinalScore,
+			Included:        included,
+			RedundantWith:   trace.Budget.RedundantWith[chunk.Chunk.ID],
 		})
 	}
 
@@ -82,8 +99,19 @@ func buildExplainReport(query string, opts ExplainOptions) (ExplainReport, error
 			MinSimilarity: trace.VectorMemStatus.MinSimilarity,
 			Error:         trace.VectorMemStatus.Error,
 		},
+		Rerank: trace.RerankStatus,
+		Selection: SelectionExplain{
+			Mode:      trace.Budget.Selection,
+			Redundant: len(trace.Budget.RedundantWith),
+		},
 		Budget: contextPack.Budget,
 	}
 
+	if report.Selection.Mode == selectionMMR {
+		report.Selection.MMRLambda = normalizeMMRLambda(trace.BudgetOptions.MMRLambda)
+		report.Selection.MMRRedundantCosine = normalizeMMRThreshold(trace.BudgetOptions.RedundantCosine, mmrRedundantCosine)
+		report.Selection.MMRRedundantOverlap = normalizeMMRThreshold(trace.BudgetOptions.RedundantOverlap, mmrRedundantOverlap)
+	}
+
 	return report, nil
 }
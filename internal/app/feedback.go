@@ -0,0 +1,245 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"mem/internal/store"
+)
+
+const (
+	// Feedback decays with a 30-day half-life so stale votes fade out.
+	feedbackHalfLifeDays = 30.0
+	// Every vote counts for the item; votes cast with a query count up to
+	// twice as much when that query overlaps the current one.
+	feedbackItemWeight  = 0.5
+	feedbackQueryWeight = 0.5
+	// The summed signal is squashed into [-feedbackMaxBonus, feedbackMaxBonus]
+	// so feedback nudges fused ranks without overriding them.
+	feedbackMaxBonus = 0.5
+)
+
+type FeedbackResponse struct {
+	ID        string `json:"id"`
+	Kind      string `json:"kind"`
+	Vote      string `json:"vote"`
+	Query     string `json:"query,omitempty"`
+	CreatedAt string `json:"created_at"`
+	Status    string `json:"status"`
+}
+
+func runFeedback(args []string, out, errOut io.Writer) int {
+	fs := flag.NewFlagSet("feedback", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	query := fs.String("query", "", "Query the item was retrieved for")
+	workspace := fs.String("workspace", "", "Workspace name")
+	repoOverride := fs.String("repo", "", "Override repo id")
+	positional, flagArgs, err := splitFlagArgs(args, map[string]flagSpec{
+		"query":     {RequiresValue: true},
+		"workspace": {RequiresValue: true},
+		"repo":      {RequiresValue: true},
+	})
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 2
+	}
+	if err := fs.Parse(flagArgs); err != nil {
+		return 2
+	}
+	if len(positional) < 2 {
+		fmt.Fprintln(errOut, "usage: mem feedback <id> up|down [--query <text>]")
+		return 2
+	}
+	if len(positional) > 2 {
+		fmt.Fprintf(errOut, "unexpected args: %s\n", strings.Join(positional[2:], " "))
+		return 2
+	}
+	id := strings.TrimSpace(positional[0])
+	if id == "" {
+		fmt.Fprintln(errOut, "missing id")
+		return 2
+	}
+	vote, err := parseFeedbackVote(positional[1])
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 2
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(errOut, "config error: %v\n", err)
+		return 1
+	}
+	workspaceName := resolveWorkspace(cfg, strings.TrimSpace(*workspace))
+
+	repoInfo, err := resolveRepo(&cfg, strings.TrimSpace(*repoOverride))
+	if err != nil {
+		fmt.Fprintf(errOut, "repo detection error: %v\n", err)
+		return 1
+	}
+
+	st, err := openStore(cfg, repoInfo.ID)
+	if err != nil {
+		fmt.Fprintf(errOut, "store open error: %v\n", err)
+		return 1
+	}
+	defer st.Close()
+
+	if err := st.EnsureRepo(repoInfo); err != nil {
+		fmt.Fprintf(errOut, "store repo error: %v\n", err)
+		return 1
+	}
+
+	resp, err := recordFeedback(st, repoInfo.ID, workspaceName, id, vote, *query)
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 1
+	}
+	return writeJSON(out, errOut, resp)
+}
+
+func parseFeedbackVote(raw string) (int, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "up", "+1", "+", "useful":
+		return 1, nil
+	case "down", "-1", "-", "useless":
+		return -1, nil
+	default:
+		return 0, fmt.Errorf("invalid vote: %s (use up|down)", raw)
+	}
+}
+
+func formatFeedbackVote(vote int) string {
+	if vote < 0 {
+		return "down"
+	}
+	return "up"
+}
+
+// recordFeedback resolves id to a live memory or chunk and stores the vote.
+func recordFeedback(st *store.Store, repoID, workspace, id string, vote int, query string) (FeedbackResponse, error) {
+	kind, err := resolveFeedbackKind(st, repoID, workspace, id)
+	if err != nil {
+		return FeedbackResponse{}, err
+	}
+	fb, err := st.AddFeedback(store.Feedback{
+		RepoID:    repoID,
+		Workspace: workspace,
+		ItemID:    id,
+		Kind:      kind,
+		Vote:      vote,
+		Query:     query,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return FeedbackResponse{}, fmt.Errorf("feedback error: %v", err)
+	}
+	return FeedbackResponse{
+		ID:        fb.ItemID,
+		Kind:      fb.Kind,
+		Vote:      formatFeedbackVote(fb.Vote),
+		Query:     fb.Query,
+		CreatedAt: fb.CreatedAt.Format(time.RFC3339Nano),
+		Status:    "recorded",
+	}, nil
+}
+
+func resolveFeedbackKind(st *store.Store, repoID, workspace, id string) (string, error) {
+	if !strings.HasPrefix(id, "C") {
+		if mem, err := st.GetMemory(repoID, workspace, id); err == nil {
+			if !mem.DeletedAt.IsZero() {
+				return "", fmt.Errorf("memory is deleted: %s", id)
+			}
+			return store.FeedbackKindMemory, nil
+		}
+	}
+	if !strings.HasPrefix(id, "M") {
+		if chunk, err := st.GetChunk(repoID, workspace, id); err == nil {
+			if !chunk.DeletedAt.IsZero() {
+				return "", fmt.Errorf("chunk is deleted: %s", id)
+			}
+			return store.FeedbackKindChunk, nil
+		}
+	}
+	return "", fmt.Errorf("id not found: %s", id)
+}
+
+func loadFeedbackBonuses(st *store.Store, repoID, workspace, query string, ids []string) (map[string]float64, error) {
+	events, err := st.ListFeedbackForIDs(repoID, workspace, ids)
+	if err != nil {
+		return nil, err
+	}
+	return feedbackBonuses(events, query, time.Now().UTC()), nil
+}
+
+// feedbackBonuses sums decayed votes per item. A vote always counts at
+// feedbackItemWeight; the share of its query terms found in the current query
+// adds up to feedbackQueryWeight on top.
+func feedbackBonuses(events []store.Feedback, query string, now time.Time) map[string]float64 {
+	if len(events) == 0 {
+		return nil
+	}
+	queryTokens := tokenSet(query)
+	sums := make(map[string]float64)
+	for _, event := range events {
+		weight := feedbackItemWeight + feedbackQueryWeight*queryOverlap(tokenSet(event.Query), queryTokens)
+		sums[event.ItemID] += float64(event.Vote) * weight * feedbackDecay(now, event.CreatedAt)
+	}
+	bonuses := make(map[string]float64, len(sums))
+	for id, sum := range sums {
+		bonuses[id] = feedbackMaxBonus * math.Tanh(sum)
+	}
+	return bonuses
+}
+
+func feedbackDecay(now, createdAt time.Time) float64 {
+	if createdAt.IsZero() {
+		return 0
+	}
+	ageDays := now.Sub(createdAt).Hours() / 24
+	if ageDays < 0 {
+		ageDays = 0
+	}
+	return math.Exp(-math.Ln2 * ageDays / feedbackHalfLifeDays)
+}
+
+// queryOverlap is the fraction of the vote's query terms present in the
+// current query.
+func queryOverlap(voteTokens, queryTokens map[string]struct{}) float64 {
+	if len(voteTokens) == 0 || len(queryTokens) == 0 {
+		return 0
+	}
+	shared := 0
+	for token := range voteTokens {
+		if _, ok := queryTokens[token]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(voteTokens))
+}
+
+func memoryCandidateIDs(results []store.MemoryResult, vectorOnly []store.Memory) []string {
+	ids := make([]string, 0, len(results)+len(vectorOnly))
+	for _, res := range results {
+		ids = append(ids, res.Memory.ID)
+	}
+	for _, mem := range vectorOnly {
+		ids = append(ids, mem.ID)
+	}
+	return ids
+}
+
+func chunkCandidateIDs(results []store.ChunkResult, vectorOnly []store.Chunk) []string {
+	ids := make([]string, 0, len(results)+len(vectorOnly))
+	for _, res := range results {
+		ids = append(ids, res.Chunk.ID)
+	}
+	for _, chunk := range vectorOnly {
+		ids = append(ids, chunk.ID)
+	}
+	return ids
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"mem/internal/store"
+)
+
+func TestFeedbackBonusesDecayAndQueryMatch(t *testing.T) {
+	now := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	events := []store.Feedback{
+		{ItemID: "M-fresh", Vote: 1, Query: "auth middleware", CreatedAt: now},
+		{ItemID: "M-old", Vote: 1, Query: "auth middleware", CreatedAt: now.Add(-90 * 24 * time.Hour)},
+		{ItemID: "M-other", Vote: 1, Query: "billing export", CreatedAt: now},
+		{ItemID: "M-down", Vote: -1, CreatedAt: now},
+	}
+
+	bonuses := feedbackBonuses(events, "auth middleware order", now)
+
+	if got, want := bonuses["M-fresh"], feedbackMaxBonus*math.Tanh(1); math.Abs(got-want) > 1e-9 {
+		t.Fatalf("fresh matching vote: got %v want %v", got, want)
+	}
+	if bonuses["M-old"] >= bonuses["M-fresh"]/4 {
+		t.Fatalf("expected 90-day-old vote to decay, got %v vs %v", bonuses["M-old"], bonuses["M-fresh"])
+	}
+	if got, want := bonuses["M-other"], feedbackMaxBonus*math.Tanh(feedbackItemWeight); math.Abs(got-want) > 1e-9 {
+		t.Fatalf("unrelated-query vote should count at item weight: got %v want %v", got, want)
+	}
+	if bonuses["M-down"] >= 0 {
+		t.Fatalf("expected negative bonus for down vote, got %v", bonuses["M-down"])
+	}
+}
+
+func TestFeedbackBonusIsBounded(t *testing.T) {
+	now := time.Now().UTC()
+	events := make([]store.Feedback, 0, 50)
+	for i := 0; i < 50; i++ {
+		events = append(events, store.Feedback{ItemID: "M-1", Vote: 1, Query: "q", CreatedAt: now})
+	}
+	bonus := feedbackBonuses(events, "q", now)["M-1"]
+	if bonus <= 0 || bonus > feedbackMaxBonus {
+		t.Fatalf("expected bonus in (0, %v], got %v", feedbackMaxBonus, bonus)
+	}
+}
+
+func TestRankChunksAppliesFeedbackBonus(t *testing.T) {
+	createdAt := time.Now().UTC()
+	results := []store.ChunkResult{
+		{Chunk: store.Chunk{ID: "C-1", CreatedAt: createdAt}, BM25: -2},
+		{Chunk: store.Chunk{ID: "C-2", CreatedAt: createdAt}, BM25: -1},
+	}
+	ranked := rankChunks(results, nil, nil, nil, RankOptions{
+		FeedbackBonuses: map[string]float64{"C-1": -0.5, "C-2": 0.5},
+	})
+	if len(ranked) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(ranked))
+	}
+	if ranked[0].Chunk.ID != "C-2" {
+		t.Fatalf("expected upvoted chunk first, got %s", ranked[0].Chunk.ID)
+	}
+	if ranked[0].FeedbackBonus != 0.5 || ranked[1].FeedbackBonus != -0.5 {
+		t.Fatalf("unexpected feedback bonuses: %v, %v", ranked[0].FeedbackBonus, ranked[1].FeedbackBonus)
+	}
+}
+
+func TestParseFeedbackVote(t *testing.T) {
+	for raw, want := range map[string]int{"up": 1, "UP": 1, "+1": 1, "down": -1, "-1": -1} {
+		got, err := parseFeedbackVote(raw)
+		if err != nil || got != want {
+			t.Fatalf("parseFeedbackVote(%q) = %d, %v; want %d", raw, got, err, want)
+		}
+	}
+	if _, err := parseFeedbackVote("maybe"); err == nil {
+		t.Fatalf("expected invalid vote error")
+	}
+}
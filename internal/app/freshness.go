@@ -0,0 +1,223 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"mem/internal/pack"
+)
+
+// Chunk locators pin line ranges at ingest time. Before handing a chunk to
+// an agent, it is re-anchored in the working tree: first at its recorded
+// lines, then anywhere in the file by exact content, and finally by the most
+// similar window of lines. Only line-range locators are resolved; commits
+// and notebook cells carry no line range to check.
+
+const (
+	freshnessUnchanged = "unchanged"
+	freshnessMoved     = "moved"
+	freshnessModified  = "modified"
+	freshnessDeleted   = "deleted"
+
+	freshnessMinSimilarity = 0.5
+)
+
+var lineLocatorPattern = regexp.MustCompile(`^(?:git:[0-9A-Za-z]+:|file:)(.+)#L(\d+)-L(\d+)(?::.*)?$`)
+
+type chunkResolver struct {
+	root  string
+	files map[string][]string
+}
+
+func newChunkResolver(root string) *chunkResolver {
+	return &chunkResolver{root: root, files: map[string][]string{}}
+}
+
+// resolve reports how the chunk at locator compares with the working tree,
+// or nil when the locator has no line range or there is no tree to check.
+func (r *chunkResolver) resolve(locator, text string) *pack.ChunkFreshness {
+	if r == nil || r.root == "" {
+		return nil
+	}
+	m := lineLocatorPattern.FindStringSubmatch(locator)
+	if m == nil {
+		return nil
+	}
+	relPath := m[1]
+	start, _ := strconv.Atoi(m[2])
+	end, _ := strconv.Atoi(m[3])
+	lines, ok := r.lines(relPath)
+	if !ok {
+		return &pack.ChunkFreshness{Status: freshnessDeleted}
+	}
+
+	want := normalizeChunkLines(text)
+	if len(want) == 0 {
+		return nil
+	}
+	// Text past the locator's range never came from the file, such as the
+	// identifier listing appended to Go package overviews, so only the lines
+	// the range covers are compared.
+	if height := end - start + 1; start >= 1 && height > 0 && len(want) > height {
+		want = want[:height]
+	}
+	at := func(s, e int) string {
+		return fmt.Sprintf("file:%s#L%d-L%d", relPath, s, e)
+	}
+	if start >= 1 && end == start+len(want)-1 && linesEqualAt(lines, want, start-1) {
+		return &pack.ChunkFreshness{Status: freshnessUnchanged, Locator: at(start, end)}
+	}
+
+	// Exact content elsewhere in the file; the occurrence nearest the old
+	// position wins when the chunk repeats.
+	best := -1
+	for i := 0; i+len(want) <= len(lines); i++ {
+		if linesEqualAt(lines, want, i) && (best < 0 || absInt(i+1-start) < absInt(best+1-start)) {
+			best = i
+		}
+	}
+	if best >= 0 {
+		return &pack.ChunkFreshness{Status: freshnessMoved, Locator: at(best+1, best+len(want))}
+	}
+
+	bestStart, score := mostSimilarWindow(lines, want, start-1)
+	if score < freshnessMinSimilarity {
+		return &pack.ChunkFreshness{Status: freshnessDeleted}
+	}
+	windowEnd := bestStart + len(want)
+	if windowEnd > len(lines) {
+		windowEnd = len(lines)
+	}
+	return &pack.ChunkFreshness{
+		Status:     freshnessModified,
+		Locator:    at(bestStart+1, windowEnd),
+		Similarity: math.Round(score*100) / 100,
+	}
+}
+
+func (r *chunkResolver) lines(relPath string) ([]string, bool) {
+	if lines, ok := r.files[relPath]; ok {
+		return lines, lines != nil
+	}
+	path := relPath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.root, filepath.FromSlash(relPath))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		r.files[relPath] = nil
+		return nil, false
+	}
+	lines := normalizeChunkLines(string(data))
+	if lines == nil {
+		lines = []string{}
+	}
+	r.files[relPath] = lines
+	return lines, true
+}
+
+// annotateChunkFreshness sets Freshness on chunks whose locators can be
+// checked against the working tree under root. Pack text may be cut to the
+// per-chunk budget, so each chunk is resolved with its stored text from
+// texts, keyed by chunk ID, when there is one.
+func annotateChunkFreshness(root string, chunks []pack.ChunkItem, texts map[string]string) {
+	if root == "" || len(chunks) == 0 {
+		return
+	}
+	resolver := newChunkResolver(root)
+	for i := range chunks {
+		text, ok := texts[chunks[i].ChunkID]
+		if !ok {
+			text = chunks[i].Text
+		}
+		chunks[i].Freshness = resolver.resolve(chunks[i].Locator, text)
+	}
+}
+
+func normalizeChunkLines(text string) []string {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return lines
+}
+
+func linesEqualAt(lines, want []string, offset int) bool {
+	if offset < 0 || offset+len(want) > len(lines) {
+		return false
+	}
+	for i, line := range want {
+		if lines[offset+i] != line {
+			return false
+		}
+	}
+	return true
+}
+
+// mostSimilarWindow slides a window the chunk's height over lines and
+// returns the start of the window sharing the most non-blank lines with the
+// chunk (Dice coefficient), preferring windows nearer the old start.
+func mostSimilarWindow(lines, want []string, oldStart int) (int, float64) {
+	wantCounts := map[string]int{}
+	wantTotal := 0
+	for _, line := range want {
+		if key := strings.TrimSpace(line); key != "" {
+			wantCounts[key]++
+			wantTotal++
+		}
+	}
+	if wantTotal == 0 || len(lines) == 0 {
+		return 0, 0
+	}
+	height := len(want)
+	if height > len(lines) {
+		height = len(lines)
+	}
+
+	bestStart, bestScore := 0, -1.0
+	for start := 0; start+height <= len(lines); start++ {
+		remaining := make(map[string]int, len(wantCounts))
+		for key, count := range wantCounts {
+			remaining[key] = count
+		}
+		common, windowTotal := 0, 0
+		for _, line := range lines[start : start+height] {
+			key := strings.TrimSpace(line)
+			if key == "" {
+				continue
+			}
+			windowTotal++
+			if remaining[key] > 0 {
+				remaining[key]--
+				common++
+			}
+		}
+		if windowTotal == 0 {
+			continue
+		}
+		score := 2 * float64(common) / float64(wantTotal+windowTotal)
+		if score > bestScore || (score == bestScore && absInt(start-oldStart) < absInt(bestStart-oldStart)) {
+			bestStart, bestScore = start, score
+		}
+	}
+	if bestScore < 0 {
+		return 0, 0
+	}
+	return bestStart, bestScore
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
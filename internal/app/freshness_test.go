@@ -0,0 +1,140 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mem/internal/config"
+	"mem/internal/pack"
+)
+
+func TestChunkResolverStatuses(t *testing.T) {
+	root := t.TempDir()
+	chunk := "func Add(a, b int) int {\n\treturn a + b\n}"
+	writeFile(t, root, "calc.go", "package calc\n\n// Add adds.\n"+chunk+"\n")
+	writeFile(t, root, "moved.go", "package calc\n\nimport \"fmt\"\n\nvar _ = fmt.Sprint\n\n"+chunk+"\n")
+	writeFile(t, root, "edited.go", "package calc\n\n// Add adds.\nfunc Add(a, b int) int {\n\tsum := a + b\n\treturn a + b\n}\n")
+	writeFile(t, root, "gutted.go", "package calc\n\nfunc Sub(a, b int) int { return a - b }\n")
+
+	resolver := newChunkResolver(root)
+	cases := []struct {
+		locator string
+		status  string
+		at      string
+	}{
+		{"git:abc123:calc.go#L4-L6", freshnessUnchanged, "file:calc.go#L4-L6"},
+		{"git:abc123:moved.go#L4-L6", freshnessMoved, "file:moved.go#L7-L9"},
+		{"file:edited.go#L4-L6", freshnessModified, "file:edited.go#L4-L6"},
+		{"file:gutted.go#L4-L6", freshnessDeleted, ""},
+		{"file:missing.go#L4-L6", freshnessDeleted, ""},
+	}
+	for _, tc := range cases {
+		got := resolver.resolve(tc.locator, chunk)
+		if got == nil || got.Status != tc.status || got.Locator != tc.at {
+			t.Fatalf("%s: expected %s at %q, got %+v", tc.locator, tc.status, tc.at, got)
+		}
+	}
+	if got := resolver.resolve("file:edited.go#L4-L6", chunk); got.Similarity <= freshnessMinSimilarity || got.Similarity >= 1 {
+		t.Fatalf("expected a partial similarity for the edited chunk, got %+v", got)
+	}
+	if got := resolver.resolve("git:abc123", chunk); got != nil {
+		t.Fatalf("expected commit locators to be skipped, got %+v", got)
+	}
+	if got := resolver.resolve("file:nb.ipynb#cell-fit", chunk); got != nil {
+		t.Fatalf("expected cell locators to be skipped, got %+v", got)
+	}
+}
+
+func TestChunkResolverComparesOnlyLocatorLines(t *testing.T) {
+	root := t.TempDir()
+	src := "// Package calc does sums.\npackage calc\n\n// Add adds.\nfunc Add(a, b int) int {\n\treturn a + b\n}\n\n// Sub subtracts.\nfunc Sub(a, b int) int {\n\treturn a - b\n}\n"
+	writeFile(t, root, "calc.go", src)
+	chunks, err := chunkFile("calc.go", []byte(src), 400, 0, testTokenCounter(t))
+	if err != nil {
+		t.Fatalf("chunk: %v", err)
+	}
+
+	resolver := newChunkResolver(root)
+	sawPackage := false
+	for _, chunk := range chunks {
+		sawPackage = sawPackage || chunk.SymbolKind == "package"
+		locator := fmt.Sprintf("file:calc.go#L%d-L%d", chunk.StartLine, chunk.EndLine)
+		if got := resolver.resolve(locator, chunk.Text); got == nil || got.Status != freshnessUnchanged {
+			t.Fatalf("%s (%s): expected unchanged, got %+v", locator, chunk.SymbolKind, got)
+		}
+	}
+	if !sawPackage {
+		t.Fatalf("expected a package overview chunk, got %+v", chunks)
+	}
+}
+
+func TestAnnotateChunkFreshnessUsesStoredText(t *testing.T) {
+	root := t.TempDir()
+	full := "func Long() {\n\tstep(1)\n\tstep(2)\n\tstep(3)\n}"
+	writeFile(t, root, "long.go", "package long\n\n"+full+"\n")
+
+	chunks := []pack.ChunkItem{{ChunkID: "C-long", Locator: "file:long.go#L3-L7", Text: "func Long() {\n\tstep(1)"}}
+	annotateChunkFreshness(root, chunks, map[string]string{"C-long": full})
+	if got := chunks[0].Freshness; got == nil || got.Status != freshnessUnchanged {
+		t.Fatalf("expected the stored text to resolve unchanged, got %+v", got)
+	}
+}
+
+func TestShowAndGetReportChunkFreshness(t *testing.T) {
+	base := t.TempDir()
+	setXDGEnv(t, base)
+	repoDir := setupRepo(t, base)
+	withCwd(t, repoDir)
+	writeTestConfig(t, base, func(cfg *config.Config) {
+		cfg.EmbeddingProvider = "none"
+	})
+
+	writeFile(t, repoDir, "quota.go", "package quota\n\n// Refill tops up the quota bucket.\nfunc Refill(bucket int) int {\n\treturn bucket + 10\n}\n")
+	runCLI(t, "ingest", "quota.go", "--thread", "T-fresh")
+
+	var symbols SymbolResponse
+	if err := json.Unmarshal(runCLI(t, "symbol", "Refill"), &symbols); err != nil {
+		t.Fatalf("decode symbol: %v", err)
+	}
+	if len(symbols.Definitions) != 1 {
+		t.Fatalf("expected Refill definition, got %+v", symbols.Definitions)
+	}
+	chunkID := symbols.Definitions[0].ChunkID
+
+	show := func() ShowResponse {
+		t.Helper()
+		var resp ShowResponse
+		if err := json.Unmarshal(runCLI(t, "show", chunkID), &resp); err != nil {
+			t.Fatalf("decode show: %v", err)
+		}
+		return resp
+	}
+	if resp := show(); resp.Chunk == nil || resp.Chunk.Freshness == nil || resp.Chunk.Freshness.Status != freshnessUnchanged {
+		t.Fatalf("expected an unchanged chunk, got %+v", resp.Chunk)
+	}
+
+	writeFile(t, repoDir, "quota.go", "package quota\n\nconst limit = 100\n\n// Refill tops up the quota bucket.\nfunc Refill(bucket int) int {\n\treturn bucket + 10\n}\n")
+	if resp := show(); resp.Chunk.Freshness == nil || resp.Chunk.Freshness.Status != freshnessMoved || resp.Chunk.Freshness.Locator != "file:quota.go#L5-L8" {
+		t.Fatalf("expected the chunk to have moved, got %+v", resp.Chunk.Freshness)
+	}
+
+	var ctx pack.ContextPack
+	if err := json.Unmarshal(runCLI(t, "get", "quota bucket refill", "--format", "json"), &ctx); err != nil {
+		t.Fatalf("decode get: %v", err)
+	}
+	if len(ctx.TopChunks) == 0 || ctx.TopChunks[0].Freshness == nil || ctx.TopChunks[0].Freshness.Status != freshnessMoved {
+		t.Fatalf("expected freshness on top chunks, got %+v", ctx.TopChunks)
+	}
+
+	if err := os.Remove(filepath.Join(repoDir, "quota.go")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	prompt := string(runCLI(t, "get", "quota bucket refill", "--format", "prompt"))
+	if !strings.Contains(prompt, "(deleted in working tree)") {
+		t.Fatalf("expected prompt to flag the deleted chunk, got:\n%s", prompt)
+	}
+}
@@ -0,0 +1,25 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"mem/internal/config"
+	"mem/internal/embed"
+)
+
+const defaultGenerationTimeout = 60 * time.Second
+
+func generationTimeout(cfg config.Config) time.Duration {
+	if cfg.GenerationTimeoutMS <= 0 {
+		return defaultGenerationTimeout
+	}
+	return time.Duration(cfg.GenerationTimeoutMS) * time.Millisecond
+}
+
+// generateText runs one prompt against generator within generation_timeout_ms.
+func generateText(cfg config.Config, generator embed.Generator, prompt string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), generationTimeout(cfg))
+	defer cancel()
+	return generator.Generate(ctx, prompt)
+}
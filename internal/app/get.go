@@ -24,6 +24,7 @@ type getTimings struct {
 	FTSChunksFetch       time.Duration
 	OrphanFilter         time.Duration
 	ThreadMatch          time.Duration
+	Rerank               time.Duration
 	TokenizerInit        time.Duration
 	Budget               time.Duration
 	JSONEncode           time.Duration
@@ -45,6 +46,9 @@ func runGet(args []string, out, errOut io.Writer) int {
 	workspace := fs.String("workspace", "", "Workspace name")
 	includeOrphans := fs.Bool("include-orphans", false, "Include orphaned memories")
 	cluster := fs.Bool("cluster", false, "Group similar memories into clusters")
+	mmr := fs.Bool("mmr", false, "Diversify the pack with maximal marginal relevance")
+	mmrLambda := fs.Float64("mmr-lambda", 0, "MMR relevance weight in (0,1]")
+	related := fs.Bool("related", false, "Add chunks defining what the pack's chunks call or reference")
 	debug := fs.Bool("debug", false, "Print timing breakdown to stderr")
 	positional, flagArgs, err := splitFlagArgs(args, map[string]flagSpec{
 		"format":          {RequiresValue: true},
@@ -52,6 +56,9 @@ func runGet(args []string, out, errOut io.Writer) int {
 		"workspace":       {RequiresValue: true},
 		"include-orphans": {RequiresValue: false},
 		"cluster":         {RequiresValue: false},
+		"mmr":             {RequiresValue: false},
+		"mmr-lambda":      {RequiresValue: true},
+		"related":         {RequiresValue: false},
 		"debug":           {RequiresValue: false},
 	})
 	if err != nil {
@@ -71,6 +78,10 @@ func runGet(args []string, out, errOut io.Writer) int {
 		fmt.Fprintf(errOut, "unsupported format: %s\n", *format)
 		return 2
 	}
+	if *mmrLambda < 0 || *mmrLambda > 1 {
+		fmt.Fprintln(errOut, "--mmr-lambda must be between 0 and 1")
+		return 2
+	}
 
 	includeRawChunks := strings.TrimSpace(*format) == "prompt"
 	var timings getTimings
@@ -80,6 +91,10 @@ func runGet(args []string, out, errOut io.Writer) int {
 		IncludeOrphans:   *includeOrphans,
 		IncludeRawChunks: includeRawChunks,
 		ClusterMemories:  *cluster,
+		MMR:              *mmr,
+		MMRLambda:        *mmrLambda,
+		RelatedChunks:    *related,
+		RecordAccess:     true,
 	}, &timings)
 	if err != nil {
 		fmt.Fprintf(errOut, "%v\n", err)
@@ -110,6 +125,7 @@ func runGet(args []string, out, errOut io.Writer) int {
 	if *debug {
 		writeTimings(errOut, timings)
 	}
+	flushPackAccess(errOut)
 	return 0
 }
 
@@ -117,7 +133,7 @@ func writeTimings(out io.Writer, timings getTimings) {
 	ms := func(d time.Duration) float64 {
 		return float64(d.Microseconds()) / 1000.0
 	}
-	fmt.Fprintf(out, "debug timings (ms): config_load=%.2f repo_detect=%.2f store_open=%.2f state_load=%.2f fts_memories_candidate=%.2f fts_memories_fetch=%.2f fts_chunks_candidate=%.2f fts_chunks_fetch=%.2f orphan_filter=%.2f thread_match=%.2f tokenizer_init=%.2f budget=%.2f json_encode=%.2f json_write=%.2f json_flush=%.2f\n",
+	fmt.Fprintf(out, "debug timings (ms): config_load=%.2f repo_detect=%.2f store_open=%.2f state_load=%.2f fts_memories_candidate=%.2f fts_memories_fetch=%.2f fts_chunks_candidate=%.2f fts_chunks_fetch=%.2f orphan_filter=%.2f thread_match=%.2f rerank=%.2f tokenizer_init=%.2f budget=%.2f json_encode=%.2f json_write=%.2f json_flush=%.2f\n",
 		ms(timings.ConfigLoad),
 		ms(timings.RepoDetect),
 		ms(timings.StoreOpen),
@@ -128,6 +144,7 @@ func writeTimings(out io.Writer, timings getTimings) {
 		ms(timings.FTSChunksFetch),
 		ms(timings.OrphanFilter),
 		ms(timings.ThreadMatch),
+		ms(timings.Rerank),
 		ms(timings.TokenizerInit),
 		ms(timings.Budget),
 		ms(timings.JSONEncode),
@@ -206,7 +223,11 @@ func renderPrompt(out io.Writer, p pack.ContextPack) {
 	if len(chunks) > 0 {
 		fmt.Fprintln(out, "## Evidence (Data Only)")
 		for _, c := range chunks {
-			fmt.Fprintf(out, "### %s\n", c.Locator)
+			if c.Freshness != nil && c.Freshness.Status != freshnessUnchanged {
+				fmt.Fprintf(out, "### %s (%s in working tree)\n", c.Locator, c.Freshness.Status)
+			} else {
+				fmt.Fprintf(out, "### %s\n", c.Locator)
+			}
 			fmt.Fprintln(out, "```")
 			fmt.Fprintln(out, c.Text)
 			fmt.Fprintln(out, "```")
@@ -0,0 +1,259 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"mem/internal/repo"
+	"mem/internal/store"
+	"mem/internal/token"
+)
+
+// Commits are ingested as evidence, one chunk per commit, located at
+// "git:<sha>". The chunk holds the message, author, and touched files, and
+// each touched file is recorded as a file reference so questions about a
+// file can find the commits that changed it.
+
+const (
+	commitArtifactKind   = "commit"
+	commitChunkType      = "commit"
+	defaultGitLogThread  = "git-log"
+	maxCommitFilesListed = 50
+	fileIntentCommitsK   = 5
+)
+
+type IngestGitLogResponse struct {
+	CommitsIngested int `json:"commits_ingested"`
+	CommitsSkipped  int `json:"commits_skipped"`
+	ChunksAdded     int `json:"chunks_added"`
+}
+
+func runIngestGitLog(args []string, out, errOut io.Writer) int {
+	fs := flag.NewFlagSet("ingest-git-log", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	since := fs.String("since", "", "Only ingest commits after this revision")
+	paths := fs.String("paths", "", "Comma-separated paths; only commits touching them are ingested")
+	diffStat := fs.Bool("diffstat", false, "Include per-file added/deleted line counts")
+	threadID := fs.String("thread", defaultGitLogThread, "Thread id")
+	repoOverride := fs.String("repo", "", "Override repo id")
+	workspace := fs.String("workspace", "", "Workspace name")
+	positional, flagArgs, err := splitFlagArgs(args, map[string]flagSpec{
+		"since":     {RequiresValue: true},
+		"paths":     {RequiresValue: true},
+		"diffstat":  {RequiresValue: false},
+		"thread":    {RequiresValue: true},
+		"repo":      {RequiresValue: true},
+		"workspace": {RequiresValue: true},
+	})
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 2
+	}
+	if err := fs.Parse(flagArgs); err != nil {
+		return 2
+	}
+	if len(positional) > 0 {
+		fmt.Fprintln(errOut, "usage: mem ingest-git-log [--since <rev>] [--paths <a,b>] [--diffstat]")
+		return 2
+	}
+	if strings.TrimSpace(*threadID) == "" {
+		fmt.Fprintln(errOut, "--thread must not be empty")
+		return 2
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(errOut, "config error: %v\n", err)
+		return 1
+	}
+	workspaceName := resolveWorkspace(cfg, strings.TrimSpace(*workspace))
+	repoInfo, err := resolveRepo(&cfg, strings.TrimSpace(*repoOverride))
+	if err != nil {
+		fmt.Fprintf(errOut, "repo detection error: %v\n", err)
+		return 1
+	}
+	if !repoInfo.HasGit || repoInfo.GitRoot == "" {
+		fmt.Fprintln(errOut, "ingest-git-log requires a git repository")
+		return 1
+	}
+	counter, err := token.New(cfg.Tokenizer)
+	if err != nil {
+		fmt.Fprintf(errOut, "tokenizer error: %v\n", err)
+		return 1
+	}
+
+	commits, err := repo.Log(repoInfo.GitRoot, repo.LogOptions{
+		Since:    strings.TrimSpace(*since),
+		Paths:    gitLogPaths(*paths),
+		DiffStat: *diffStat,
+	})
+	if err != nil {
+		fmt.Fprintf(errOut, "git log error: %v\n", err)
+		return 1
+	}
+
+	st, err := openStore(cfg, repoInfo.ID)
+	if err != nil {
+		fmt.Fprintf(errOut, "store open error: %v\n", err)
+		return 1
+	}
+	defer st.Close()
+
+	resp, err := ingestCommits(st, counter, repoInfo.ID, workspaceName, strings.TrimSpace(*threadID), commits)
+	if err != nil {
+		fmt.Fprintf(errOut, "ingest error: %v\n", err)
+		return 1
+	}
+	return writeJSON(out, errOut, resp)
+}
+
+// ingestCommits stores each commit not ingested before as a commit artifact
+// with a single chunk. Commits are recognized by their git:<sha> source, so
+// re-running over the same history only adds new commits.
+func ingestCommits(st *store.Store, counter *token.Counter, repoID, workspace, threadID string, commits []repo.Commit) (IngestGitLogResponse, error) {
+	var resp IngestGitLogResponse
+	known, err := st.ListArtifactSources(repoID, workspace, commitArtifactKind)
+	if err != nil {
+		return resp, err
+	}
+	seen := make(map[string]struct{}, len(known))
+	for _, source := range known {
+		seen[source] = struct{}{}
+	}
+
+	// git log lists newest first; oldest-first inserts keep created order
+	// aligned with history.
+	for i := len(commits) - 1; i >= 0; i-- {
+		commit := commits[i]
+		locator := "git:" + commit.SHA
+		if _, ok := seen[locator]; ok {
+			resp.CommitsSkipped++
+			continue
+		}
+		seen[locator] = struct{}{}
+
+		text := formatCommitText(commit)
+		textHash := sha256.Sum256([]byte(text))
+		createdAt := commit.Date
+		if createdAt.IsZero() {
+			createdAt = time.Now().UTC()
+		}
+		refs := make([]store.ChunkRef, 0, len(commit.Files))
+		for _, file := range commit.Files {
+			refs = append(refs, store.ChunkRef{Kind: store.RefKindFile, Name: file.Path})
+		}
+		artifact := store.Artifact{
+			ID:          store.NewID("A"),
+			RepoID:      repoID,
+			Workspace:   workspace,
+			Kind:        commitArtifactKind,
+			Source:      locator,
+			ContentHash: commit.SHA,
+			CreatedAt:   time.Now().UTC(),
+		}
+		chunk := store.Chunk{
+			ID:         store.NewID("C"),
+			RepoID:     repoID,
+			Workspace:  workspace,
+			ArtifactID: artifact.ID,
+			ThreadID:   threadID,
+			Locator:    locator,
+			Text:       text,
+			TextHash:   hex.EncodeToString(textHash[:]),
+			TextTokens: counter.Count(text),
+			ChunkType:  commitChunkType,
+			Refs:       refs,
+			TagsJSON:   "[]",
+			TagsText:   "",
+			CreatedAt:  createdAt,
+		}
+		inserted, _, err := st.AddArtifactWithChunks(artifact, []store.Chunk{chunk})
+		if err != nil {
+			return resp, err
+		}
+		resp.CommitsIngested++
+		resp.ChunksAdded += inserted
+	}
+	return resp, nil
+}
+
+func gitLogPaths(value string) []string {
+	var paths []string
+	for _, part := range strings.Split(value, ",") {
+		if path := strings.TrimSpace(part); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+func formatCommitText(commit repo.Commit) string {
+	var b strings.Builder
+	b.WriteString(commit.Subject)
+	if commit.Body != "" {
+		b.WriteString("\n\n")
+		b.WriteString(commit.Body)
+	}
+	b.WriteString("\n\ncommit ")
+	b.WriteString(commit.SHA)
+	if commit.Author != "" {
+		fmt.Fprintf(&b, "\nAuthor: %s", commit.Author)
+		if commit.AuthorEmail != "" {
+			fmt.Fprintf(&b, " <%s>", commit.AuthorEmail)
+		}
+	}
+	if !commit.Date.IsZero() {
+		fmt.Fprintf(&b, "\nDate: %s", commit.Date.Format(time.RFC3339))
+	}
+	if len(commit.Files) > 0 {
+		b.WriteString("\nFiles:")
+		for i, file := range commit.Files {
+			if i == maxCommitFilesListed {
+				fmt.Fprintf(&b, "\n  ... and %d more", len(commit.Files)-maxCommitFilesListed)
+				break
+			}
+			b.WriteString("\n  ")
+			b.WriteString(file.Path)
+			switch {
+			case file.Added < 0 || file.Deleted < 0:
+				b.WriteString(" (binary)")
+			case file.Added > 0 || file.Deleted > 0:
+				fmt.Fprintf(&b, " (+%d -%d)", file.Added, file.Deleted)
+			}
+		}
+	}
+	return b.String()
+}
+
+// fileIntentCommitChunks returns commit chunks that touched the files named
+// in a file-intent query, best reference match first. Ranking fuses them as
+// their own list alongside the FTS and vector results.
+func fileIntentCommitChunks(st *store.Store, repoID, workspace string, parsed store.ParsedQuery) ([]store.Chunk, error) {
+	if parsed.Intent != store.IntentFile {
+		return nil, nil
+	}
+	seen := make(map[string]struct{})
+	var commits []store.Chunk
+	for _, entity := range parsed.Entities {
+		if entity.Type != "file" {
+			continue
+		}
+		refs, err := st.FindChunkRefs(repoID, workspace, entity.Value, []string{store.RefKindFile}, fileIntentCommitsK)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range refs {
+			if _, ok := seen[ref.ID]; ok || ref.ChunkType != commitChunkType {
+				continue
+			}
+			seen[ref.ID] = struct{}{}
+			commits = append(commits, ref.Chunk)
+		}
+	}
+	return commits, nil
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mem/internal/config"
+	"mem/internal/pack"
+	"mem/internal/store"
+)
+
+func TestIngestGitLogIsIncrementalAndSurfacesForFileQueries(t *testing.T) {
+	base := t.TempDir()
+	setXDGEnv(t, base)
+	repoDir := setupRepo(t, base)
+	withCwd(t, repoDir)
+	writeTestConfig(t, base, func(cfg *config.Config) {
+		cfg.EmbeddingProvider = "none"
+	})
+
+	writeFile(t, repoDir, "calc.go", "package calc\n\nfunc Add(a, b int) int { return a + b }\n")
+	runGit(t, repoDir, "add", "calc.go")
+	runGit(t, repoDir, "commit", "-m", "Add calculator", "-m", "Callers needed integer addition without overflow checks.")
+	sha := strings.TrimSpace(runGitOutput(t, repoDir, "rev-parse", "HEAD"))
+
+	var resp IngestGitLogResponse
+	if err := json.Unmarshal(runCLI(t, "ingest-git-log", "--diffstat"), &resp); err != nil {
+		t.Fatalf("decode ingest-git-log: %v", err)
+	}
+	if resp.CommitsIngested != 2 || resp.ChunksAdded != 2 || resp.CommitsSkipped != 0 {
+		t.Fatalf("unexpected first run %+v", resp)
+	}
+	resp = IngestGitLogResponse{}
+	if err := json.Unmarshal(runCLI(t, "ingest-git-log"), &resp); err != nil {
+		t.Fatalf("decode second run: %v", err)
+	}
+	if resp.CommitsIngested != 0 || resp.CommitsSkipped != 2 {
+		t.Fatalf("expected already ingested commits to be skipped, got %+v", resp)
+	}
+
+	var refs RefsResponse
+	if err := json.Unmarshal(runCLI(t, "refs", "calc.go", "--kind", "file"), &refs); err != nil {
+		t.Fatalf("decode refs: %v", err)
+	}
+	if len(refs.References) != 1 || refs.References[0].Locator != "git:"+sha {
+		t.Fatalf("expected the calculator commit, got %+v", refs.References)
+	}
+
+	var ctx pack.ContextPack
+	if err := json.Unmarshal(runCLI(t, "get", "history of calc.go", "--format", "json"), &ctx); err != nil {
+		t.Fatalf("decode get: %v", err)
+	}
+	if ctx.SearchMeta.Intent != "file" {
+		t.Fatalf("expected file intent, got %q", ctx.SearchMeta.Intent)
+	}
+	found := false
+	for _, chunk := range ctx.TopChunks {
+		if chunk.Locator == "git:"+sha {
+			found = strings.Contains(chunk.Text, "without overflow checks") && strings.Contains(chunk.Text, "calc.go (+3 -0)")
+		}
+	}
+	if !found {
+		t.Fatalf("expected the commit touching calc.go in top chunks, got %+v", ctx.TopChunks)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("config error: %v", err)
+	}
+	repoInfo, err := resolveRepo(&cfg, "")
+	if err != nil {
+		t.Fatalf("repo detection error: %v", err)
+	}
+	st, err := openStore(cfg, repoInfo.ID)
+	if err != nil {
+		t.Fatalf("store open error: %v", err)
+	}
+	defer st.Close()
+
+	extra, err := fileIntentCommitChunks(st, repoInfo.ID, "", store.ParseQuery("who owns calc.go"))
+	if err != nil {
+		t.Fatalf("file intent lookup: %v", err)
+	}
+	if len(extra) != 1 || extra[0].Locator != "git:"+sha {
+		t.Fatalf("expected the commit from the file reference index, got %+v", extra)
+	}
+	if extra, _ := fileIntentCommitChunks(st, repoInfo.ID, "", store.ParseQuery("overflow checks")); len(extra) != 0 {
+		t.Fatalf("expected no commits for a non-file query, got %+v", extra)
+	}
+}
+
+func TestFileIntentMatchesCommitsByBareFilename(t *testing.T) {
+	base := t.TempDir()
+	setXDGEnv(t, base)
+	repoDir := setupRepo(t, base)
+	withCwd(t, repoDir)
+	writeTestConfig(t, base, func(cfg *config.Config) {
+		cfg.EmbeddingProvider = "none"
+	})
+
+	writeFile(t, repoDir, "main.go", "package main\n\nfunc main() {}\n")
+	runGit(t, repoDir, "add", "main.go")
+	runGit(t, repoDir, "commit", "-m", "Add entry point")
+	mainSHA := strings.TrimSpace(runGitOutput(t, repoDir, "rev-parse", "HEAD"))
+	if err := os.MkdirAll(filepath.Join(repoDir, "internal", "app"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeFile(t, repoDir, "internal/app/ingest.go", "package app\n\nfunc Ingest() {}\n")
+	runGit(t, repoDir, "add", "internal/app/ingest.go")
+	runGit(t, repoDir, "commit", "-m", "Add ingest")
+	ingestSHA := strings.TrimSpace(runGitOutput(t, repoDir, "rev-parse", "HEAD"))
+	runCLI(t, "ingest-git-log")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("config error: %v", err)
+	}
+	repoInfo, err := resolveRepo(&cfg, "")
+	if err != nil {
+		t.Fatalf("repo detection error: %v", err)
+	}
+	st, err := openStore(cfg, repoInfo.ID)
+	if err != nil {
+		t.Fatalf("store open error: %v", err)
+	}
+	defer st.Close()
+
+	for query, want := range map[string]string{
+		"why did ingest.go change":              "git:" + ingestSHA,
+		"why did internal/app/ingest.go change": "git:" + ingestSHA,
+		"history of main.go":                    "git:" + mainSHA,
+	} {
+		extra, err := fileIntentCommitChunks(st, repoInfo.ID, "", store.ParseQuery(query))
+		if err != nil {
+			t.Fatalf("file intent lookup %q: %v", query, err)
+		}
+		if len(extra) != 1 || extra[0].Locator != want {
+			t.Fatalf("%q: expected only %s, got %+v", query, want, extra)
+		}
+	}
+}
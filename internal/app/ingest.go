@@ -1,8 +1,9 @@
 package app
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -11,7 +12,6 @@ import (
 	"path/filepath"
 	"strings"
 	"syscall"
-	"time"
 
 	"mem/internal/config"
 	"mem/internal/repo"
@@ -22,10 +22,40 @@ import (
 	ignore "github.com/sabhiram/go-gitignore"
 )
 
+// IngestResponse summarizes an ingest run. FilesIngested counts files whose
+// chunks were written, FilesUpdated the subset that replaced an earlier
+// version, and FilesUnchanged files whose content hash matched the last
+// ingest. FilesRemoved counts sources tombstoned because they are gone.
 type IngestResponse struct {
-	FilesIngested int `json:"files_ingested"`
-	ChunksAdded   int `json:"chunks_added"`
-	FilesSkipped  int `json:"files_skipped"`
+	FilesIngested  int `json:"files_ingested"`
+	ChunksAdded    int `json:"chunks_added"`
+	FilesSkipped   int `json:"files_skipped"`
+	FilesUnchanged int `json:"files_unchanged"`
+	FilesUpdated   int `json:"files_updated"`
+	FilesRemoved   int `json:"files_removed"`
+}
+
+// IngestExplainResponse describes how ingest would chunk one file.
+type IngestExplainResponse struct {
+	Path          string               `json:"path"`
+	Skipped       string               `json:"skipped,omitempty"`
+	Chunker       string               `json:"chunker,omitempty"`
+	Strategy      string               `json:"strategy,omitempty"`
+	Rule          string               `json:"rule,omitempty"`
+	Fallback      bool                 `json:"fallback,omitempty"`
+	ChunkTokens   int                  `json:"chunk_tokens,omitempty"`
+	OverlapTokens int                  `json:"overlap_tokens,omitempty"`
+	Chunks        []IngestExplainChunk `json:"chunks"`
+}
+
+type IngestExplainChunk struct {
+	StartLine  int    `json:"start_line"`
+	EndLine    int    `json:"end_line"`
+	Tokens     int    `json:"tokens"`
+	ChunkType  string `json:"chunk_type"`
+	SymbolName string `json:"symbol_name,omitempty"`
+	SymbolKind string `json:"symbol_kind,omitempty"`
+	Anchor     string `json:"anchor,omitempty"`
 }
 
 type ignoreMatcher struct {
@@ -41,16 +71,27 @@ func (m ignoreMatcher) Matches(path string) bool {
 	return false
 }
 
+const (
+	defaultIngestMaxFileMB     = 2
+	defaultIngestChunkTokens   = 320
+	defaultIngestOverlapTokens = 40
+)
+
 func runIngest(args []string, out, errOut io.Writer) int {
 	fs := flag.NewFlagSet("ingest-artifact", flag.ContinueOnError)
 	fs.SetOutput(errOut)
 	threadID := fs.String("thread", "", "Thread id")
 	repoOverride := fs.String("repo", "", "Override repo id")
 	workspace := fs.String("workspace", "", "Workspace name")
-	maxFileMB := fs.Int("max-file-mb", 2, "Max file size (MB)")
-	chunkTokens := fs.Int("chunk-tokens", 320, "Chunk size (tokens)")
-	overlapTokens := fs.Int("overlap-tokens", 40, "Chunk overlap (tokens)")
+	maxFileMB := fs.Int("max-file-mb", defaultIngestMaxFileMB, "Max file size (MB)")
+	chunkTokens := fs.Int("chunk-tokens", defaultIngestChunkTokens, "Chunk size (tokens)")
+	overlapTokens := fs.Int("overlap-tokens", defaultIngestOverlapTokens, "Chunk overlap (tokens)")
 	watch := fs.Bool("watch", false, "Watch for file changes and auto-ingest")
+	explain := fs.String("explain", "", "Print the chunks a file would produce without ingesting it")
+	since := fs.String("since", "", "Only ingest paths changed since this commit")
+	force := fs.Bool("force", false, "Re-chunk files even when their content is unchanged")
+	workers := fs.Int("workers", 0, "Files read and chunked in parallel (default: number of CPUs)")
+	progressMode := fs.String("progress", ingestProgressText, "Progress output: text (stderr), json (stdout), or none")
 	positional, flagArgs, err := splitFlagArgs(args, map[string]flagSpec{
 		"thread":         {RequiresValue: true},
 		"repo":           {RequiresValue: true},
@@ -59,6 +100,11 @@ func runIngest(args []string, out, errOut io.Writer) int {
 		"chunk-tokens":   {RequiresValue: true},
 		"overlap-tokens": {RequiresValue: true},
 		"watch":          {RequiresValue: false},
+		"explain":        {RequiresValue: true},
+		"since":          {RequiresValue: true},
+		"force":          {RequiresValue: false},
+		"workers":        {RequiresValue: true},
+		"progress":       {RequiresValue: true},
 	})
 	if err != nil {
 		fmt.Fprintln(errOut, err.Error())
@@ -69,14 +115,44 @@ func runIngest(args []string, out, errOut io.Writer) int {
 	}
 
 	pathArg := strings.TrimSpace(strings.Join(positional, " "))
+	explainPath := strings.TrimSpace(*explain)
+	if explainPath != "" {
+		if pathArg != "" || *watch {
+			fmt.Fprintln(errOut, "--explain takes a single file and cannot be combined with a path or --watch")
+			return 2
+		}
+		pathArg = explainPath
+	}
 	if pathArg == "" {
 		fmt.Fprintln(errOut, "missing path")
 		return 2
 	}
-	if strings.TrimSpace(*threadID) == "" {
+	sinceCommit := strings.TrimSpace(*since)
+	if sinceCommit != "" && (*watch || explainPath != "") {
+		fmt.Fprintln(errOut, "--since cannot be combined with --watch or --explain")
+		return 2
+	}
+	if explainPath == "" && strings.TrimSpace(*threadID) == "" {
 		fmt.Fprintln(errOut, "missing --thread")
 		return 2
 	}
+	if *workers < 0 {
+		fmt.Fprintln(errOut, "--workers must not be negative")
+		return 2
+	}
+	progress := strings.TrimSpace(*progressMode)
+	switch progress {
+	case ingestProgressText, ingestProgressJSON, ingestProgressNone:
+	default:
+		fmt.Fprintf(errOut, "invalid --progress %q (want text, json, or none)\n", progress)
+		return 2
+	}
+	fixedSizes := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "chunk-tokens" || f.Name == "overlap-tokens" {
+			fixedSizes = true
+		}
+	})
 
 	cfg, err := loadConfig()
 	if err != nil {
@@ -91,13 +167,6 @@ func runIngest(args []string, out, errOut io.Writer) int {
 		return 1
 	}
 
-	st, err := openStore(cfg, repoInfo.ID)
-	if err != nil {
-		fmt.Fprintf(errOut, "store open error: %v\n", err)
-		return 1
-	}
-	defer st.Close()
-
 	counter, err := token.New(cfg.Tokenizer)
 	if err != nil {
 		fmt.Fprintf(errOut, "tokenizer error: %v\n", err)
@@ -121,6 +190,10 @@ func runIngest(args []string, out, errOut io.Writer) int {
 	if absRoot, err := filepath.Abs(root); err == nil {
 		root = absRoot
 	}
+	if sinceCommit != "" && repoInfo.GitRoot == "" {
+		fmt.Fprintln(errOut, "--since requires a git repository")
+		return 1
+	}
 	if absPath, err := filepath.Abs(pathArg); err == nil {
 		pathArg = absPath
 	}
@@ -128,29 +201,62 @@ func runIngest(args []string, out, errOut io.Writer) int {
 	matcher := loadIgnoreMatcher(root)
 	maxBytes := int64(*maxFileMB) * 1024 * 1024
 
+	repoCfg, _, err := config.LoadRepoConfig(root)
+	if err != nil {
+		fmt.Fprintf(errOut, "repo config error: %v\n", err)
+		return 1
+	}
+	chunkers, err := newChunkerSelector(repoCfg, *chunkTokens, *overlapTokens, fixedSizes)
+	if err != nil {
+		fmt.Fprintf(errOut, "repo config error: %v\n", err)
+		return 1
+	}
+
+	if explainPath != "" {
+		if info.IsDir() {
+			fmt.Fprintln(errOut, "--explain expects a file, not a directory")
+			return 2
+		}
+		resp, err := explainIngestFile(pathArg, relPathFor(root, pathArg), matcher, maxBytes, chunkers, counter)
+		if err != nil {
+			fmt.Fprintf(errOut, "explain error: %v\n", err)
+			return 1
+		}
+		return writeJSON(out, errOut, resp)
+	}
+
+	st, err := openStore(cfg, repoInfo.ID)
+	if err != nil {
+		fmt.Fprintf(errOut, "store open error: %v\n", err)
+		return 1
+	}
+	defer st.Close()
+
 	ingestParams := ingestPathParams{
-		path:          pathArg,
-		root:          root,
-		matcher:       matcher,
-		repoInfo:      repoInfo,
-		workspace:     workspaceName,
-		threadID:      strings.TrimSpace(*threadID),
-		maxBytes:      maxBytes,
-		chunkTokens:   *chunkTokens,
-		overlapTokens: *overlapTokens,
-		st:            st,
-		counter:       counter,
+		path:      pathArg,
+		root:      root,
+		matcher:   matcher,
+		repoInfo:  repoInfo,
+		workspace: workspaceName,
+		threadID:  strings.TrimSpace(*threadID),
+		maxBytes:  maxBytes,
+		chunkers:  chunkers,
+		st:        st,
+		counter:   counter,
+		since:     sinceCommit,
+		force:     *force,
+		workers:   *workers,
 	}
 
 	if *watch {
-		resp, err := ingestPath(ingestParams)
+		resp, err := ingestPath(context.Background(), ingestParams)
 		if err != nil {
 			fmt.Fprintf(errOut, "ingest error: %v\n", err)
 			return 1
 		}
-		if resp.FilesIngested > 0 || resp.ChunksAdded > 0 || resp.FilesSkipped > 0 {
-			fmt.Fprintf(out, "Initial ingest: files=%d chunks=%d skipped=%d\n",
-				resp.FilesIngested, resp.ChunksAdded, resp.FilesSkipped)
+		if resp.FilesIngested > 0 || resp.ChunksAdded > 0 || resp.FilesSkipped > 0 || resp.FilesUnchanged > 0 || resp.FilesRemoved > 0 {
+			fmt.Fprintf(out, "Initial ingest: files=%d chunks=%d skipped=%d unchanged=%d removed=%d\n",
+				resp.FilesIngested, resp.ChunksAdded, resp.FilesSkipped, resp.FilesUnchanged, resp.FilesRemoved)
 		}
 
 		watchFile := ""
@@ -158,219 +264,169 @@ func runIngest(args []string, out, errOut io.Writer) int {
 			watchFile = relPathFor(root, pathArg)
 		}
 		return runIngestWatch(runIngestWatchParams{
-			root:          root,
-			watchFile:     watchFile,
-			repoInfo:      repoInfo,
-			workspace:     workspaceName,
-			threadID:      strings.TrimSpace(*threadID),
-			maxBytes:      maxBytes,
-			chunkTokens:   *chunkTokens,
-			overlapTokens: *overlapTokens,
-			st:            st,
-			counter:       counter,
-			matcher:       matcher,
-			out:           out,
-			errOut:        errOut,
+			root:      root,
+			watchFile: watchFile,
+			repoInfo:  repoInfo,
+			workspace: workspaceName,
+			threadID:  strings.TrimSpace(*threadID),
+			maxBytes:  maxBytes,
+			chunkers:  chunkers,
+			st:        st,
+			counter:   counter,
+			matcher:   matcher,
+			out:       out,
+			errOut:    errOut,
 		})
 	}
 
-	resp, err := ingestPath(ingestParams)
-	if err != nil {
+	// On SIGINT the workers stop picking up files, whatever was already
+	// chunked is written, and the partial summary is still reported.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if progress == ingestProgressText {
+		ingestParams.progress = newIngestProgress(progress, errOut)
+	} else {
+		ingestParams.progress = newIngestProgress(progress, out)
+	}
+	resp, err := ingestPath(ctx, ingestParams)
+	interrupted := errors.Is(err, context.Canceled)
+	if err != nil && !interrupted {
 		fmt.Fprintf(errOut, "ingest error: %v\n", err)
 		return 1
 	}
-	return writeJSON(out, errOut, resp)
+	code := writeIngestResponse(out, errOut, resp, progress == ingestProgressJSON)
+	if interrupted {
+		fmt.Fprintln(errOut, "ingest interrupted")
+		return 1
+	}
+	return code
+}
+
+// writeIngestResponse writes the ingest summary; with --progress json it is
+// a single compact line following the progress events.
+func writeIngestResponse(out, errOut io.Writer, resp IngestResponse, compact bool) int {
+	if !compact {
+		return writeJSON(out, errOut, resp)
+	}
+	encoded, err := json.Marshal(resp)
+	if err != nil {
+		fmt.Fprintf(errOut, "json error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(out, string(encoded))
+	return 0
 }
 
 type ingestPathParams struct {
-	path           string
-	root           string
-	matcher        ignoreMatcher
-	repoInfo       repo.Info
-	workspace      string
-	threadID       string
-	maxBytes       int64
-	chunkTokens    int
-	overlapTokens  int
-	st             *store.Store
-	counter        *token.Counter
-	deleteExisting bool
+	path      string
+	root      string
+	matcher   ignoreMatcher
+	repoInfo  repo.Info
+	workspace string
+	threadID  string
+	maxBytes  int64
+	chunkers  chunkerSelector
+	st        *store.Store
+	counter   *token.Counter
+	since     string
+	force     bool
+	workers   int
+	progress  *ingestProgress
 }
 
 type ingestSingleFileParams struct {
-	path           string
-	relPath        string
-	repoInfo       repo.Info
-	workspace      string
-	threadID       string
-	maxBytes       int64
-	chunkTokens    int
-	overlapTokens  int
-	st             *store.Store
-	counter        *token.Counter
-	deleteExisting bool
+	path      string
+	relPath   string
+	repoInfo  repo.Info
+	workspace string
+	threadID  string
+	maxBytes  int64
+	chunkers  chunkerSelector
+	st        *store.Store
+	counter   *token.Counter
+	force     bool
 }
 
 type runIngestWatchParams struct {
-	root          string
-	watchFile     string
-	repoInfo      repo.Info
-	workspace     string
-	threadID      string
-	maxBytes      int64
-	chunkTokens   int
-	overlapTokens int
-	st            *store.Store
-	counter       *token.Counter
-	matcher       ignoreMatcher
-	out           io.Writer
-	errOut        io.Writer
+	root      string
+	watchFile string
+	repoInfo  repo.Info
+	workspace string
+	threadID  string
+	maxBytes  int64
+	chunkers  chunkerSelector
+	st        *store.Store
+	counter   *token.Counter
+	matcher   ignoreMatcher
+	out       io.Writer
+	errOut    io.Writer
 }
 
-func ingestPath(p ingestPathParams) (IngestResponse, error) {
-	info, err := os.Stat(p.path)
-	if err != nil {
-		return IngestResponse{}, err
-	}
-	var resp IngestResponse
-
-	processFile := func(path string) error {
-		relPath := relPathFor(p.root, path)
-		if p.matcher.Matches(relPath) {
-			resp.FilesSkipped++
-			return nil
-		}
-		if !allowedExtension(path) {
-			resp.FilesSkipped++
-			return nil
-		}
-
-		fileResp, err := ingestSingleFile(ingestSingleFileParams{
-			path:           path,
-			relPath:        relPath,
-			repoInfo:       p.repoInfo,
-			workspace:      p.workspace,
-			threadID:       p.threadID,
-			maxBytes:       p.maxBytes,
-			chunkTokens:    p.chunkTokens,
-			overlapTokens:  p.overlapTokens,
-			st:             p.st,
-			counter:        p.counter,
-			deleteExisting: p.deleteExisting,
-		})
-		if err != nil {
-			return err
-		}
-		resp.FilesIngested += fileResp.FilesIngested
-		resp.ChunksAdded += fileResp.ChunksAdded
-		resp.FilesSkipped += fileResp.FilesSkipped
-		return nil
+// ingestPrefix is the source prefix covered by ingesting path: "" for the
+// root itself, otherwise path as relPathFor records it.
+func ingestPrefix(root, path string) string {
+	if filepath.Clean(root) == filepath.Clean(path) {
+		return ""
 	}
+	return relPathFor(root, path)
+}
 
-	if info.IsDir() {
-		if err := filepath.WalkDir(p.path, func(path string, d os.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
-			if d.IsDir() {
-				relPath := relPathFor(p.root, path)
-				if d.Name() == ".git" || p.matcher.Matches(relPath) {
-					return filepath.SkipDir
-				}
-				return nil
-			}
-			return processFile(path)
-		}); err != nil {
-			return resp, err
-		}
-	} else {
-		if err := processFile(p.path); err != nil {
-			return resp, err
-		}
+// pathWithin reports whether relPath is prefix or lies under it. An empty
+// prefix is the repo root.
+func pathWithin(relPath, prefix string) bool {
+	if prefix == "" {
+		return true
 	}
-
-	return resp, nil
+	return relPath == prefix || strings.HasPrefix(relPath, prefix+"/")
 }
 
-func ingestSingleFile(p ingestSingleFileParams) (IngestResponse, error) {
-	var resp IngestResponse
-
-	info, err := os.Stat(p.path)
-	if err != nil {
-		return resp, err
-	}
-	if info.IsDir() {
+// explainIngestFile runs the same selection and chunking as ingest for one
+// file without touching the store.
+func explainIngestFile(path, relPath string, matcher ignoreMatcher, maxBytes int64, chunkers chunkerSelector, counter *token.Counter) (IngestExplainResponse, error) {
+	resp := IngestExplainResponse{Path: relPath, Chunks: []IngestExplainChunk{}}
+	if matcher.Matches(relPath) {
+		resp.Skipped = "ignored"
 		return resp, nil
 	}
-	if info.Size() > p.maxBytes {
-		resp.FilesSkipped++
+	plan, ok := chunkers.plan(relPath)
+	if !ok {
+		resp.Skipped = "no chunker"
 		return resp, nil
 	}
+	resp.Chunker = plan.Chunker.Name()
+	resp.Strategy = plan.Chunker.Strategy()
+	resp.Rule = plan.Rule
+	resp.ChunkTokens = plan.ChunkTokens
+	resp.OverlapTokens = plan.OverlapTokens
 
-	data, err := os.ReadFile(p.path)
+	info, err := os.Stat(path)
 	if err != nil {
 		return resp, err
 	}
-
-	semanticChunks, err := chunkFile(p.path, data, p.chunkTokens, p.overlapTokens, p.counter)
-	if err != nil {
-		return resp, err
-	}
-	if len(semanticChunks) == 0 {
-		resp.FilesSkipped++
+	if info.Size() > maxBytes {
+		resp.Skipped = "too large"
 		return resp, nil
 	}
-
-	if p.deleteExisting {
-		if _, err := p.st.DeleteChunksBySource(p.repoInfo.ID, p.workspace, p.relPath); err != nil {
-			return resp, err
-		}
-		if _, err := p.st.DeleteArtifactsBySource(p.repoInfo.ID, p.workspace, p.relPath); err != nil {
-			return resp, err
-		}
-	}
-
-	hash := sha256.Sum256(data)
-	artifact := store.Artifact{
-		ID:          store.NewID("A"),
-		RepoID:      p.repoInfo.ID,
-		Workspace:   p.workspace,
-		Kind:        "file",
-		Source:      p.relPath,
-		ContentHash: hex.EncodeToString(hash[:]),
-		CreatedAt:   time.Now().UTC(),
-	}
-
-	chunks := make([]store.Chunk, 0, len(semanticChunks))
-	for _, sc := range semanticChunks {
-		chunkHash := sha256.Sum256([]byte(sc.Text))
-		locator := formatLocator(p.repoInfo, p.relPath, sc.StartLine, sc.EndLine)
-		chunks = append(chunks, store.Chunk{
-			ID:         store.NewID("C"),
-			RepoID:     p.repoInfo.ID,
-			Workspace:  p.workspace,
-			ArtifactID: artifact.ID,
-			ThreadID:   p.threadID,
-			Locator:    locator,
-			Text:       sc.Text,
-			TextHash:   hex.EncodeToString(chunkHash[:]),
-			TextTokens: p.counter.Count(sc.Text),
-			ChunkType:  sc.ChunkType,
-			SymbolName: sc.SymbolName,
-			SymbolKind: sc.SymbolKind,
-			TagsJSON:   "[]",
-			TagsText:   "",
-			CreatedAt:  time.Now().UTC(),
-		})
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return resp, err
 	}
-
-	inserted, _, err := p.st.AddArtifactWithChunks(artifact, chunks)
+	chunks, fallback, err := runChunker(plan.Chunker, path, data, plan.ChunkTokens, plan.OverlapTokens, counter)
 	if err != nil {
 		return resp, err
 	}
-
-	resp.FilesIngested++
-	resp.ChunksAdded += inserted
+	resp.Fallback = fallback
+	for _, chunk := range chunks {
+		resp.Chunks = append(resp.Chunks, IngestExplainChunk{
+			StartLine:  chunk.StartLine,
+			EndLine:    chunk.EndLine,
+			Tokens:     counter.Count(chunk.Text),
+			ChunkType:  chunk.ChunkType,
+			SymbolName: chunk.SymbolName,
+			SymbolKind: chunk.SymbolKind,
+			Anchor:     chunk.Anchor,
+		})
+	}
 	return resp, nil
 }
 
@@ -417,21 +473,19 @@ func runIngestWatch(p runIngestWatchParams) int {
 
 			switch event.Op {
 			case watcher.OpCreate, watcher.OpModify:
-				if !allowedExtension(event.Path) {
+				if _, ok := p.chunkers.plan(event.RelPath); !ok {
 					continue
 				}
 				resp, err := ingestSingleFile(ingestSingleFileParams{
-					path:           event.Path,
-					relPath:        event.RelPath,
-					repoInfo:       p.repoInfo,
-					workspace:      p.workspace,
-					threadID:       p.threadID,
-					maxBytes:       p.maxBytes,
-					chunkTokens:    p.chunkTokens,
-					overlapTokens:  p.overlapTokens,
-					st:             p.st,
-					counter:        p.counter,
-					deleteExisting: true,
+					path:      event.Path,
+					relPath:   event.RelPath,
+					repoInfo:  p.repoInfo,
+					workspace: p.workspace,
+					threadID:  p.threadID,
+					maxBytes:  p.maxBytes,
+					chunkers:  p.chunkers,
+					st:        p.st,
+					counter:   p.counter,
 				})
 				if err != nil {
 					if os.IsNotExist(err) {
@@ -572,21 +626,16 @@ func defaultIgnoreLines() []string {
 	}
 }
 
-func allowedExtension(path string) bool {
-	lower := strings.ToLower(filepath.Ext(path))
-	switch lower {
-	case ".md", ".txt", ".rst", ".log", ".json", ".yaml", ".yml", ".toml":
-		return true
-	case ".py", ".go", ".js", ".jsx", ".ts", ".tsx", ".mts", ".cts", ".java", ".kt", ".rs", ".c", ".cpp", ".h", ".cs", ".sql", ".sh":
-		return true
-	default:
-		return false
-	}
-}
-
 func formatLocator(info repo.Info, relPath string, startLine, endLine int) string {
 	if info.HasGit && info.Head != "" {
 		return fmt.Sprintf("git:%s:%s#L%d-L%d", info.Head, relPath, startLine, endLine)
 	}
 	return fmt.Sprintf("file:%s#L%d-L%d", relPath, startLine, endLine)
 }
+
+func formatAnchorLocator(info repo.Info, relPath, anchor string) string {
+	if info.HasGit && info.Head != "" {
+		return fmt.Sprintf("git:%s:%s#%s", info.Head, relPath, anchor)
+	}
+	return fmt.Sprintf("file:%s#%s", relPath, anchor)
+}
@@ -0,0 +1,550 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"mem/internal/repo"
+	"mem/internal/store"
+)
+
+// ingestPath runs in three stages: the files to ingest are listed up front
+// (a directory walk, or git's changed paths with --since), a bounded pool of
+// workers reads, chunks, and counts tokens for them, and a single writer
+// stores the results in batched transactions. Files whose content hash
+// matches their last ingest into the thread are dropped by the workers
+// without being chunked.
+
+const (
+	ingestBatchFiles       = 64
+	ingestBatchChunks      = 2000
+	ingestProgressInterval = time.Second
+)
+
+// ingestOutcome is what a worker decided for one file.
+type ingestOutcome int
+
+const (
+	ingestOutcomeSkipped ingestOutcome = iota
+	ingestOutcomeUnchanged
+	ingestOutcomeWrite
+)
+
+type preparedIngestFile struct {
+	outcome     ingestOutcome
+	hadPrevious bool
+	write       store.IngestWrite
+}
+
+func ingestPath(ctx context.Context, p ingestPathParams) (IngestResponse, error) {
+	var resp IngestResponse
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return resp, err
+	}
+	hashes, err := p.st.SourceHashes(p.repoInfo.ID, p.workspace, p.threadID)
+	if err != nil {
+		return resp, err
+	}
+
+	var paths, removals []string
+	switch {
+	case p.since != "":
+		paths, removals, err = changedIngestPaths(p, &resp)
+	case info.IsDir():
+		paths, removals, err = walkIngestPaths(p, &resp)
+	default:
+		if p.selects(p.path, &resp) {
+			paths = []string{p.path}
+		}
+	}
+	if err != nil {
+		return resp, err
+	}
+
+	if len(removals) > 0 {
+		writes := make([]store.IngestWrite, 0, len(removals))
+		for _, relPath := range removals {
+			writes = append(writes, store.IngestWrite{
+				Artifact:      store.Artifact{RepoID: p.repoInfo.ID, Workspace: p.workspace, Source: relPath},
+				ReplaceThread: p.threadID,
+			})
+		}
+		results, err := p.st.WriteIngestBatch(writes)
+		if err != nil {
+			return resp, err
+		}
+		for _, result := range results {
+			if result.Removed > 0 {
+				resp.FilesRemoved++
+			}
+		}
+	}
+
+	return runIngestPipeline(ctx, p, paths, hashes, resp)
+}
+
+// walkIngestPaths lists the files under p.path that have a chunker and are
+// not ignored, and the thread's sources under it that no longer exist.
+func walkIngestPaths(p ingestPathParams, resp *IngestResponse) ([]string, []string, error) {
+	var paths []string
+	err := filepath.WalkDir(p.path, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath := relPathFor(p.root, path)
+		if d.IsDir() {
+			if d.Name() == ".git" || p.matcher.Matches(relPath) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if p.selects(path, resp) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sources, err := p.st.ListThreadSources(p.repoInfo.ID, p.workspace, p.threadID, "file")
+	if err != nil {
+		return nil, nil, err
+	}
+	prefix := ingestPrefix(p.root, p.path)
+	var removals []string
+	for _, source := range sources {
+		if !pathWithin(source, prefix) {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(p.root, filepath.FromSlash(source))); err == nil || !os.IsNotExist(err) {
+			continue
+		}
+		removals = append(removals, source)
+	}
+	return paths, removals, nil
+}
+
+// changedIngestPaths lists the paths under p.path that git reports as
+// changed since p.since: added and modified files are ingested, deleted
+// files removed, and renamed files removed under the old name.
+func changedIngestPaths(p ingestPathParams, resp *IngestResponse) ([]string, []string, error) {
+	changes, err := repo.ChangedSince(p.root, p.since)
+	if err != nil {
+		return nil, nil, err
+	}
+	prefix := ingestPrefix(p.root, p.path)
+	var paths, removals []string
+	for _, change := range changes {
+		if change.OldPath != "" && pathWithin(change.OldPath, prefix) {
+			removals = append(removals, change.OldPath)
+		}
+		if !pathWithin(change.Path, prefix) {
+			continue
+		}
+		if change.Status == repo.ChangeDeleted {
+			removals = append(removals, change.Path)
+			continue
+		}
+		path := filepath.Join(p.root, filepath.FromSlash(change.Path))
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			removals = append(removals, change.Path)
+			continue
+		}
+		if p.selects(path, resp) {
+			paths = append(paths, path)
+		}
+	}
+	return paths, removals, nil
+}
+
+// selects reports whether path is ingested at all, counting it as skipped
+// when it is ignored or no chunker handles it.
+func (p ingestPathParams) selects(path string, resp *IngestResponse) bool {
+	relPath := relPathFor(p.root, path)
+	if p.matcher.Matches(relPath) {
+		resp.FilesSkipped++
+		return false
+	}
+	if _, ok := p.chunkers.plan(relPath); !ok {
+		resp.FilesSkipped++
+		return false
+	}
+	return true
+}
+
+type ingestJobResult struct {
+	file preparedIngestFile
+	err  error
+}
+
+func runIngestPipeline(ctx context.Context, p ingestPathParams, paths []string, hashes map[string]string, resp IngestResponse) (IngestResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := p.workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+
+	jobs := make(chan string)
+	results := make(chan ingestJobResult, workers)
+	go func() {
+		defer close(jobs)
+		for _, path := range paths {
+			select {
+			case jobs <- path:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				relPath := relPathFor(p.root, path)
+				file, err := prepareIngestFile(ingestSingleFileParams{
+					path:      path,
+					relPath:   relPath,
+					repoInfo:  p.repoInfo,
+					workspace: p.workspace,
+					threadID:  p.threadID,
+					maxBytes:  p.maxBytes,
+					chunkers:  p.chunkers,
+					st:        p.st,
+					counter:   p.counter,
+					force:     p.force,
+				}, hashes[relPath])
+				select {
+				case results <- ingestJobResult{file: file, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	progress := p.progress
+	progress.start(len(paths))
+	var batch []preparedIngestFile
+	batchChunks := 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := writeIngestBatch(p.st, batch, &resp); err != nil {
+			return err
+		}
+		batch, batchChunks = batch[:0], 0
+		return nil
+	}
+
+	var firstErr error
+	for result := range results {
+		if result.err != nil {
+			if firstErr == nil {
+				firstErr = result.err
+				cancel()
+			}
+			continue
+		}
+		switch result.file.outcome {
+		case ingestOutcomeSkipped:
+			resp.FilesSkipped++
+		case ingestOutcomeUnchanged:
+			resp.FilesUnchanged++
+		case ingestOutcomeWrite:
+			batch = append(batch, result.file)
+			batchChunks += len(result.file.write.Chunks)
+			if len(batch) >= ingestBatchFiles || batchChunks >= ingestBatchChunks {
+				if err := flush(); err != nil && firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+			}
+		}
+		progress.fileDone(resp.ChunksAdded)
+	}
+	if firstErr == nil {
+		firstErr = flush()
+	} else {
+		// Files already prepared are complete; keep them even though the
+		// run is stopping.
+		_ = flush()
+	}
+	progress.finish(resp.ChunksAdded)
+	if firstErr == nil && ctx.Err() != nil {
+		firstErr = ctx.Err()
+	}
+	return resp, firstErr
+}
+
+func writeIngestBatch(st *store.Store, batch []preparedIngestFile, resp *IngestResponse) error {
+	writes := make([]store.IngestWrite, len(batch))
+	for i, file := range batch {
+		writes[i] = file.write
+	}
+	results, err := st.WriteIngestBatch(writes)
+	if err != nil {
+		return err
+	}
+	for i, result := range results {
+		resp.recordWrite(batch[i], result)
+	}
+	return nil
+}
+
+func (r *IngestResponse) recordWrite(file preparedIngestFile, result store.IngestWriteResult) {
+	if len(file.write.Chunks) == 0 {
+		// The file chunked to nothing; only its old chunks were dropped.
+		if file.hadPrevious {
+			r.FilesRemoved++
+		} else {
+			r.FilesSkipped++
+		}
+		return
+	}
+	r.FilesIngested++
+	if file.hadPrevious {
+		r.FilesUpdated++
+	}
+	r.ChunksAdded += result.Inserted
+}
+
+func (r *IngestResponse) add(other IngestResponse) {
+	r.FilesIngested += other.FilesIngested
+	r.ChunksAdded += other.ChunksAdded
+	r.FilesSkipped += other.FilesSkipped
+	r.FilesUnchanged += other.FilesUnchanged
+	r.FilesUpdated += other.FilesUpdated
+	r.FilesRemoved += other.FilesRemoved
+}
+
+// prepareIngestFile reads, hashes, and chunks one file without writing to
+// the store. previousHash is the content hash of the thread's last ingest
+// of the file, if any.
+func prepareIngestFile(p ingestSingleFileParams, previousHash string) (preparedIngestFile, error) {
+	var file preparedIngestFile
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return file, err
+	}
+	if info.IsDir() {
+		return file, nil
+	}
+	plan, ok := p.chunkers.plan(p.relPath)
+	if !ok || info.Size() > p.maxBytes {
+		return file, nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return file, err
+	}
+	hash := sha256.Sum256(data)
+	contentHash := hex.EncodeToString(hash[:])
+	if previousHash == contentHash && !p.force {
+		file.outcome = ingestOutcomeUnchanged
+		return file, nil
+	}
+
+	semanticChunks, _, err := runChunker(plan.Chunker, p.path, data, plan.ChunkTokens, plan.OverlapTokens, p.counter)
+	if err != nil {
+		return file, err
+	}
+	file.hadPrevious = previousHash != ""
+	if len(semanticChunks) == 0 && !file.hadPrevious {
+		return file, nil
+	}
+	attachChunkRefs(plan.Chunker.Name(), p.path, data, semanticChunks)
+
+	// Chunks from the previous version are tombstoned before the new ones
+	// go in; chunks that did not change are revived by the insert.
+	file.outcome = ingestOutcomeWrite
+	file.write.Artifact = store.Artifact{
+		ID:          store.NewID("A"),
+		RepoID:      p.repoInfo.ID,
+		Workspace:   p.workspace,
+		Kind:        "file",
+		Source:      p.relPath,
+		ContentHash: contentHash,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if file.hadPrevious {
+		file.write.ReplaceThread = p.threadID
+	}
+	file.write.Chunks = make([]store.Chunk, 0, len(semanticChunks))
+	for _, sc := range semanticChunks {
+		chunkHash := sha256.Sum256([]byte(sc.Text))
+		locator := formatLocator(p.repoInfo, p.relPath, sc.StartLine, sc.EndLine)
+		if sc.Anchor != "" {
+			locator = formatAnchorLocator(p.repoInfo, p.relPath, sc.Anchor)
+		}
+		if sc.SymbolKind == docSectionKind && sc.SymbolName != "" {
+			locator += ":" + sc.SymbolName
+		}
+		file.write.Chunks = append(file.write.Chunks, store.Chunk{
+			ID:         store.NewID("C"),
+			RepoID:     p.repoInfo.ID,
+			Workspace:  p.workspace,
+			ArtifactID: file.write.Artifact.ID,
+			ThreadID:   p.threadID,
+			Locator:    locator,
+			Text:       sc.Text,
+			TextHash:   hex.EncodeToString(chunkHash[:]),
+			TextTokens: p.counter.Count(sc.Text),
+			ChunkType:  sc.ChunkType,
+			SymbolName: sc.SymbolName,
+			SymbolKind: sc.SymbolKind,
+			Refs:       sc.Refs,
+			TagsJSON:   "[]",
+			TagsText:   "",
+			CreatedAt:  time.Now().UTC(),
+		})
+	}
+	return file, nil
+}
+
+func ingestSingleFile(p ingestSingleFileParams) (IngestResponse, error) {
+	var resp IngestResponse
+	previousHash, err := p.st.LatestSourceHash(p.repoInfo.ID, p.workspace, p.threadID, p.relPath)
+	if err != nil {
+		return resp, err
+	}
+	file, err := prepareIngestFile(p, previousHash)
+	if err != nil {
+		return resp, err
+	}
+	switch file.outcome {
+	case ingestOutcomeSkipped:
+		resp.FilesSkipped++
+	case ingestOutcomeUnchanged:
+		resp.FilesUnchanged++
+	case ingestOutcomeWrite:
+		if err := writeIngestBatch(p.st, []preparedIngestFile{file}, &resp); err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
+
+const (
+	ingestProgressNone = "none"
+	ingestProgressText = "text"
+	ingestProgressJSON = "json"
+)
+
+// IngestProgressEvent is one --progress json line. Event is "progress"
+// while files are being processed and "done" once at the end.
+type IngestProgressEvent struct {
+	Event          string  `json:"event"`
+	FilesDone      int     `json:"files_done"`
+	FilesTotal     int     `json:"files_total"`
+	ChunksAdded    int     `json:"chunks_added"`
+	FilesPerSecond float64 `json:"files_per_second"`
+	ETASeconds     float64 `json:"eta_seconds"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+}
+
+// ingestProgress reports pipeline progress at most once per interval, as
+// text lines or as JSON lines. A nil ingestProgress reports nothing.
+type ingestProgress struct {
+	mode     string
+	w        io.Writer
+	interval time.Duration
+	now      func() time.Time
+
+	started  time.Time
+	last     time.Time
+	total    int
+	done     int
+	reported bool
+}
+
+func newIngestProgress(mode string, w io.Writer) *ingestProgress {
+	if mode == ingestProgressNone {
+		return nil
+	}
+	return &ingestProgress{mode: mode, w: w, interval: ingestProgressInterval, now: time.Now}
+}
+
+func (p *ingestProgress) start(total int) {
+	if p == nil {
+		return
+	}
+	p.total = total
+	p.done = 0
+	p.started = p.now()
+	p.last = p.started
+}
+
+func (p *ingestProgress) fileDone(chunks int) {
+	if p == nil {
+		return
+	}
+	p.done++
+	if now := p.now(); now.Sub(p.last) >= p.interval {
+		p.last = now
+		p.emit("progress", chunks, now)
+	}
+}
+
+func (p *ingestProgress) finish(chunks int) {
+	if p == nil {
+		return
+	}
+	// Text mode stays quiet for runs that finished within one interval.
+	if p.mode == ingestProgressJSON || p.reported {
+		p.emit("done", chunks, p.now())
+	}
+}
+
+func (p *ingestProgress) emit(event string, chunks int, now time.Time) {
+	elapsed := now.Sub(p.started).Seconds()
+	ev := IngestProgressEvent{
+		Event:          event,
+		FilesDone:      p.done,
+		FilesTotal:     p.total,
+		ChunksAdded:    chunks,
+		ElapsedSeconds: math.Round(elapsed*10) / 10,
+	}
+	if elapsed > 0 {
+		rate := float64(p.done) / elapsed
+		ev.FilesPerSecond = math.Round(rate*10) / 10
+		if rate > 0 {
+			ev.ETASeconds = math.Round(float64(p.total-p.done) / rate)
+		}
+	}
+	p.reported = true
+	if p.mode == ingestProgressJSON {
+		encoded, err := json.Marshal(ev)
+		if err == nil {
+			fmt.Fprintln(p.w, string(encoded))
+		}
+		return
+	}
+	eta := time.Duration(ev.ETASeconds) * time.Second
+	fmt.Fprintf(p.w, "ingest: %d/%d files, %d chunks, %.1f files/s, ETA %s\n",
+		ev.FilesDone, ev.FilesTotal, ev.ChunksAdded, ev.FilesPerSecond, eta)
+}
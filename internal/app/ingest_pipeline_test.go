@@ -0,0 +1,98 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mem/internal/config"
+)
+
+func TestIngestParallelWorkersReportJSONProgress(t *testing.T) {
+	base := t.TempDir()
+	setXDGEnv(t, base)
+	repoDir := setupRepo(t, base)
+	withCwd(t, repoDir)
+	writeTestConfig(t, base, func(cfg *config.Config) {
+		cfg.EmbeddingProvider = "none"
+	})
+
+	const files = 150
+	if err := os.MkdirAll(filepath.Join(repoDir, "pkg"), 0o755); err != nil {
+		t.Fatalf("mkdir pkg: %v", err)
+	}
+	for i := 0; i < files; i++ {
+		writeFile(t, repoDir, fmt.Sprintf("pkg/f%03d.go", i), fmt.Sprintf("package pkg\n\nfunc F%03d() int { return %d }\n", i, i))
+	}
+
+	lines := strings.Split(string(runCLI(t, "ingest", "pkg", "--thread", "T-par", "--workers", "4", "--progress", "json")), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("expected progress events and a summary, got %q", lines)
+	}
+	var resp IngestResponse
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &resp); err != nil {
+		t.Fatalf("decode summary: %v", err)
+	}
+	if resp.FilesIngested != files || resp.ChunksAdded < files {
+		t.Fatalf("unexpected summary %+v", resp)
+	}
+	var done IngestProgressEvent
+	if err := json.Unmarshal([]byte(lines[len(lines)-2]), &done); err != nil {
+		t.Fatalf("decode done event: %v", err)
+	}
+	if done.Event != "done" || done.FilesDone != files || done.FilesTotal != files || done.ChunksAdded != resp.ChunksAdded {
+		t.Fatalf("unexpected done event %+v", done)
+	}
+
+	if err := json.Unmarshal(runCLI(t, "ingest", "pkg", "--thread", "T-par", "--progress", "none"), &resp); err != nil {
+		t.Fatalf("decode re-ingest: %v", err)
+	}
+	if resp.FilesUnchanged != files || resp.ChunksAdded != 0 {
+		t.Fatalf("expected every file unchanged on re-ingest, got %+v", resp)
+	}
+
+	if errOut := runCLIExpectError(t, "ingest", "pkg", "--thread", "T-par", "--progress", "xml"); !strings.Contains(errOut, "invalid --progress") {
+		t.Fatalf("expected invalid --progress error, got %q", errOut)
+	}
+}
+
+func TestIngestProgressThrottlesTextLines(t *testing.T) {
+	var out bytes.Buffer
+	now := time.Unix(0, 0)
+	progress := newIngestProgress(ingestProgressText, &out)
+	progress.now = func() time.Time { return now }
+
+	progress.start(10)
+	for i := 0; i < 4; i++ {
+		now = now.Add(300 * time.Millisecond)
+		progress.fileDone(i * 2)
+	}
+	// 1.2s in: the fourth file crosses the interval and prints once.
+	if got := strings.Count(out.String(), "\n"); got != 1 {
+		t.Fatalf("expected one progress line, got %q", out.String())
+	}
+	if !strings.Contains(out.String(), "ingest: 4/10 files, 6 chunks, 3.3 files/s, ETA 2s") {
+		t.Fatalf("unexpected progress line %q", out.String())
+	}
+	progress.finish(6)
+	if got := strings.Count(out.String(), "\n"); got != 2 {
+		t.Fatalf("expected a final line after progress was shown, got %q", out.String())
+	}
+
+	out.Reset()
+	quick := newIngestProgress(ingestProgressText, &out)
+	quick.start(1)
+	quick.fileDone(1)
+	quick.finish(1)
+	if out.Len() != 0 {
+		t.Fatalf("expected no output for a run under one interval, got %q", out.String())
+	}
+	if newIngestProgress(ingestProgressNone, &out) != nil {
+		t.Fatal("expected no reporter for --progress none")
+	}
+}
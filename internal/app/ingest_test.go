@@ -1,10 +1,14 @@
 package app
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+
+	"mem/internal/config"
 )
 
 func TestChunkRangesOverlap(t *testing.T) {
@@ -61,3 +65,189 @@ func TestLoadIgnoreMatcherLegacyMempackIgnore(t *testing.T) {
 		t.Fatalf("expected keep.txt not to match")
 	}
 }
+
+func TestIngestExplainUsesRepoChunkerRules(t *testing.T) {
+	base := t.TempDir()
+	setXDGEnv(t, base)
+	repoDir := setupRepo(t, base)
+	withCwd(t, repoDir)
+	writeTestConfig(t, base, func(cfg *config.Config) {
+		cfg.EmbeddingProvider = "none"
+	})
+
+	if err := os.MkdirAll(filepath.Join(repoDir, ".mem"), 0o755); err != nil {
+		t.Fatalf("mkdir .mem: %v", err)
+	}
+	writeFile(t, repoDir, filepath.Join(".mem", "config.json"), `{
+  "chunkers": [{"glob": "*.vue", "chunker": "typescript"}],
+  "chunk_sizes": {"typescript": {"chunk_tokens": 100}}
+}`)
+	writeFile(t, repoDir, "App.vue", "export function mount(el) {\n  return el;\n}\n")
+	writeFile(t, repoDir, "main.tf", "resource \"null\" \"x\" {}\n")
+
+	var resp IngestExplainResponse
+	if err := json.Unmarshal(runCLI(t, "ingest", "--explain", "App.vue"), &resp); err != nil {
+		t.Fatalf("decode explain: %v", err)
+	}
+	if resp.Path != "App.vue" || resp.Chunker != "typescript" || resp.Strategy != "semantic" || resp.Rule != "*.vue" || resp.ChunkTokens != 100 {
+		t.Fatalf("unexpected explain response %+v", resp)
+	}
+	if len(resp.Chunks) != 1 || resp.Chunks[0].SymbolName != "mount" || resp.Chunks[0].StartLine != 1 || resp.Chunks[0].EndLine != 3 {
+		t.Fatalf("unexpected explain chunks %+v", resp.Chunks)
+	}
+
+	resp = IngestExplainResponse{}
+	if err := json.Unmarshal(runCLI(t, "ingest", "--explain", "main.tf"), &resp); err != nil {
+		t.Fatalf("decode explain: %v", err)
+	}
+	if resp.Skipped != "no chunker" || len(resp.Chunks) != 0 {
+		t.Fatalf("expected main.tf to be skipped, got %+v", resp)
+	}
+
+	var ingest IngestResponse
+	if err := json.Unmarshal(runCLI(t, "ingest", ".", "--thread", "T-chunkers"), &ingest); err != nil {
+		t.Fatalf("decode ingest: %v", err)
+	}
+	// App.vue, file.txt and .mem/config.json are ingested; main.tf is skipped.
+	if ingest.FilesIngested != 3 || ingest.FilesSkipped != 1 {
+		t.Fatalf("unexpected ingest counts %+v", ingest)
+	}
+}
+
+func TestIngestNotebookUsesCellLocators(t *testing.T) {
+	base := t.TempDir()
+	setXDGEnv(t, base)
+	repoDir := setupRepo(t, base)
+	withCwd(t, repoDir)
+	writeTestConfig(t, base, func(cfg *config.Config) {
+		cfg.EmbeddingProvider = "none"
+	})
+
+	writeFile(t, repoDir, "churn.ipynb", `{
+ "cells": [
+  {"id": "why", "cell_type": "markdown", "metadata": {}, "source": ["# Churn\n", "Drop customers without tenure."]},
+  {"id": "fit", "cell_type": "code", "execution_count": 1, "metadata": {}, "outputs": [], "source": "model.fit(tenure_frame)"}
+ ],
+ "metadata": {},
+ "nbformat": 4,
+ "nbformat_minor": 5
+}
+`)
+	runCLI(t, "ingest", "churn.ipynb", "--thread", "T-notebook")
+
+	var report ExplainReport
+	if err := json.Unmarshal(runCLI(t, "explain", "tenure"), &report); err != nil {
+		t.Fatalf("decode explain: %v", err)
+	}
+	locators := map[string]bool{}
+	for _, chunk := range report.Chunks {
+		locators[chunk.Locator[strings.LastIndex(chunk.Locator, ":")+1:]] = true
+	}
+	if !locators["churn.ipynb#cell-why"] || !locators["churn.ipynb#cell-fit"] {
+		t.Fatalf("expected cell locators, got %v", locators)
+	}
+}
+
+func TestIngestSkipsUnchangedFilesAndRemovesDeletedOnes(t *testing.T) {
+	base := t.TempDir()
+	setXDGEnv(t, base)
+	repoDir := setupRepo(t, base)
+	withCwd(t, repoDir)
+	writeTestConfig(t, base, func(cfg *config.Config) {
+		cfg.EmbeddingProvider = "none"
+	})
+
+	writeFile(t, repoDir, "calc.go", "package calc\n\nfunc Keep() int { return 1 }\n\nfunc Change() int { return 2 }\n")
+	ingest := func() IngestResponse {
+		t.Helper()
+		var resp IngestResponse
+		if err := json.Unmarshal(runCLI(t, "ingest", ".", "--thread", "T-incremental"), &resp); err != nil {
+			t.Fatalf("decode ingest: %v", err)
+		}
+		return resp
+	}
+	definition := func(name string) []SymbolDefinition {
+		t.Helper()
+		var resp SymbolResponse
+		if err := json.Unmarshal(runCLI(t, "symbol", name), &resp); err != nil {
+			t.Fatalf("decode symbol: %v", err)
+		}
+		return resp.Definitions
+	}
+
+	if resp := ingest(); resp.FilesIngested != 2 || resp.FilesUnchanged != 0 {
+		t.Fatalf("unexpected first ingest %+v", resp)
+	}
+	keep := definition("Keep")
+	if len(keep) != 1 {
+		t.Fatalf("expected Keep to be ingested, got %+v", keep)
+	}
+
+	if resp := ingest(); resp.FilesIngested != 0 || resp.ChunksAdded != 0 || resp.FilesUnchanged != 2 {
+		t.Fatalf("expected unchanged files to be skipped, got %+v", resp)
+	}
+
+	writeFile(t, repoDir, "calc.go", "package calc\n\nfunc Keep() int { return 1 }\n\nfunc Change() int { return 3 }\n")
+	if resp := ingest(); resp.FilesUpdated != 1 || resp.FilesUnchanged != 1 {
+		t.Fatalf("expected calc.go to be updated, got %+v", resp)
+	}
+	if after := definition("Keep"); len(after) != 1 || after[0].ChunkID != keep[0].ChunkID {
+		t.Fatalf("expected the unchanged Keep chunk to survive, got %+v", after)
+	}
+	if changed := definition("Change"); len(changed) != 1 || !strings.Contains(changed[0].Text, "return 3") {
+		t.Fatalf("expected only the new Change chunk, got %+v", changed)
+	}
+
+	if err := os.Remove(filepath.Join(repoDir, "calc.go")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if resp := ingest(); resp.FilesRemoved != 1 {
+		t.Fatalf("expected calc.go to be removed, got %+v", resp)
+	}
+	if gone := definition("Keep"); len(gone) != 0 {
+		t.Fatalf("expected deleted file's chunks to be tombstoned, got %+v", gone)
+	}
+}
+
+func TestIngestSinceOnlyTouchesChangedPaths(t *testing.T) {
+	base := t.TempDir()
+	setXDGEnv(t, base)
+	repoDir := setupRepo(t, base)
+	withCwd(t, repoDir)
+	writeTestConfig(t, base, func(cfg *config.Config) {
+		cfg.EmbeddingProvider = "none"
+	})
+
+	writeFile(t, repoDir, "edit.go", "package demo\n\nfunc Edit() {}\n")
+	writeFile(t, repoDir, "old.go", "package demo\n\nfunc Moved() {}\n")
+	writeFile(t, repoDir, "gone.go", "package demo\n\nfunc Gone() {}\n")
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "add demo")
+	since := strings.TrimSpace(runGitOutput(t, repoDir, "rev-parse", "HEAD"))
+	runCLI(t, "ingest", ".", "--thread", "T-since")
+
+	writeFile(t, repoDir, "edit.go", "package demo\n\nfunc Edit() { println() }\n")
+	runGit(t, repoDir, "mv", "old.go", "new.go")
+	runGit(t, repoDir, "rm", "-q", "gone.go")
+	runGit(t, repoDir, "commit", "-am", "churn")
+
+	var resp IngestResponse
+	if err := json.Unmarshal(runCLI(t, "ingest", ".", "--thread", "T-since", "--since", since), &resp); err != nil {
+		t.Fatalf("decode ingest: %v", err)
+	}
+	if resp.FilesIngested != 2 || resp.FilesUpdated != 1 || resp.FilesRemoved != 2 || resp.FilesUnchanged != 0 {
+		t.Fatalf("unexpected --since summary %+v", resp)
+	}
+
+	var symbols SymbolResponse
+	if err := json.Unmarshal(runCLI(t, "symbol", "Moved"), &symbols); err != nil {
+		t.Fatalf("decode symbol: %v", err)
+	}
+	if len(symbols.Definitions) != 1 || !strings.Contains(symbols.Definitions[0].Locator, "new.go") {
+		t.Fatalf("expected Moved only under its new path, got %+v", symbols.Definitions)
+	}
+
+	if errOut := runCLIExpectError(t, "ingest", ".", "--thread", "T-since", "--since", "no-such-ref"); !strings.Contains(errOut, "unknown commit") {
+		t.Fatalf("expected unknown commit error, got %q", errOut)
+	}
+}
@@ -109,13 +109,21 @@ func runMCP(args []string, out, errOut io.Writer) int {
 			setActiveMCPRuntime(nil)
 			_ = rt.close()
 		}()
+		// Runs before the runtime closes its stores.
+		defer startAccessFlusher(errOut)()
 	}
 	modeLabel := formatMCPWriteModeLabel(writeCfg, startupDecision.Detached)
 	if *daemonMode {
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 		defer cancel()
+		embeddingRepoID := ""
 		if cfgErr == nil && !startupDecision.Detached {
-			startEmbeddingWorker(ctx, cfg, startupDecision.Report.Repo.ID)
+			embeddingRepoID = startupDecision.Report.Repo.ID
+			startEmbeddingWorker(ctx, cfg, embeddingRepoID)
+		}
+		if cfgErr == nil {
+			watchers := startDaemonWatchers(ctx, cfgBase, errOut, embeddingRepoID)
+			defer watchers.wait()
 		}
 		return runMCPDaemon(ctx, errOut, startupDecision.Report, modeLabel)
 	}
@@ -187,6 +195,9 @@ func registerMCPTools(srv *server.MCPServer, writeCfg mcpWriteConfig, requireRep
 		mcp.WithString("format", mcp.Description("Output format: json|prompt"), mcp.Enum("json", "prompt"), mcp.DefaultString("json")),
 		mcp.WithNumber("budget", mcp.Description("Token budget override")),
 		mcp.WithBoolean("cluster", mcp.Description("Group similar memories into clusters")),
+		mcp.WithBoolean("mmr", mcp.Description("Diversify the pack with maximal marginal relevance")),
+		mcp.WithNumber("mmr_lambda", mcp.Description("MMR relevance weight in (0,1]")),
+		mcp.WithBoolean("related", mcp.Description("Add chunks defining what the pack's chunks call or reference")),
 	)
 	srv.AddTool(getTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		return handleGetContext(ctx, request, requireRepo)
@@ -220,6 +231,23 @@ func registerMCPTools(srv *server.MCPServer, writeCfg mcpWriteConfig, requireRep
 	})
 	tools++
 
+	symbolTool := mcp.NewTool("mem_find_symbol",
+		mcp.WithDescription("Find where a code symbol is defined. Exact and prefix lookup over ingested chunk symbols; returns the defining chunks with locators and memories whose entities mention the symbol."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithString("name", mcp.Required(), mcp.Description("Symbol name, e.g. Store.Search")),
+		mcp.WithString("kind", mcp.Description("Restrict to a symbol kind: func|type|method"), mcp.Enum("func", "type", "method")),
+		mcp.WithBoolean("fuzzy", mcp.Description("Also match names containing the query, ignoring case")),
+		mcp.WithNumber("limit", mcp.Description("Maximum definitions to return")),
+		mcp.WithString("repo", mcp.Description("Repo id or path override")),
+		mcp.WithString("workspace", mcp.Description("Workspace name")),
+	)
+	srv.AddTool(symbolTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleFindSymbol(ctx, request, requireRepo)
+	})
+	tools++
+
 	addTool := mcp.NewTool("mem_add_memory",
 		mcp.WithDescription("Save a short decision/summary memory. Call when the user asked to save/store/remember, or when repo policy requires autosave after a completed fix. In write_mode=ask, use confirmed=true after approval."),
 		mcp.WithReadOnlyHintAnnotation(false),
@@ -230,6 +258,8 @@ func registerMCPTools(srv *server.MCPServer, writeCfg mcpWriteConfig, requireRep
 		mcp.WithString("summary", mcp.Description("Optional summary text")),
 		mcp.WithString("tags", mcp.Description("Comma-separated tags")),
 		mcp.WithString("entities", mcp.Description("Comma-separated entities")),
+		mcp.WithString("on_duplicate", mcp.Description("When a near-duplicate exists: warn (default, saves and returns candidates), reject (saves nothing and returns candidates), update, supersede, link, or force")),
+		mcp.WithString("duplicate_of", mcp.Description("Existing memory id to update, supersede, or link (defaults to the closest candidate)")),
 		mcp.WithString("workspace", mcp.Description("Workspace name")),
 		mcp.WithString("repo", mcp.Description("Repo id or path override")),
 		mcp.WithBoolean("confirmed", mcp.Description("Set true after user approval when write_mode=ask")),
@@ -279,6 +309,23 @@ func registerMCPTools(srv *server.MCPServer, writeCfg mcpWriteConfig, requireRep
 	})
 	tools++
 
+	feedbackTool := mcp.NewTool("mem_feedback",
+		mcp.WithDescription("Record whether a retrieved memory or chunk was useful (up) or useless (down). Feedback adjusts future ranking. In write_mode=ask, use confirmed=true after approval."),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithString("id", mcp.Required(), mcp.Description("Memory or chunk id")),
+		mcp.WithString("vote", mcp.Required(), mcp.Description("up or down")),
+		mcp.WithString("query", mcp.Description("Query the item was retrieved for")),
+		mcp.WithString("workspace", mcp.Description("Workspace name")),
+		mcp.WithString("repo", mcp.Description("Repo id or path override")),
+		mcp.WithBoolean("confirmed", mcp.Description("Set true after user approval when write_mode=ask")),
+	)
+	srv.AddTool(feedbackTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleFeedback(ctx, request, writeCfg, requireRepo)
+	})
+	tools++
+
 	checkpointTool := mcp.NewTool("mem_checkpoint",
 		mcp.WithDescription("Save current state JSON. Call when the user asked to save/store/remember, or when repo policy requires autosave after a completed fix. In write_mode=ask, use confirmed=true after approval."),
 		mcp.WithReadOnlyHintAnnotation(false),
@@ -405,6 +452,12 @@ func handleGetContext(_ context.Context, request mcp.CallToolRequest, requireRep
 		return mcp.NewToolResultError("budget must be >= 0"), nil
 	}
 	cluster := request.GetBool("cluster", false)
+	mmr := request.GetBool("mmr", false)
+	mmrLambda := request.GetFloat("mmr_lambda", 0)
+	if mmrLambda < 0 || mmrLambda > 1 {
+		return mcp.NewToolResultError("mmr_lambda must be between 0 and 1"), nil
+	}
+	related := request.GetBool("related", false)
 
 	includeRawChunks := format == "prompt"
 	packJSON, err := buildContextPack(query, ContextOptions{
@@ -415,6 +468,10 @@ func handleGetContext(_ context.Context, request mcp.CallToolRequest, requireRep
 		IncludeRawChunks: includeRawChunks,
 		ClusterMemories:  cluster,
 		RequireRepo:      requireRepo,
+		MMR:              mmr,
+		MMRLambda:        mmrLambda,
+		RelatedChunks:    related,
+		RecordAccess:     true,
 	}, nil)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
@@ -506,6 +563,41 @@ func handleExplain(_ context.Context, request mcp.CallToolRequest, requireRepo b
 	}, nil
 }
 
+func handleFindSymbol(_ context.Context, request mcp.CallToolRequest, requireRepo bool) (*mcp.CallToolResult, error) {
+	name, err := request.RequireString("name")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if strings.TrimSpace(name) == "" {
+		return mcp.NewToolResultError("name must not be empty"), nil
+	}
+	kind := strings.TrimSpace(request.GetString("kind", ""))
+	limit := request.GetInt("limit", defaultSymbolLimit)
+	if err := validateSymbolOptions(kind, limit); err != nil {
+		return mcp.NewToolResultError(strings.ReplaceAll(err.Error(), "--", "")), nil
+	}
+
+	resp, err := buildSymbolResponse(name, SymbolOptions{
+		RepoOverride: strings.TrimSpace(request.GetString("repo", "")),
+		Workspace:    strings.TrimSpace(request.GetString("workspace", "")),
+		Kind:         kind,
+		Fuzzy:        request.GetBool("fuzzy", false),
+		Limit:        limit,
+		RequireRepo:  requireRepo,
+	})
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	summary := fmt.Sprintf("Symbol lookup: name=%s definitions=%d memories=%d", resp.Query, len(resp.Definitions), len(resp.Memories))
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: summary},
+		},
+		StructuredContent: resp,
+	}, nil
+}
+
 func explainSummary(report ExplainReport) string {
 	includedMemories := 0
 	for _, mem := range report.Memories {
@@ -218,6 +218,11 @@ func runMCPStatusLocal(out, errOut io.Writer) int {
 		return 1
 	}
 	fmt.Fprintf(out, "mem mcp running (pid=%d)\n", pid)
+	if state, ok := readDaemonWatchState(cfg, pid); ok {
+		for _, status := range state.Repos {
+			fmt.Fprintln(out, formatRepoWatchStatus(status))
+		}
+	}
 	return 0
 }
 
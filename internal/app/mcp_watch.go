@@ -0,0 +1,655 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mem/internal/config"
+	"mem/internal/repo"
+	"mem/internal/store"
+	"mem/internal/token"
+	"mem/internal/watcher"
+)
+
+// The MCP daemon keeps the paths a repo's config lists under "watch"
+// indexed. Every known repo whose config declares watch paths gets a
+// watcher.Watcher rooted at its git root; changed files are re-ingested
+// into the watch thread, deleted files are tombstoned, and new chunks are
+// queued for the embedding worker. Repos are rescanned periodically so
+// config edits take effect without restarting the daemon. Watcher state is
+// written to mcp.watch.json for `mem mcp status` and `mem doctor`.
+
+const (
+	watchStateFile          = "mcp.watch.json"
+	defaultWatchThread      = "watch"
+	defaultWatchDebounce    = 500 * time.Millisecond
+	defaultWatchFilesPerMin = 600
+	watchRescanInterval     = time.Minute
+	watchStateInterval      = 2 * time.Second
+	watchDrainInterval      = time.Second
+)
+
+const (
+	watchStateWatching = "watching"
+	watchStateError    = "error"
+)
+
+// RepoWatchStatus is one repo's background watcher as recorded in
+// mcp.watch.json.
+type RepoWatchStatus struct {
+	RepoID        string   `json:"repo_id"`
+	GitRoot       string   `json:"git_root"`
+	Paths         []string `json:"paths"`
+	Thread        string   `json:"thread"`
+	State         string   `json:"state"`
+	Error         string   `json:"error,omitempty"`
+	StartedAt     string   `json:"started_at,omitempty"`
+	LastEventAt   string   `json:"last_event_at,omitempty"`
+	FilesIngested int      `json:"files_ingested"`
+	FilesRemoved  int      `json:"files_removed"`
+	ChunksAdded   int      `json:"chunks_added"`
+	Pending       int      `json:"pending"`
+	RateLimited   int      `json:"rate_limited"`
+	Errors        int      `json:"errors"`
+	LastError     string   `json:"last_error,omitempty"`
+}
+
+type daemonWatchState struct {
+	PID       int               `json:"pid"`
+	UpdatedAt string            `json:"updated_at"`
+	Repos     []RepoWatchStatus `json:"repos"`
+}
+
+type daemonWatchers struct {
+	cfg       config.Config
+	counter   *token.Counter
+	errOut    io.Writer
+	statePath string
+
+	mu           sync.Mutex
+	repos        map[string]*repoWatch
+	embedWorkers map[string]bool
+	done         chan struct{}
+}
+
+// startDaemonWatchers starts watchers for every known repo with watch
+// paths and keeps them in line with repo config until ctx is done.
+// embeddingRepoID names a repo that already has an embedding worker.
+func startDaemonWatchers(ctx context.Context, cfg config.Config, errOut io.Writer, embeddingRepoID string) *daemonWatchers {
+	d := &daemonWatchers{
+		cfg:          cloneConfig(cfg),
+		errOut:       errOut,
+		statePath:    filepath.Join(cfg.ConfigDir, watchStateFile),
+		repos:        map[string]*repoWatch{},
+		embedWorkers: map[string]bool{},
+		done:         make(chan struct{}),
+	}
+	if embeddingRepoID != "" {
+		d.embedWorkers[embeddingRepoID] = true
+	}
+	counter, err := token.New(cfg.Tokenizer)
+	if err != nil {
+		fmt.Fprintf(errOut, "mem mcp daemon: watch disabled: tokenizer error: %v\n", err)
+		close(d.done)
+		return d
+	}
+	d.counter = counter
+
+	go func() {
+		defer close(d.done)
+		defer os.Remove(d.statePath)
+		defer d.stopAll()
+
+		d.reconcile(ctx)
+		d.writeState()
+		rescan := time.NewTicker(watchRescanInterval)
+		defer rescan.Stop()
+		state := time.NewTicker(watchStateInterval)
+		defer state.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-rescan.C:
+				d.reconcile(ctx)
+			case <-state.C:
+				d.writeState()
+			}
+		}
+	}()
+	return d
+}
+
+// wait blocks until every watcher has stopped after the daemon context ends.
+func (d *daemonWatchers) wait() {
+	if d != nil {
+		<-d.done
+	}
+}
+
+// reconcile starts watchers for repos that gained watch config, restarts
+// those whose config changed, and stops those that lost it.
+func (d *daemonWatchers) reconcile(ctx context.Context) {
+	repos, err := listKnownRepos(d.cfg)
+	if err != nil {
+		fmt.Fprintf(d.errOut, "mem mcp daemon: watch: list repos: %v\n", err)
+		return
+	}
+	wanted := map[string]bool{}
+	for _, item := range repos {
+		root := strings.TrimSpace(item.GitRoot)
+		if root == "" || !pathIsDirectory(root) {
+			continue
+		}
+		repoCfg, _, err := config.LoadRepoConfig(root)
+		if err != nil || repoCfg.Watch == nil || len(repoCfg.Watch.Paths) == 0 {
+			continue
+		}
+		key, _ := json.Marshal(repoCfg)
+		wanted[item.RepoID] = true
+
+		d.mu.Lock()
+		existing := d.repos[item.RepoID]
+		d.mu.Unlock()
+		if existing != nil && existing.key == string(key) && existing.snapshot().State != watchStateError {
+			continue
+		}
+		if existing != nil {
+			existing.stop()
+		}
+		w := d.startRepo(ctx, item.RepoID, root, repoCfg, string(key))
+		d.mu.Lock()
+		d.repos[item.RepoID] = w
+		d.mu.Unlock()
+	}
+
+	d.mu.Lock()
+	var stale []*repoWatch
+	for repoID, w := range d.repos {
+		if !wanted[repoID] {
+			stale = append(stale, w)
+			delete(d.repos, repoID)
+		}
+	}
+	d.mu.Unlock()
+	for _, w := range stale {
+		w.stop()
+		fmt.Fprintf(d.errOut, "mem mcp daemon: watch stopped: repo=%s\n", w.repoID)
+	}
+}
+
+func (d *daemonWatchers) startRepo(ctx context.Context, repoID, root string, repoCfg config.RepoConfig, key string) *repoWatch {
+	cfg := cloneConfig(d.cfg)
+	_ = config.ApplyRepoOverrides(&cfg, root)
+	w := newRepoWatch(repoID, root, *repoCfg.Watch, cfg)
+	w.key = key
+	w.log = d.errOut
+	if err := w.start(ctx, repoCfg, d.counter); err != nil {
+		w.fail(err)
+		fmt.Fprintf(d.errOut, "mem mcp daemon: watch error: repo=%s: %v\n", repoID, err)
+		return w
+	}
+	fmt.Fprintf(d.errOut, "mem mcp daemon: watching repo=%s paths=%s thread=%s\n", repoID, strings.Join(w.status.Paths, ","), w.thread)
+
+	d.mu.Lock()
+	startWorker := !d.embedWorkers[repoID]
+	d.embedWorkers[repoID] = true
+	d.mu.Unlock()
+	if startWorker {
+		startEmbeddingWorker(ctx, cfg, repoID)
+	}
+	return w
+}
+
+func (d *daemonWatchers) stopAll() {
+	d.mu.Lock()
+	repos := make([]*repoWatch, 0, len(d.repos))
+	for _, w := range d.repos {
+		repos = append(repos, w)
+	}
+	d.repos = map[string]*repoWatch{}
+	d.mu.Unlock()
+	for _, w := range repos {
+		w.stop()
+	}
+}
+
+func (d *daemonWatchers) snapshot() []RepoWatchStatus {
+	d.mu.Lock()
+	repos := make([]*repoWatch, 0, len(d.repos))
+	for _, w := range d.repos {
+		repos = append(repos, w)
+	}
+	d.mu.Unlock()
+	statuses := make([]RepoWatchStatus, 0, len(repos))
+	for _, w := range repos {
+		statuses = append(statuses, w.snapshot())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].RepoID < statuses[j].RepoID })
+	return statuses
+}
+
+func (d *daemonWatchers) writeState() {
+	state := daemonWatchState{
+		PID:       os.Getpid(),
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339Nano),
+		Repos:     d.snapshot(),
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return
+	}
+	tmpPath := d.statePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return
+	}
+	_ = os.Rename(tmpPath, d.statePath)
+}
+
+// readDaemonWatchState reads the watcher state written by the daemon with
+// the given pid. A missing or stale file reads as no watchers.
+func readDaemonWatchState(cfg config.Config, pid int) (daemonWatchState, bool) {
+	data, err := os.ReadFile(filepath.Join(cfg.ConfigDir, watchStateFile))
+	if err != nil {
+		return daemonWatchState{}, false
+	}
+	var state daemonWatchState
+	if err := json.Unmarshal(data, &state); err != nil || state.PID != pid {
+		return daemonWatchState{}, false
+	}
+	return state, true
+}
+
+// repoWatch is the background watcher for one repo.
+type repoWatch struct {
+	repoID    string
+	root      string
+	workspace string
+	thread    string
+	paths     []string
+	debounce  time.Duration
+	perMinute int
+	cfg       config.Config
+	key       string
+	log       io.Writer
+
+	matcher  ignoreMatcher
+	chunkers chunkerSelector
+	counter  *token.Counter
+	st       *store.Store
+	w        *watcher.Watcher
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	mu     sync.Mutex
+	status RepoWatchStatus
+}
+
+func newRepoWatch(repoID, root string, watchCfg config.WatchConfig, cfg config.Config) *repoWatch {
+	thread := strings.TrimSpace(watchCfg.Thread)
+	if thread == "" {
+		thread = defaultWatchThread
+	}
+	debounce := time.Duration(watchCfg.DebounceMS) * time.Millisecond
+	if debounce <= 0 {
+		debounce = defaultWatchDebounce
+	}
+	perMinute := watchCfg.MaxFilesPerMinute
+	if perMinute <= 0 {
+		perMinute = defaultWatchFilesPerMin
+	}
+	paths := normalizeWatchPaths(watchCfg.Paths)
+	display := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if p == "" {
+			p = "."
+		}
+		display = append(display, p)
+	}
+	return &repoWatch{
+		repoID:    repoID,
+		root:      root,
+		workspace: resolveWorkspace(cfg, ""),
+		thread:    thread,
+		paths:     paths,
+		debounce:  debounce,
+		perMinute: perMinute,
+		cfg:       cfg,
+		log:       io.Discard,
+		done:      make(chan struct{}),
+		status: RepoWatchStatus{
+			RepoID:  repoID,
+			GitRoot: root,
+			Paths:   display,
+			Thread:  thread,
+		},
+	}
+}
+
+// normalizeWatchPaths cleans configured paths to the slash-separated form
+// sources are stored in, with "" for the repo root. Paths escaping the repo
+// are dropped.
+func normalizeWatchPaths(paths []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		if p == "" || filepath.IsAbs(p) {
+			continue
+		}
+		p = path.Clean(filepath.ToSlash(p))
+		if p == ".." || strings.HasPrefix(p, "../") {
+			continue
+		}
+		if p == "." {
+			p = ""
+		}
+		if !seen[p] {
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// watches reports whether relPath is under a watched path, or is a
+// directory leading to one.
+func (w *repoWatch) watches(relPath string) bool {
+	for _, p := range w.paths {
+		if pathWithin(relPath, p) || pathWithin(p, relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *repoWatch) start(ctx context.Context, repoCfg config.RepoConfig, counter *token.Counter) error {
+	if len(w.paths) == 0 {
+		return fmt.Errorf("no valid watch paths")
+	}
+	chunkers, err := newChunkerSelector(repoCfg, defaultIngestChunkTokens, defaultIngestOverlapTokens, false)
+	if err != nil {
+		return err
+	}
+	st, err := openStore(w.cfg, w.repoID)
+	if err != nil {
+		return err
+	}
+	w.matcher = loadIgnoreMatcher(w.root)
+	w.chunkers = chunkers
+	w.counter = counter
+	w.st = st
+
+	fsw, err := watcher.New(w.root, func(relPath string) bool {
+		if relPath == ".git" || strings.HasPrefix(relPath, ".git/") {
+			return true
+		}
+		return !w.watches(relPath) || w.matcher.Matches(relPath)
+	})
+	if err != nil {
+		_ = st.Close()
+		return err
+	}
+	fsw.SetDebounce(w.debounce)
+	if err := fsw.Start(); err != nil {
+		_ = st.Close()
+		return err
+	}
+	w.w = fsw
+
+	runCtx, cancel := context.WithCancel(ctx)
+	w.cancel = cancel
+	w.mu.Lock()
+	w.status.State = watchStateWatching
+	w.status.StartedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	w.mu.Unlock()
+	go w.run(runCtx)
+	return nil
+}
+
+func (w *repoWatch) fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.status.State = watchStateError
+	w.status.Error = err.Error()
+	close(w.done)
+}
+
+func (w *repoWatch) stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+	<-w.done
+}
+
+func (w *repoWatch) snapshot() RepoWatchStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	status := w.status
+	status.Paths = append([]string(nil), w.status.Paths...)
+	return status
+}
+
+func (w *repoWatch) run(ctx context.Context) {
+	defer close(w.done)
+	defer w.st.Close()
+	defer w.w.Stop()
+
+	limiter := newWatchRateLimiter(w.perMinute, time.Now())
+	pending := map[string]watcher.Event{}
+	ticker := time.NewTicker(watchDrainInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.w.Events():
+			if !ok {
+				return
+			}
+			if !w.watches(event.RelPath) || w.matcher.Matches(event.RelPath) {
+				continue
+			}
+			// The latest event for a path wins; a file written twice before
+			// it is processed is ingested once.
+			pending[event.RelPath] = event
+			w.mu.Lock()
+			w.status.LastEventAt = event.Timestamp.UTC().Format(time.RFC3339Nano)
+			w.mu.Unlock()
+		case <-ticker.C:
+		}
+		w.drain(ctx, pending, limiter)
+	}
+}
+
+// drain applies pending events in path order while the rate limit allows;
+// the rest wait for the next tick.
+func (w *repoWatch) drain(ctx context.Context, pending map[string]watcher.Event, limiter *watchRateLimiter) {
+	if len(pending) == 0 {
+		return
+	}
+	relPaths := make([]string, 0, len(pending))
+	for relPath := range pending {
+		relPaths = append(relPaths, relPath)
+	}
+	sort.Strings(relPaths)
+
+	var info repo.Info
+	limited := false
+	for _, relPath := range relPaths {
+		if ctx.Err() != nil {
+			return
+		}
+		if !limiter.allow(time.Now()) {
+			limited = true
+			break
+		}
+		if info.GitRoot == "" {
+			// Locators pin HEAD, so read it once per drain rather than
+			// reusing the commit the watcher started on.
+			info, _ = repo.InfoFromCache(w.repoID, w.root, "", "", true)
+			info.ID = w.repoID
+		}
+		event := pending[relPath]
+		delete(pending, relPath)
+		w.apply(info, event)
+	}
+
+	w.mu.Lock()
+	w.status.Pending = len(pending)
+	if limited {
+		w.status.RateLimited++
+	}
+	w.mu.Unlock()
+}
+
+func (w *repoWatch) apply(info repo.Info, event watcher.Event) {
+	var (
+		resp IngestResponse
+		ids  []string
+		err  error
+	)
+	switch event.Op {
+	case watcher.OpCreate, watcher.OpModify:
+		resp, ids, err = w.ingestFile(info, event)
+	case watcher.OpDelete:
+		resp, err = w.removeFile(event.RelPath)
+	}
+	if err == nil && len(ids) > 0 {
+		err = maybeEmbedChunks(w.cfg, w.st, w.repoID, w.workspace, ids)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		w.status.Errors++
+		w.status.LastError = fmt.Sprintf("%s: %v", event.RelPath, err)
+		fmt.Fprintf(w.log, "[watch] repo=%s %s %s: error: %v\n", w.repoID, event.Op, event.RelPath, err)
+		return
+	}
+	w.status.FilesIngested += resp.FilesIngested
+	w.status.FilesRemoved += resp.FilesRemoved
+	w.status.ChunksAdded += resp.ChunksAdded
+	if resp.FilesIngested > 0 || resp.FilesRemoved > 0 {
+		fmt.Fprintf(w.log, "[watch] repo=%s %s %s: %d chunks\n", w.repoID, event.Op, event.RelPath, resp.ChunksAdded)
+	}
+}
+
+func (w *repoWatch) ingestFile(info repo.Info, event watcher.Event) (IngestResponse, []string, error) {
+	var resp IngestResponse
+	if _, ok := w.chunkers.plan(event.RelPath); !ok {
+		return resp, nil, nil
+	}
+	previousHash, err := w.st.LatestSourceHash(w.repoID, w.workspace, w.thread, event.RelPath)
+	if err != nil {
+		return resp, nil, err
+	}
+	file, err := prepareIngestFile(ingestSingleFileParams{
+		path:      event.Path,
+		relPath:   event.RelPath,
+		repoInfo:  info,
+		workspace: w.workspace,
+		threadID:  w.thread,
+		maxBytes:  int64(defaultIngestMaxFileMB) * 1024 * 1024,
+		chunkers:  w.chunkers,
+		st:        w.st,
+		counter:   w.counter,
+	}, previousHash)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Gone before the debounce fired; the delete event follows.
+			return resp, nil, nil
+		}
+		return resp, nil, err
+	}
+	if file.outcome != ingestOutcomeWrite {
+		return resp, nil, nil
+	}
+	results, err := w.st.WriteIngestBatch([]store.IngestWrite{file.write})
+	if err != nil {
+		return resp, nil, err
+	}
+	resp.recordWrite(file, results[0])
+	return resp, results[0].InsertedIDs, nil
+}
+
+func (w *repoWatch) removeFile(relPath string) (IngestResponse, error) {
+	var resp IngestResponse
+	results, err := w.st.WriteIngestBatch([]store.IngestWrite{{
+		Artifact:      store.Artifact{RepoID: w.repoID, Workspace: w.workspace, Source: relPath},
+		ReplaceThread: w.thread,
+	}})
+	if err != nil {
+		return resp, err
+	}
+	if results[0].Removed > 0 {
+		resp.FilesRemoved++
+	}
+	return resp, nil
+}
+
+// watchRateLimiter is a token bucket refilled at perMinute files a minute,
+// allowing bursts of up to perMinute files.
+type watchRateLimiter struct {
+	capacity  float64
+	tokens    float64
+	perSecond float64
+	last      time.Time
+}
+
+func newWatchRateLimiter(perMinute int, now time.Time) *watchRateLimiter {
+	return &watchRateLimiter{
+		capacity:  float64(perMinute),
+		tokens:    float64(perMinute),
+		perSecond: float64(perMinute) / 60,
+		last:      now,
+	}
+}
+
+func (l *watchRateLimiter) allow(now time.Time) bool {
+	if elapsed := now.Sub(l.last).Seconds(); elapsed > 0 {
+		l.tokens += elapsed * l.perSecond
+		if l.tokens > l.capacity {
+			l.tokens = l.capacity
+		}
+		l.last = now
+	}
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+func formatRepoWatchStatus(status RepoWatchStatus) string {
+	if status.State == watchStateError {
+		return fmt.Sprintf("watch: repo=%s error: %s", status.RepoID, status.Error)
+	}
+	line := fmt.Sprintf("watch: repo=%s paths=%s thread=%s ingested=%d removed=%d chunks=%d pending=%d",
+		status.RepoID, strings.Join(status.Paths, ","), status.Thread,
+		status.FilesIngested, status.FilesRemoved, status.ChunksAdded, status.Pending)
+	if status.LastEventAt != "" {
+		line += " last_event=" + status.LastEventAt
+	}
+	if status.RateLimited > 0 {
+		line += fmt.Sprintf(" rate_limited=%d", status.RateLimited)
+	}
+	if status.Errors > 0 {
+		line += fmt.Sprintf(" errors=%d last_error=%q", status.Errors, status.LastError)
+	}
+	return line
+}
+
+func pathIsDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"mem/internal/config"
+)
+
+func TestDaemonWatchersReingestWatchedPaths(t *testing.T) {
+	base := t.TempDir()
+	setXDGEnv(t, base)
+	repoDir := setupRepo(t, base)
+	withCwd(t, repoDir)
+	writeTestConfig(t, base, func(cfg *config.Config) {
+		cfg.EmbeddingProvider = "none"
+	})
+	runCLI(t, "init", "--no-agents")
+	for _, dir := range []string{".mem", "src"} {
+		if err := os.MkdirAll(filepath.Join(repoDir, dir), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", dir, err)
+		}
+	}
+	writeFile(t, repoDir, ".mem/config.json", `{"watch":{"paths":["src"],"thread":"T-watch","debounce_ms":20}}`)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	repoInfo, err := resolveRepo(&cfg, "")
+	if err != nil {
+		t.Fatalf("resolve repo: %v", err)
+	}
+	st, err := openStore(cfg, repoInfo.ID)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var log bytes.Buffer
+	watchers := startDaemonWatchers(ctx, cfg, &log, repoInfo.ID)
+	defer func() {
+		cancel()
+		watchers.wait()
+	}()
+	waitFor(t, "watcher to start", func() bool {
+		return len(watchers.snapshot()) == 1
+	})
+
+	writeFile(t, repoDir, "src/app.go", "package src\n\nfunc Watched() {}\n")
+	writeFile(t, repoDir, "outside.go", "package demo\n\nfunc Outside() {}\n")
+	sources := func() []string {
+		got, err := st.ListThreadSources(repoInfo.ID, "", "T-watch", "file")
+		if err != nil {
+			t.Fatalf("list sources: %v", err)
+		}
+		return got
+	}
+	waitFor(t, "src/app.go to be ingested", func() bool {
+		return reflect.DeepEqual(sources(), []string{"src/app.go"})
+	})
+
+	watchers.writeState()
+	state, ok := readDaemonWatchState(cfg, os.Getpid())
+	if !ok || len(state.Repos) != 1 {
+		t.Fatalf("expected watch state for one repo, got %+v", state)
+	}
+	status := state.Repos[0]
+	if status.State != watchStateWatching || status.Thread != "T-watch" || status.FilesIngested != 1 || status.ChunksAdded == 0 {
+		t.Fatalf("unexpected watch status %+v", status)
+	}
+	if line := formatRepoWatchStatus(status); !strings.Contains(line, "paths=src thread=T-watch ingested=1") {
+		t.Fatalf("unexpected status line %q", line)
+	}
+
+	if err := os.Remove(filepath.Join(repoDir, "src", "app.go")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	waitFor(t, "src/app.go to be removed", func() bool {
+		return len(sources()) == 0
+	})
+
+	cancel()
+	watchers.wait()
+	if _, err := os.Stat(filepath.Join(cfg.ConfigDir, watchStateFile)); !os.IsNotExist(err) {
+		t.Fatalf("expected watch state removed on shutdown, got %v", err)
+	}
+}
+
+func TestWatchRateLimiterRefillsPerMinute(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := newWatchRateLimiter(60, now)
+	for i := 0; i < 60; i++ {
+		if !limiter.allow(now) {
+			t.Fatalf("expected burst of 60, blocked at %d", i)
+		}
+	}
+	if limiter.allow(now) {
+		t.Fatal("expected the 61st file to wait")
+	}
+	if !limiter.allow(now.Add(time.Second)) {
+		t.Fatal("expected one file a second after the burst")
+	}
+	if limiter.allow(now.Add(time.Second)) {
+		t.Fatal("expected only one refilled token")
+	}
+}
+
+func TestRepoWatchPathFilter(t *testing.T) {
+	w := newRepoWatch("R", "/repo", config.WatchConfig{Paths: []string{"./internal/app/", "docs", "../escape", "/abs", "docs"}}, config.Config{})
+	if !reflect.DeepEqual(w.paths, []string{"internal/app", "docs"}) {
+		t.Fatalf("unexpected normalized paths %q", w.paths)
+	}
+	for relPath, want := range map[string]bool{
+		"internal":               true,
+		"internal/app":           true,
+		"internal/app/ingest.go": true,
+		"internal/store":         false,
+		"docs/cli.md":            true,
+		"docsite/index.md":       false,
+		"main.go":                false,
+	} {
+		if got := w.watches(relPath); got != want {
+			t.Fatalf("watches(%q) = %v, want %v", relPath, got, want)
+		}
+	}
+	if root := newRepoWatch("R", "/repo", config.WatchConfig{Paths: []string{"."}}, config.Config{}); !root.watches("any/file.go") {
+		t.Fatal("expected \".\" to watch the whole repo")
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -106,10 +106,17 @@ func handleAddMemory(_ context.Context, request mcp.CallToolRequest, writeCfg mc
 	entities := strings.TrimSpace(request.GetString("entities", ""))
 	workspace := strings.TrimSpace(request.GetString("workspace", ""))
 	repoOverride := strings.TrimSpace(request.GetString("repo", ""))
+	dedupe := dedupeOptions{
+		Mode:        request.GetString("on_duplicate", ""),
+		DuplicateOf: request.GetString("duplicate_of", ""),
+	}
 
 	if title == "" {
 		return mcp.NewToolResultError("missing title"), nil
 	}
+	if _, err := normalizeOnDuplicate(dedupe.Mode); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 	if pattern, ok := detectSensitive(title); ok {
 		return mcp.NewToolResultError(fmt.Sprintf("potential secret detected (%s); redact and retry", pattern)), nil
 	}
@@ -178,7 +185,7 @@ func handleAddMemory(_ context.Context, request mcp.CallToolRequest, writeCfg mc
 
 	createdAt := time.Now().UTC()
 	summaryTokens := counter.Count(summary)
-	memory, err := st.AddMemory(store.AddMemoryInput{
+	outcome, err := addMemoryWithDedupe(cfg, st, store.AddMemoryInput{
 		RepoID:        repoInfo.ID,
 		Workspace:     workspace,
 		ThreadID:      threadUsed,
@@ -191,10 +198,19 @@ func handleAddMemory(_ context.Context, request mcp.CallToolRequest, writeCfg mc
 		EntitiesText:  entitiesText,
 		AnchorCommit:  anchorCommit,
 		CreatedAt:     createdAt,
-	})
+	}, dedupe)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("add memory error: %v", err)), nil
 	}
+	if outcome.Status == "duplicate" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{Type: "text", Text: fmt.Sprintf("Not saved: near-duplicate of %s. Retry with on_duplicate=update|supersede|link|force (optionally duplicate_of=<id>).", outcome.DuplicateOf)},
+			},
+			StructuredContent: duplicateResponse(title, outcome),
+		}, nil
+	}
+	memory := outcome.Memory
 	_ = maybeEmbedMemory(cfg, st, memory)
 
 	result := map[string]any{
@@ -205,10 +221,18 @@ func handleAddMemory(_ context.Context, request mcp.CallToolRequest, writeCfg mc
 		"title":            memory.Title,
 		"anchor_commit":    memory.AnchorCommit,
 		"created_at":       memory.CreatedAt.UTC().Format(time.RFC3339Nano),
+		"status":           outcome.Status,
+	}
+	addDuplicateFields(result, outcome)
+	text := fmt.Sprintf("Memory saved: %s", memory.ID)
+	if outcome.Status == "added" && len(outcome.Duplicates) > 0 {
+		text = fmt.Sprintf("Memory saved: %s (near-duplicate of %s; use on_duplicate=update|supersede|link to resolve or reject to skip)", memory.ID, outcome.Duplicates[0].ID)
+	} else if outcome.DuplicateOf != "" {
+		text = fmt.Sprintf("Memory %s: %s (duplicate of %s)", outcome.Status, memory.ID, outcome.DuplicateOf)
 	}
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
-			mcp.TextContent{Type: "text", Text: fmt.Sprintf("Memory saved: %s", memory.ID)},
+			mcp.TextContent{Type: "text", Text: text},
 		},
 		StructuredContent: result,
 	}, nil
@@ -425,6 +449,74 @@ func handleLinkMemories(_ context.Context, request mcp.CallToolRequest, writeCfg
 	}, nil
 }
 
+func handleFeedback(_ context.Context, request mcp.CallToolRequest, writeCfg mcpWriteConfig, requireRepo bool) (*mcp.CallToolResult, error) {
+	id := strings.TrimSpace(request.GetString("id", ""))
+	voteRaw := request.GetString("vote", "")
+	query := strings.TrimSpace(request.GetString("query", ""))
+	workspace := strings.TrimSpace(request.GetString("workspace", ""))
+	repoOverride := strings.TrimSpace(request.GetString("repo", ""))
+
+	if id == "" {
+		return mcp.NewToolResultError("missing id"), nil
+	}
+	vote, err := parseFeedbackVote(voteRaw)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("config error: %v", err)), nil
+	}
+	workspace = resolveWorkspace(cfg, workspace)
+
+	repoInfo, err := resolveRepoWithOptions(&cfg, repoOverride, repoResolveOptions{
+		RequireRepo: requireRepo,
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("repo detection error: %v", err)), nil
+	}
+	writeCfg, err = resolveMCPWriteConfig(cfg, repoInfo.GitRoot, writeCfg)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !writeCfg.Allowed {
+		return mcp.NewToolResultError("write tools disabled (use --allow-write or set mcp_allow_write in config or .mem/config.json or .mempack/config.json)"), nil
+	}
+	if err := requireWriteConfirmation(request, writeCfg); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	st, releaseStore, err := openStoreForRequest(cfg, repoInfo.ID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("store open error: %v", err)), nil
+	}
+	defer releaseStore()
+
+	if err := st.EnsureRepo(repoInfo); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("store repo error: %v", err)), nil
+	}
+	resp, err := recordFeedback(st, repoInfo.ID, workspace, id, vote, query)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	result := map[string]any{
+		"id":         resp.ID,
+		"kind":       resp.Kind,
+		"vote":       resp.Vote,
+		"query":      resp.Query,
+		"created_at": resp.CreatedAt,
+		"status":     resp.Status,
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{Type: "text", Text: fmt.Sprintf("Feedback recorded: %s %s %s", resp.Kind, resp.ID, resp.Vote)},
+		},
+		StructuredContent: result,
+	}, nil
+}
+
 func handleCheckpoint(_ context.Context, request mcp.CallToolRequest, writeCfg mcpWriteConfig, requireRepo bool) (*mcp.CallToolResult, error) {
 	reason := strings.TrimSpace(request.GetString("reason", ""))
 	stateJSON := strings.TrimSpace(request.GetString("state_json", ""))
@@ -0,0 +1,177 @@
+package app
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	selectionScore = "score"
+	selectionMMR   = "mmr"
+
+	defaultMMRLambda = 0.7
+	// Candidates at least this similar to an already-selected item are skipped
+	// outright instead of competing for budget. mmr_redundant_cosine and
+	// mmr_redundant_overlap override them.
+	mmrRedundantCosine  = 0.92
+	mmrRedundantOverlap = 0.8
+)
+
+func normalizeSelection(mode string) string {
+	switch strings.TrimSpace(strings.ToLower(mode)) {
+	case selectionMMR:
+		return selectionMMR
+	default:
+		return selectionScore
+	}
+}
+
+func normalizeMMRLambda(lambda float64) float64 {
+	if lambda <= 0 || lambda > 1 {
+		return defaultMMRLambda
+	}
+	return lambda
+}
+
+// normalizeMMRThreshold returns threshold, or fallback when it is outside
+// (0, 1].
+func normalizeMMRThreshold(threshold, fallback float64) float64 {
+	if threshold <= 0 || threshold > 1 {
+		return fallback
+	}
+	return threshold
+}
+
+// selectMMR greedily orders items by maximal marginal relevance:
+// lambda*relevance - (1-lambda)*max similarity to the items picked so far.
+// Relevance is the fused score min-max normalized over the candidates.
+// Similarity uses embeddings when both items have one and token-set overlap
+// otherwise. Items that near-duplicate a selected item are returned separately
+// along with the ID they duplicate.
+func selectMMR(items []budgetItem, opts BudgetOptions) ([]budgetItem, []budgetItem, map[string]string) {
+	redundantWith := map[string]string{}
+	if len(items) == 0 {
+		return items, nil, redundantWith
+	}
+	lambda := normalizeMMRLambda(opts.MMRLambda)
+	redundantCosine := normalizeMMRThreshold(opts.RedundantCosine, mmrRedundantCosine)
+	redundantOverlap := normalizeMMRThreshold(opts.RedundantOverlap, mmrRedundantOverlap)
+
+	lo, hi := items[0].Score, items[0].Score
+	for _, item := range items[1:] {
+		if item.Score < lo {
+			lo = item.Score
+		}
+		if item.Score > hi {
+			hi = item.Score
+		}
+	}
+	relevance := make([]float64, len(items))
+	for i, item := range items {
+		if hi == lo {
+			relevance[i] = 1
+			continue
+		}
+		relevance[i] = (item.Score - lo) / (hi - lo)
+	}
+
+	vectors := make([][]float64, len(items))
+	tokenSets := make([]map[string]struct{}, len(items))
+	for i, item := range items {
+		switch item.Kind {
+		case "memory":
+			vectors[i] = opts.MemoryEmbeddings[item.ID]
+		case "chunk":
+			vectors[i] = opts.ChunkEmbeddings[item.ID]
+		}
+		if len(vectors[i]) == 0 {
+			tokenSets[i] = tokenSet(item.Text)
+		}
+	}
+	similarity := func(a, b int) (float64, float64) {
+		if len(vectors[a]) > 0 && len(vectors[b]) > 0 && len(vectors[a]) == len(vectors[b]) {
+			return cosineSimilarityPair(vectors[a], vectors[b]), redundantCosine
+		}
+		if tokenSets[a] == nil {
+			tokenSets[a] = tokenSet(items[a].Text)
+		}
+		if tokenSets[b] == nil {
+			tokenSets[b] = tokenSet(items[b].Text)
+		}
+		return jaccard(tokenSets[a], tokenSets[b]), redundantOverlap
+	}
+
+	// Input is already sorted by score, so the first pick is the top item and
+	// ties keep the score ordering.
+	remaining := make([]int, len(items))
+	for i := range items {
+		remaining[i] = i
+	}
+	maxSim := make([]float64, len(items))
+
+	selected := make([]budgetItem, 0, len(items))
+	var redundant []budgetItem
+	for len(remaining) > 0 {
+		bestPos := 0
+		bestScore := 0.0
+		for pos, idx := range remaining {
+			score := lambda*relevance[idx] - (1-lambda)*maxSim[idx]
+			if pos == 0 || score > bestScore {
+				bestPos, bestScore = pos, score
+			}
+		}
+		pick := remaining[bestPos]
+		remaining = append(remaining[:bestPos], remaining[bestPos+1:]...)
+		selected = append(selected, items[pick])
+
+		kept := remaining[:0]
+		for _, idx := range remaining {
+			sim, threshold := similarity(pick, idx)
+			if sim > maxSim[idx] {
+				maxSim[idx] = sim
+			}
+			if sim >= threshold {
+				redundant = append(redundant, items[idx])
+				redundantWith[items[idx].ID] = items[pick].ID
+				continue
+			}
+			kept = append(kept, idx)
+		}
+		remaining = kept
+	}
+	return selected, redundant, redundantWith
+}
+
+func tokenSet(text string) map[string]struct{} {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	set := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		if len(field) < 2 {
+			continue
+		}
+		set[field] = struct{}{}
+	}
+	return set
+}
+
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	shared := 0
+	for token := range a {
+		if _, ok := b[token]; ok {
+			shared++
+		}
+	}
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
@@ -12,33 +12,44 @@ import (
 )
 
 type RankedMemory struct {
-	Memory        store.Memory
-	BM25          float64
-	FTSScore      float64
-	FTSRank       int
-	VectorScore   float64
-	VectorRank    int
-	RRFScore      float64
-	RecencyBonus  float64
-	ThreadBonus   float64
-	SafetyPenalty float64
-	FinalScore    float64
-	Orphaned      bool
-	Superseded    bool
+	Memory          store.Memory
+	BM25            float64
+	FTSScore        float64
+	FTSRank         int
+	VectorScore     float64
+	VectorRank      int
+	RRFScore        float64
+	RecencyBonus    float64
+	ThreadBonus     float64
+	FeedbackBonus   float64
+	PopularityBonus float64
+	RerankScore     float64
+	RerankBonus     float64
+	Reranked        bool
+	SafetyPenalty   float64
+	FinalScore      float64
+	Orphaned        bool
+	Superseded      bool
 }
 
 type RankedChunk struct {
-	Chunk         store.Chunk
-	BM25          float64
-	FTSScore      float64
-	FTSRank       int
-	VectorScore   float64
-	VectorRank    int
-	RRFScore      float64
-	RecencyBonus  float64
-	ThreadBonus   float64
-	SafetyPenalty float64
-	FinalScore    float64
+	Chunk           store.Chunk
+	BM25            float64
+	FTSScore        float64
+	FTSRank         int
+	VectorScore     float64
+	VectorRank      int
+	CommitRank      int
+	RRFScore        float64
+	RecencyBonus    float64
+	ThreadBonus     float64
+	FeedbackBonus   float64
+	PopularityBonus float64
+	RerankScore     float64
+	RerankBonus     float64
+	Reranked        bool
+	SafetyPenalty   float64
+	FinalScore      float64
 }
 
 type RankStats struct {
@@ -60,6 +71,13 @@ type RankOptions struct {
 	RRFWeight           float64
 	RecencyMultiplier   float64
 	TimeFilter          *time.Time
+	// FeedbackBonuses maps item IDs to their decayed relevance-feedback bonus.
+	FeedbackBonuses map[string]float64
+	// PopularityBonuses maps item IDs to their usage-based popularity bonus.
+	PopularityBonuses map[string]float64
+	// CommitResults are commit chunks touching the files a file-intent query
+	// names, fused as a third ranked list. Only chunk ranking uses them.
+	CommitResults []store.Chunk
 }
 
 func rankMemories(query string, results []store.MemoryResult, vectorOnly []store.Memory, repoInfo repo.Info, opts RankOptions) ([]RankedMemory, []pack.MatchedThread, map[string]struct{}, RankStats, error) {
@@ -158,13 +176,15 @@ func rankMemories(query string, results []store.MemoryResult, vectorOnly []store
 		if _, ok := matchedThreadIDs[mem.Memory.ThreadID]; ok {
 			mem.ThreadBonus = 0.10
 		}
+		mem.FeedbackBonus = opts.FeedbackBonuses[mem.Memory.ID]
+		mem.PopularityBonus = opts.PopularityBonuses[mem.Memory.ID]
 		if mem.Memory.SupersededBy != "" {
 			mem.Superseded = true
 		}
 		if containsPromptInjectionPhrase(mem.Memory.Title) || containsPromptInjectionPhrase(mem.Memory.Summary) {
 			mem.SafetyPenalty = -100.0
 		}
-		mem.FinalScore = mem.RRFScore + mem.RecencyBonus + mem.ThreadBonus + mem.SafetyPenalty
+		mem.FinalScore = mem.RRFScore + mem.RecencyBonus + mem.ThreadBonus + mem.FeedbackBonus + mem.PopularityBonus + mem.SafetyPenalty
 		if opts.TimeFilter != nil && mem.Memory.CreatedAt.Before(*opts.TimeFilter) {
 			mem.FinalScore -= 2.0
 		}
@@ -173,15 +193,7 @@ func rankMemories(query string, results []store.MemoryResult, vectorOnly []store
 		}
 	}
 
-	sort.SliceStable(candidates, func(i, j int) bool {
-		if candidates[i].FinalScore != candidates[j].FinalScore {
-			return candidates[i].FinalScore > candidates[j].FinalScore
-		}
-		if !candidates[i].Memory.CreatedAt.Equal(candidates[j].Memory.CreatedAt) {
-			return candidates[i].Memory.CreatedAt.After(candidates[j].Memory.CreatedAt)
-		}
-		return candidates[i].Memory.ID < candidates[j].Memory.ID
-	})
+	sortRankedMemories(candidates)
 
 	return candidates, matchedThreads, matchedThreadIDs, stats, nil
 }
@@ -214,7 +226,7 @@ func rankChunks(results []store.ChunkResult, vectorOnly []store.Chunk, vectorRes
 		seenIDs[res.Chunk.ID] = struct{}{}
 	}
 
-	for _, res := range vectorOnly {
+	for _, res := range append(opts.CommitResults, vectorOnly...) {
 		if _, ok := seenIDs[res.ID]; ok {
 			continue
 		}
@@ -245,19 +257,46 @@ func rankChunks(results []store.ChunkResult, vectorOnly []store.Chunk, vectorRes
 		vectorRanks[res.ID] = idx + 1
 		vectorScores[res.ID] = res.Score
 	}
+	commitRanks := make(map[string]int, len(opts.CommitResults))
+	for idx, res := range opts.CommitResults {
+		if _, ok := commitRanks[res.ID]; !ok {
+			commitRanks[res.ID] = idx + 1
+		}
+	}
 
 	for i := range candidates {
 		chunk := &candidates[i]
 		chunk.FTSRank = ftsRanks[chunk.Chunk.ID]
 		chunk.VectorRank = vectorRanks[chunk.Chunk.ID]
 		chunk.VectorScore = vectorScores[chunk.Chunk.ID]
-		chunk.RRFScore = (rrfScore(chunk.FTSRank, opts.RRFK) + rrfScore(chunk.VectorRank, opts.RRFK)) * opts.RRFWeight
-		chunk.FinalScore = chunk.RRFScore + chunk.RecencyBonus + chunk.ThreadBonus + chunk.SafetyPenalty
+		chunk.CommitRank = commitRanks[chunk.Chunk.ID]
+		chunk.RRFScore = (rrfScore(chunk.FTSRank, opts.RRFK) + rrfScore(chunk.VectorRank, opts.RRFK) + rrfScore(chunk.CommitRank, opts.RRFK)) * opts.RRFWeight
+		chunk.FeedbackBonus = opts.FeedbackBonuses[chunk.Chunk.ID]
+		chunk.PopularityBonus = opts.PopularityBonuses[chunk.Chunk.ID]
+		chunk.FinalScore = chunk.RRFScore + chunk.RecencyBonus + chunk.ThreadBonus + chunk.FeedbackBonus + chunk.PopularityBonus + chunk.SafetyPenalty
 		if opts.TimeFilter != nil && chunk.Chunk.CreatedAt.Before(*opts.TimeFilter) {
 			chunk.FinalScore -= 2.0
 		}
 	}
 
+	sortRankedChunks(candidates)
+
+	return candidates
+}
+
+func sortRankedMemories(candidates []RankedMemory) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].FinalScore != candidates[j].FinalScore {
+			return candidates[i].FinalScore > candidates[j].FinalScore
+		}
+		if !candidates[i].Memory.CreatedAt.Equal(candidates[j].Memory.CreatedAt) {
+			return candidates[i].Memory.CreatedAt.After(candidates[j].Memory.CreatedAt)
+		}
+		return candidates[i].Memory.ID < candidates[j].Memory.ID
+	})
+}
+
+func sortRankedChunks(candidates []RankedChunk) {
 	sort.SliceStable(candidates, func(i, j int) bool {
 		if candidates[i].FinalScore != candidates[j].FinalScore {
 			return candidates[i].FinalScore > candidates[j].FinalScore
@@ -267,8 +306,6 @@ func rankChunks(results []store.ChunkResult, vectorOnly []store.Chunk, vectorRes
 		}
 		return candidates[i].Chunk.ID < candidates[j].Chunk.ID
 	})
-
-	return candidates
 }
 
 func matchThreads(query string, memories []RankedMemory) ([]pack.MatchedThread, map[string]struct{}) {
@@ -145,3 +145,33 @@ func writeFile(t testing.TB, dir, name, content string) {
 		t.Fatalf("write file: %v", err)
 	}
 }
+
+func TestRankChunksFusesFileIntentCommitsAsRankedList(t *testing.T) {
+	createdAt := time.Unix(10, 0)
+	results := []store.ChunkResult{
+		{Chunk: store.Chunk{ID: "C-1", CreatedAt: createdAt}, BM25: -3},
+		{Chunk: store.Chunk{ID: "C-2", CreatedAt: createdAt}, BM25: -2},
+		{Chunk: store.Chunk{ID: "C-GIT-1", CreatedAt: createdAt}, BM25: -1},
+	}
+	commits := []store.Chunk{
+		{ID: "C-GIT-2", CreatedAt: createdAt},
+		{ID: "C-GIT-1", CreatedAt: createdAt},
+	}
+	ranked := rankChunks(results, nil, nil, nil, RankOptions{CommitResults: commits})
+	if len(ranked) != 4 {
+		t.Fatalf("expected the unseen commit to join the candidates, got %+v", ranked)
+	}
+	byID := map[string]RankedChunk{}
+	for _, chunk := range ranked {
+		byID[chunk.Chunk.ID] = chunk
+	}
+	if byID["C-GIT-2"].CommitRank != 1 || byID["C-GIT-1"].CommitRank != 2 || byID["C-1"].CommitRank != 0 {
+		t.Fatalf("unexpected commit ranks: %+v", byID)
+	}
+	if byID["C-GIT-2"].RRFScore <= 0 || byID["C-GIT-2"].RRFScore != byID["C-1"].RRFScore {
+		t.Fatalf("expected the top commit to score like the top FTS hit, got commit=%v fts=%v", byID["C-GIT-2"].RRFScore, byID["C-1"].RRFScore)
+	}
+	if ranked[0].Chunk.ID != "C-GIT-1" {
+		t.Fatalf("expected the commit found by FTS and by file to rank first, got %s", ranked[0].Chunk.ID)
+	}
+}
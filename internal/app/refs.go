@@ -0,0 +1,120 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"mem/internal/store"
+)
+
+const defaultRefsLimit = 50
+
+type RefsResponse struct {
+	Query      string            `json:"query"`
+	Kind       string            `json:"kind,omitempty"`
+	RepoID     string            `json:"repo_id"`
+	Workspace  string            `json:"workspace"`
+	References []SymbolReference `json:"references"`
+}
+
+// SymbolReference is a chunk that references the queried name. Match is
+// "exact" when the source spells the name the same way and "name" when only
+// the unqualified name agrees, such as st.Search for Store.Search.
+type SymbolReference struct {
+	ChunkID    string `json:"chunk_id"`
+	RefKind    string `json:"ref_kind"`
+	RefName    string `json:"ref_name"`
+	Match      string `json:"match"`
+	SymbolName string `json:"symbol_name,omitempty"`
+	SymbolKind string `json:"symbol_kind,omitempty"`
+	Locator    string `json:"locator,omitempty"`
+}
+
+func runRefs(args []string, out, errOut io.Writer) int {
+	fs := flag.NewFlagSet("refs", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	kind := fs.String("kind", "", "Reference kind: import|call|type|file")
+	limit := fs.Int("limit", defaultRefsLimit, "Maximum references to return")
+	workspace := fs.String("workspace", "", "Workspace name")
+	repoOverride := fs.String("repo", "", "Override repo id")
+	positional, flagArgs, err := splitFlagArgs(args, map[string]flagSpec{
+		"kind":      {RequiresValue: true},
+		"limit":     {RequiresValue: true},
+		"workspace": {RequiresValue: true},
+		"repo":      {RequiresValue: true},
+	})
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 2
+	}
+	if err := fs.Parse(flagArgs); err != nil {
+		return 2
+	}
+
+	if len(positional) != 1 || strings.TrimSpace(positional[0]) == "" {
+		fmt.Fprintln(errOut, "usage: mem refs <symbol> [--kind import|call|type|file]")
+		return 2
+	}
+	refKind := strings.ToLower(strings.TrimSpace(*kind))
+	switch refKind {
+	case "", store.RefKindImport, store.RefKindCall, store.RefKindType, store.RefKindFile:
+	default:
+		fmt.Fprintln(errOut, "--kind must be import, call, type, or file")
+		return 2
+	}
+	if *limit <= 0 {
+		fmt.Fprintln(errOut, "--limit must be > 0")
+		return 2
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(errOut, "config error: %v\n", err)
+		return 1
+	}
+	workspaceName := resolveWorkspace(cfg, strings.TrimSpace(*workspace))
+	repoInfo, err := resolveRepo(&cfg, strings.TrimSpace(*repoOverride))
+	if err != nil {
+		fmt.Fprintf(errOut, "repo detection error: %v\n", err)
+		return 1
+	}
+	st, err := openStore(cfg, repoInfo.ID)
+	if err != nil {
+		fmt.Fprintf(errOut, "store open error: %v\n", err)
+		return 1
+	}
+	defer st.Close()
+
+	var kinds []string
+	if refKind != "" {
+		kinds = []string{refKind}
+	}
+	name := strings.TrimSpace(positional[0])
+	refs, err := st.FindChunkRefs(repoInfo.ID, workspaceName, name, kinds, *limit)
+	if err != nil {
+		fmt.Fprintf(errOut, "refs lookup error: %v\n", err)
+		return 1
+	}
+
+	resp := RefsResponse{
+		Query:      name,
+		Kind:       refKind,
+		RepoID:     repoInfo.ID,
+		Workspace:  workspaceName,
+		References: make([]SymbolReference, 0, len(refs)),
+	}
+	for _, ref := range refs {
+		resp.References = append(resp.References, SymbolReference{
+			ChunkID:    ref.ID,
+			RefKind:    ref.RefKind,
+			RefName:    ref.RefName,
+			Match:      ref.Match,
+			SymbolName: ref.SymbolName,
+			SymbolKind: ref.SymbolKind,
+			Locator:    ref.Locator,
+		})
+	}
+	return writeJSON(out, errOut, resp)
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"mem/internal/config"
+)
+
+func TestRefsListsCallersAcrossFiles(t *testing.T) {
+	base := t.TempDir()
+	setXDGEnv(t, base)
+	repoDir := setupRepo(t, base)
+	withCwd(t, repoDir)
+	writeTestConfig(t, base, func(cfg *config.Config) {
+		cfg.EmbeddingProvider = "none"
+	})
+
+	writeFile(t, repoDir, "store.go", "package main\n\ntype Store struct{}\n\nfunc (s *Store) Search(q string) {}\n")
+	writeFile(t, repoDir, "main.go", "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tst := &Store{}\n\tst.Search(\"x\")\n\tfmt.Println(\"done\")\n}\n")
+	runCLI(t, "ingest", ".", "--thread", "T-refs")
+
+	var resp RefsResponse
+	if err := json.Unmarshal(runCLI(t, "refs", "Store.Search"), &resp); err != nil {
+		t.Fatalf("decode refs: %v", err)
+	}
+	if len(resp.References) != 1 {
+		t.Fatalf("expected one caller, got %+v", resp.References)
+	}
+	ref := resp.References[0]
+	if ref.SymbolName != "main" || ref.RefKind != "call" || ref.RefName != "st.Search" || ref.Match != "name" || !strings.Contains(ref.Locator, "main.go#L5-L9") {
+		t.Fatalf("unexpected reference %+v", ref)
+	}
+
+	resp = RefsResponse{}
+	if err := json.Unmarshal(runCLI(t, "refs", "Store", "--kind", "type"), &resp); err != nil {
+		t.Fatalf("decode type refs: %v", err)
+	}
+	if len(resp.References) != 1 || resp.References[0].SymbolName != "main" || resp.References[0].Match != "exact" {
+		t.Fatalf("expected the Store composite literal in main, got %+v", resp.References)
+	}
+
+	resp = RefsResponse{}
+	if err := json.Unmarshal(runCLI(t, "refs", "fmt", "--kind", "import"), &resp); err != nil {
+		t.Fatalf("decode import refs: %v", err)
+	}
+	if len(resp.References) != 1 || resp.References[0].SymbolName != "main" {
+		t.Fatalf("expected main to import fmt, got %+v", resp.References)
+	}
+}
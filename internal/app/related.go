@@ -0,0 +1,84 @@
+package app
+
+import (
+	"errors"
+
+	"mem/internal/config"
+	"mem/internal/pack"
+	"mem/internal/store"
+	"mem/internal/token"
+)
+
+// Packs follow chunk_refs one hop. Every call or type reference from a chunk
+// in the pack that resolves to a single live definition is reported in the
+// link trail as "<chunk> depends_on <definition>". With related expansion
+// the definitions themselves are appended to the pack, in reference order,
+// while the token budget has room for them.
+
+const (
+	relDependsOn     = "depends_on"
+	maxRelatedChunks = 5
+)
+
+type relatedResult struct {
+	Chunks    []pack.ChunkItem
+	LinkTrail []pack.LinkTrail
+	// Texts holds each added chunk's stored text, which Chunks may truncate.
+	Texts map[string]string
+}
+
+// relateChunks resolves the dependencies of the budgeted chunks and, when
+// expand is set, adds their definitions to budget within its remaining room.
+func relateChunks(cfg config.Config, st *store.Store, repoID, workspace string, counter TokenCounter, budget *BudgetResult, expand bool) (relatedResult, error) {
+	var result relatedResult
+	chunkIDs := make([]string, 0, len(budget.Chunks))
+	for _, chunk := range budget.Chunks {
+		chunkIDs = append(chunkIDs, chunk.ChunkID)
+	}
+	deps, err := st.ListChunkDependencies(repoID, workspace, chunkIDs)
+	if err != nil || len(deps) == 0 {
+		return result, err
+	}
+
+	included := make(map[string]bool, len(budget.IncludedChunkIDs))
+	for id := range budget.IncludedChunkIDs {
+		included[id] = true
+	}
+	for _, dep := range deps {
+		result.LinkTrail = append(result.LinkTrail, pack.LinkTrail{From: dep.FromID, Rel: relDependsOn, To: dep.ID})
+		if !expand || included[dep.ID] || len(result.Chunks) >= maxRelatedChunks {
+			continue
+		}
+		text, originalTokens, tokens, err := summarizeChunk(counter, dep.Text, dep.TextTokens, cfg.ChunkMaxEach)
+		if errors.Is(err, ErrTokenizerRequired) {
+			counter, err = token.New(cfg.Tokenizer)
+			if err == nil {
+				text, originalTokens, tokens, err = summarizeChunk(counter, dep.Text, dep.TextTokens, cfg.ChunkMaxEach)
+			}
+		}
+		if err != nil {
+			return result, err
+		}
+		if tokens == 0 || budget.UsedTokens+tokens > cfg.TokenBudget {
+			continue
+		}
+		included[dep.ID] = true
+		if result.Texts == nil {
+			result.Texts = map[string]string{}
+		}
+		result.Texts[dep.ID] = dep.Text
+		budget.UsedTokens += tokens
+		budget.CandidateTokens += originalTokens
+		budget.TruncatedTokens += originalTokens - tokens
+		budget.SavedTokens = budget.CandidateTokens - budget.UsedTokens
+		result.Chunks = append(result.Chunks, pack.ChunkItem{
+			ChunkID:    dep.ID,
+			ArtifactID: dep.ArtifactID,
+			ThreadID:   dep.ThreadID,
+			Locator:    dep.Locator,
+			Text:       text,
+			RelatedTo:  dep.FromID,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	"mem/internal/config"
+	"mem/internal/pack"
+)
+
+func TestContextPackLinksAndExpandsReferencedDefinitions(t *testing.T) {
+	base := t.TempDir()
+	setXDGEnv(t, base)
+	repoDir := setupRepo(t, base)
+	withCwd(t, repoDir)
+	writeTestConfig(t, base, func(cfg *config.Config) {
+		cfg.EmbeddingProvider = "none"
+	})
+
+	writeFile(t, repoDir, "store.go", "package main\n\ntype Store struct{}\n\nfunc (s *Store) Search(q string) {}\n\nfunc (s *Store) Close() {}\n")
+	writeFile(t, repoDir, "index.go", "package main\n\ntype Index struct{}\n\nfunc (i *Index) Close() {}\n")
+	writeFile(t, repoDir, "main.go", "package main\n\nfunc main() {\n\tst := &Store{}\n\tst.Search(\"zebrafish\")\n\tst.Close()\n}\n")
+	runCLI(t, "ingest", ".", "--thread", "T-related")
+
+	symbolID := func(name, kind string) string {
+		var resp SymbolResponse
+		if err := json.Unmarshal(runCLI(t, "symbol", name, "--kind", kind), &resp); err != nil {
+			t.Fatalf("decode symbol %s: %v", name, err)
+		}
+		if len(resp.Definitions) == 0 {
+			t.Fatalf("no definition for %s", name)
+		}
+		return resp.Definitions[0].ChunkID
+	}
+	mainID, searchID, storeID := symbolID("main", "func"), symbolID("Store.Search", "method"), symbolID("Store", "type")
+
+	var plain pack.ContextPack
+	if err := json.Unmarshal(runCLI(t, "get", "zebrafish"), &plain); err != nil {
+		t.Fatalf("decode pack: %v", err)
+	}
+	if len(plain.TopChunks) != 1 || plain.TopChunks[0].ChunkID != mainID {
+		t.Fatalf("expected only main in the pack, got %+v", plain.TopChunks)
+	}
+	deps := map[string]bool{}
+	for _, link := range plain.LinkTrail {
+		if link.Rel == "depends_on" && link.From == mainID {
+			deps[link.To] = true
+		}
+	}
+	// st.Close is ambiguous between Store.Close and Index.Close.
+	if len(deps) != 2 || !deps[searchID] || !deps[storeID] {
+		t.Fatalf("expected main to depend on Store.Search and Store, got %+v", plain.LinkTrail)
+	}
+
+	var expanded pack.ContextPack
+	if err := json.Unmarshal(runCLI(t, "get", "zebrafish", "--related"), &expanded); err != nil {
+		t.Fatalf("decode related pack: %v", err)
+	}
+	related := map[string]string{}
+	for _, chunk := range expanded.TopChunks[1:] {
+		related[chunk.ChunkID] = chunk.RelatedTo
+	}
+	if len(expanded.TopChunks) != 3 || related[searchID] != mainID || related[storeID] != mainID {
+		t.Fatalf("expected Store.Search and Store added after main, got %+v", expanded.TopChunks)
+	}
+	if expanded.Budget.UsedTotal <= plain.Budget.UsedTotal {
+		t.Fatalf("expected related chunks to count against the budget, got %d vs %d", expanded.Budget.UsedTotal, plain.Budget.UsedTotal)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"mem/internal/config"
 	"mem/internal/store"
 )
 
@@ -45,11 +46,26 @@ func runRepos(args []string, out, errOut io.Writer) int {
 		return 1
 	}
 
+	items, err := listKnownRepos(cfg)
+	if err != nil {
+		fmt.Fprintf(errOut, "repos error: %v\n", err)
+		return 1
+	}
+
+	if formatValue == "json" {
+		return writeJSON(out, errOut, items)
+	}
+	writeReposTable(out, items, *fullPaths)
+	return 0
+}
+
+// listKnownRepos lists repos with a readable database under the data dir,
+// most recently seen first.
+func listKnownRepos(cfg config.Config) ([]RepoListItem, error) {
 	repoDir := cfg.RepoRootDir()
 	entries, err := os.ReadDir(repoDir)
 	if err != nil && !os.IsNotExist(err) {
-		fmt.Fprintf(errOut, "repos error: %v\n", err)
-		return 1
+		return nil, err
 	}
 
 	var items []RepoListItem
@@ -83,12 +99,7 @@ func runRepos(args []string, out, errOut io.Writer) int {
 	sort.SliceStable(items, func(i, j int) bool {
 		return items[i].LastSeenAt > items[j].LastSeenAt
 	})
-
-	if formatValue == "json" {
-		return writeJSON(out, errOut, items)
-	}
-	writeReposTable(out, items, *fullPaths)
-	return 0
+	return items, nil
 }
 
 func writeReposTable(out io.Writer, items []RepoListItem, fullPaths bool) {
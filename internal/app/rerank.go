@@ -0,0 +1,178 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"mem/internal/config"
+	"mem/internal/embed"
+)
+
+const (
+	defaultRerankTopN      = 20
+	defaultRerankTimeout   = 1500 * time.Millisecond
+	rerankBonusWeight      = 1.0
+	rerankMaxDocumentRunes = 2000
+)
+
+type RerankStatus struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model,omitempty"`
+	Enabled  bool   `json:"enabled"`
+	Applied  bool   `json:"applied"`
+	TopN     int    `json:"top_n,omitempty"`
+	FellBack bool   `json:"fell_back,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func resolveReranker(cfg config.Config) (embed.Reranker, RerankStatus) {
+	reranker, status := embed.ResolveReranker(cfg)
+	topN := cfg.RerankTopN
+	if topN <= 0 {
+		topN = defaultRerankTopN
+	}
+	return reranker, RerankStatus{
+		Provider: strings.TrimSpace(status.Provider),
+		Model:    strings.TrimSpace(status.Model),
+		Enabled:  status.Enabled,
+		TopN:     topN,
+		Error:    status.Error,
+	}
+}
+
+func rerankTimeout(cfg config.Config) time.Duration {
+	if cfg.RerankTimeoutMS <= 0 {
+		return defaultRerankTimeout
+	}
+	return time.Duration(cfg.RerankTimeoutMS) * time.Millisecond
+}
+
+// rerankCandidates rescores the top-N fused memories and chunks in place and
+// re-sorts them. Any failure, including the timeout, leaves the fused order
+// untouched for the affected list.
+func rerankCandidates(cfg config.Config, query string, memories []RankedMemory, chunks []RankedChunk) RerankStatus {
+	reranker, status := resolveReranker(cfg)
+	if !status.Enabled || reranker == nil {
+		return status
+	}
+	return applyRerank(reranker, status, rerankTimeout(cfg), query, memories, chunks)
+}
+
+func applyRerank(reranker embed.Reranker, status RerankStatus, timeout time.Duration, query string, memories []RankedMemory, chunks []RankedChunk) RerankStatus {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	errs := []string{}
+	memCount := min(status.TopN, len(memories))
+	if memCount > 0 {
+		docs := make([]string, memCount)
+		for i := 0; i < memCount; i++ {
+			docs[i] = rerankDocument(memories[i].Memory.Title + "\n" + memories[i].Memory.Summary)
+		}
+		scores, err := callReranker(ctx, reranker, query, docs)
+		if err != nil {
+			errs = append(errs, "memories: "+err.Error())
+		} else {
+			bonuses := rerankBonuses(scores)
+			for i := 0; i < memCount; i++ {
+				mem := &memories[i]
+				mem.Reranked = true
+				mem.RerankScore = scores[i]
+				mem.RerankBonus = bonuses[i]
+				mem.FinalScore += mem.RerankBonus
+			}
+			sortRankedMemories(memories)
+			status.Applied = true
+		}
+	}
+
+	chunkCount := min(status.TopN, len(chunks))
+	if chunkCount > 0 {
+		docs := make([]string, chunkCount)
+		for i := 0; i < chunkCount; i++ {
+			docs[i] = rerankDocument(chunks[i].Chunk.Text)
+		}
+		scores, err := callReranker(ctx, reranker, query, docs)
+		if err != nil {
+			errs = append(errs, "chunks: "+err.Error())
+		} else {
+			bonuses := rerankBonuses(scores)
+			for i := 0; i < chunkCount; i++ {
+				chunk := &chunks[i]
+				chunk.Reranked = true
+				chunk.RerankScore = scores[i]
+				chunk.RerankBonus = bonuses[i]
+				chunk.FinalScore += chunk.RerankBonus
+			}
+			sortRankedChunks(chunks)
+			status.Applied = true
+		}
+	}
+
+	if len(errs) > 0 {
+		status.FellBack = true
+		status.Error = strings.Join(errs, "; ")
+	}
+	return status
+}
+
+func callReranker(ctx context.Context, reranker embed.Reranker, query string, docs []string) ([]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, rerankContextError(err)
+	}
+	scores, err := reranker.Rerank(ctx, query, docs)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, rerankContextError(ctxErr)
+		}
+		return nil, err
+	}
+	if len(scores) != len(docs) {
+		return nil, fmt.Errorf("rerank count mismatch: expected %d, got %d", len(docs), len(scores))
+	}
+	return scores, nil
+}
+
+func rerankContextError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return errors.New("rerank timed out")
+	}
+	return err
+}
+
+// rerankBonuses min-max normalizes reranker scores into [0, rerankBonusWeight]
+// so cross-encoder and LLM graders land on the same scale as the fused score.
+func rerankBonuses(scores []float64) []float64 {
+	bonuses := make([]float64, len(scores))
+	if len(scores) == 0 {
+		return bonuses
+	}
+	lo, hi := scores[0], scores[0]
+	for _, score := range scores[1:] {
+		if score < lo {
+			lo = score
+		}
+		if score > hi {
+			hi = score
+		}
+	}
+	if hi == lo {
+		return bonuses
+	}
+	for i, score := range scores {
+		bonuses[i] = (score - lo) / (hi - lo) * rerankBonusWeight
+	}
+	return bonuses
+}
+
+func rerankDocument(text string) string {
+	text = strings.TrimSpace(text)
+	runes := []rune(text)
+	if len(runes) > rerankMaxDocumentRunes {
+		return string(runes[:rerankMaxDocumentRunes])
+	}
+	return text
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mem/internal/store"
+)
+
+type fakeReranker struct {
+	scores map[string]float64
+	delay  time.Duration
+	err    error
+}
+
+func (fakeReranker) Name() string {
+	return "fake"
+}
+
+func (f fakeReranker) Rerank(ctx context.Context, query string, documents []string) ([]float64, error) {
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	scores := make([]float64, len(documents))
+	for i, doc := range documents {
+		scores[i] = f.scores[doc]
+	}
+	return scores, nil
+}
+
+func rerankTestMemories() []RankedMemory {
+	return []RankedMemory{
+		{Memory: store.Memory{ID: "M-1", Title: "first", CreatedAt: time.Unix(10, 0)}, FinalScore: 1.2},
+		{Memory: store.Memory{ID: "M-2", Title: "second", CreatedAt: time.Unix(10, 0)}, FinalScore: 1.1},
+		{Memory: store.Memory{ID: "M-3", Title: "third", CreatedAt: time.Unix(10, 0)}, FinalScore: 0.5},
+	}
+}
+
+func TestApplyRerankReordersTopN(t *testing.T) {
+	memories := rerankTestMemories()
+	reranker := fakeReranker{scores: map[string]float64{"first": 0.1, "second": 0.9}}
+
+	status := applyRerank(reranker, RerankStatus{Enabled: true, TopN: 2}, time.Second, "q", memories, nil)
+	if !status.Applied || status.FellBack {
+		t.Fatalf("expected rerank to apply, got %#v", status)
+	}
+	if memories[0].Memory.ID != "M-2" || memories[1].Memory.ID != "M-1" {
+		t.Fatalf("expected M-2 then M-1, got %s then %s", memories[0].Memory.ID, memories[1].Memory.ID)
+	}
+	if !memories[0].Reranked || memories[0].RerankScore != 0.9 || memories[0].RerankBonus != rerankBonusWeight {
+		t.Fatalf("unexpected rerank fields: %#v", memories[0])
+	}
+	if memories[2].Reranked {
+		t.Fatalf("expected candidates beyond top-N to be left alone")
+	}
+}
+
+func TestApplyRerankFallsBackOnTimeout(t *testing.T) {
+	memories := rerankTestMemories()
+	reranker := fakeReranker{delay: time.Second}
+
+	status := applyRerank(reranker, RerankStatus{Enabled: true, TopN: 3}, 10*time.Millisecond, "q", memories, nil)
+	if status.Applied || !status.FellBack {
+		t.Fatalf("expected fallback, got %#v", status)
+	}
+	if status.Error != "memories: rerank timed out" {
+		t.Fatalf("unexpected error: %q", status.Error)
+	}
+	if memories[0].Memory.ID != "M-1" || memories[0].FinalScore != 1.2 || memories[0].Reranked {
+		t.Fatalf("expected fused order to be preserved, got %#v", memories[0])
+	}
+}
+
+func TestApplyRerankFallsBackOnError(t *testing.T) {
+	chunks := []RankedChunk{
+		{Chunk: store.Chunk{ID: "C-1", Text: "a"}, FinalScore: 1},
+		{Chunk: store.Chunk{ID: "C-2", Text: "b"}, FinalScore: 0.5},
+	}
+	status := applyRerank(fakeReranker{err: errors.New("boom")}, RerankStatus{Enabled: true, TopN: 5}, time.Second, "q", nil, chunks)
+	if !status.FellBack || status.Error != "chunks: boom" {
+		t.Fatalf("expected chunk fallback, got %#v", status)
+	}
+	if chunks[0].Chunk.ID != "C-1" {
+		t.Fatalf("expected fused order to be preserved")
+	}
+}
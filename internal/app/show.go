@@ -7,6 +7,8 @@ import (
 	"io"
 	"strings"
 	"time"
+
+	"mem/internal/pack"
 )
 
 type ShowResponse struct {
@@ -16,29 +18,31 @@ type ShowResponse struct {
 }
 
 type MemoryDetail struct {
-	ID           string `json:"id"`
-	RepoID       string `json:"repo_id"`
-	ThreadID     string `json:"thread_id,omitempty"`
-	Title        string `json:"title"`
-	Summary      string `json:"summary"`
-	TagsJSON     string `json:"tags_json,omitempty"`
-	EntitiesJSON string `json:"entities_json,omitempty"`
-	CreatedAt    string `json:"created_at"`
-	AnchorCommit string `json:"anchor_commit,omitempty"`
-	SupersededBy string `json:"superseded_by,omitempty"`
-	DeletedAt    string `json:"deleted_at,omitempty"`
+	ID           string   `json:"id"`
+	RepoID       string   `json:"repo_id"`
+	ThreadID     string   `json:"thread_id,omitempty"`
+	Title        string   `json:"title"`
+	Summary      string   `json:"summary"`
+	TagsJSON     string   `json:"tags_json,omitempty"`
+	EntitiesJSON string   `json:"entities_json,omitempty"`
+	CreatedAt    string   `json:"created_at"`
+	AnchorCommit string   `json:"anchor_commit,omitempty"`
+	SupersededBy string   `json:"superseded_by,omitempty"`
+	Supersedes   []string `json:"supersedes,omitempty"`
+	DeletedAt    string   `json:"deleted_at,omitempty"`
 }
 
 type ChunkDetail struct {
-	ID         string `json:"id"`
-	RepoID     string `json:"repo_id"`
-	ArtifactID string `json:"artifact_id,omitempty"`
-	ThreadID   string `json:"thread_id,omitempty"`
-	Locator    string `json:"locator,omitempty"`
-	Text       string `json:"text"`
-	TagsJSON   string `json:"tags_json,omitempty"`
-	CreatedAt  string `json:"created_at"`
-	DeletedAt  string `json:"deleted_at,omitempty"`
+	ID         string               `json:"id"`
+	RepoID     string               `json:"repo_id"`
+	ArtifactID string               `json:"artifact_id,omitempty"`
+	ThreadID   string               `json:"thread_id,omitempty"`
+	Locator    string               `json:"locator,omitempty"`
+	Text       string               `json:"text"`
+	TagsJSON   string               `json:"tags_json,omitempty"`
+	CreatedAt  string               `json:"created_at"`
+	DeletedAt  string               `json:"deleted_at,omitempty"`
+	Freshness  *pack.ChunkFreshness `json:"freshness,omitempty"`
 }
 
 func runShow(args []string, out, errOut io.Writer) int {
@@ -111,6 +115,9 @@ func runShow(args []string, out, errOut io.Writer) int {
 				SupersededBy: mem.SupersededBy,
 				DeletedAt:    formatTime(mem.DeletedAt),
 			}
+			if supersedes, err := st.ListSupersededMemoryIDs(repoInfo.ID, workspaceName, mem.ID); err == nil {
+				resp.Memory.Supersedes = supersedes
+			}
 			return writeJSON(out, errOut, resp)
 		}
 	}
@@ -129,6 +136,7 @@ func runShow(args []string, out, errOut io.Writer) int {
 				TagsJSON:   chunk.TagsJSON,
 				CreatedAt:  chunk.CreatedAt.UTC().Format(time.RFC3339Nano),
 				DeletedAt:  formatTime(chunk.DeletedAt),
+				Freshness:  newChunkResolver(repoInfo.GitRoot).resolve(chunk.Locator, chunk.Text),
 			}
 			return writeJSON(out, errOut, resp)
 		}
@@ -0,0 +1,434 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"mem/internal/config"
+	"mem/internal/repo"
+	"mem/internal/store"
+	"mem/internal/token"
+)
+
+// File sources are compared with the working tree by content hash: a
+// source is "current" when the file still hashes to what was last ingested
+// into the thread, "stale" when it changed, and "missing" when it is gone.
+// Other kinds, such as commits, have nothing on disk to compare.
+
+const (
+	sourceCurrent = "current"
+	sourceStale   = "stale"
+	sourceMissing = "missing"
+)
+
+type SourcesResponse struct {
+	RepoID    string       `json:"repo_id"`
+	Workspace string       `json:"workspace"`
+	Sources   []SourceItem `json:"sources"`
+}
+
+type SourceItem struct {
+	Source      string `json:"source"`
+	Kind        string `json:"kind"`
+	Thread      string `json:"thread"`
+	Chunks      int    `json:"chunks"`
+	Tokens      int    `json:"tokens"`
+	IngestedAt  string `json:"ingested_at"`
+	ContentHash string `json:"content_hash,omitempty"`
+	Status      string `json:"status,omitempty"`
+}
+
+type SourcesPruneResponse struct {
+	DryRun        bool         `json:"dry_run,omitempty"`
+	SourcesPruned int          `json:"sources_pruned"`
+	ChunksRemoved int          `json:"chunks_removed"`
+	Pruned        []SourceItem `json:"pruned"`
+}
+
+func runSources(args []string, out, errOut io.Writer) int {
+	if len(args) > 0 {
+		switch strings.ToLower(strings.TrimSpace(args[0])) {
+		case "prune":
+			return runSourcesPrune(args[1:], out, errOut)
+		case "reingest":
+			return runSourcesReingest(args[1:], out, errOut)
+		}
+	}
+
+	fs := flag.NewFlagSet("sources", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	stale := fs.Bool("stale", false, "Only list sources changed on disk since ingest")
+	missing := fs.Bool("missing", false, "Only list sources deleted from disk")
+	threadID := fs.String("thread", "", "Only list sources in this thread")
+	kind := fs.String("kind", "", "Only list sources of this artifact kind")
+	format := fs.String("format", "table", "Output format: table|json")
+	repoOverride := fs.String("repo", "", "Override repo id")
+	workspace := fs.String("workspace", "", "Workspace name")
+	positional, flagArgs, err := splitFlagArgs(args, map[string]flagSpec{
+		"stale":     {RequiresValue: false},
+		"missing":   {RequiresValue: false},
+		"thread":    {RequiresValue: true},
+		"kind":      {RequiresValue: true},
+		"format":    {RequiresValue: true},
+		"repo":      {RequiresValue: true},
+		"workspace": {RequiresValue: true},
+	})
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 2
+	}
+	if err := fs.Parse(flagArgs); err != nil {
+		return 2
+	}
+	if len(positional) > 0 {
+		fmt.Fprintf(errOut, "unknown sources command: %s\n", positional[0])
+		return 2
+	}
+	formatValue := strings.ToLower(strings.TrimSpace(*format))
+	if formatValue != "table" && formatValue != "json" {
+		fmt.Fprintf(errOut, "unsupported format: %s\n", *format)
+		return 2
+	}
+
+	scope, code := openSourcesScope(strings.TrimSpace(*repoOverride), strings.TrimSpace(*workspace), errOut)
+	if code != 0 {
+		return code
+	}
+	defer scope.st.Close()
+
+	items, err := scope.list(strings.TrimSpace(*threadID), strings.TrimSpace(*kind))
+	if err != nil {
+		fmt.Fprintf(errOut, "sources error: %v\n", err)
+		return 1
+	}
+	if *stale || *missing {
+		filtered := items[:0]
+		for _, item := range items {
+			if (*stale && item.Status == sourceStale) || (*missing && item.Status == sourceMissing) {
+				filtered = append(filtered, item)
+			}
+		}
+		items = filtered
+	}
+
+	if formatValue == "json" {
+		return writeJSON(out, errOut, SourcesResponse{RepoID: scope.repoInfo.ID, Workspace: scope.workspace, Sources: items})
+	}
+	writeSourcesTable(out, items)
+	return 0
+}
+
+func runSourcesPrune(args []string, out, errOut io.Writer) int {
+	fs := flag.NewFlagSet("sources prune", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	threadID := fs.String("thread", "", "Only prune sources in this thread")
+	dryRun := fs.Bool("dry-run", false, "Report what would be pruned without changing anything")
+	repoOverride := fs.String("repo", "", "Override repo id")
+	workspace := fs.String("workspace", "", "Workspace name")
+	positional, flagArgs, err := splitFlagArgs(args, map[string]flagSpec{
+		"thread":    {RequiresValue: true},
+		"dry-run":   {RequiresValue: false},
+		"repo":      {RequiresValue: true},
+		"workspace": {RequiresValue: true},
+	})
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 2
+	}
+	if err := fs.Parse(flagArgs); err != nil {
+		return 2
+	}
+	if len(positional) > 0 {
+		fmt.Fprintln(errOut, "usage: mem sources prune [--thread <id>] [--dry-run]")
+		return 2
+	}
+
+	scope, code := openSourcesScope(strings.TrimSpace(*repoOverride), strings.TrimSpace(*workspace), errOut)
+	if code != 0 {
+		return code
+	}
+	defer scope.st.Close()
+
+	items, err := scope.list(strings.TrimSpace(*threadID), "file")
+	if err != nil {
+		fmt.Fprintf(errOut, "sources error: %v\n", err)
+		return 1
+	}
+	resp := SourcesPruneResponse{DryRun: *dryRun, Pruned: []SourceItem{}}
+	for _, item := range items {
+		if item.Status != sourceMissing {
+			continue
+		}
+		removed := item.Chunks
+		if !*dryRun {
+			removed, err = scope.st.DeleteThreadChunksBySource(scope.repoInfo.ID, scope.workspace, item.Thread, item.Source)
+			if err != nil {
+				fmt.Fprintf(errOut, "prune error: %v\n", err)
+				return 1
+			}
+		}
+		resp.SourcesPruned++
+		resp.ChunksRemoved += removed
+		resp.Pruned = append(resp.Pruned, item)
+	}
+	return writeJSON(out, errOut, resp)
+}
+
+func runSourcesReingest(args []string, out, errOut io.Writer) int {
+	fs := flag.NewFlagSet("sources reingest", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	stale := fs.Bool("stale", false, "Re-ingest sources changed on disk since ingest")
+	threadID := fs.String("thread", "", "Only re-ingest sources in this thread")
+	workers := fs.Int("workers", 0, "Files read and chunked in parallel (default: number of CPUs)")
+	repoOverride := fs.String("repo", "", "Override repo id")
+	workspace := fs.String("workspace", "", "Workspace name")
+	positional, flagArgs, err := splitFlagArgs(args, map[string]flagSpec{
+		"stale":     {RequiresValue: false},
+		"thread":    {RequiresValue: true},
+		"workers":   {RequiresValue: true},
+		"repo":      {RequiresValue: true},
+		"workspace": {RequiresValue: true},
+	})
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 2
+	}
+	if err := fs.Parse(flagArgs); err != nil {
+		return 2
+	}
+	if len(positional) > 0 || !*stale {
+		fmt.Fprintln(errOut, "usage: mem sources reingest --stale [--thread <id>] [--workers <n>]")
+		return 2
+	}
+	if *workers < 0 {
+		fmt.Fprintln(errOut, "--workers must not be negative")
+		return 2
+	}
+
+	scope, code := openSourcesScope(strings.TrimSpace(*repoOverride), strings.TrimSpace(*workspace), errOut)
+	if code != 0 {
+		return code
+	}
+	defer scope.st.Close()
+
+	items, err := scope.list(strings.TrimSpace(*threadID), "file")
+	if err != nil {
+		fmt.Fprintf(errOut, "sources error: %v\n", err)
+		return 1
+	}
+	var threads []string
+	pathsByThread := map[string][]string{}
+	for _, item := range items {
+		if item.Status != sourceStale {
+			continue
+		}
+		if _, ok := pathsByThread[item.Thread]; !ok {
+			threads = append(threads, item.Thread)
+		}
+		pathsByThread[item.Thread] = append(pathsByThread[item.Thread], scope.sourcePath(item.Source))
+	}
+
+	repoCfg, _, err := config.LoadRepoConfig(scope.root)
+	if err != nil {
+		fmt.Fprintf(errOut, "repo config error: %v\n", err)
+		return 1
+	}
+	chunkers, err := newChunkerSelector(repoCfg, defaultIngestChunkTokens, defaultIngestOverlapTokens, false)
+	if err != nil {
+		fmt.Fprintf(errOut, "repo config error: %v\n", err)
+		return 1
+	}
+	counter, err := token.New(scope.cfg.Tokenizer)
+	if err != nil {
+		fmt.Fprintf(errOut, "tokenizer error: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var resp IngestResponse
+	for _, thread := range threads {
+		hashes, err := scope.st.SourceHashes(scope.repoInfo.ID, scope.workspace, thread)
+		if err == nil {
+			var threadResp IngestResponse
+			threadResp, err = runIngestPipeline(ctx, ingestPathParams{
+				path:      scope.root,
+				root:      scope.root,
+				matcher:   loadIgnoreMatcher(scope.root),
+				repoInfo:  scope.repoInfo,
+				workspace: scope.workspace,
+				threadID:  thread,
+				maxBytes:  int64(defaultIngestMaxFileMB) * 1024 * 1024,
+				chunkers:  chunkers,
+				st:        scope.st,
+				counter:   counter,
+				workers:   *workers,
+			}, pathsByThread[thread], hashes, IngestResponse{})
+			resp.add(threadResp)
+		}
+		if errors.Is(err, context.Canceled) {
+			writeJSON(out, errOut, resp)
+			fmt.Fprintln(errOut, "reingest interrupted")
+			return 1
+		}
+		if err != nil {
+			fmt.Fprintf(errOut, "reingest error: %v\n", err)
+			return 1
+		}
+	}
+	return writeJSON(out, errOut, resp)
+}
+
+// sourcesScope is the repo, workspace, and store the sources commands
+// operate on, with root the directory file sources are relative to.
+type sourcesScope struct {
+	cfg       config.Config
+	repoInfo  repo.Info
+	workspace string
+	root      string
+	st        *store.Store
+}
+
+func openSourcesScope(repoOverride, workspace string, errOut io.Writer) (sourcesScope, int) {
+	var scope sourcesScope
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(errOut, "config error: %v\n", err)
+		return scope, 1
+	}
+	scope.cfg = cfg
+	scope.workspace = resolveWorkspace(cfg, workspace)
+	scope.repoInfo, err = resolveRepo(&scope.cfg, repoOverride)
+	if err != nil {
+		fmt.Fprintf(errOut, "repo detection error: %v\n", err)
+		return scope, 1
+	}
+	scope.root = scope.repoInfo.GitRoot
+	if scope.root == "" {
+		if scope.root, err = os.Getwd(); err != nil {
+			fmt.Fprintf(errOut, "path error: %v\n", err)
+			return scope, 1
+		}
+	}
+	scope.st, err = openStore(scope.cfg, scope.repoInfo.ID)
+	if err != nil {
+		fmt.Fprintf(errOut, "store open error: %v\n", err)
+		return scope, 1
+	}
+	return scope, 0
+}
+
+func (s sourcesScope) sourcePath(source string) string {
+	if filepath.IsAbs(source) {
+		return source
+	}
+	return filepath.Join(s.root, filepath.FromSlash(source))
+}
+
+// list returns the live sources, optionally narrowed to one thread and
+// kind, with file sources checked against disk.
+func (s sourcesScope) list(threadID, kind string) ([]SourceItem, error) {
+	summaries, err := s.st.ListSourceSummaries(s.repoInfo.ID, s.workspace)
+	if err != nil {
+		return nil, err
+	}
+	hashes := map[string]string{}
+	items := []SourceItem{}
+	for _, summary := range summaries {
+		if (threadID != "" && summary.ThreadID != threadID) || (kind != "" && summary.Kind != kind) {
+			continue
+		}
+		item := SourceItem{
+			Source:      summary.Source,
+			Kind:        summary.Kind,
+			Thread:      summary.ThreadID,
+			Chunks:      summary.Chunks,
+			Tokens:      summary.Tokens,
+			IngestedAt:  summary.IngestedAt.UTC().Format(time.RFC3339Nano),
+			ContentHash: summary.ContentHash,
+		}
+		if summary.Kind == "file" {
+			hash, ok := hashes[summary.Source]
+			if !ok {
+				hash, err = fileContentHash(s.sourcePath(summary.Source))
+				if err != nil && !os.IsNotExist(err) {
+					return nil, err
+				}
+				hashes[summary.Source] = hash
+			}
+			switch {
+			case hash == "":
+				item.Status = sourceMissing
+			case hash == summary.ContentHash:
+				item.Status = sourceCurrent
+			default:
+				item.Status = sourceStale
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// fileContentHash hashes path the way ingest does, or returns "" and the
+// not-exist error when the file is gone.
+func fileContentHash(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func writeSourcesTable(out io.Writer, items []SourceItem) {
+	headers := []string{"SOURCE", "KIND", "THREAD", "CHUNKS", "TOKENS", "INGESTED", "STATUS"}
+	rows := make([][]string, 0, len(items))
+	for _, item := range items {
+		ingested := item.IngestedAt
+		if parsed, err := time.Parse(time.RFC3339Nano, item.IngestedAt); err == nil {
+			ingested = parsed.Format("2006-01-02 15:04")
+		}
+		status := item.Status
+		if status == "" {
+			status = "-"
+		}
+		rows = append(rows, []string{
+			truncateMiddle(item.Source, 60), item.Kind, item.Thread,
+			strconv.Itoa(item.Chunks), strconv.Itoa(item.Tokens), ingested, status,
+		})
+	}
+
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = len(header)
+	}
+	for _, row := range rows {
+		for i, col := range row {
+			if len(col) > widths[i] {
+				widths[i] = len(col)
+			}
+		}
+	}
+
+	border := asciiBorder(widths)
+	fmt.Fprintln(out, border)
+	writeASCIIRow(out, widths, headers)
+	fmt.Fprintln(out, border)
+	for _, row := range rows {
+		writeASCIIRow(out, widths, row)
+	}
+	fmt.Fprintln(out, border)
+}
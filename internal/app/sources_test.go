@@ -0,0 +1,95 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mem/internal/config"
+)
+
+func TestSourcesReportStalenessAndPruneAndReingest(t *testing.T) {
+	base := t.TempDir()
+	setXDGEnv(t, base)
+	repoDir := setupRepo(t, base)
+	withCwd(t, repoDir)
+	writeTestConfig(t, base, func(cfg *config.Config) {
+		cfg.EmbeddingProvider = "none"
+	})
+
+	writeFile(t, repoDir, "keep.go", "package demo\n\nfunc Keep() {}\n")
+	writeFile(t, repoDir, "edit.go", "package demo\n\nfunc Edit() {}\n")
+	writeFile(t, repoDir, "gone.go", "package demo\n\nfunc Gone() {}\n")
+	runCLI(t, "ingest", ".", "--thread", "T-src", "--progress", "none")
+
+	writeFile(t, repoDir, "edit.go", "package demo\n\nfunc Edit() { println() }\n")
+	if err := os.Remove(filepath.Join(repoDir, "gone.go")); err != nil {
+		t.Fatalf("remove gone.go: %v", err)
+	}
+
+	list := func(args ...string) map[string]SourceItem {
+		t.Helper()
+		var resp SourcesResponse
+		if err := json.Unmarshal(runCLI(t, append([]string{"sources", "--format", "json"}, args...)...), &resp); err != nil {
+			t.Fatalf("decode sources: %v", err)
+		}
+		items := map[string]SourceItem{}
+		for _, item := range resp.Sources {
+			items[item.Source] = item
+		}
+		return items
+	}
+
+	all := list()
+	for source, want := range map[string]string{"keep.go": sourceCurrent, "edit.go": sourceStale, "gone.go": sourceMissing, "file.txt": sourceCurrent} {
+		item, ok := all[source]
+		if !ok || item.Status != want || item.Kind != "file" || item.Thread != "T-src" || item.Chunks == 0 || item.Tokens == 0 {
+			t.Fatalf("expected %s to be %s, got %+v", source, want, item)
+		}
+	}
+	if stale := list("--stale"); len(stale) != 1 || stale["edit.go"].Status != sourceStale {
+		t.Fatalf("expected only edit.go with --stale, got %+v", stale)
+	}
+	if both := list("--stale", "--missing"); len(both) != 2 {
+		t.Fatalf("expected stale and missing sources, got %+v", both)
+	}
+
+	var prune SourcesPruneResponse
+	if err := json.Unmarshal(runCLI(t, "sources", "prune", "--dry-run"), &prune); err != nil {
+		t.Fatalf("decode prune: %v", err)
+	}
+	if !prune.DryRun || prune.SourcesPruned != 1 || prune.Pruned[0].Source != "gone.go" {
+		t.Fatalf("unexpected dry-run prune %+v", prune)
+	}
+	if missing := list("--missing"); len(missing) != 1 {
+		t.Fatalf("expected dry run to leave gone.go, got %+v", missing)
+	}
+	if err := json.Unmarshal(runCLI(t, "sources", "prune"), &prune); err != nil {
+		t.Fatalf("decode prune: %v", err)
+	}
+	if prune.SourcesPruned != 1 || prune.ChunksRemoved == 0 {
+		t.Fatalf("unexpected prune %+v", prune)
+	}
+	if missing := list("--missing"); len(missing) != 0 {
+		t.Fatalf("expected gone.go pruned, got %+v", missing)
+	}
+
+	var resp IngestResponse
+	if err := json.Unmarshal(runCLI(t, "sources", "reingest", "--stale"), &resp); err != nil {
+		t.Fatalf("decode reingest: %v", err)
+	}
+	if resp.FilesIngested != 1 || resp.FilesUpdated != 1 {
+		t.Fatalf("expected edit.go re-ingested, got %+v", resp)
+	}
+	for source, item := range list() {
+		if item.Status != sourceCurrent {
+			t.Fatalf("expected %s current after reingest, got %+v", source, item)
+		}
+	}
+
+	if errOut := runCLIExpectError(t, "sources", "reingest"); !strings.Contains(errOut, "usage: mem sources reingest --stale") {
+		t.Fatalf("expected usage error, got %q", errOut)
+	}
+}
@@ -189,34 +189,36 @@ func runSupersede(args []string, out, errOut io.Writer) int {
 		return 1
 	}
 
-	if err := st.MarkMemorySuperseded(repoInfo.ID, workspaceName, oldMem.ID, newMem.ID); err != nil {
+	if err := markSuperseded(st, repoInfo.ID, workspaceName, oldMem.ID, newMem.ID, createdAt); err != nil {
 		fmt.Fprintf(errOut, "supersede link error: %v\n", err)
 		return 1
 	}
 
-	linkTime := createdAt
+	return writeJSON(out, errOut, SupersedeResponse{OldID: oldMem.ID, NewID: newMem.ID, Status: "superseded"})
+}
+
+// markSuperseded retires oldID in favour of newID and records the
+// superseded_by/supersedes link pair.
+func markSuperseded(st *store.Store, repoID, workspace, oldID, newID string, at time.Time) error {
+	if err := st.MarkMemorySuperseded(repoID, workspace, oldID, newID); err != nil {
+		return err
+	}
 	if err := st.AddLink(store.Link{
-		FromID:    oldMem.ID,
+		FromID:    oldID,
 		Rel:       "superseded_by",
-		ToID:      newMem.ID,
+		ToID:      newID,
 		Weight:    1,
-		CreatedAt: linkTime,
+		CreatedAt: at,
 	}); err != nil {
-		fmt.Fprintf(errOut, "supersede link error: %v\n", err)
-		return 1
+		return err
 	}
-	if err := st.AddLink(store.Link{
-		FromID:    newMem.ID,
+	return st.AddLink(store.Link{
+		FromID:    newID,
 		Rel:       "supersedes",
-		ToID:      oldMem.ID,
+		ToID:      oldID,
 		Weight:    1,
-		CreatedAt: linkTime,
-	}); err != nil {
-		fmt.Fprintf(errOut, "supersede link error: %v\n", err)
-		return 1
-	}
-
-	return writeJSON(out, errOut, SupersedeResponse{OldID: oldMem.ID, NewID: newMem.ID, Status: "superseded"})
+		CreatedAt: at,
+	})
 }
 
 func parseTagsJSON(raw string) []string {
@@ -0,0 +1,203 @@
+package app
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"mem/internal/pack"
+)
+
+const (
+	defaultSymbolLimit       = 20
+	defaultSymbolMemoryLimit = 10
+)
+
+// symbolKindAliases maps the --kind values to the symbol kinds chunkers
+// record for them across languages.
+var symbolKindAliases = map[string][]string{
+	"func":   {"function", "constructor", "destructor"},
+	"type":   {"type", "struct", "interface", "class", "enum", "record", "trait", "union", "typedef", "object", "annotation"},
+	"method": {"method"},
+}
+
+type SymbolOptions struct {
+	RepoOverride string
+	Workspace    string
+	Kind         string
+	Fuzzy        bool
+	Limit        int
+	RequireRepo  bool
+}
+
+type SymbolResponse struct {
+	Query       string             `json:"query"`
+	Kind        string             `json:"kind,omitempty"`
+	Fuzzy       bool               `json:"fuzzy,omitempty"`
+	RepoID      string             `json:"repo_id"`
+	Workspace   string             `json:"workspace"`
+	Definitions []SymbolDefinition `json:"definitions"`
+	Memories    []SymbolMemory     `json:"memories"`
+}
+
+// SymbolDefinition is a live chunk defining a matched symbol. Re-ingesting a
+// file retires its old chunks, so Locator points at the latest ingest;
+// Freshness checks it against the working tree and carries the current
+// locator when the definition has moved since.
+type SymbolDefinition struct {
+	ChunkID    string               `json:"chunk_id"`
+	SymbolName string               `json:"symbol_name"`
+	SymbolKind string               `json:"symbol_kind,omitempty"`
+	ChunkType  string               `json:"chunk_type,omitempty"`
+	Match      string               `json:"match"`
+	Locator    string               `json:"locator,omitempty"`
+	Freshness  *pack.ChunkFreshness `json:"freshness,omitempty"`
+	Text       string               `json:"text"`
+}
+
+type SymbolMemory struct {
+	ID        string   `json:"id"`
+	ThreadID  string   `json:"thread_id,omitempty"`
+	Title     string   `json:"title"`
+	Summary   string   `json:"summary,omitempty"`
+	Entities  []string `json:"entities"`
+	CreatedAt string   `json:"created_at"`
+}
+
+func runSymbol(args []string, out, errOut io.Writer) int {
+	fs := flag.NewFlagSet("symbol", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	kind := fs.String("kind", "", "Symbol kind: func|type|method")
+	fuzzy := fs.Bool("fuzzy", false, "Also match names containing the query, ignoring case")
+	limit := fs.Int("limit", defaultSymbolLimit, "Maximum definitions to return")
+	workspace := fs.String("workspace", "", "Workspace name")
+	repoOverride := fs.String("repo", "", "Override repo id")
+	positional, flagArgs, err := splitFlagArgs(args, map[string]flagSpec{
+		"kind":      {RequiresValue: true},
+		"fuzzy":     {RequiresValue: false},
+		"limit":     {RequiresValue: true},
+		"workspace": {RequiresValue: true},
+		"repo":      {RequiresValue: true},
+	})
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 2
+	}
+	if err := fs.Parse(flagArgs); err != nil {
+		return 2
+	}
+
+	if len(positional) != 1 || strings.TrimSpace(positional[0]) == "" {
+		fmt.Fprintln(errOut, "usage: mem symbol <name> [--kind func|type|method] [--fuzzy]")
+		return 2
+	}
+	if err := validateSymbolOptions(*kind, *limit); err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 2
+	}
+
+	resp, err := buildSymbolResponse(positional[0], SymbolOptions{
+		RepoOverride: *repoOverride,
+		Workspace:    *workspace,
+		Kind:         *kind,
+		Fuzzy:        *fuzzy,
+		Limit:        *limit,
+	})
+	if err != nil {
+		fmt.Fprintf(errOut, "%v\n", err)
+		return 1
+	}
+	return writeJSON(out, errOut, resp)
+}
+
+func validateSymbolOptions(kind string, limit int) error {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if _, ok := symbolKindAliases[kind]; kind != "" && !ok {
+		return fmt.Errorf("--kind must be func, type, or method")
+	}
+	if limit <= 0 {
+		return fmt.Errorf("--limit must be > 0")
+	}
+	return nil
+}
+
+func buildSymbolResponse(name string, opts SymbolOptions) (SymbolResponse, error) {
+	name = strings.TrimSpace(name)
+	kind := strings.ToLower(strings.TrimSpace(opts.Kind))
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultSymbolLimit
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return SymbolResponse{}, fmt.Errorf("config error: %v", err)
+	}
+	workspace := resolveWorkspace(cfg, opts.Workspace)
+	repoInfo, err := resolveRepoWithOptions(&cfg, strings.TrimSpace(opts.RepoOverride), repoResolveOptions{
+		RequireRepo: opts.RequireRepo,
+	})
+	if err != nil {
+		return SymbolResponse{}, fmt.Errorf("repo detection error: %v", err)
+	}
+	st, releaseStore, err := openStoreForRequest(cfg, repoInfo.ID)
+	if err != nil {
+		return SymbolResponse{}, fmt.Errorf("store open error: %v", err)
+	}
+	defer releaseStore()
+
+	matches, err := st.FindChunksBySymbol(repoInfo.ID, workspace, name, symbolKindAliases[kind], opts.Fuzzy, limit)
+	if err != nil {
+		return SymbolResponse{}, fmt.Errorf("symbol lookup error: %v", err)
+	}
+
+	resp := SymbolResponse{
+		Query:       name,
+		Kind:        kind,
+		Fuzzy:       opts.Fuzzy,
+		RepoID:      repoInfo.ID,
+		Workspace:   workspace,
+		Definitions: make([]SymbolDefinition, 0, len(matches)),
+		Memories:    []SymbolMemory{},
+	}
+	names := []string{name}
+	seen := map[string]struct{}{strings.ToLower(name): {}}
+	resolver := newChunkResolver(repoInfo.GitRoot)
+	for _, match := range matches {
+		resp.Definitions = append(resp.Definitions, SymbolDefinition{
+			ChunkID:    match.ID,
+			SymbolName: match.SymbolName,
+			SymbolKind: match.SymbolKind,
+			ChunkType:  match.ChunkType,
+			Match:      match.Match,
+			Locator:    match.Locator,
+			Freshness:  resolver.resolve(match.Locator, match.Text),
+			Text:       match.Text,
+		})
+		if _, ok := seen[strings.ToLower(match.SymbolName)]; !ok {
+			seen[strings.ToLower(match.SymbolName)] = struct{}{}
+			names = append(names, match.SymbolName)
+		}
+	}
+
+	memories, err := st.ListMemoriesByEntity(repoInfo.ID, workspace, names, defaultSymbolMemoryLimit)
+	if err != nil {
+		return SymbolResponse{}, fmt.Errorf("memory lookup error: %v", err)
+	}
+	for _, mem := range memories {
+		var entities []string
+		_ = json.Unmarshal([]byte(mem.EntitiesJSON), &entities)
+		resp.Memories = append(resp.Memories, SymbolMemory{
+			ID:        mem.ID,
+			ThreadID:  mem.ThreadID,
+			Title:     mem.Title,
+			Summary:   mem.Summary,
+			Entities:  entities,
+			CreatedAt: mem.CreatedAt.UTC().Format(time.RFC3339Nano),
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"mem/internal/config"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestSymbolLookupCLIAndMCP(t *testing.T) {
+	base := t.TempDir()
+	setXDGEnv(t, base)
+	repoDir := setupRepo(t, base)
+	withCwd(t, repoDir)
+	writeTestConfig(t, base, func(cfg *config.Config) {
+		cfg.EmbeddingProvider = "none"
+	})
+
+	writeFile(t, repoDir, "store.go", `package store
+
+type Store struct{}
+
+// Search runs a query.
+func (s *Store) Search(q string) {}
+
+func (s *Store) SearchChunks(q string) {}
+
+func search() {}
+`)
+	runCLI(t, "ingest", "store.go", "--thread", "T-symbols")
+	runCLI(t, "add", "--thread", "T-symbols", "--title", "Search ranks by BM25", "--summary", "Keep BM25 scores for chunks", "--entities", "Store.Search")
+
+	var resp SymbolResponse
+	if err := json.Unmarshal(runCLI(t, "symbol", "Store.Search"), &resp); err != nil {
+		t.Fatalf("decode symbol: %v", err)
+	}
+	if len(resp.Definitions) != 2 {
+		t.Fatalf("expected exact and prefix definitions, got %+v", resp.Definitions)
+	}
+	first := resp.Definitions[0]
+	if first.SymbolName != "Store.Search" || first.Match != "exact" || first.SymbolKind != "method" || !strings.HasSuffix(first.Locator, "store.go#L5-L6") {
+		t.Fatalf("unexpected first definition %+v", first)
+	}
+	if resp.Definitions[1].SymbolName != "Store.SearchChunks" || resp.Definitions[1].Match != "prefix" {
+		t.Fatalf("unexpected second definition %+v", resp.Definitions[1])
+	}
+	if len(resp.Memories) != 1 || resp.Memories[0].Title != "Search ranks by BM25" {
+		t.Fatalf("expected the memory mentioning Store.Search, got %+v", resp.Memories)
+	}
+	if first.Freshness == nil || first.Freshness.Status != freshnessUnchanged {
+		t.Fatalf("expected an unchanged definition, got %+v", first.Freshness)
+	}
+
+	writeFile(t, repoDir, "store.go", `package store
+
+import "fmt"
+
+type Store struct{}
+
+// Search runs a query.
+func (s *Store) Search(q string) {}
+
+func (s *Store) SearchChunks(q string) { fmt.Println(q) }
+
+func search() {}
+`)
+	resp = SymbolResponse{}
+	if err := json.Unmarshal(runCLI(t, "symbol", "Store.Search"), &resp); err != nil {
+		t.Fatalf("decode moved symbol: %v", err)
+	}
+	if moved := resp.Definitions[0].Freshness; moved == nil || moved.Status != freshnessMoved || moved.Locator != "file:store.go#L7-L8" {
+		t.Fatalf("expected the definition to be re-anchored, got %+v", moved)
+	}
+
+	resp = SymbolResponse{}
+	if err := json.Unmarshal(runCLI(t, "symbol", "search", "--kind", "func", "--fuzzy"), &resp); err != nil {
+		t.Fatalf("decode fuzzy symbol: %v", err)
+	}
+	if len(resp.Definitions) != 1 || resp.Definitions[0].SymbolName != "search" {
+		t.Fatalf("expected only the search function, got %+v", resp.Definitions)
+	}
+
+	if errOut := runCLIExpectError(t, "symbol", "Store", "--kind", "field"); !strings.Contains(errOut, "--kind must be") {
+		t.Fatalf("expected kind validation error, got %q", errOut)
+	}
+
+	result, err := handleFindSymbol(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "mem_find_symbol",
+			Arguments: map[string]any{"name": "Store", "kind": "type"},
+		},
+	}, false)
+	if err != nil {
+		t.Fatalf("find symbol: %v", err)
+	}
+	found, ok := result.StructuredContent.(SymbolResponse)
+	if !ok {
+		t.Fatalf("expected SymbolResponse, got %T", result.StructuredContent)
+	}
+	if len(found.Definitions) != 1 || found.Definitions[0].SymbolName != "Store" || found.Definitions[0].SymbolKind != "struct" {
+		t.Fatalf("unexpected MCP definitions %+v", found.Definitions)
+	}
+}
@@ -105,6 +105,9 @@ func runThreads(args []string, out, errOut io.Writer) int {
 }
 
 func runThreadShow(args []string, out, errOut io.Writer) int {
+	if len(args) > 0 && strings.EqualFold(strings.TrimSpace(args[0]), "summarize") {
+		return runThreadSummarize(args[1:], out, errOut)
+	}
 	fs := flag.NewFlagSet("thread", flag.ContinueOnError)
 	fs.SetOutput(errOut)
 	repoOverride := fs.String("repo", "", "Override repo id")
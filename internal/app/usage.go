@@ -29,9 +29,19 @@ func writeUsage(w io.Writer) {
 	fmt.Fprintln(tw, "  init\tInitialize memory in current repo")
 	fmt.Fprintln(tw, "  delete\tRemove Mem setup and repo DB for current repo")
 	fmt.Fprintln(tw, "  get\tRetrieve context by query")
+	fmt.Fprintln(tw, "  eval\tScore retrieval against a golden query suite")
+	fmt.Fprintln(tw, "  symbol\tLook up where a symbol is defined")
+	fmt.Fprintln(tw, "  refs\tList chunks that import, call, or use a symbol")
+	fmt.Fprintln(tw, "  ingest-git-log\tIngest commit history as evidence")
+	fmt.Fprintln(tw, "  sources\tList ingested sources and prune or refresh them")
+	fmt.Fprintln(tw, "  stale\tList memories not surfaced recently")
 	fmt.Fprintln(tw, "  usage\tShow cumulative token usage and savings")
 	fmt.Fprintln(tw, "  add\tSave a memory")
 	fmt.Fprintln(tw, "  update\tUpdate a memory")
+	fmt.Fprintln(tw, "  feedback\tMark a retrieved item as useful or not")
+	fmt.Fprintln(tw, "  dedupe\tPropose merges for near-duplicate memories")
+	fmt.Fprintln(tw, "  consolidate\tMerge similar memories into superseding ones")
+	fmt.Fprintln(tw, "  thread summarize\tWrite or refresh a thread digest")
 	fmt.Fprintln(tw, "  repos\tList known repos")
 	fmt.Fprintln(tw, "  share export\tExport memories to mem-share/")
 	fmt.Fprintln(tw, "  share import\tImport from mem-share/")
@@ -31,6 +31,22 @@ type Config struct {
 	EmbeddingModel         string            `toml:"embedding_model"`
 	EmbeddingMinSimilarity float64           `toml:"embedding_min_similarity"`
 	EmbeddingSetupComplete bool              `toml:"embedding_setup_complete"`
+	RerankProvider         string            `toml:"rerank_provider"`
+	RerankModel            string            `toml:"rerank_model"`
+	RerankURL              string            `toml:"rerank_url"`
+	RerankTopN             int               `toml:"rerank_top_n"`
+	RerankTimeoutMS        int               `toml:"rerank_timeout_ms"`
+	ContextSelection       string            `toml:"context_selection"`
+	MMRLambda              float64           `toml:"mmr_lambda"`
+	MMRRedundantCosine     float64           `toml:"mmr_redundant_cosine"`
+	MMRRedundantOverlap    float64           `toml:"mmr_redundant_overlap"`
+	AccessTracking         bool              `toml:"access_tracking"`
+	PopularityWeight       float64           `toml:"popularity_weight"`
+	DedupeOnAdd            bool              `toml:"dedupe_on_add"`
+	GenerationProvider     string            `toml:"generation_provider"`
+	GenerationModel        string            `toml:"generation_model"`
+	GenerationURL          string            `toml:"generation_url"`
+	GenerationTimeoutMS    int               `toml:"generation_timeout_ms"`
 }
 
 var dataDirOverride string
@@ -72,6 +88,22 @@ func Default() (Config, error) {
 		EmbeddingModel:         "nomic-embed-text",
 		EmbeddingMinSimilarity: 0.6,
 		EmbeddingSetupComplete: false,
+		RerankProvider:         "none",
+		RerankModel:            "",
+		RerankURL:              "",
+		RerankTopN:             20,
+		RerankTimeoutMS:        1500,
+		ContextSelection:       "score",
+		MMRLambda:              0.7,
+		MMRRedundantCosine:     0.92,
+		MMRRedundantOverlap:    0.8,
+		AccessTracking:         true,
+		PopularityWeight:       0,
+		DedupeOnAdd:            true,
+		GenerationProvider:     "none",
+		GenerationModel:        "",
+		GenerationURL:          "",
+		GenerationTimeoutMS:    60000,
 	}, nil
 }
 
@@ -16,6 +16,33 @@ type RepoConfig struct {
 	EmbeddingModel    *string `json:"embedding_model,omitempty"`
 	TokenBudget       *int    `json:"token_budget,omitempty"`
 	DefaultThread     *string `json:"default_thread,omitempty"`
+
+	Chunkers   []ChunkerRule        `json:"chunkers,omitempty"`
+	ChunkSizes map[string]ChunkSize `json:"chunk_sizes,omitempty"`
+
+	Watch *WatchConfig `json:"watch,omitempty"`
+}
+
+// WatchConfig declares the paths the MCP daemon keeps indexed in the
+// background. Paths are relative to the repo root; "." is the whole repo.
+type WatchConfig struct {
+	Paths             []string `json:"paths"`
+	Thread            string   `json:"thread,omitempty"`
+	DebounceMS        int      `json:"debounce_ms,omitempty"`
+	MaxFilesPerMinute int      `json:"max_files_per_minute,omitempty"`
+}
+
+// ChunkerRule routes files matching Glob (gitignore syntax, relative to the
+// repo root) to the named chunker.
+type ChunkerRule struct {
+	Glob    string `json:"glob"`
+	Chunker string `json:"chunker"`
+}
+
+// ChunkSize overrides the ingest token sizes for one chunker.
+type ChunkSize struct {
+	ChunkTokens   int  `json:"chunk_tokens,omitempty"`
+	OverlapTokens *int `json:"overlap_tokens,omitempty"`
 }
 
 type repoConfigCacheEntry struct {
@@ -0,0 +1,209 @@
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"mem/internal/config"
+)
+
+// Generator produces free text from a prompt, parallel to Provider for
+// embeddings. Write paths use it to summarize memories.
+type Generator interface {
+	Name() string
+	Generate(ctx context.Context, prompt string) (string, error)
+}
+
+func ResolveGenerator(cfg config.Config) (Generator, Status) {
+	name := strings.TrimSpace(strings.ToLower(cfg.GenerationProvider))
+	if name == "" || name == "none" {
+		return nil, Status{Provider: "none", Enabled: false}
+	}
+
+	model := strings.TrimSpace(cfg.GenerationModel)
+	baseURL := strings.TrimSpace(cfg.GenerationURL)
+	switch name {
+	case "ollama":
+		if model == "" {
+			return nil, Status{
+				Provider: name,
+				Enabled:  false,
+				Error:    "generation_model is required for ollama",
+			}
+		}
+		if baseURL == "" {
+			baseURL = resolveOllamaURL()
+		}
+		return NewOllamaGenerator(baseURL, model), Status{
+			Provider: name,
+			Model:    model,
+			Enabled:  true,
+		}
+	case "openai":
+		if model == "" || baseURL == "" {
+			return nil, Status{
+				Provider: name,
+				Model:    model,
+				Enabled:  false,
+				Error:    "generation_model and generation_url are required for openai",
+			}
+		}
+		return NewOpenAIGenerator(baseURL, model, os.Getenv("OPENAI_API_KEY")), Status{
+			Provider: name,
+			Model:    model,
+			Enabled:  true,
+		}
+	default:
+		return nil, Status{
+			Provider: name,
+			Model:    model,
+			Enabled:  false,
+			Error:    fmt.Sprintf("unknown generation provider: %s", name),
+		}
+	}
+}
+
+// OllamaGenerator calls a local model through Ollama's /api/generate.
+type OllamaGenerator struct {
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+func NewOllamaGenerator(baseURL, model string) *OllamaGenerator {
+	return &OllamaGenerator{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		client:  &http.Client{},
+	}
+}
+
+func (g *OllamaGenerator) Name() string {
+	return "ollama"
+}
+
+func (g *OllamaGenerator) Generate(ctx context.Context, prompt string) (string, error) {
+	reqBody, err := json.Marshal(ollamaGenerateRequest{
+		Model:   g.model,
+		Prompt:  prompt,
+		Stream:  false,
+		Options: map[string]any{"temperature": 0},
+	})
+	if err != nil {
+		return "", err
+	}
+	body, err := postJSON(ctx, g.client, g.baseURL+"/api/generate", reqBody)
+	if err != nil {
+		return "", fmt.Errorf("ollama generate error: %w", err)
+	}
+	var payload ollamaGenerateResponse
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", err
+	}
+	if payload.Error != "" {
+		return "", fmt.Errorf("ollama generate error: %s", payload.Error)
+	}
+	text := strings.TrimSpace(payload.Response)
+	if text == "" {
+		return "", fmt.Errorf("ollama generate returned empty response")
+	}
+	return text, nil
+}
+
+// OpenAIGenerator calls an OpenAI-compatible /chat/completions endpoint such
+// as llama.cpp server, vLLM, or LM Studio. baseURL includes the API prefix,
+// for example http://localhost:8000/v1.
+type OpenAIGenerator struct {
+	baseURL string
+	model   string
+	apiKey  string
+	client  *http.Client
+}
+
+func NewOpenAIGenerator(baseURL, model, apiKey string) *OpenAIGenerator {
+	return &OpenAIGenerator{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		apiKey:  strings.TrimSpace(apiKey),
+		client:  &http.Client{},
+	}
+}
+
+func (g *OpenAIGenerator) Name() string {
+	return "openai"
+}
+
+type openAIChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIChatRequest struct {
+	Model       string              `json:"model"`
+	Messages    []openAIChatMessage `json:"messages"`
+	Temperature float64             `json:"temperature"`
+	Stream      bool                `json:"stream"`
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message openAIChatMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func (g *OpenAIGenerator) Generate(ctx context.Context, prompt string) (string, error) {
+	reqBody, err := json.Marshal(openAIChatRequest{
+		Model:    g.model,
+		Messages: []openAIChatMessage{{Role: "user", Content: prompt}},
+		Stream:   false,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+"/chat/completions", bytes.NewReader(reqBody))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+g.apiKey)
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("openai generate error: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var payload openAIChatResponse
+	decodeErr := json.Unmarshal(body, &payload)
+	if payload.Error != nil && payload.Error.Message != "" {
+		return "", fmt.Errorf("openai generate error: %s", payload.Error.Message)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("openai generate error: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if decodeErr != nil {
+		return "", decodeErr
+	}
+	if len(payload.Choices) == 0 {
+		return "", fmt.Errorf("openai generate returned no choices")
+	}
+	text := strings.TrimSpace(payload.Choices[0].Message.Content)
+	if text == "" {
+		return "", fmt.Errorf("openai generate returned empty response")
+	}
+	return text, nil
+}
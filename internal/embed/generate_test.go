@@ -0,0 +1,103 @@
+package embed
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mem/internal/config"
+)
+
+func TestOllamaGeneratorPostsPrompt(t *testing.T) {
+	var gotPath string
+	var gotBody ollamaGenerateRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		_ = json.NewEncoder(w).Encode(ollamaGenerateResponse{Response: "  merged summary \n"})
+	}))
+	defer server.Close()
+
+	generator := NewOllamaGenerator(server.URL, "llama3.2")
+	text, err := generator.Generate(context.Background(), "summarize")
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if gotPath != "/api/generate" || gotBody.Model != "llama3.2" || gotBody.Prompt != "summarize" || gotBody.Stream {
+		t.Fatalf("unexpected request %s %#v", gotPath, gotBody)
+	}
+	if text != "merged summary" {
+		t.Fatalf("expected trimmed response, got %q", text)
+	}
+}
+
+func TestOllamaGeneratorReportsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(ollamaGenerateResponse{Error: "model not found"})
+	}))
+	defer server.Close()
+
+	if _, err := NewOllamaGenerator(server.URL, "missing").Generate(context.Background(), "x"); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestResolveGenerator(t *testing.T) {
+	if gen, status := ResolveGenerator(config.Config{GenerationProvider: "none"}); gen != nil || status.Enabled {
+		t.Fatalf("expected disabled generator, got %v %+v", gen, status)
+	}
+	if gen, status := ResolveGenerator(config.Config{GenerationProvider: "ollama"}); gen != nil || status.Error == "" {
+		t.Fatalf("expected missing model error, got %v %+v", gen, status)
+	}
+	gen, status := ResolveGenerator(config.Config{GenerationProvider: "ollama", GenerationModel: "llama3.2", GenerationURL: "http://127.0.0.1:1"})
+	if gen == nil || !status.Enabled || gen.Name() != "ollama" {
+		t.Fatalf("expected ollama generator, got %v %+v", gen, status)
+	}
+}
+
+func TestOpenAIGeneratorSendsChatRequest(t *testing.T) {
+	var gotPath, gotAuth string
+	var gotBody openAIChatRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":" digest "}}]}`))
+	}))
+	defer server.Close()
+
+	text, err := NewOpenAIGenerator(server.URL+"/v1/", "qwen2.5", "secret").Generate(context.Background(), "summarize")
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if gotPath != "/v1/chat/completions" || gotAuth != "Bearer secret" {
+		t.Fatalf("unexpected request %s auth=%q", gotPath, gotAuth)
+	}
+	if gotBody.Model != "qwen2.5" || len(gotBody.Messages) != 1 || gotBody.Messages[0].Content != "summarize" {
+		t.Fatalf("unexpected body %#v", gotBody)
+	}
+	if text != "digest" {
+		t.Fatalf("expected trimmed content, got %q", text)
+	}
+}
+
+func TestOpenAIGeneratorReportsAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"message":"model not loaded"}}`))
+	}))
+	defer server.Close()
+
+	_, err := NewOpenAIGenerator(server.URL, "missing", "").Generate(context.Background(), "x")
+	if err == nil || err.Error() != "openai generate error: model not loaded" {
+		t.Fatalf("expected api error, got %v", err)
+	}
+}
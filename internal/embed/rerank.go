@@ -0,0 +1,262 @@
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mem/internal/config"
+)
+
+// Reranker scores documents against a query in a second stage after fusion.
+// Scores are returned in input order; higher means more relevant.
+type Reranker interface {
+	Name() string
+	Rerank(ctx context.Context, query string, documents []string) ([]float64, error)
+}
+
+const DefaultTEIRerankURL = "http://localhost:8080"
+
+func ResolveReranker(cfg config.Config) (Reranker, Status) {
+	name := strings.TrimSpace(strings.ToLower(cfg.RerankProvider))
+	if name == "" || name == "none" {
+		return nil, Status{Provider: "none", Enabled: false}
+	}
+
+	model := strings.TrimSpace(cfg.RerankModel)
+	baseURL := strings.TrimSpace(cfg.RerankURL)
+	switch name {
+	case "tei", "http":
+		if baseURL == "" {
+			baseURL = DefaultTEIRerankURL
+		}
+		return NewTEIReranker(baseURL, model), Status{
+			Provider: "tei",
+			Model:    model,
+			Enabled:  true,
+		}
+	case "ollama":
+		if model == "" {
+			return nil, Status{
+				Provider: name,
+				Enabled:  false,
+				Error:    "rerank_model is required for ollama",
+			}
+		}
+		if baseURL == "" {
+			baseURL = resolveOllamaURL()
+		}
+		return NewOllamaReranker(baseURL, model), Status{
+			Provider: name,
+			Model:    model,
+			Enabled:  true,
+		}
+	default:
+		return nil, Status{
+			Provider: name,
+			Model:    model,
+			Enabled:  false,
+			Error:    fmt.Sprintf("unknown rerank provider: %s", name),
+		}
+	}
+}
+
+// TEIReranker calls a cross-encoder served behind a TEI-style /rerank endpoint.
+type TEIReranker struct {
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+func NewTEIReranker(baseURL, model string) *TEIReranker {
+	return &TEIReranker{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		client:  &http.Client{},
+	}
+}
+
+func (r *TEIReranker) Name() string {
+	return "tei"
+}
+
+type teiRerankRequest struct {
+	Query    string   `json:"query"`
+	Texts    []string `json:"texts"`
+	Model    string   `json:"model,omitempty"`
+	Truncate bool     `json:"truncate"`
+}
+
+type teiRerankResult struct {
+	Index int     `json:"index"`
+	Score float64 `json:"score"`
+}
+
+type teiRerankError struct {
+	Error string `json:"error"`
+}
+
+func (r *TEIReranker) Rerank(ctx context.Context, query string, documents []string) ([]float64, error) {
+	if len(documents) == 0 {
+		return nil, nil
+	}
+	reqBody, err := json.Marshal(teiRerankRequest{
+		Query:    query,
+		Texts:    documents,
+		Model:    r.model,
+		Truncate: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	body, err := postJSON(ctx, r.client, r.baseURL+"/rerank", reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("tei rerank error: %w", err)
+	}
+
+	var results []teiRerankResult
+	if err := json.Unmarshal(body, &results); err != nil {
+		var payload teiRerankError
+		if json.Unmarshal(body, &payload) == nil && payload.Error != "" {
+			return nil, fmt.Errorf("tei rerank error: %s", payload.Error)
+		}
+		return nil, err
+	}
+	scores := make([]float64, len(documents))
+	seen := make([]bool, len(documents))
+	for _, res := range results {
+		if res.Index < 0 || res.Index >= len(documents) {
+			return nil, fmt.Errorf("tei rerank returned out-of-range index %d", res.Index)
+		}
+		scores[res.Index] = res.Score
+		seen[res.Index] = true
+	}
+	for i, ok := range seen {
+		if !ok {
+			return nil, fmt.Errorf("tei rerank missing score for index %d", i)
+		}
+	}
+	return scores, nil
+}
+
+// OllamaReranker asks a local generation model to grade each document. It is
+// slower than a dedicated cross-encoder and intended for small top-N windows.
+type OllamaReranker struct {
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+func NewOllamaReranker(baseURL, model string) *OllamaReranker {
+	return &OllamaReranker{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		client:  &http.Client{},
+	}
+}
+
+func (r *OllamaReranker) Name() string {
+	return "ollama"
+}
+
+type ollamaGenerateRequest struct {
+	Model   string         `json:"model"`
+	Prompt  string         `json:"prompt"`
+	Stream  bool           `json:"stream"`
+	Options map[string]any `json:"options,omitempty"`
+}
+
+type ollamaGenerateResponse struct {
+	Response string `json:"response"`
+	Error    string `json:"error,omitempty"`
+}
+
+const ollamaRerankPrompt = `Rate how relevant the document is to the query on a scale from 0 to 10.
+Reply with a single number and nothing else.
+
+Query: %s
+
+Document:
+%s
+
+Score:`
+
+func (r *OllamaReranker) Rerank(ctx context.Context, query string, documents []string) ([]float64, error) {
+	scores := make([]float64, len(documents))
+	for i, doc := range documents {
+		reqBody, err := json.Marshal(ollamaGenerateRequest{
+			Model:   r.model,
+			Prompt:  fmt.Sprintf(ollamaRerankPrompt, query, doc),
+			Stream:  false,
+			Options: map[string]any{"temperature": 0},
+		})
+		if err != nil {
+			return nil, err
+		}
+		body, err := postJSON(ctx, r.client, r.baseURL+"/api/generate", reqBody)
+		if err != nil {
+			return nil, fmt.Errorf("ollama rerank error: %w", err)
+		}
+		var payload ollamaGenerateResponse
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, err
+		}
+		if payload.Error != "" {
+			return nil, fmt.Errorf("ollama rerank error: %s", payload.Error)
+		}
+		score, ok := parseRelevanceScore(payload.Response)
+		if !ok {
+			return nil, fmt.Errorf("ollama rerank returned non-numeric score at index %d", i)
+		}
+		scores[i] = score / 10
+	}
+	return scores, nil
+}
+
+func parseRelevanceScore(text string) (float64, bool) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r == '.')
+	})
+	for _, field := range fields {
+		value, err := strconv.ParseFloat(strings.Trim(field, "."), 64)
+		if err != nil {
+			continue
+		}
+		if value < 0 {
+			value = 0
+		}
+		if value > 10 {
+			value = 10
+		}
+		return value, true
+	}
+	return 0, false
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, reqBody []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
@@ -0,0 +1,91 @@
+package embed
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"mem/internal/config"
+)
+
+func TestTEIRerankerMapsScoresByIndex(t *testing.T) {
+	var gotPath string
+	var gotBody teiRerankRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]teiRerankResult{
+			{Index: 1, Score: 0.9},
+			{Index: 0, Score: 0.2},
+		})
+	}))
+	defer server.Close()
+
+	reranker := NewTEIReranker(server.URL, "bge-reranker-base")
+	scores, err := reranker.Rerank(context.Background(), "auth flow", []string{"alpha", "beta"})
+	if err != nil {
+		t.Fatalf("rerank: %v", err)
+	}
+	if gotPath != "/rerank" {
+		t.Fatalf("expected path /rerank, got %s", gotPath)
+	}
+	if gotBody.Query != "auth flow" || !reflect.DeepEqual(gotBody.Texts, []string{"alpha", "beta"}) {
+		t.Fatalf("unexpected request: %#v", gotBody)
+	}
+	if !reflect.DeepEqual(scores, []float64{0.2, 0.9}) {
+		t.Fatalf("unexpected scores: %#v", scores)
+	}
+}
+
+func TestTEIRerankerRejectsMissingIndex(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]teiRerankResult{{Index: 0, Score: 0.5}})
+	}))
+	defer server.Close()
+
+	reranker := NewTEIReranker(server.URL, "")
+	if _, err := reranker.Rerank(context.Background(), "q", []string{"a", "b"}); err == nil {
+		t.Fatal("expected missing index error")
+	}
+}
+
+func TestOllamaRerankerParsesNumericGrade(t *testing.T) {
+	responses := []string{"8", "Score: 2.5"}
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/generate" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		_ = json.NewEncoder(w).Encode(ollamaGenerateResponse{Response: responses[calls]})
+		calls++
+	}))
+	defer server.Close()
+
+	reranker := NewOllamaReranker(server.URL, "qwen2.5")
+	scores, err := reranker.Rerank(context.Background(), "q", []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("rerank: %v", err)
+	}
+	if !reflect.DeepEqual(scores, []float64{0.8, 0.25}) {
+		t.Fatalf("unexpected scores: %#v", scores)
+	}
+}
+
+func TestResolveRerankerDefaultsToDisabled(t *testing.T) {
+	reranker, status := ResolveReranker(config.Config{})
+	if reranker != nil || status.Enabled || status.Provider != "none" {
+		t.Fatalf("expected disabled reranker, got %#v", status)
+	}
+
+	_, status = ResolveReranker(config.Config{RerankProvider: "ollama"})
+	if status.Enabled || status.Error == "" {
+		t.Fatalf("expected ollama without model to be disabled with error, got %#v", status)
+	}
+}
@@ -46,12 +46,25 @@ type MemoryItem struct {
 }
 
 type ChunkItem struct {
-	ChunkID    string        `json:"chunk_id"`
-	ArtifactID string        `json:"artifact_id,omitempty"`
-	ThreadID   string        `json:"thread_id,omitempty"`
-	Locator    string        `json:"locator,omitempty"`
-	Text       string        `json:"text"`
-	Sources    []ChunkSource `json:"sources,omitempty"`
+	ChunkID    string          `json:"chunk_id"`
+	ArtifactID string          `json:"artifact_id,omitempty"`
+	ThreadID   string          `json:"thread_id,omitempty"`
+	Locator    string          `json:"locator,omitempty"`
+	Text       string          `json:"text"`
+	Sources    []ChunkSource   `json:"sources,omitempty"`
+	Freshness  *ChunkFreshness `json:"freshness,omitempty"`
+	// RelatedTo is set on chunks added by related expansion: the pack chunk
+	// whose reference they define.
+	RelatedTo string `json:"related_to,omitempty"`
+}
+
+// ChunkFreshness compares a chunk with the current working tree. Status is
+// unchanged, moved, modified, or deleted; Locator is where the chunk's code
+// sits now, omitted when it is gone.
+type ChunkFreshness struct {
+	Status     string  `json:"status"`
+	Locator    string  `json:"locator,omitempty"`
+	Similarity float64 `json:"similarity,omitempty"`
 }
 
 type ChunkSource struct {
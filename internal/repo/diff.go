@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	ChangeAdded    = "added"
+	ChangeModified = "modified"
+	ChangeDeleted  = "deleted"
+	ChangeRenamed  = "renamed"
+)
+
+// Change is one path that differs between a commit and the working tree.
+// Paths are relative to the repo root; OldPath is set for renames.
+type Change struct {
+	Status  string
+	Path    string
+	OldPath string
+}
+
+// ChangedSince lists paths that differ between since and the working tree,
+// including uncommitted edits. Untracked files are not reported.
+func ChangedSince(repoRoot, since string) ([]Change, error) {
+	since = strings.TrimSpace(since)
+	if since == "" {
+		return nil, fmt.Errorf("missing commit")
+	}
+	if _, err := gitOutput(repoRoot, "rev-parse", "--verify", "--quiet", since+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("unknown commit %q", since)
+	}
+	out, err := gitOutput(repoRoot, "-c", "core.quotePath=false", "diff", "--name-status", "-M", "--no-color", since, "--")
+	if err != nil {
+		return nil, err
+	}
+	return parseNameStatus(out), nil
+}
+
+func parseNameStatus(output string) []Change {
+	var changes []Change
+	for _, line := range splitLines(output) {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 || fields[0] == "" {
+			continue
+		}
+		switch fields[0][0] {
+		case 'A', 'C':
+			changes = append(changes, Change{Status: ChangeAdded, Path: fields[len(fields)-1]})
+		case 'M', 'T':
+			changes = append(changes, Change{Status: ChangeModified, Path: fields[1]})
+		case 'D':
+			changes = append(changes, Change{Status: ChangeDeleted, Path: fields[1]})
+		case 'R':
+			if len(fields) < 3 {
+				continue
+			}
+			changes = append(changes, Change{Status: ChangeRenamed, Path: fields[2], OldPath: fields[1]})
+		}
+	}
+	return changes
+}
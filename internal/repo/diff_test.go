@@ -0,0 +1,24 @@
+package repo
+
+import "testing"
+
+func TestParseNameStatus(t *testing.T) {
+	output := "M\tinternal/app/ingest.go\nA\tdocs/new.md\nD\told.go\nR087\tpkg/a.go\tpkg/b.go\nC100\tsrc.go\tcopy.go\nT\tlink\n"
+	changes := parseNameStatus(output)
+	want := []Change{
+		{Status: ChangeModified, Path: "internal/app/ingest.go"},
+		{Status: ChangeAdded, Path: "docs/new.md"},
+		{Status: ChangeDeleted, Path: "old.go"},
+		{Status: ChangeRenamed, Path: "pkg/b.go", OldPath: "pkg/a.go"},
+		{Status: ChangeAdded, Path: "copy.go"},
+		{Status: ChangeModified, Path: "link"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("change %d: expected %+v, got %+v", i, want[i], changes[i])
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package repo
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Commit is one commit read from git log.
+type Commit struct {
+	SHA         string
+	Author      string
+	AuthorEmail string
+	Date        time.Time
+	Subject     string
+	Body        string
+	Files       []CommitFile
+}
+
+// CommitFile is a path a commit touched. Added and Deleted are line counts,
+// filled only when the log was read with diffstats; binary files report -1.
+type CommitFile struct {
+	Path    string
+	Added   int
+	Deleted int
+}
+
+// LogOptions narrows the commits Log reads.
+type LogOptions struct {
+	// Since excludes commits reachable from this revision.
+	Since string
+	// Paths keeps only commits touching these paths.
+	Paths    []string
+	DiffStat bool
+}
+
+const (
+	logRecordSep = "\x1e"
+	logFieldSep  = "\x1f"
+)
+
+// Log reads the commits reachable from HEAD, newest first.
+func Log(repoRoot string, opts LogOptions) ([]Commit, error) {
+	args := []string{
+		"-c", "core.quotePath=false", "log", "--no-color", "--no-renames",
+		"--format=" + logRecordSep + "%H" + logFieldSep + "%an" + logFieldSep + "%ae" + logFieldSep + "%aI" + logFieldSep + "%s" + logFieldSep + "%b" + logFieldSep,
+	}
+	if opts.DiffStat {
+		args = append(args, "--numstat")
+	} else {
+		args = append(args, "--name-only")
+	}
+	rev := "HEAD"
+	if since := strings.TrimSpace(opts.Since); since != "" {
+		rev = since + "..HEAD"
+	}
+	args = append(args, rev, "--")
+	args = append(args, opts.Paths...)
+
+	out, err := gitOutput(repoRoot, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(out, opts.DiffStat), nil
+}
+
+func parseLog(output string, diffStat bool) []Commit {
+	var commits []Commit
+	for _, record := range strings.Split(output, logRecordSep) {
+		fields := strings.SplitN(record, logFieldSep, 7)
+		if len(fields) < 7 || strings.TrimSpace(fields[0]) == "" {
+			continue
+		}
+		commit := Commit{
+			SHA:         strings.TrimSpace(fields[0]),
+			Author:      fields[1],
+			AuthorEmail: fields[2],
+			Subject:     strings.TrimSpace(fields[4]),
+			Body:        strings.TrimSpace(fields[5]),
+		}
+		if date, err := time.Parse(time.RFC3339, strings.TrimSpace(fields[3])); err == nil {
+			commit.Date = date.UTC()
+		}
+		for _, line := range splitLines(fields[6]) {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			if !diffStat {
+				commit.Files = append(commit.Files, CommitFile{Path: line})
+				continue
+			}
+			parts := strings.SplitN(line, "\t", 3)
+			if len(parts) != 3 {
+				continue
+			}
+			commit.Files = append(commit.Files, CommitFile{Path: parts[2], Added: numstatCount(parts[0]), Deleted: numstatCount(parts[1])})
+		}
+		commits = append(commits, commit)
+	}
+	return commits
+}
+
+func numstatCount(value string) int {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return -1
+	}
+	return n
+}
@@ -0,0 +1,25 @@
+package repo
+
+import "testing"
+
+func TestParseLogWithNumstat(t *testing.T) {
+	output := "\x1eaaa111\x1fAda\x1fada@example.com\x1f2026-03-01T10:00:00+02:00\x1fFix ingest skip\x1fHash files before chunking.\n\nRefs #12\x1f\n\n3\t1\tinternal/app/ingest.go\n-\t-\tlogo.png\n" +
+		"\x1ebbb222\x1fBo\x1fbo@example.com\x1f2026-02-28T09:00:00Z\x1fMerge pull request #11\x1f\x1f\n"
+	commits := parseLog(output, true)
+	if len(commits) != 2 {
+		t.Fatalf("expected 2 commits, got %+v", commits)
+	}
+	first := commits[0]
+	if first.SHA != "aaa111" || first.Author != "Ada" || first.Subject != "Fix ingest skip" || first.Body != "Hash files before chunking.\n\nRefs #12" {
+		t.Fatalf("unexpected first commit %+v", first)
+	}
+	if first.Date.Hour() != 8 {
+		t.Fatalf("expected date normalized to UTC, got %v", first.Date)
+	}
+	if len(first.Files) != 2 || first.Files[0] != (CommitFile{Path: "internal/app/ingest.go", Added: 3, Deleted: 1}) || first.Files[1].Added != -1 {
+		t.Fatalf("unexpected files %+v", first.Files)
+	}
+	if commits[1].Subject != "Merge pull request #11" || len(commits[1].Files) != 0 {
+		t.Fatalf("unexpected merge commit %+v", commits[1])
+	}
+}
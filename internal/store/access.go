@@ -0,0 +1,185 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	AccessKindMemory = "memory"
+	AccessKindChunk  = "chunk"
+)
+
+type AccessStat struct {
+	ItemID         string
+	Kind           string
+	HitCount       int
+	LastIncludedAt time.Time
+}
+
+type StaleMemory struct {
+	MemorySummary
+	HitCount       int
+	LastIncludedAt time.Time
+}
+
+// AccessHit is a queued batch of inclusions for one item: Hits inclusions,
+// the latest at LastIncludedAt.
+type AccessHit struct {
+	Kind           string
+	ItemID         string
+	Hits           int
+	LastIncludedAt time.Time
+}
+
+// RecordAccess bumps hit counts and last-included times for every item in a
+// single transaction.
+func (s *Store) RecordAccess(repoID, workspace string, memoryIDs, chunkIDs []string, now time.Time) error {
+	hits := make([]AccessHit, 0, len(memoryIDs)+len(chunkIDs))
+	for _, id := range memoryIDs {
+		hits = append(hits, AccessHit{Kind: AccessKindMemory, ItemID: id, Hits: 1, LastIncludedAt: now})
+	}
+	for _, id := range chunkIDs {
+		hits = append(hits, AccessHit{Kind: AccessKindChunk, ItemID: id, Hits: 1, LastIncludedAt: now})
+	}
+	return s.RecordAccessHits(repoID, workspace, hits)
+}
+
+// RecordAccessHits adds queued hits to the access stats in a single
+// transaction.
+func (s *Store) RecordAccessHits(repoID, workspace string, hits []AccessHit) error {
+	repoID = strings.TrimSpace(repoID)
+	if repoID == "" {
+		return fmt.Errorf("repo_id is required")
+	}
+	if len(hits) == 0 {
+		return nil
+	}
+	workspace = normalizeWorkspace(workspace)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if tx != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO access_stats (repo_id, workspace, kind, item_id, hit_count, last_included_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(repo_id, workspace, kind, item_id) DO UPDATE SET
+			hit_count = access_stats.hit_count + excluded.hit_count,
+			last_included_at = excluded.last_included_at
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, hit := range hits {
+		if hit.Hits <= 0 {
+			continue
+		}
+		includedAt := hit.LastIncludedAt.UTC().Format(time.RFC3339Nano)
+		if _, err := stmt.Exec(repoID, workspace, hit.Kind, hit.ItemID, hit.Hits, includedAt); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	tx = nil
+	return nil
+}
+
+// ListAccessStats returns access stats keyed by item id for the given ids.
+func (s *Store) ListAccessStats(repoID, workspace string, ids []string) (map[string]AccessStat, error) {
+	stats := map[string]AccessStat{}
+	if len(ids) == 0 {
+		return stats, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]any, 0, len(ids)+2)
+	args = append(args, repoID, normalizeWorkspace(workspace))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT item_id, kind, hit_count, last_included_at
+		FROM access_stats
+		WHERE repo_id = ? AND workspace = ? AND item_id IN (%s)
+	`, placeholders), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stat AccessStat
+		var lastIncludedAt string
+		if err := rows.Scan(&stat.ItemID, &stat.Kind, &stat.HitCount, &lastIncludedAt); err != nil {
+			return nil, err
+		}
+		stat.LastIncludedAt = parseTime(lastIncludedAt)
+		stats[stat.ItemID] = stat
+	}
+	return stats, rows.Err()
+}
+
+// ListStaleMemories returns active memories created before cutoff that have
+// not been included in a context pack since cutoff, least recently used first.
+func (s *Store) ListStaleMemories(repoID, workspace string, cutoff time.Time, limit int) ([]StaleMemory, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	workspace = normalizeWorkspace(workspace)
+	cutoffText := cutoff.UTC().Format(time.RFC3339Nano)
+	rows, err := s.db.Query(`
+		SELECT m.id, m.thread_id, m.title, m.summary, m.created_at, m.anchor_commit,
+			COALESCE(a.hit_count, 0), COALESCE(a.last_included_at, '')
+		FROM memories m
+		LEFT JOIN access_stats a
+			ON a.repo_id = m.repo_id
+			AND a.workspace = m.workspace
+			AND a.kind = ?
+			AND a.item_id = m.id
+		WHERE m.repo_id = ? AND m.workspace = ?
+			AND m.deleted_at IS NULL
+			AND (m.superseded_by IS NULL OR m.superseded_by = '')
+			AND m.created_at < ?
+			AND (a.last_included_at IS NULL OR a.last_included_at < ?)
+		ORDER BY COALESCE(a.last_included_at, m.created_at) ASC, m.id ASC
+		LIMIT ?
+	`, AccessKindMemory, repoID, workspace, cutoffText, cutoffText, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []StaleMemory
+	for rows.Next() {
+		var mem StaleMemory
+		var threadID sql.NullString
+		var createdAt string
+		var anchorCommit sql.NullString
+		var lastIncludedAt string
+		if err := rows.Scan(&mem.ID, &threadID, &mem.Title, &mem.Summary, &createdAt, &anchorCommit, &mem.HitCount, &lastIncludedAt); err != nil {
+			return nil, err
+		}
+		mem.ThreadID = threadID.String
+		mem.CreatedAt = parseTime(createdAt)
+		mem.AnchorCommit = anchorCommit.String
+		mem.LastIncludedAt = parseTime(lastIncludedAt)
+		results = append(results, mem)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
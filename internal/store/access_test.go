@@ -0,0 +1,74 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordAccessAccumulatesHits(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+
+	first := time.Now().UTC().Add(-time.Hour)
+	second := first.Add(30 * time.Minute)
+	if err := st.RecordAccess("r1", "default", []string{"M-1"}, []string{"C-1"}, first); err != nil {
+		t.Fatalf("record access: %v", err)
+	}
+	if err := st.RecordAccess("r1", "default", []string{"M-1"}, nil, second); err != nil {
+		t.Fatalf("record access: %v", err)
+	}
+
+	stats, err := st.ListAccessStats("r1", "default", []string{"M-1", "C-1", "M-2"})
+	if err != nil {
+		t.Fatalf("list access stats: %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 stats, got %d", len(stats))
+	}
+	if got := stats["M-1"]; got.HitCount != 2 || !got.LastIncludedAt.Equal(second) || got.Kind != AccessKindMemory {
+		t.Fatalf("unexpected memory stat: %+v", got)
+	}
+	if got := stats["C-1"]; got.HitCount != 1 || got.Kind != AccessKindChunk {
+		t.Fatalf("unexpected chunk stat: %+v", got)
+	}
+}
+
+func TestListStaleMemories(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+
+	now := time.Now().UTC()
+	old := now.Add(-60 * 24 * time.Hour)
+	addLinkTestMemory(t, st, "r1", "default", "M-never", old)
+	addLinkTestMemory(t, st, "r1", "default", "M-used-long-ago", old.Add(time.Hour))
+	addLinkTestMemory(t, st, "r1", "default", "M-used-recently", old)
+	addLinkTestMemory(t, st, "r1", "default", "M-new", now)
+
+	if err := st.RecordAccess("r1", "default", []string{"M-used-long-ago"}, nil, now.Add(-45*24*time.Hour)); err != nil {
+		t.Fatalf("record access: %v", err)
+	}
+	if err := st.RecordAccess("r1", "default", []string{"M-used-recently"}, nil, now.Add(-time.Hour)); err != nil {
+		t.Fatalf("record access: %v", err)
+	}
+
+	stale, err := st.ListStaleMemories("r1", "default", now.Add(-30*24*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("list stale: %v", err)
+	}
+	if len(stale) != 2 {
+		t.Fatalf("expected 2 stale memories, got %d", len(stale))
+	}
+	if stale[0].ID != "M-never" || stale[0].HitCount != 0 || !stale[0].LastIncludedAt.IsZero() {
+		t.Fatalf("unexpected first stale memory: %+v", stale[0])
+	}
+	if stale[1].ID != "M-used-long-ago" || stale[1].HitCount != 1 {
+		t.Fatalf("unexpected second stale memory: %+v", stale[1])
+	}
+}
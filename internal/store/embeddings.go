@@ -238,6 +238,72 @@ func (s *Store) ListMemoryEmbeddingsByIDs(repoID, workspace, model string, ids [
 	return embeddings, nil
 }
 
+func (s *Store) ListChunkEmbeddingsByIDs(repoID, workspace, model string, ids []string) (map[string][]float64, error) {
+	if repoID == "" || strings.TrimSpace(model) == "" {
+		return nil, fmt.Errorf("embedding lookup requires repo_id and model")
+	}
+	if len(ids) == 0 {
+		return map[string][]float64{}, nil
+	}
+	workspace = normalizeWorkspace(workspace)
+	model = strings.TrimSpace(model)
+
+	placeholders := strings.Repeat("?,", len(ids))
+	placeholders = strings.TrimSuffix(placeholders, ",")
+	args := make([]any, 0, len(ids)+4)
+	args = append(args, repoID, workspace, EmbeddingKindChunk, model)
+	for _, id := range ids {
+		args = append(args, strings.TrimSpace(id))
+	}
+
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT e.item_id, e.content_hash, e.vector_json, e.vector_dim,
+			c.locator, c.text, c.tags_text
+		FROM embeddings e
+		JOIN chunks c
+			ON c.chunk_id = e.item_id
+			AND c.repo_id = e.repo_id
+			AND c.workspace = e.workspace
+			AND c.deleted_at IS NULL
+		WHERE e.repo_id = ? AND e.workspace = ? AND e.kind = ? AND e.model = ?
+			AND e.item_id IN (%s)
+	`, placeholders), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	embeddings := make(map[string][]float64, len(ids))
+	for rows.Next() {
+		var itemID string
+		var contentHash string
+		var vectorJSON string
+		var vectorDim int
+		var locator sql.NullString
+		var text sql.NullString
+		var tagsText sql.NullString
+		if err := rows.Scan(&itemID, &contentHash, &vectorJSON, &vectorDim, &locator, &text, &tagsText); err != nil {
+			return nil, err
+		}
+		expected := embeddingContentHash(EmbeddingKindChunk, "", "", tagsText.String, "", locator.String, text.String)
+		if contentHash == "" || expected != contentHash {
+			continue
+		}
+		var vector []float64
+		if err := json.Unmarshal([]byte(vectorJSON), &vector); err != nil {
+			return nil, err
+		}
+		if len(vector) == 0 || (vectorDim > 0 && len(vector) != vectorDim) {
+			continue
+		}
+		embeddings[itemID] = vector
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return embeddings, nil
+}
+
 func (s *Store) ListMemoriesMissingEmbedding(repoID, workspace, model string, limit int) ([]Memory, error) {
 	workspace = normalizeWorkspace(workspace)
 	model = strings.TrimSpace(model)
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	FeedbackKindMemory = "memory"
+	FeedbackKindChunk  = "chunk"
+)
+
+type Feedback struct {
+	ID        int64
+	RepoID    string
+	Workspace string
+	ItemID    string
+	Kind      string
+	Vote      int
+	Query     string
+	CreatedAt time.Time
+}
+
+func (s *Store) AddFeedback(fb Feedback) (Feedback, error) {
+	fb.RepoID = strings.TrimSpace(fb.RepoID)
+	fb.Workspace = normalizeWorkspace(fb.Workspace)
+	fb.ItemID = strings.TrimSpace(fb.ItemID)
+	fb.Kind = strings.TrimSpace(fb.Kind)
+	fb.Query = strings.TrimSpace(fb.Query)
+	if fb.RepoID == "" || fb.ItemID == "" {
+		return Feedback{}, fmt.Errorf("feedback requires repo_id and item_id")
+	}
+	switch fb.Kind {
+	case FeedbackKindMemory, FeedbackKindChunk:
+	default:
+		return Feedback{}, fmt.Errorf("invalid feedback kind: %s", fb.Kind)
+	}
+	switch {
+	case fb.Vote > 0:
+		fb.Vote = 1
+	case fb.Vote < 0:
+		fb.Vote = -1
+	default:
+		return Feedback{}, fmt.Errorf("feedback vote must be non-zero")
+	}
+	if fb.CreatedAt.IsZero() {
+		fb.CreatedAt = time.Now().UTC()
+	}
+
+	res, err := s.db.Exec(`
+		INSERT INTO feedback (repo_id, workspace, item_id, kind, vote, query, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, fb.RepoID, fb.Workspace, fb.ItemID, fb.Kind, fb.Vote, fb.Query, fb.CreatedAt.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return Feedback{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Feedback{}, err
+	}
+	fb.ID = id
+	return fb, nil
+}
+
+// ListFeedbackForIDs returns feedback events for the given items, newest first.
+func (s *Store) ListFeedbackForIDs(repoID, workspace string, ids []string) ([]Feedback, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]any, 0, len(ids)+2)
+	args = append(args, repoID, normalizeWorkspace(workspace))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT feedback_id, repo_id, workspace, item_id, kind, vote, query, created_at
+		FROM feedback
+		WHERE repo_id = ? AND workspace = ? AND item_id IN (%s)
+		ORDER BY created_at DESC, feedback_id DESC
+	`, placeholders), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Feedback
+	for rows.Next() {
+		var fb Feedback
+		var createdAt string
+		if err := rows.Scan(&fb.ID, &fb.RepoID, &fb.Workspace, &fb.ItemID, &fb.Kind, &fb.Vote, &fb.Query, &createdAt); err != nil {
+			return nil, err
+		}
+		fb.CreatedAt = parseTime(createdAt)
+		events = append(events, fb)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAddAndListFeedback(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+
+	now := time.Now().UTC()
+	first, err := st.AddFeedback(Feedback{RepoID: "r1", ItemID: "M-1", Kind: FeedbackKindMemory, Vote: 3, Query: " auth flow ", CreatedAt: now.Add(-time.Hour)})
+	if err != nil {
+		t.Fatalf("add feedback: %v", err)
+	}
+	if first.Vote != 1 || first.Workspace != "default" || first.Query != "auth flow" {
+		t.Fatalf("unexpected normalized feedback: %+v", first)
+	}
+	if _, err := st.AddFeedback(Feedback{RepoID: "r1", ItemID: "C-1", Kind: FeedbackKindChunk, Vote: -1, CreatedAt: now}); err != nil {
+		t.Fatalf("add chunk feedback: %v", err)
+	}
+	if _, err := st.AddFeedback(Feedback{RepoID: "r1", Workspace: "other", ItemID: "M-1", Kind: FeedbackKindMemory, Vote: -1}); err != nil {
+		t.Fatalf("add other workspace feedback: %v", err)
+	}
+
+	events, err := st.ListFeedbackForIDs("r1", "default", []string{"M-1", "C-1"})
+	if err != nil {
+		t.Fatalf("list feedback: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].ItemID != "C-1" || events[0].Vote != -1 {
+		t.Fatalf("expected newest chunk event first, got %+v", events[0])
+	}
+	if !events[1].CreatedAt.Equal(now.Add(-time.Hour)) {
+		t.Fatalf("unexpected created_at: %v", events[1].CreatedAt)
+	}
+
+	if _, err := st.AddFeedback(Feedback{RepoID: "r1", ItemID: "M-1", Kind: FeedbackKindMemory}); err == nil {
+		t.Fatalf("expected zero vote to be rejected")
+	}
+	if _, err := st.AddFeedback(Feedback{RepoID: "r1", ItemID: "M-1", Kind: "thread", Vote: 1}); err == nil {
+		t.Fatalf("expected invalid kind to be rejected")
+	}
+}
@@ -21,6 +21,23 @@ func (s *Store) AddLink(link Link) error {
 }
 
 func (s *Store) AddLinkIfMissing(link Link) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	added, err := addLinkIfMissingTx(tx, link)
+	if err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return added, nil
+}
+
+func addLinkIfMissingTx(tx *sql.Tx, link Link) (bool, error) {
 	fromID := strings.TrimSpace(link.FromID)
 	rel := strings.TrimSpace(link.Rel)
 	toID := strings.TrimSpace(link.ToID)
@@ -33,7 +50,7 @@ func (s *Store) AddLinkIfMissing(link Link) (bool, error) {
 		createdAt = time.Now().UTC()
 	}
 
-	res, err := s.db.Exec(`
+	res, err := tx.Exec(`
 		INSERT INTO links (from_id, rel, to_id, weight, created_at)
 		SELECT ?, ?, ?, ?, ?
 		WHERE NOT EXISTS (
@@ -225,3 +225,110 @@ func TestWouldCreateLinkCycle(t *testing.T) {
 		t.Fatalf("expected C->D to be non-cyclic")
 	}
 }
+
+func TestConsolidateMemoriesIsAtomic(t *testing.T) {
+	dir := t.TempDir()
+	st, err := Open(filepath.Join(dir, "memory.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+
+	repoID := "r1"
+	workspace := "default"
+	now := time.Now().UTC()
+	addLinkTestMemory(t, st, repoID, workspace, "M-A", now)
+	addLinkTestMemory(t, st, repoID, workspace, "M-B", now.Add(time.Second))
+	addLinkTestMemory(t, st, repoID, workspace, "M-C", now.Add(2*time.Second))
+	merged := AddMemoryInput{
+		ID:        "M-MERGED",
+		RepoID:    repoID,
+		Workspace: workspace,
+		ThreadID:  "T-LINK",
+		Title:     "merged",
+		Summary:   "merged summary",
+		TagsJSON:  "[]",
+		CreatedAt: now.Add(3 * time.Second),
+	}
+
+	// The second carried link is invalid, so nothing may be written.
+	_, _, err = st.ConsolidateMemories(merged, []string{"M-A", "M-B"}, []Link{
+		{FromID: "M-MERGED", Rel: "depends_on", ToID: "M-C", Weight: 1},
+		{FromID: "M-MERGED", Rel: "", ToID: "M-C", Weight: 1},
+	})
+	if err == nil {
+		t.Fatal("expected invalid carried link to fail")
+	}
+	if _, err := st.GetMemory(repoID, workspace, "M-MERGED"); err == nil {
+		t.Fatal("expected merged memory to be rolled back")
+	}
+	if mem, err := st.GetMemory(repoID, workspace, "M-A"); err != nil || mem.SupersededBy != "" {
+		t.Fatalf("expected M-A to stay active, got %+v (%v)", mem, err)
+	}
+
+	mem, carried, err := st.ConsolidateMemories(merged, []string{"M-A", "M-B"}, []Link{
+		{FromID: "M-MERGED", Rel: "depends_on", ToID: "M-C", Weight: 1},
+	})
+	if err != nil {
+		t.Fatalf("consolidate: %v", err)
+	}
+	if mem.ID != "M-MERGED" || carried != 1 {
+		t.Fatalf("unexpected result %+v carried=%d", mem, carried)
+	}
+	for _, id := range []string{"M-A", "M-B"} {
+		old, err := st.GetMemory(repoID, workspace, id)
+		if err != nil || old.SupersededBy != "M-MERGED" {
+			t.Fatalf("expected %s superseded by M-MERGED, got %+v (%v)", id, old, err)
+		}
+	}
+}
+
+func TestAddLinkedAndSupersedingMemoryAreAtomic(t *testing.T) {
+	dir := t.TempDir()
+	st, err := Open(filepath.Join(dir, "memory.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+
+	repoID := "r1"
+	workspace := "default"
+	now := time.Now().UTC()
+	addLinkTestMemory(t, st, repoID, workspace, "M-OLD", now)
+	input := func(id string) AddMemoryInput {
+		return AddMemoryInput{
+			ID:        id,
+			RepoID:    repoID,
+			Workspace: workspace,
+			ThreadID:  "T-LINK",
+			Title:     id,
+			Summary:   "duplicate summary",
+			TagsJSON:  "[]",
+			CreatedAt: now.Add(time.Second),
+		}
+	}
+
+	if _, err := st.AddLinkedMemory(input("M-BAD"), "", "M-OLD"); err == nil {
+		t.Fatal("expected an invalid link to fail")
+	}
+	if _, err := st.GetMemory(repoID, workspace, "M-BAD"); err == nil {
+		t.Fatal("expected the new memory to be rolled back with its link")
+	}
+
+	if _, err := st.AddLinkedMemory(input("M-LINKED"), "related_to", "M-OLD"); err != nil {
+		t.Fatalf("add linked: %v", err)
+	}
+	links, err := st.ListLinksForIDs([]string{"M-LINKED"})
+	if err != nil || len(links) != 1 || links[0].Rel != "related_to" || links[0].ToID != "M-OLD" {
+		t.Fatalf("expected related_to link, got %+v (%v)", links, err)
+	}
+
+	mem, err := st.AddSupersedingMemory(input("M-NEW"), "M-OLD")
+	if err != nil {
+		t.Fatalf("add superseding: %v", err)
+	}
+	old, err := st.GetMemory(repoID, workspace, "M-OLD")
+	if err != nil || old.SupersededBy != mem.ID {
+		t.Fatalf("expected M-OLD superseded by %s, got %+v (%v)", mem.ID, old, err)
+	}
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -78,6 +79,124 @@ type UpdateMemoryInput struct {
 }
 
 func (s *Store) AddMemory(input AddMemoryInput) (Memory, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Memory{}, err
+	}
+	defer tx.Rollback()
+
+	mem, err := addMemoryTx(tx, input)
+	if err != nil {
+		return Memory{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Memory{}, err
+	}
+	return mem, nil
+}
+
+// ConsolidateMemories adds merged, carries links onto it, and marks every
+// source memory superseded by it in one transaction, so a failure never
+// leaves the merged memory next to sources that are still active. Carried
+// links must already point at merged.ID. It returns the new memory and how
+// many carried links were not already present.
+func (s *Store) ConsolidateMemories(merged AddMemoryInput, sourceIDs []string, carried []Link) (Memory, int, error) {
+	if strings.TrimSpace(merged.ID) == "" {
+		return Memory{}, 0, fmt.Errorf("consolidated memory id is required")
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Memory{}, 0, err
+	}
+	defer tx.Rollback()
+
+	mem, err := addMemoryTx(tx, merged)
+	if err != nil {
+		return Memory{}, 0, err
+	}
+	added := 0
+	for _, link := range carried {
+		ok, err := addLinkIfMissingTx(tx, link)
+		if err != nil {
+			return Memory{}, 0, err
+		}
+		if ok {
+			added++
+		}
+	}
+	for _, id := range sourceIDs {
+		if err := supersedeMemoryTx(tx, mem, id, merged.CreatedAt); err != nil {
+			return Memory{}, 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return Memory{}, 0, err
+	}
+	return mem, added, nil
+}
+
+// AddSupersedingMemory adds input and marks oldID superseded by it in one
+// transaction.
+func (s *Store) AddSupersedingMemory(input AddMemoryInput, oldID string) (Memory, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Memory{}, err
+	}
+	defer tx.Rollback()
+
+	mem, err := addMemoryTx(tx, input)
+	if err != nil {
+		return Memory{}, err
+	}
+	if err := supersedeMemoryTx(tx, mem, oldID, mem.CreatedAt); err != nil {
+		return Memory{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Memory{}, err
+	}
+	return mem, nil
+}
+
+// AddLinkedMemory adds input and a rel link from it to toID in one
+// transaction.
+func (s *Store) AddLinkedMemory(input AddMemoryInput, rel, toID string) (Memory, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Memory{}, err
+	}
+	defer tx.Rollback()
+
+	mem, err := addMemoryTx(tx, input)
+	if err != nil {
+		return Memory{}, err
+	}
+	if _, err := addLinkIfMissingTx(tx, Link{FromID: mem.ID, Rel: rel, ToID: toID, Weight: 1, CreatedAt: mem.CreatedAt}); err != nil {
+		return Memory{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Memory{}, err
+	}
+	return mem, nil
+}
+
+// supersedeMemoryTx marks oldID superseded by mem and records the
+// superseded_by/supersedes link pair.
+func supersedeMemoryTx(tx *sql.Tx, mem Memory, oldID string, at time.Time) error {
+	if err := markMemorySupersededTx(tx, mem.RepoID, mem.Workspace, oldID, mem.ID); err != nil {
+		return err
+	}
+	for _, link := range []Link{
+		{FromID: oldID, Rel: "superseded_by", ToID: mem.ID, Weight: 1, CreatedAt: at},
+		{FromID: mem.ID, Rel: "supersedes", ToID: oldID, Weight: 1, CreatedAt: at},
+	} {
+		if _, err := addLinkIfMissingTx(tx, link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addMemoryTx(tx *sql.Tx, input AddMemoryInput) (Memory, error) {
 	createdAt := input.CreatedAt.UTC().Format(time.RFC3339Nano)
 	id := input.ID
 	if id == "" {
@@ -85,7 +204,7 @@ func (s *Store) AddMemory(input AddMemoryInput) (Memory, error) {
 	}
 	workspace := normalizeWorkspace(input.Workspace)
 
-	_, err := s.db.Exec(`
+	_, err := tx.Exec(`
 		INSERT OR IGNORE INTO threads (thread_id, repo_id, workspace, title, tags_json, created_at)
 		VALUES (?, ?, ?, ?, ?, ?)
 	`, input.ThreadID, input.RepoID, workspace, input.ThreadID, "[]", createdAt)
@@ -93,7 +212,7 @@ func (s *Store) AddMemory(input AddMemoryInput) (Memory, error) {
 		return Memory{}, err
 	}
 
-	_, err = s.db.Exec(`
+	_, err = tx.Exec(`
 		INSERT INTO memories (
 			id, repo_id, workspace, thread_id, title, summary, summary_tokens, tags_json, tags_text, entities_json, entities_text,
 			created_at, anchor_commit, superseded_by, deleted_at
@@ -404,6 +523,8 @@ type Chunk struct {
 	SymbolKind string
 	CreatedAt  time.Time
 	DeletedAt  time.Time
+	// Refs are written to chunk_refs on insert; reads leave them empty.
+	Refs []ChunkRef
 }
 
 type ChunkResult struct {
@@ -7,7 +7,7 @@ import (
 	"time"
 )
 
-const schemaVersion = 10
+const schemaVersion = 11
 
 func migrate(db *sql.DB) error {
 	version, err := getUserVersion(db)
@@ -42,6 +42,15 @@ func migrate(db *sql.DB) error {
 	if err := ensureChunkSymbolIndex(db); err != nil {
 		return err
 	}
+	if err := ensureFeedbackTable(db); err != nil {
+		return err
+	}
+	if err := ensureAccessStatsTable(db); err != nil {
+		return err
+	}
+	if err := ensureChunkRefsTable(db); err != nil {
+		return err
+	}
 
 	if version < 5 {
 		if err := rebuildThreadsTable(db); err != nil {
@@ -69,6 +78,11 @@ func migrate(db *sql.DB) error {
 			return err
 		}
 	}
+	if version < 11 {
+		if err := rebaseFileRefs(db); err != nil {
+			return err
+		}
+	}
 	if version < schemaVersion {
 		if err := rebuildFTS(db); err != nil {
 			return err
@@ -210,6 +224,64 @@ func ensureLinksIndexes(db *sql.DB) error {
 	return nil
 }
 
+func ensureFeedbackTable(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS feedback (
+			feedback_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			repo_id TEXT NOT NULL,
+			workspace TEXT NOT NULL DEFAULT 'default',
+			item_id TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			vote INTEGER NOT NULL,
+			query TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL
+		)
+	`); err != nil {
+		return err
+	}
+	_, err := db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_feedback_item
+		ON feedback (repo_id, workspace, item_id)
+	`)
+	return err
+}
+
+func ensureAccessStatsTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS access_stats (
+			repo_id TEXT NOT NULL,
+			workspace TEXT NOT NULL DEFAULT 'default',
+			kind TEXT NOT NULL,
+			item_id TEXT NOT NULL,
+			hit_count INTEGER NOT NULL DEFAULT 0,
+			last_included_at TEXT NOT NULL,
+			PRIMARY KEY (repo_id, workspace, kind, item_id)
+		)
+	`)
+	return err
+}
+
+func ensureChunkRefsTable(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS chunk_refs (
+			repo_id TEXT NOT NULL,
+			workspace TEXT NOT NULL DEFAULT 'default',
+			chunk_id TEXT NOT NULL,
+			ref_kind TEXT NOT NULL,
+			ref_name TEXT NOT NULL,
+			ref_base TEXT NOT NULL,
+			PRIMARY KEY (chunk_id, ref_kind, ref_name)
+		)
+	`); err != nil {
+		return err
+	}
+	_, err := db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_chunk_refs_base
+		ON chunk_refs (repo_id, workspace, ref_base)
+	`)
+	return err
+}
+
 func ensureChunkSymbolIndex(db *sql.DB) error {
 	_, err := db.Exec(`
 		CREATE INDEX IF NOT EXISTS idx_chunks_symbol
@@ -285,6 +357,17 @@ func backfillChunkType(db *sql.DB) error {
 	return err
 }
 
+// rebaseFileRefs re-indexes file references by path base name. The inner
+// rtrim strips everything after the last "/", leaving the directory prefix.
+func rebaseFileRefs(db *sql.DB) error {
+	_, err := db.Exec(`
+		UPDATE chunk_refs
+		SET ref_base = substr(ref_name, length(rtrim(ref_name, replace(ref_name, '/', ''))) + 1)
+		WHERE ref_kind = 'file'
+	`)
+	return err
+}
+
 func ensureColumn(db *sql.DB, table, column, columnType string) error {
 	exists, err := columnExists(db, table, column)
 	if err != nil {
@@ -41,7 +41,7 @@ type TimeHint struct {
 var (
 	threadIDPattern = regexp.MustCompile(`\bT-[A-Za-z0-9_-]+\b`)
 	filePathPattern = regexp.MustCompile(`\b[\w./\\-]+\.(go|py|ts|js|tsx|jsx|md|json|yaml|yml|sql|sh)\b`)
-	symbolPattern   = regexp.MustCompile(`\b[A-Za-z_][A-Za-z0-9_]*\.[A-Z][A-Za-z0-9_]*\b`)
+	symbolPattern   = regexp.MustCompile(`\b[A-Za-z_][A-Za-z0-9_]*(?:\.[A-Z][A-Za-z0-9_]*|::[A-Za-z_][A-Za-z0-9_]*)\b`)
 )
 
 var stopWords = map[string]bool{
@@ -328,6 +328,9 @@ func buildSymbolQuery(symbol string) string {
 		return ""
 	}
 	parts := strings.Split(symbol, ".")
+	if len(parts) != 2 {
+		parts = strings.Split(symbol, "::")
+	}
 	if len(parts) == 2 {
 		left := formatFTSVariant(parts[0])
 		right := formatFTSVariant(parts[1])
@@ -20,6 +20,7 @@ func TestQueryIntentDetection(t *testing.T) {
 		{"thread T-AUTH", IntentThread, 1.0},
 		{"Store.AddMemory function", IntentSymbol, 1.0},
 		{"store.Open function", IntentSymbol, 1.0},
+		{"Parser::parse", IntentSymbol, 1.0},
 		{"changes in auth.go", IntentFile, 1.0},
 	}
 
@@ -72,6 +73,7 @@ func TestEntityExtraction(t *testing.T) {
 		{"call ctx.Done safely", 1},
 		{"changes in auth.go and user.go", 2},
 		{"T-AUTH Store.Get auth.go", 3},
+		{"where is Parser::parse called", 1},
 	}
 
 	for _, tt := range tests {
@@ -82,6 +84,16 @@ func TestEntityExtraction(t *testing.T) {
 	}
 }
 
+func TestParseQueryRustPathSymbol(t *testing.T) {
+	parsed := ParseQuery("Parser::parse")
+	if len(parsed.Entities) != 1 || parsed.Entities[0].Value != "Parser::parse" {
+		t.Fatalf("expected Parser::parse symbol entity, got %#v", parsed.Entities)
+	}
+	if !strings.Contains(parsed.FTSQuery, `"Parser" AND "parse"`) {
+		t.Fatalf("expected split symbol terms in FTS query, got %q", parsed.FTSQuery)
+	}
+}
+
 func TestBackwardCompatibility(t *testing.T) {
 	queries := []string{
 		"authentication",
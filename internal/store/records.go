@@ -371,14 +371,49 @@ func (s *Store) ForgetChunk(repoID, workspace, id string, now time.Time) (bool,
 }
 
 func (s *Store) MarkMemorySuperseded(repoID, workspace, oldID, newID string) error {
-	_, err := s.db.Exec(`
-		UPDATE memories
-		SET superseded_by = ?
-		WHERE repo_id = ? AND workspace = ? AND id = ?
-	`, newID, repoID, normalizeWorkspace(workspace), oldID)
+	_, err := s.db.Exec(markMemorySupersededSQL, newID, repoID, normalizeWorkspace(workspace), oldID)
+	return err
+}
+
+func markMemorySupersededTx(tx *sql.Tx, repoID, workspace, oldID, newID string) error {
+	_, err := tx.Exec(markMemorySupersededSQL, newID, repoID, normalizeWorkspace(workspace), oldID)
 	return err
 }
 
+const markMemorySupersededSQL = `
+	UPDATE memories
+	SET superseded_by = ?
+	WHERE repo_id = ? AND workspace = ? AND id = ?
+`
+
+// ListSupersededMemoryIDs returns the ids of memories retired in favour of id,
+// oldest first.
+func (s *Store) ListSupersededMemoryIDs(repoID, workspace, id string) ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT id
+		FROM memories
+		WHERE repo_id = ? AND workspace = ? AND superseded_by = ?
+		ORDER BY created_at ASC, id ASC
+	`, repoID, normalizeWorkspace(workspace), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var oldID string
+		if err := rows.Scan(&oldID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, oldID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
 func (s *Store) ListThreads(repoID, workspace string) ([]Thread, error) {
 	rows, err := s.db.Query(`
 		SELECT t.thread_id, t.repo_id, t.workspace, t.title, t.tags_json, t.created_at,
@@ -599,14 +634,74 @@ func buildSessionTagClause(needsSummary bool) (string, []any) {
 }
 
 func (s *Store) AddArtifactWithChunks(artifact Artifact, chunks []Chunk) (int, []string, error) {
-	workspace := normalizeWorkspace(artifact.Workspace)
 	tx, err := s.db.Begin()
 	if err != nil {
 		return 0, nil, err
 	}
 	defer tx.Rollback()
 
-	_, err = tx.Exec(`
+	inserted, insertedIDs, err := addArtifactWithChunksTx(tx, artifact, chunks)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+	return inserted, insertedIDs, nil
+}
+
+// IngestWrite is one file's share of an ingest batch. When ReplaceThread is
+// set, that thread's chunks from Artifact.Source are tombstoned first; a
+// write with no chunks only tombstones.
+type IngestWrite struct {
+	Artifact      Artifact
+	Chunks        []Chunk
+	ReplaceThread string
+}
+
+type IngestWriteResult struct {
+	Inserted    int
+	InsertedIDs []string
+	Removed     int
+}
+
+// WriteIngestBatch applies writes in one transaction, so a batch of files
+// is either fully stored or not at all.
+func (s *Store) WriteIngestBatch(writes []IngestWrite) ([]IngestWriteResult, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := make([]IngestWriteResult, len(writes))
+	for i, write := range writes {
+		if write.ReplaceThread != "" {
+			removed, err := deleteThreadChunksBySourceTx(tx, write.Artifact.RepoID, write.Artifact.Workspace, write.ReplaceThread, write.Artifact.Source)
+			if err != nil {
+				return nil, err
+			}
+			results[i].Removed = removed
+		}
+		if len(write.Chunks) == 0 {
+			continue
+		}
+		inserted, insertedIDs, err := addArtifactWithChunksTx(tx, write.Artifact, write.Chunks)
+		if err != nil {
+			return nil, err
+		}
+		results[i].Inserted = inserted
+		results[i].InsertedIDs = insertedIDs
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func addArtifactWithChunksTx(tx *sql.Tx, artifact Artifact, chunks []Chunk) (int, []string, error) {
+	workspace := normalizeWorkspace(artifact.Workspace)
+	_, err := tx.Exec(`
 		INSERT INTO artifacts (artifact_id, repo_id, workspace, kind, source, content_hash, created_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`, artifact.ID, artifact.RepoID, workspace, artifact.Kind, artifact.Source, artifact.ContentHash, artifact.CreatedAt.UTC().Format(time.RFC3339Nano))
@@ -629,31 +724,46 @@ func (s *Store) AddArtifactWithChunks(artifact Artifact, chunks []Chunk) (int, [
 		if textHash == "" {
 			textHash = sha256Hex(chunk.Text)
 		}
-		res, err := tx.Exec(`
-			INSERT OR IGNORE INTO chunks (
+		// A chunk identical to a tombstoned one (an unchanged function in a
+		// re-ingested file) revives the old row so its id and embedding carry
+		// over; a live duplicate is left alone.
+		var chunkID string
+		err := tx.QueryRow(`
+			INSERT INTO chunks (
 				chunk_id, repo_id, workspace, artifact_id, thread_id, locator,
 				text, text_hash, text_tokens, tags_json, tags_text,
 				chunk_type, symbol_name, symbol_kind, created_at, deleted_at
 			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULL)
+			ON CONFLICT (repo_id, workspace, locator, text_hash, thread_id) DO UPDATE SET
+				artifact_id = excluded.artifact_id,
+				text_tokens = excluded.text_tokens,
+				tags_json = excluded.tags_json,
+				tags_text = excluded.tags_text,
+				chunk_type = excluded.chunk_type,
+				symbol_name = excluded.symbol_name,
+				symbol_kind = excluded.symbol_kind,
+				created_at = excluded.created_at,
+				deleted_at = NULL
+			WHERE chunks.deleted_at IS NOT NULL
+			RETURNING chunk_id
 		`, chunk.ID, chunk.RepoID, chunkWorkspace, chunk.ArtifactID, chunk.ThreadID, chunk.Locator,
 			chunk.Text, textHash, chunk.TextTokens, chunk.TagsJSON, chunk.TagsText,
 			chunkType, nullIfEmpty(chunk.SymbolName), nullIfEmpty(chunk.SymbolKind),
-			chunk.CreatedAt.UTC().Format(time.RFC3339Nano))
+			chunk.CreatedAt.UTC().Format(time.RFC3339Nano)).Scan(&chunkID)
+		if err == sql.ErrNoRows {
+			continue
+		}
 		if err != nil {
 			return 0, nil, err
 		}
-		if res != nil {
-			if affected, err := res.RowsAffected(); err == nil {
-				inserted += int(affected)
-				if affected > 0 {
-					insertedIDs = append(insertedIDs, chunk.ID)
-				}
-			}
+		inserted++
+		insertedIDs = append(insertedIDs, chunkID)
+		if _, err := tx.Exec(`DELETE FROM chunk_refs WHERE chunk_id = ?`, chunkID); err != nil {
+			return 0, nil, err
+		}
+		if err := insertChunkRefs(tx, chunk.RepoID, chunkWorkspace, chunkID, chunk.Refs); err != nil {
+			return 0, nil, err
 		}
-	}
-
-	if err := tx.Commit(); err != nil {
-		return 0, nil, err
 	}
 	return inserted, insertedIDs, nil
 }
@@ -718,6 +828,226 @@ func (s *Store) DeleteArtifactsBySource(repoID, workspace, source string) (int,
 	return int(affected), nil
 }
 
+// LatestSourceHash returns the content hash of the most recent artifact
+// ingested from source that still has live chunks in threadID, or "" when
+// the thread holds nothing from source.
+func (s *Store) LatestSourceHash(repoID, workspace, threadID, source string) (string, error) {
+	workspace = normalizeWorkspace(workspace)
+	var hash sql.NullString
+	err := s.db.QueryRow(`
+		SELECT a.content_hash FROM artifacts a
+		WHERE a.repo_id = ? AND a.workspace = ? AND a.source = ?
+			AND EXISTS (
+				SELECT 1 FROM chunks c
+				WHERE c.artifact_id = a.artifact_id AND c.thread_id = ? AND c.deleted_at IS NULL
+			)
+		ORDER BY a.created_at DESC
+		LIMIT 1
+	`, repoID, workspace, source, threadID).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return hash.String, nil
+}
+
+// SourceHashes maps each source with live chunks in threadID to the content
+// hash of its most recent artifact, for checking many files at once.
+func (s *Store) SourceHashes(repoID, workspace, threadID string) (map[string]string, error) {
+	workspace = normalizeWorkspace(workspace)
+	rows, err := s.db.Query(`
+		SELECT a.source, a.content_hash FROM artifacts a
+		WHERE a.repo_id = ? AND a.workspace = ? AND a.source IS NOT NULL
+			AND EXISTS (
+				SELECT 1 FROM chunks c
+				WHERE c.artifact_id = a.artifact_id AND c.thread_id = ? AND c.deleted_at IS NULL
+			)
+		ORDER BY a.created_at
+	`, repoID, workspace, threadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := map[string]string{}
+	for rows.Next() {
+		var source string
+		var hash sql.NullString
+		if err := rows.Scan(&source, &hash); err != nil {
+			return nil, err
+		}
+		hashes[source] = hash.String
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+// ListThreadSources returns the distinct sources of artifacts of kind that
+// still have live chunks in threadID.
+func (s *Store) ListThreadSources(repoID, workspace, threadID, kind string) ([]string, error) {
+	workspace = normalizeWorkspace(workspace)
+	rows, err := s.db.Query(`
+		SELECT DISTINCT a.source FROM artifacts a
+		JOIN chunks c ON c.artifact_id = a.artifact_id
+		WHERE a.repo_id = ? AND a.workspace = ? AND a.kind = ? AND a.source IS NOT NULL
+			AND c.thread_id = ? AND c.deleted_at IS NULL
+		ORDER BY a.source
+	`, repoID, workspace, kind, threadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sources []string
+	for rows.Next() {
+		var source string
+		if err := rows.Scan(&source); err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// ListArtifactSources returns the distinct sources of artifacts of kind.
+func (s *Store) ListArtifactSources(repoID, workspace, kind string) ([]string, error) {
+	workspace = normalizeWorkspace(workspace)
+	rows, err := s.db.Query(`
+		SELECT DISTINCT source FROM artifacts
+		WHERE repo_id = ? AND workspace = ? AND kind = ? AND source IS NOT NULL
+		ORDER BY source
+	`, repoID, workspace, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sources []string
+	for rows.Next() {
+		var source string
+		if err := rows.Scan(&source); err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// SourceSummary is what one thread holds live from one source: its chunk
+// and token totals, and the hash and time of the latest artifact ingested.
+type SourceSummary struct {
+	Kind        string
+	Source      string
+	ThreadID    string
+	ContentHash string
+	Chunks      int
+	Tokens      int
+	IngestedAt  time.Time
+}
+
+// ListSourceSummaries returns one summary per source and thread with live
+// chunks, ordered by source then thread.
+func (s *Store) ListSourceSummaries(repoID, workspace string) ([]SourceSummary, error) {
+	workspace = normalizeWorkspace(workspace)
+	rows, err := s.db.Query(`
+		SELECT a.kind, a.source, c.thread_id, a.content_hash, a.created_at,
+			COUNT(*), COALESCE(SUM(c.text_tokens), 0)
+		FROM artifacts a
+		JOIN chunks c ON c.artifact_id = a.artifact_id
+		WHERE a.repo_id = ? AND a.workspace = ? AND a.source IS NOT NULL AND c.deleted_at IS NULL
+		GROUP BY a.artifact_id, c.thread_id
+		ORDER BY a.source, c.thread_id, a.kind, a.created_at
+	`, repoID, workspace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []SourceSummary
+	for rows.Next() {
+		var item SourceSummary
+		var hash sql.NullString
+		var createdAt string
+		if err := rows.Scan(&item.Kind, &item.Source, &item.ThreadID, &hash, &createdAt, &item.Chunks, &item.Tokens); err != nil {
+			return nil, err
+		}
+		item.ContentHash = hash.String
+		item.IngestedAt = parseTime(createdAt)
+		// Rows for older artifacts of the same source and thread come first;
+		// fold them into the latest one.
+		if n := len(summaries); n > 0 && summaries[n-1].Source == item.Source && summaries[n-1].ThreadID == item.ThreadID && summaries[n-1].Kind == item.Kind {
+			item.Chunks += summaries[n-1].Chunks
+			item.Tokens += summaries[n-1].Tokens
+			summaries[n-1] = item
+			continue
+		}
+		summaries = append(summaries, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// DeleteThreadChunksBySource soft-deletes threadID's chunks ingested from
+// source, then deletes the source's artifacts no live chunk points at. Other
+// threads' chunks from the same source are left alone.
+func (s *Store) DeleteThreadChunksBySource(repoID, workspace, threadID, source string) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	deleted, err := deleteThreadChunksBySourceTx(tx, repoID, workspace, threadID, source)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+func deleteThreadChunksBySourceTx(tx *sql.Tx, repoID, workspace, threadID, source string) (int, error) {
+	workspace = normalizeWorkspace(workspace)
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	result, err := tx.Exec(`
+		UPDATE chunks SET deleted_at = ?
+		WHERE repo_id = ? AND workspace = ? AND thread_id = ? AND deleted_at IS NULL
+			AND artifact_id IN (
+				SELECT artifact_id FROM artifacts
+				WHERE repo_id = ? AND workspace = ? AND source = ?
+			)
+	`, now, repoID, workspace, threadID, repoID, workspace, source)
+	if err != nil {
+		return 0, err
+	}
+	affected, _ := result.RowsAffected()
+
+	if _, err := tx.Exec(`
+		DELETE FROM artifacts
+		WHERE repo_id = ? AND workspace = ? AND source = ?
+			AND NOT EXISTS (
+				SELECT 1 FROM chunks c
+				WHERE c.artifact_id = artifacts.artifact_id AND c.deleted_at IS NULL
+			)
+	`, repoID, workspace, source); err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
+
 func nullIfEmpty(value string) interface{} {
 	if value == "" {
 		return nil
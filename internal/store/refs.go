@@ -0,0 +1,317 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	RefKindImport = "import"
+	RefKindCall   = "call"
+	RefKindType   = "type"
+	RefKindFile   = "file"
+
+	RefMatchExact = "exact"
+	RefMatchName  = "name"
+)
+
+// ChunkRef is something a chunk references: an imported package, a called
+// function, or a referenced type, named as written in the source
+// ("fmt.Println", "st.Search", "mem/internal/store"). Commit chunks reference
+// the files the commit touched.
+type ChunkRef struct {
+	Kind string
+	Name string
+}
+
+// ChunkReference is a live chunk that references a looked-up name.
+type ChunkReference struct {
+	Chunk
+	RefKind string
+	RefName string
+	Match   string
+}
+
+// RefBase is the unqualified name references are indexed by: the last path
+// segment of an import ("store" for "mem/internal/store") or the last dotted
+// segment of anything else ("Search" for "st.Search").
+func RefBase(name string) string {
+	name = strings.TrimSpace(name)
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		return name[idx+1:]
+	}
+	if idx := strings.LastIndex(name, "."); idx >= 0 && idx < len(name)-1 {
+		return name[idx+1:]
+	}
+	return name
+}
+
+// refBaseFor is the ref_base stored for a reference of kind. File paths are
+// indexed by their base name ("ingest.go" for "internal/app/ingest.go") so a
+// bare filename finds them; RefBase would cut "ingest.go" down to "go".
+func refBaseFor(kind, name string) string {
+	if kind == RefKindFile {
+		return path.Base(strings.TrimSpace(name))
+	}
+	return RefBase(name)
+}
+
+func insertChunkRefs(tx *sql.Tx, repoID, workspace, chunkID string, refs []ChunkRef) error {
+	for _, ref := range refs {
+		name := strings.TrimSpace(ref.Name)
+		if name == "" || ref.Kind == "" {
+			continue
+		}
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO chunk_refs (repo_id, workspace, chunk_id, ref_kind, ref_name, ref_base)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, repoID, workspace, chunkID, ref.Kind, name, refBaseFor(ref.Kind, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindChunkRefs returns live chunks referencing name. References written
+// exactly as name sort first, then references sharing its unqualified name,
+// since callers rarely spell out the receiver type ("st.Search" for
+// "Store.Search"), or for file references its base name. kinds, when set,
+// restricts the reference kind.
+func (s *Store) FindChunkRefs(repoID, workspace, name string, kinds []string, limit int) ([]ChunkReference, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || limit <= 0 {
+		return nil, nil
+	}
+	workspace = normalizeWorkspace(workspace)
+	args := []any{name, repoID, workspace, RefKindFile, refBaseFor(RefKindFile, name), RefKindFile, RefBase(name), name}
+	kindFilter := ""
+	if len(kinds) > 0 {
+		kindFilter = fmt.Sprintf(" AND r.ref_kind IN (%s)", strings.TrimSuffix(strings.Repeat("?,", len(kinds)), ","))
+		for _, kind := range kinds {
+			args = append(args, kind)
+		}
+	}
+	args = append(args, limit)
+
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT c.chunk_id, c.repo_id, c.workspace, c.artifact_id, c.thread_id, c.locator,
+			c.text, c.text_hash, c.text_tokens, c.tags_json, c.tags_text,
+			c.chunk_type, c.symbol_name, c.symbol_kind, c.created_at, c.deleted_at,
+			r.ref_kind, r.ref_name, CASE WHEN r.ref_name = ? THEN 0 ELSE 1 END AS match_rank
+		FROM chunk_refs r
+		JOIN chunks c ON c.chunk_id = r.chunk_id
+		WHERE r.repo_id = ? AND r.workspace = ?
+			AND ((r.ref_kind = ? AND r.ref_base = ?) OR (r.ref_kind != ? AND r.ref_base = ?) OR r.ref_name = ?)
+			AND c.deleted_at IS NULL%s
+		ORDER BY match_rank, c.locator, r.ref_kind
+		LIMIT ?
+	`, kindFilter), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []ChunkReference
+	for rows.Next() {
+		var ref ChunkReference
+		var rank int
+		chunk, err := scanChunkFields(func(dest ...any) error {
+			return rows.Scan(append(dest, &ref.RefKind, &ref.RefName, &rank)...)
+		})
+		if err != nil {
+			return nil, err
+		}
+		ref.Chunk = chunk
+		ref.Match = RefMatchName
+		if rank == 0 {
+			ref.Match = RefMatchExact
+		}
+		results = append(results, ref)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ChunkDependency is a call or type reference from chunk FromID resolved to
+// the live chunk that defines the referenced symbol.
+type ChunkDependency struct {
+	FromID  string
+	RefKind string
+	RefName string
+	Chunk
+}
+
+// Definitions are only looked up among code symbols; document sections,
+// package overviews, and config keys never satisfy a reference. Config keys
+// are excluded by chunk type, since their "object" kind is also the kind of
+// Kotlin object declarations.
+var (
+	nonDefinitionSymbolKinds = []string{"section", "package"}
+	nonDefinitionChunkTypes  = []string{"config"}
+)
+
+// ListChunkDependencies resolves the call and type references of chunkIDs to
+// the chunks defining them. A definition whose symbol equals the reference as
+// written wins over one equal to its unqualified name, which wins over a
+// method of that name ("Store.Search" for "st.Search"). References whose best
+// match is ambiguous are skipped, as are self-references. Results follow the
+// order of chunkIDs.
+func (s *Store) ListChunkDependencies(repoID, workspace string, chunkIDs []string) ([]ChunkDependency, error) {
+	if len(chunkIDs) == 0 {
+		return nil, nil
+	}
+	workspace = normalizeWorkspace(workspace)
+	args := []any{repoID, workspace, RefKindCall, RefKindType}
+	for _, id := range chunkIDs {
+		args = append(args, id)
+	}
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT chunk_id, ref_kind, ref_name, ref_base
+		FROM chunk_refs
+		WHERE repo_id = ? AND workspace = ? AND ref_kind IN (?, ?)
+			AND chunk_id IN (%s)
+		ORDER BY ref_kind, ref_name
+	`, strings.TrimSuffix(strings.Repeat("?,", len(chunkIDs)), ",")), args...)
+	if err != nil {
+		return nil, err
+	}
+	type chunkRefRow struct {
+		chunkID, kind, name, base string
+	}
+	refsByChunk := map[string][]chunkRefRow{}
+	names := map[string]struct{}{}
+	bases := map[string]struct{}{}
+	for rows.Next() {
+		var ref chunkRefRow
+		if err := rows.Scan(&ref.chunkID, &ref.kind, &ref.name, &ref.base); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		refsByChunk[ref.chunkID] = append(refsByChunk[ref.chunkID], ref)
+		names[ref.name] = struct{}{}
+		bases[ref.base] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	rows.Close()
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	defs, err := s.listDefinitionChunks(repoID, workspace, names, bases)
+	if err != nil {
+		return nil, err
+	}
+	byName := map[string][]Chunk{}
+	byMethod := map[string][]Chunk{}
+	for _, def := range defs {
+		byName[def.SymbolName] = append(byName[def.SymbolName], def)
+		if idx := strings.Index(def.SymbolName, "."); idx >= 0 {
+			method := def.SymbolName[idx+1:]
+			byMethod[method] = append(byMethod[method], def)
+		}
+	}
+
+	var deps []ChunkDependency
+	seen := map[[2]string]struct{}{}
+	for _, fromID := range chunkIDs {
+		for _, ref := range refsByChunk[fromID] {
+			def, ok := uniqueDefinition(fromID, byName[ref.name], byName[ref.base], byMethod[ref.base])
+			if !ok {
+				continue
+			}
+			key := [2]string{fromID, def.ID}
+			if _, dup := seen[key]; dup {
+				continue
+			}
+			seen[key] = struct{}{}
+			deps = append(deps, ChunkDependency{FromID: fromID, RefKind: ref.kind, RefName: ref.name, Chunk: def})
+		}
+	}
+	return deps, nil
+}
+
+func (s *Store) listDefinitionChunks(repoID, workspace string, names, bases map[string]struct{}) ([]Chunk, error) {
+	args := []any{repoID, workspace}
+	for _, kind := range nonDefinitionSymbolKinds {
+		args = append(args, kind)
+	}
+	for _, chunkType := range nonDefinitionChunkTypes {
+		args = append(args, chunkType)
+	}
+	for name := range names {
+		args = append(args, name)
+	}
+	for base := range bases {
+		args = append(args, base)
+	}
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT chunk_id, repo_id, workspace, artifact_id, thread_id, locator,
+			text, text_hash, text_tokens, tags_json, tags_text,
+			chunk_type, symbol_name, symbol_kind, created_at, deleted_at
+		FROM chunks
+		WHERE repo_id = ? AND workspace = ? AND deleted_at IS NULL
+			AND symbol_name IS NOT NULL AND symbol_name != ''
+			AND COALESCE(symbol_kind, '') NOT IN (%s)
+			AND COALESCE(chunk_type, '') NOT IN (%s)
+			AND (symbol_name IN (%s) OR substr(symbol_name, instr(symbol_name, '.') + 1) IN (%s))
+		ORDER BY created_at DESC, chunk_id
+	`,
+		strings.TrimSuffix(strings.Repeat("?,", len(nonDefinitionSymbolKinds)), ","),
+		strings.TrimSuffix(strings.Repeat("?,", len(nonDefinitionChunkTypes)), ","),
+		strings.TrimSuffix(strings.Repeat("?,", len(names)), ","),
+		strings.TrimSuffix(strings.Repeat("?,", len(bases)), ","),
+	), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var defs []Chunk
+	for rows.Next() {
+		chunk, err := scanChunkFields(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, chunk)
+	}
+	return defs, rows.Err()
+}
+
+// uniqueDefinition picks the definition from the best non-empty tier. Copies
+// of one definition ingested into several threads share a text hash and
+// count once; the newest copy is used.
+func uniqueDefinition(fromID string, tiers ...[]Chunk) (Chunk, bool) {
+	for _, tier := range tiers {
+		var pick Chunk
+		hashes := map[string]struct{}{}
+		for _, def := range tier {
+			if def.ID == fromID {
+				continue
+			}
+			key := def.TextHash
+			if key == "" {
+				key = def.ID
+			}
+			if _, ok := hashes[key]; !ok && len(hashes) == 0 {
+				pick = def
+			}
+			hashes[key] = struct{}{}
+		}
+		switch len(hashes) {
+		case 0:
+			continue
+		case 1:
+			return pick, true
+		default:
+			return Chunk{}, false
+		}
+	}
+	return Chunk{}, false
+}
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRefBase(t *testing.T) {
+	cases := map[string]string{
+		"mem/internal/store": "store",
+		"st.Search":          "Search",
+		"Store.Search":       "Search",
+		"fmt":                "fmt",
+		"./store":            "store",
+	}
+	for name, want := range cases {
+		if got := RefBase(name); got != want {
+			t.Fatalf("RefBase(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestRebaseFileRefsUsesPathBase(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+
+	for _, name := range []string{"internal/app/ingest.go", "main.go"} {
+		if _, err := st.db.Exec(`
+			INSERT INTO chunk_refs (repo_id, workspace, chunk_id, ref_kind, ref_name, ref_base)
+			VALUES ('r1', 'default', 'C-1', 'file', ?, 'go')
+		`, name); err != nil {
+			t.Fatalf("insert ref: %v", err)
+		}
+	}
+	if err := rebaseFileRefs(st.db); err != nil {
+		t.Fatalf("rebase: %v", err)
+	}
+	for name, want := range map[string]string{"internal/app/ingest.go": "ingest.go", "main.go": "main.go"} {
+		var got string
+		if err := st.db.QueryRow(`SELECT ref_base FROM chunk_refs WHERE ref_name = ?`, name).Scan(&got); err != nil {
+			t.Fatalf("read ref: %v", err)
+		}
+		if got != want || got != refBaseFor(RefKindFile, name) {
+			t.Fatalf("ref_base for %q = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestFindChunkRefsSkipsDeletedChunks(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+
+	now := time.Now().UTC()
+	add := func(artifactID, source, chunkID string, refs ...ChunkRef) {
+		_, _, err := st.AddArtifactWithChunks(Artifact{ID: artifactID, RepoID: "r1", Kind: "file", Source: source, CreatedAt: now}, []Chunk{{
+			ID: chunkID, RepoID: "r1", ArtifactID: artifactID, Locator: "file:" + source, Text: "text of " + chunkID, SymbolName: "caller", CreatedAt: now, Refs: refs,
+		}})
+		if err != nil {
+			t.Fatalf("add %s: %v", chunkID, err)
+		}
+	}
+	add("A-1", "a.go", "C-1", ChunkRef{Kind: RefKindCall, Name: "Store.Search"})
+	add("A-2", "b.go", "C-2", ChunkRef{Kind: RefKindCall, Name: "st.Search"}, ChunkRef{Kind: RefKindImport, Name: "fmt"})
+	add("A-3", "c.go", "C-3", ChunkRef{Kind: RefKindCall, Name: "Search"})
+
+	if _, err := st.DeleteChunksBySource("r1", "", "c.go"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	refs, err := st.FindChunkRefs("r1", "", "Store.Search", nil, 10)
+	if err != nil {
+		t.Fatalf("find refs: %v", err)
+	}
+	if len(refs) != 2 || refs[0].ID != "C-1" || refs[0].Match != RefMatchExact || refs[1].ID != "C-2" || refs[1].Match != RefMatchName {
+		t.Fatalf("unexpected refs %+v", refs)
+	}
+
+	refs, err = st.FindChunkRefs("r1", "", "Search", []string{RefKindImport}, 10)
+	if err != nil {
+		t.Fatalf("find import refs: %v", err)
+	}
+	if len(refs) != 0 {
+		t.Fatalf("expected kind filter to drop call refs, got %+v", refs)
+	}
+}
+
+func TestListChunkDependenciesSkipsConfigKeysButNotKotlinObjects(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+
+	now := time.Now().UTC()
+	add := func(artifactID, source string, chunk Chunk) {
+		chunk.RepoID, chunk.ArtifactID, chunk.Locator, chunk.CreatedAt = "r1", artifactID, "file:"+source, now
+		if _, _, err := st.AddArtifactWithChunks(Artifact{ID: artifactID, RepoID: "r1", Kind: "file", Source: source, CreatedAt: now}, []Chunk{chunk}); err != nil {
+			t.Fatalf("add %s: %v", chunk.ID, err)
+		}
+	}
+	add("A-1", "Main.kt", Chunk{ID: "C-MAIN", Text: "fun main() { Registry.load() }", SymbolName: "main", SymbolKind: "function", ChunkType: "function",
+		Refs: []ChunkRef{{Kind: RefKindType, Name: "Registry"}}})
+	add("A-2", "Registry.kt", Chunk{ID: "C-OBJECT", Text: "object Registry { fun load() {} }", SymbolName: "Registry", SymbolKind: "object", ChunkType: "class"})
+	add("A-3", "settings.json", Chunk{ID: "C-CONFIG", Text: `"Registry": {"url": "x"}`, SymbolName: "Registry", SymbolKind: "object", ChunkType: "config"})
+
+	deps, err := st.ListChunkDependencies("r1", "", []string{"C-MAIN"})
+	if err != nil {
+		t.Fatalf("list dependencies: %v", err)
+	}
+	if len(deps) != 1 || deps[0].ID != "C-OBJECT" {
+		t.Fatalf("expected the Kotlin object to satisfy the reference, got %+v", deps)
+	}
+}
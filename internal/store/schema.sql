@@ -117,6 +117,37 @@ CREATE TABLE IF NOT EXISTS links (
     PRIMARY KEY (from_id, rel, to_id)
 );
 
+CREATE TABLE IF NOT EXISTS feedback (
+    feedback_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    repo_id TEXT NOT NULL,
+    workspace TEXT NOT NULL DEFAULT 'default',
+    item_id TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    vote INTEGER NOT NULL,
+    query TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS access_stats (
+    repo_id TEXT NOT NULL,
+    workspace TEXT NOT NULL DEFAULT 'default',
+    kind TEXT NOT NULL,
+    item_id TEXT NOT NULL,
+    hit_count INTEGER NOT NULL DEFAULT 0,
+    last_included_at TEXT NOT NULL,
+    PRIMARY KEY (repo_id, workspace, kind, item_id)
+);
+
+CREATE TABLE IF NOT EXISTS chunk_refs (
+    repo_id TEXT NOT NULL,
+    workspace TEXT NOT NULL DEFAULT 'default',
+    chunk_id TEXT NOT NULL,
+    ref_kind TEXT NOT NULL,
+    ref_name TEXT NOT NULL,
+    ref_base TEXT NOT NULL,
+    PRIMARY KEY (chunk_id, ref_kind, ref_name)
+);
+
 CREATE TABLE IF NOT EXISTS meta (
     key TEXT PRIMARY KEY,
     value TEXT NOT NULL
@@ -130,6 +161,8 @@ CREATE INDEX IF NOT EXISTS idx_embeddings_kind_model ON embeddings (repo_id, wor
 CREATE UNIQUE INDEX IF NOT EXISTS idx_embedding_queue_unique ON embedding_queue (repo_id, workspace, kind, item_id, model);
 CREATE INDEX IF NOT EXISTS idx_links_from ON links (from_id);
 CREATE INDEX IF NOT EXISTS idx_links_to ON links (to_id);
+CREATE INDEX IF NOT EXISTS idx_feedback_item ON feedback (repo_id, workspace, item_id);
+CREATE INDEX IF NOT EXISTS idx_chunk_refs_base ON chunk_refs (repo_id, workspace, ref_base);
 
 CREATE VIRTUAL TABLE IF NOT EXISTS memories_fts USING fts5 (
     title,
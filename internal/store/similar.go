@@ -0,0 +1,62 @@
+package store
+
+import (
+	"strings"
+	"unicode"
+)
+
+const maxSimilarTerms = 32
+
+// SearchSimilarMemories finds active, non-superseded memories sharing any
+// term with text. Unlike SearchMemories it ORs the terms, so paraphrased
+// near-duplicates still surface; callers score the overlap themselves.
+func (s *Store) SearchSimilarMemories(repoID, workspace, text string, limit int) ([]MemoryResult, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	terms := similarityTerms(text)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		parts = append(parts, formatFTSVariant(term))
+	}
+	results, _, err := s.searchMemoriesWithQuery(repoID, normalizeWorkspace(workspace), strings.Join(parts, " OR "), limit*2)
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]MemoryResult, 0, len(results))
+	for _, res := range results {
+		if strings.TrimSpace(res.Memory.SupersededBy) != "" {
+			continue
+		}
+		filtered = append(filtered, res)
+		if len(filtered) >= limit {
+			break
+		}
+	}
+	return filtered, nil
+}
+
+func similarityTerms(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	seen := make(map[string]struct{}, len(fields))
+	terms := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if len(field) < 2 {
+			continue
+		}
+		if _, ok := seen[field]; ok {
+			continue
+		}
+		seen[field] = struct{}{}
+		terms = append(terms, field)
+		if len(terms) >= maxSimilarTerms {
+			break
+		}
+	}
+	return terms
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSearchSimilarMemoriesMatchesAnyTerm(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+
+	now := time.Now().UTC()
+	add := func(id, title, summary string) {
+		t.Helper()
+		if _, err := st.AddMemory(AddMemoryInput{
+			ID:           id,
+			RepoID:       "r1",
+			Workspace:    "default",
+			ThreadID:     "T-DUP",
+			Title:        title,
+			Summary:      summary,
+			TagsJSON:     "[]",
+			EntitiesJSON: "[]",
+			CreatedAt:    now,
+		}); err != nil {
+			t.Fatalf("add memory %s: %v", id, err)
+		}
+	}
+	add("M-1", "Use middleware for auth", "Session tokens checked in middleware")
+	add("M-2", "Billing export cron", "Runs nightly")
+	add("M-3", "Auth via middleware", "Old plan")
+	if err := st.MarkMemorySuperseded("r1", "default", "M-3", "M-1"); err != nil {
+		t.Fatalf("supersede: %v", err)
+	}
+
+	results, err := st.SearchSimilarMemories("r1", "default", "Auth middleware, with refresh tokens!", 5)
+	if err != nil {
+		t.Fatalf("search similar: %v", err)
+	}
+	if len(results) != 1 || results[0].Memory.ID != "M-1" {
+		t.Fatalf("expected only M-1, got %+v", results)
+	}
+
+	results, err = st.SearchSimilarMemories("r1", "default", "  ", 5)
+	if err != nil || len(results) != 0 {
+		t.Fatalf("expected no results for blank text, got %v, %v", results, err)
+	}
+}
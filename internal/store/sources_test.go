@@ -0,0 +1,98 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReingestRevivesUnchangedChunksPerThread(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+
+	now := time.Now().UTC()
+	ingest := func(artifactID, hash, thread string, texts ...string) []string {
+		t.Helper()
+		chunks := make([]Chunk, 0, len(texts))
+		for i, text := range texts {
+			chunks = append(chunks, Chunk{ID: NewID("C"), RepoID: "r1", ArtifactID: artifactID, ThreadID: thread, Locator: fmt.Sprintf("file:calc.go#L%d", i+1), Text: text, CreatedAt: now})
+		}
+		_, ids, err := st.AddArtifactWithChunks(Artifact{ID: artifactID, RepoID: "r1", Kind: "file", Source: "calc.go", ContentHash: hash, CreatedAt: now}, chunks)
+		if err != nil {
+			t.Fatalf("add chunks: %v", err)
+		}
+		return ids
+	}
+
+	first := ingest("A-1", "h1", "T-a", "keep", "change v1")
+	ingest("A-2", "h1", "T-b", "keep", "change v1")
+	if hash, err := st.LatestSourceHash("r1", "", "T-a", "calc.go"); err != nil || hash != "h1" {
+		t.Fatalf("expected h1 for T-a, got %q (%v)", hash, err)
+	}
+
+	if deleted, err := st.DeleteThreadChunksBySource("r1", "", "T-a", "calc.go"); err != nil || deleted != 2 {
+		t.Fatalf("expected 2 tombstoned chunks, got %d (%v)", deleted, err)
+	}
+	if hash, _ := st.LatestSourceHash("r1", "", "T-a", "calc.go"); hash != "" {
+		t.Fatalf("expected no live T-a chunks, got hash %q", hash)
+	}
+	if sources, _ := st.ListThreadSources("r1", "", "T-b", "file"); len(sources) != 1 || sources[0] != "calc.go" {
+		t.Fatalf("expected T-b to keep calc.go, got %v", sources)
+	}
+
+	second := ingest("A-3", "h2", "T-a", "keep", "change v2")
+	if len(second) != 2 || second[0] != first[0] || second[1] == first[1] {
+		t.Fatalf("expected the unchanged chunk to be revived with id %s, got %v", first[0], second)
+	}
+	if hash, _ := st.LatestSourceHash("r1", "", "T-a", "calc.go"); hash != "h2" {
+		t.Fatalf("expected h2 after re-ingest, got %q", hash)
+	}
+}
+
+func TestListSourceSummariesPerThread(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+
+	now := time.Now().UTC()
+	add := func(artifactID, source, hash, thread string, at time.Time, texts ...string) {
+		t.Helper()
+		chunks := make([]Chunk, 0, len(texts))
+		for i, text := range texts {
+			chunks = append(chunks, Chunk{ID: NewID("C"), RepoID: "r1", ArtifactID: artifactID, ThreadID: thread, Locator: fmt.Sprintf("file:%s#L%d", source, i+1), Text: text, TextTokens: 3, CreatedAt: at})
+		}
+		if _, _, err := st.AddArtifactWithChunks(Artifact{ID: artifactID, RepoID: "r1", Kind: "file", Source: source, ContentHash: hash, CreatedAt: at}, chunks); err != nil {
+			t.Fatalf("add chunks: %v", err)
+		}
+	}
+	add("A-1", "a.go", "h1", "T-a", now, "one", "two")
+	// A later artifact in the same thread that adds chunks folds into one
+	// summary carrying the newer hash.
+	add("A-2", "a.go", "h2", "T-a", now.Add(time.Second), "three")
+	add("A-3", "a.go", "h1", "T-b", now, "one")
+	add("A-4", "b.go", "hb", "T-a", now, "bee")
+	if _, err := st.DeleteThreadChunksBySource("r1", "", "T-a", "b.go"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	summaries, err := st.ListSourceSummaries("r1", "")
+	if err != nil {
+		t.Fatalf("list summaries: %v", err)
+	}
+	if len(summaries) != 2 {
+		t.Fatalf("expected a.go in two threads only, got %+v", summaries)
+	}
+	first, second := summaries[0], summaries[1]
+	if first.Source != "a.go" || first.ThreadID != "T-a" || first.ContentHash != "h2" || first.Chunks != 3 || first.Tokens != 9 {
+		t.Fatalf("unexpected T-a summary %+v", first)
+	}
+	if second.ThreadID != "T-b" || second.ContentHash != "h1" || second.Chunks != 1 {
+		t.Fatalf("unexpected T-b summary %+v", second)
+	}
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	SymbolMatchExact  = "exact"
+	SymbolMatchPrefix = "prefix"
+	SymbolMatchFuzzy  = "fuzzy"
+)
+
+// SymbolChunk is a live chunk whose symbol matched a lookup, with how it
+// matched.
+type SymbolChunk struct {
+	Chunk
+	Match string
+}
+
+// FindChunksBySymbol looks up live chunks by symbol name. Exact matches sort
+// first, then case-sensitive prefix matches; fuzzy adds case-insensitive
+// substring matches such as "search" for "Store.Search". kinds, when set,
+// restricts symbol_kind.
+func (s *Store) FindChunksBySymbol(repoID, workspace, name string, kinds []string, fuzzy bool, limit int) ([]SymbolChunk, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || limit <= 0 {
+		return nil, nil
+	}
+	prefix := globEscape(name) + "*"
+	args := []any{name, prefix, repoID, normalizeWorkspace(workspace), name, prefix}
+	match := "symbol_name = ? OR symbol_name GLOB ?"
+	if fuzzy {
+		match += ` OR symbol_name LIKE ? ESCAPE '\'`
+		args = append(args, "%"+likeEscape(name)+"%")
+	}
+	kindFilter := ""
+	if len(kinds) > 0 {
+		kindFilter = fmt.Sprintf(" AND symbol_kind IN (%s)", strings.TrimSuffix(strings.Repeat("?,", len(kinds)), ","))
+		for _, kind := range kinds {
+			args = append(args, kind)
+		}
+	}
+	args = append(args, limit)
+
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT chunk_id, repo_id, workspace, artifact_id, thread_id, locator,
+			text, text_hash, text_tokens, tags_json, tags_text,
+			chunk_type, symbol_name, symbol_kind, created_at, deleted_at,
+			CASE WHEN symbol_name = ? THEN 0 WHEN symbol_name GLOB ? THEN 1 ELSE 2 END AS match_rank
+		FROM chunks
+		WHERE repo_id = ? AND workspace = ? AND deleted_at IS NULL
+			AND symbol_name IS NOT NULL AND symbol_name != ''
+			AND (%s)%s
+		ORDER BY match_rank, symbol_name, locator
+		LIMIT ?
+	`, match, kindFilter), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []SymbolChunk
+	for rows.Next() {
+		var rank int
+		chunk, err := scanChunkFields(func(dest ...any) error {
+			return rows.Scan(append(dest, &rank)...)
+		})
+		if err != nil {
+			return nil, err
+		}
+		matchKind := SymbolMatchFuzzy
+		switch rank {
+		case 0:
+			matchKind = SymbolMatchExact
+		case 1:
+			matchKind = SymbolMatchPrefix
+		}
+		results = append(results, SymbolChunk{Chunk: chunk, Match: matchKind})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ListMemoriesByEntity returns live, unsuperseded memories with an entity
+// equal to one of names, ignoring case, newest first.
+func (s *Store) ListMemoriesByEntity(repoID, workspace string, names []string, limit int) ([]Memory, error) {
+	if len(names) == 0 || limit <= 0 {
+		return nil, nil
+	}
+	args := []any{repoID, normalizeWorkspace(workspace)}
+	for _, name := range names {
+		args = append(args, strings.ToLower(strings.TrimSpace(name)))
+	}
+	args = append(args, limit)
+
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT id, repo_id, workspace, thread_id, title, summary, summary_tokens, tags_json, tags_text, entities_json, entities_text,
+			created_at, anchor_commit, superseded_by, deleted_at
+		FROM memories
+		WHERE repo_id = ? AND workspace = ? AND deleted_at IS NULL
+			AND (superseded_by IS NULL OR superseded_by = '')
+			AND json_valid(entities_json)
+			AND EXISTS (
+				SELECT 1 FROM json_each(memories.entities_json)
+				WHERE lower(json_each.value) IN (%s)
+			)
+		ORDER BY created_at DESC
+		LIMIT ?
+	`, strings.TrimSuffix(strings.Repeat("?,", len(names)), ",")), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memories []Memory
+	for rows.Next() {
+		mem, err := scanMemoryFields(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		memories = append(memories, mem)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return memories, nil
+}
+
+func globEscape(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		switch r {
+		case '*', '?', '[':
+			b.WriteRune('[')
+			b.WriteRune(r)
+			b.WriteRune(']')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func likeEscape(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return replacer.Replace(value)
+}
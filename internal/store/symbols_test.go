@@ -0,0 +1,102 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFindChunksBySymbolExactPrefixFuzzy(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+
+	now := time.Now().UTC()
+	chunk := func(id, name, kind string) Chunk {
+		return Chunk{ID: id, RepoID: "r1", ArtifactID: "A-1", Locator: "file:store.go#L1-L2", Text: "text of " + id, ChunkType: "function", SymbolName: name, SymbolKind: kind, CreatedAt: now}
+	}
+	_, _, err = st.AddArtifactWithChunks(Artifact{ID: "A-1", RepoID: "r1", Kind: "file", Source: "store.go", CreatedAt: now}, []Chunk{
+		chunk("C-1", "Search", "function"),
+		chunk("C-2", "SearchChunks", "function"),
+		chunk("C-3", "Store.Search", "method"),
+		chunk("C-4", "Searcher", "interface"),
+		chunk("C-5", "search", "function"),
+	})
+	if err != nil {
+		t.Fatalf("add chunks: %v", err)
+	}
+
+	matches, err := st.FindChunksBySymbol("r1", "", "Search", nil, false, 10)
+	if err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	got := make([]string, 0, len(matches))
+	for _, m := range matches {
+		got = append(got, m.SymbolName+":"+m.Match)
+	}
+	want := []string{"Search:exact", "SearchChunks:prefix", "Searcher:prefix"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+
+	matches, err = st.FindChunksBySymbol("r1", "", "Search", []string{"function"}, true, 10)
+	if err != nil {
+		t.Fatalf("find fuzzy: %v", err)
+	}
+	names := map[string]string{}
+	for _, m := range matches {
+		names[m.SymbolName] = m.Match
+	}
+	if len(names) != 3 || names["search"] != SymbolMatchFuzzy || names["Search"] != SymbolMatchExact {
+		t.Fatalf("unexpected fuzzy function matches: %v", names)
+	}
+
+	matches, err = st.FindChunksBySymbol("r1", "", "Sea*", nil, false, 10)
+	if err != nil {
+		t.Fatalf("find glob: %v", err)
+	}
+	if len(matches) != 0 {
+		t.Fatalf("expected glob characters to match literally, got %d", len(matches))
+	}
+}
+
+func TestListMemoriesByEntity(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+
+	add := func(id, entities string) {
+		_, err := st.AddMemory(AddMemoryInput{
+			ID:           id,
+			RepoID:       "r1",
+			Workspace:    "default",
+			Title:        id,
+			TagsJSON:     "[]",
+			EntitiesJSON: entities,
+			CreatedAt:    time.Now().UTC(),
+		})
+		if err != nil {
+			t.Fatalf("add memory %s: %v", id, err)
+		}
+	}
+	add("M-1", `["Store.Search","ranking"]`)
+	add("M-2", `["SearchChunks"]`)
+	add("M-3", `[]`)
+
+	memories, err := st.ListMemoriesByEntity("r1", "", []string{"store.search"}, 10)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(memories) != 1 || memories[0].ID != "M-1" {
+		t.Fatalf("expected only M-1, got %#v", memories)
+	}
+}
@@ -61,6 +61,14 @@ func New(root string, ignorer func(relPath string) bool) (*Watcher, error) {
 	}, nil
 }
 
+// SetDebounce sets how long a path must be quiet before its event is
+// emitted. It must be called before Start.
+func (w *Watcher) SetDebounce(d time.Duration) {
+	if d > 0 {
+		w.debounce = d
+	}
+}
+
 func (w *Watcher) Start() error {
 	if err := w.addDirRecursive(w.root); err != nil {
 		return err